@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/i18n"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// activityThreadKey repo activity thread 在 store 裡的 key（格式："owner/repo#activity"）
+// 和 PR 的 "owner/repo#123" 共用同一個 store，不會撞 key
+func activityThreadKey(repoFullName string) string {
+	return repoFullName + "#activity"
+}
+
+// ensureActivityThread 取得 repo 的 activity thread（push 這類不屬於單一 PR 的事件都發在這裡）
+// 有設定 DISCORD_ACTIVITY_THREAD_ID 時所有 repo 共用那個 thread；否則每個 repo 自動建立一個
+func (app *App) ensureActivityThread(ctx context.Context, repoFullName string) (string, error) {
+	log := applogger.Log
+
+	if threadID := config.Current().DiscordActivityThreadID; threadID != "" {
+		return threadID, nil
+	}
+
+	key := activityThreadKey(repoFullName)
+	threadID, exists, err := app.store.Get(key)
+	if err != nil {
+		return "", err
+	}
+
+	if exists {
+		_, err := app.discordClient.GetThread(ctx, threadID)
+		if err == nil || !errors.Is(err, discord.ErrNotFound) {
+			return threadID, nil
+		}
+
+		log.Warn("Stored activity thread no longer exists, recreating", "repo", repoFullName, "threadID", threadID)
+		if err := app.store.Delete(key); err != nil {
+			return "", fmt.Errorf("failed to delete stale mapping: %w", err)
+		}
+	}
+
+	repoName := repoFullName
+	if idx := strings.LastIndex(repoFullName, "/"); idx >= 0 {
+		repoName = repoFullName[idx+1:]
+	}
+
+	message := discord.ThreadMessage{
+		Embeds: []discord.Embed{
+			{
+				Title:       i18n.Tf("📋 %s activity", repoFullName),
+				Description: i18n.T("Pushes and other repository-wide events are posted in this thread."),
+				URL:         github.WebURL(repoFullName),
+				Color:       discord.ColorGray,
+			},
+		},
+	}
+
+	title := discord.BuildThreadName(fmt.Sprintf("[%s]", repoName), "Activity")
+	threadID, err = app.forum(repoFullName).CreateThread(ctx, title, message, app.repoTagIDs(ctx, repoFullName)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create activity thread: %w", err)
+	}
+
+	if err := app.store.Set(key, threadID); err != nil {
+		return "", fmt.Errorf("failed to save mapping: %w", err)
+	}
+
+	log.Info("Created activity thread", "repo", repoFullName, "threadID", threadID)
+	return threadID, nil
+}
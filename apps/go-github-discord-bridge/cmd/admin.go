@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/storage"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerAdminRoutes 維運用的 admin API，request 要帶 `Authorization: Bearer <ADMIN_TOKEN>`
+// ADMIN_TOKEN 沒有設定時一律回 404（reload 設定 token 後就能使用）
+func (app *App) registerAdminRoutes(admin *gin.RouterGroup) {
+	admin.Use(adminAuth())
+
+	admin.GET("/mappings", app.handleAdminMappings)
+	admin.GET("/dead-letters", app.handleAdminDeadLetters)
+	admin.GET("/dead-letters/:id", app.handleAdminDeadLetter)
+	admin.POST("/dead-letters/:id/replay", app.handleAdminReplay)
+	admin.DELETE("/dead-letters/:id", app.handleAdminDiscard)
+	admin.POST("/caches/flush", app.handleAdminFlushCaches)
+	admin.GET("/stats", app.handleAdminStats)
+	admin.GET("/dashboard", app.handleAdminDashboard)
+	admin.GET("/dry-run", app.handleAdminDryRun)
+	admin.GET("/ha", app.handleAdminHA)
+	admin.POST("/repos/:owner/:name/disable", app.handleAdminSetRepo(true))
+	admin.POST("/repos/:owner/:name/enable", app.handleAdminSetRepo(false))
+}
+
+// adminAuth 以 constant-time 比對 bearer token
+func adminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := config.Current().AdminToken
+		if token == "" {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// handleAdminMappings GET /admin/mappings?repo=owner/name：列出 thread mapping，可依 repo 過濾
+func (app *App) handleAdminMappings(c *gin.Context) {
+	// ListStale 給未來的時間 = 全部
+	mappings, err := app.store.ListStale(time.Now().Add(time.Hour))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if repo := strings.ToLower(c.Query("repo")); repo != "" {
+		filtered := mappings[:0]
+		for _, m := range mappings {
+			if strings.HasPrefix(strings.ToLower(m.Key), repo+"#") {
+				filtered = append(filtered, m)
+			}
+		}
+		mappings = filtered
+	}
+	c.JSON(http.StatusOK, gin.H{"count": len(mappings), "mappings": mappings})
+}
+
+// handleAdminDeadLetters GET /admin/dead-letters：列出 dead letter（不含 payload，完整內容見 /admin/dead-letters/:id）
+func (app *App) handleAdminDeadLetters(c *gin.Context) {
+	letters, err := app.store.ListDeadLetters()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range letters {
+		letters[i].Body = nil
+	}
+	c.JSON(http.StatusOK, gin.H{"count": len(letters), "dead_letters": letters})
+}
+
+// handleAdminDeadLetter GET /admin/dead-letters/:id：一個 dead letter 的完整內容
+func (app *App) handleAdminDeadLetter(c *gin.Context) {
+	dl, ok := app.lookupDeadLetter(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, dl)
+}
+
+// handleAdminReplay POST /admin/dead-letters/:id/replay：在這個 instance 上重新處理，成功時刪除 dead letter
+func (app *App) handleAdminReplay(c *gin.Context) {
+	dl, ok := app.lookupDeadLetter(c)
+	if !ok {
+		return
+	}
+	if err := app.replayDeadLetter(context.WithoutCancel(c.Request.Context()), dl); err != nil {
+		applogger.Log.Error("Replay failed", "deliveryID", dl.DeliveryID, "ghEvent", dl.Event, "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"status": "failed", "error": err.Error()})
+		return
+	}
+	applogger.Log.Info("Replayed dead letter", "deliveryID", dl.DeliveryID, "ghEvent", dl.Event, "repo", dl.Repo)
+	c.JSON(http.StatusOK, gin.H{"status": "replayed"})
+}
+
+// handleAdminDiscard DELETE /admin/dead-letters/:id：捨棄不處理
+func (app *App) handleAdminDiscard(c *gin.Context) {
+	dl, ok := app.lookupDeadLetter(c)
+	if !ok {
+		return
+	}
+	if err := app.store.DeleteDeadLetter(dl.DeliveryID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	applogger.Log.Info("Discarded dead letter", "deliveryID", dl.DeliveryID, "ghEvent", dl.Event)
+	c.Status(http.StatusNoContent)
+}
+
+// lookupDeadLetter 依 :id 取得 dead letter，找不到或失敗時寫出回應並回傳 false
+func (app *App) lookupDeadLetter(c *gin.Context) (storage.DeadLetter, bool) {
+	dl, exists, err := app.store.GetDeadLetter(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return dl, false
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "dead letter not found"})
+		return dl, false
+	}
+	return dl, true
+}
+
+// handleAdminFlushCaches POST /admin/caches/flush：清掉 forum tag、GitHub App installation token 和 /readyz token 檢查的快取
+// 例如在 Discord 手動改了 forum tag、或調整了 GitHub App 權限之後
+func (app *App) handleAdminFlushCaches(c *gin.Context) {
+	flushed := []string{"discord_forum_tags", "discord_token_check"}
+	app.discordClient.InvalidateTagCache()
+	app.token.mu.Lock()
+	app.token.checkedAt = time.Time{}
+	app.token.mu.Unlock()
+	if app.githubApp != nil {
+		app.githubApp.InvalidateTokens()
+		flushed = append(flushed, "github_installation_tokens")
+	}
+	applogger.Log.Info("Flushed caches via admin API", "caches", flushed)
+	c.JSON(http.StatusOK, gin.H{"flushed": flushed})
+}
+
+// handleAdminStats GET /admin/stats：每個 repo 的處理統計、暫停中的 repo 和 queue 狀態（只包含這個 instance）
+func (app *App) handleAdminStats(c *gin.Context) {
+	resp := gin.H{
+		"repos":          app.stats.snapshot(),
+		"disabled_repos": app.repoSwitches.list(),
+	}
+	if app.queue != nil {
+		resp["queue"] = gin.H{"depth": app.queue.Len(), "capacity": app.queue.Cap()}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleAdminDryRun GET /admin/dry-run?repo=owner/name：DRY_RUN / DRY_RUN_REPOS 的設定和最近沒有送出的 Discord request（新的在前）
+func (app *App) handleAdminDryRun(c *gin.Context) {
+	cfg := config.Current()
+	repos := cfg.DryRunRepos
+	if repos == nil {
+		repos = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":  cfg.DryRun,
+		"repos":    repos,
+		"requests": app.dryRuns.list(c.Query("repo")),
+	})
+}
+
+// handleAdminSetRepo POST /admin/repos/:owner/:name/disable|enable：暫停 / 恢復處理某個 repo 的 webhook
+// 只影響這個 instance，重啟後恢復；要永久停用請用 GITHUB_REPO_BLOCKLIST
+func (app *App) handleAdminSetRepo(disabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		repo := c.Param("owner") + "/" + c.Param("name")
+		app.repoSwitches.set(repo, disabled)
+		applogger.Log.Info("Toggled repository via admin API", "repo", repo, "disabled", disabled)
+		c.JSON(http.StatusOK, gin.H{"repo": strings.ToLower(repo), "disabled": disabled})
+	}
+}
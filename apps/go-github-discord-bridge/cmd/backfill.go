@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// backfillKey context 裡標記「backfill 中」，handler 據此不發 announcement、不 cross-link
+type backfillKey struct{}
+
+// isBackfill 是否在 backfill 中（舊的 issue / PR 補建 thread，不是新事件）
+func isBackfill(ctx context.Context) bool {
+	v, _ := ctx.Value(backfillKey{}).(bool)
+	return v
+}
+
+// runBackfill `main backfill --repo owner/name`：把目前開啟的 issue / PR 補建成 forum thread 並寫入 mapping
+// 已經有 mapping 的直接略過，可以重複執行
+func runBackfill(cfg *config.Config, args []string) error {
+	log := applogger.Log
+
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	repo := fs.String("repo", "", "repository to backfill (owner/name)")
+	dryRun := fs.Bool("dry-run", false, "list what would be created without creating threads")
+	skipIssues := fs.Bool("skip-issues", false, "only backfill pull requests")
+	skipPRs := fs.Bool("skip-prs", false, "only backfill issues")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !strings.Contains(*repo, "/") {
+		return errors.New("--repo owner/name is required")
+	}
+
+	app, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.store.Close()
+
+	if app.githubAPI == nil {
+		return errors.New("GITHUB_TOKEN or GitHub App credentials are required for backfill")
+	}
+
+	ctx, err := app.repoContext(context.WithValue(context.Background(), backfillKey{}, true), *repo, map[string]int64{})
+	if err != nil {
+		return err
+	}
+
+	var created, skipped, failed int
+	for page := 1; ; page++ {
+		issues, err := app.githubAPI.ListOpenIssues(ctx, *repo, page)
+		if err != nil {
+			return err
+		}
+
+		for i := range issues {
+			issue := &issues[i]
+			isPR := issue.PullRequest != nil
+			key := *repo + "#" + strconv.Itoa(issue.Number)
+
+			if (isPR && *skipPRs) || (!isPR && *skipIssues) {
+				continue
+			}
+			if _, exists, err := app.store.Get(key); err != nil {
+				return err
+			} else if exists {
+				skipped++
+				continue
+			}
+
+			if *dryRun {
+				log.Info("Would create thread", "key", key, "pullRequest", isPR, "title", issue.Title)
+				created++
+				continue
+			}
+
+			if err := app.backfillItem(ctx, key, issue, *repo); err != nil {
+				// 單筆失敗不中斷，重跑時會補上
+				log.Error("Failed to backfill", "key", key, "error", err)
+				failed++
+				continue
+			}
+			created++
+		}
+
+		if len(issues) < github.APIPageSize {
+			break
+		}
+	}
+
+	log.Info("Backfill finished", "repo", *repo, "created", created, "skipped", skipped, "failed", failed, "dryRun", *dryRun)
+	if failed > 0 {
+		return fmt.Errorf("%d items failed to backfill", failed)
+	}
+	return nil
+}
+
+// backfillItem 建立單一 issue / PR 的 thread；PR 另外用 pulls API 取得完整資料
+func (app *App) backfillItem(ctx context.Context, key string, issue *github.Issue, repoFullName string) error {
+	if issue.PullRequest == nil {
+		return app.handleIssueOpened(ctx, key, issue, repoFullName)
+	}
+
+	pr, err := app.githubAPI.GetPullRequest(ctx, repoFullName, issue.Number)
+	if err != nil {
+		return err
+	}
+	return app.handlePROpened(ctx, key, pr, repoFullName)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/broker"
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/queue"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// brokerPublishTimeout receiver 等 broker 確認寫入的時間（要比 GitHub 的 10 秒 timeout 短）
+const brokerPublishTimeout = 5 * time.Second
+
+// errBrokerUnavailable receiver 發不到 broker，webhook 回 503
+var errBrokerUnavailable = errors.New("message broker unavailable")
+
+func brokerOptions(cfg *config.Config) broker.Options {
+	return broker.Options{
+		Kind:   cfg.Broker,
+		URL:    cfg.BrokerURL,
+		Topic:  cfg.BrokerTopic,
+		Stream: cfg.BrokerStream,
+		Group:  cfg.BrokerGroup,
+		Batch:  cfg.BrokerBatch,
+	}
+}
+
+// runReceiver INGEST_MODE=receiver：只驗證簽名、套用 repo / IP 過濾後把 webhook 發到 broker，broker 確認寫入後回 202
+// 不連 Discord 和 storage，由 INGEST_MODE=publisher 的 process 訂閱後處理
+func runReceiver(cfg *config.Config) error {
+	log := applogger.Log
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	workers := &backgroundWorkers{ctx: ctx}
+
+	publisher, err := broker.NewPublisher(ctx, brokerOptions(cfg))
+	if err != nil {
+		return fmt.Errorf("failed to connect to message broker: %w", err)
+	}
+	defer publisher.Close()
+
+	// handler 不會被呼叫（全部交給 publishDelivery），App 只提供 event 註冊、repo 過濾和 /readyz
+	app := &App{broker: publisher}
+	dispatch := func(ctx context.Context, delivery github.Delivery) error {
+		return publishDelivery(ctx, publisher, delivery)
+	}
+	webhooks := app.newWebhookHandler(cfg, github.WithRecorder(app.recorder.record), github.WithDispatcher(dispatch))
+	webhooks.OnError(respondProcessError)
+
+	workers.start(func(ctx context.Context) { app.watchConfig(ctx, cfg.ConfigWatchInterval, cfg.SecretsRefreshInterval) })
+
+	r, err := app.newRouter(cfg)
+	if err != nil {
+		return err
+	}
+	mountWebhook(ctx, cfg, r, workers, webhooks)
+
+	srv := newHTTPServer(cfg, r)
+	challenge := configureTLS(cfg, srv)
+	log.Info("Receiver starting", "port", cfg.Port, "broker", cfg.Broker, "topic", cfg.BrokerTopic, "tls", srv.TLSConfig != nil)
+	return serve(ctx, srv, challenge, workers, nil, cfg.ShutdownTimeout)
+}
+
+// publishDelivery 以 queuedDelivery 的格式發到 broker，key 為排序 key（Kafka 同一個 issue 進同一個 partition）
+// 訊息帶著 receiver 的 traceparent，publisher 的 span 和 receiver 在同一個 trace
+func publishDelivery(ctx context.Context, publisher broker.Publisher, delivery github.Delivery) error {
+	data, err := encodeDelivery(ctx, delivery)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, brokerPublishTimeout)
+	defer cancel()
+	msg := broker.Message{Key: orderingKey(delivery.Event, delivery.Payload), Data: data}
+	if err := publisher.Publish(ctx, msg); err != nil {
+		applogger.Log.Error("Failed to publish webhook to message broker", "ghEvent", delivery.Event, "deliveryID", delivery.ID, "error", err)
+		return fmt.Errorf("%w: %w", errBrokerUnavailable, err)
+	}
+	return nil
+}
+
+// consumeBroker INGEST_MODE=publisher：從 broker 取得 receiver 發布的 webhook，連線中斷時 backoff 後重新連線，ctx 結束時停止
+func (app *App) consumeBroker(ctx context.Context, consumer broker.Consumer) {
+	log := applogger.Log
+	defer consumer.Close()
+
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := consumer.Consume(ctx, app.handleBrokerBatch)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > time.Minute {
+			backoff = time.Second
+		}
+		log.Error("Message broker consumer stopped, reconnecting", "error", err, "retryIn", backoff.String())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+// handleBrokerBatch 把一批訊息放進 worker pool（同一個 issue 依序處理），全部結束（成功或存進 dead letter）才回傳 nil 讓 broker ack
+// shutdown 時沒處理完的訊息回傳 error 不 ack，broker 之後重送給其他 publisher（已處理的由 ClaimDelivery 略過）
+func (app *App) handleBrokerBatch(ctx context.Context, msgs []broker.Message) error {
+	log := applogger.Log
+
+	var wg sync.WaitGroup
+	var aborted atomic.Bool
+	done := func(err error) {
+		if errors.Is(err, queue.ErrAborted) {
+			aborted.Store(true)
+		}
+		wg.Done()
+	}
+
+enqueue:
+	for _, msg := range msgs {
+		var d queuedDelivery
+		var payload github.WebhookPayload
+		err := json.Unmarshal(msg.Data, &d)
+		if err == nil {
+			err = json.Unmarshal(d.Body, &payload)
+		}
+		if err != nil {
+			log.Error("Dropping malformed message from broker", "error", err)
+			continue
+		}
+		delivery := github.Delivery{ID: d.DeliveryID, Event: d.Event, Body: d.Body, Payload: &payload}
+		job := app.deliveryJob(d.context(), delivery, msg.Data)
+		job.Done = done
+
+		wg.Add(1)
+		for {
+			err := app.queue.Enqueue(job)
+			if err == nil {
+				break
+			}
+			if !errors.Is(err, queue.ErrFull) {
+				wg.Done()
+				aborted.Store(true)
+				break enqueue
+			}
+			// queue 滿了就等 worker 消化，broker 上的訊息不會遺失
+			select {
+			case <-ctx.Done():
+				wg.Done()
+				aborted.Store(true)
+				break enqueue
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	}
+
+	wg.Wait()
+	if aborted.Load() {
+		return errors.New("shutting down before all messages were processed")
+	}
+	return nil
+}
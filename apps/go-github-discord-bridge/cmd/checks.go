@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// handleCheckRun 個別 check 失敗時（lint、測試 matrix 的其中一項）發到 PR thread，成功的 check 不通知
+// GitHub Actions 的 check 已經有 workflow_run 通知，和 handleCheckSuite 一樣只處理其他 CI app
+func (app *App) handleCheckRun(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+
+	cr := payload.CheckRun
+	if cr == nil || payload.Action != "completed" {
+		return nil
+	}
+	if cr.App.Slug == "github-actions" {
+		return nil
+	}
+	if !github.IsFailedConclusion(cr.Conclusion) {
+		log.Info("Skipping check_run notification", "check", cr.Name, "conclusion", cr.Conclusion)
+		return nil
+	}
+
+	message := app.render(ctx, "check_run."+cr.Conclusion, discord.FormatCheckRun(cr))
+	app.postToPRThreads(ctx, payload.Repository.FullName, cr.PullRequests, message)
+	app.announce(ctx, "check_run."+cr.Conclusion, message)
+	return nil
+}
+
+// handleCheckSuite check suite 完成且失敗時發整體結果到 PR thread
+// GitHub Actions 的 suite 已經有 workflow_run 通知，這裡只處理其他 CI app（例如外部 CI 服務）
+func (app *App) handleCheckSuite(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+
+	cs := payload.CheckSuite
+	if cs == nil || payload.Action != "completed" {
+		return nil
+	}
+	if cs.App.Slug == "github-actions" {
+		return nil
+	}
+	if !branchAllowed(payload.Repository.FullName, cs.HeadBranch) {
+		log.Info("Skipping check_suite notification for filtered branch", "branch", cs.HeadBranch)
+		return nil
+	}
+	if !github.IsFailedConclusion(cs.Conclusion) {
+		log.Info("Skipping check_suite notification", "app", cs.App.Slug, "conclusion", cs.Conclusion)
+		return nil
+	}
+
+	app.postToPRThreads(ctx, payload.Repository.FullName, cs.PullRequests, discord.FormatCheckSuite(cs))
+	return nil
+}
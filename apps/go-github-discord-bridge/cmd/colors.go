@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+)
+
+// newEventColors 解析 DISCORD_EVENT_COLORS，任何一個顏色格式錯誤就啟動失敗（避免打錯字卻默默用內建顏色）
+func newEventColors(cfg *config.Config) (map[string]int, error) {
+	colors := make(map[string]int, len(cfg.EventColors))
+	for key, raw := range cfg.EventColors {
+		color, err := discord.ParseColor(raw)
+		if err != nil {
+			return nil, fmt.Errorf("DISCORD_EVENT_COLORS %s: %w", key, err)
+		}
+		colors[key] = color
+	}
+	return colors, nil
+}
+
+// applyEventColor 依 event key（"workflow_run.failure"）或 event type（"workflow_run"）覆寫第一個 embed 的顏色，key 優先
+func (app *App) applyEventColor(key string, message discord.ThreadMessage) discord.ThreadMessage {
+	colors := app.styles.Load().colors
+	if len(colors) == 0 || len(message.Embeds) == 0 {
+		return message
+	}
+	color, ok := colors[key]
+	if !ok {
+		eventType, _, _ := strings.Cut(key, ".")
+		if color, ok = colors[eventType]; !ok {
+			return message
+		}
+	}
+
+	embeds := append([]discord.Embed(nil), message.Embeds...)
+	embeds[0].Color = color
+	message.Embeds = embeds
+	return message
+}
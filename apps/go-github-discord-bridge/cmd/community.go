@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/i18n"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// communitySignal star / fork / watch 的顯示設定
+var communitySignals = map[string]struct {
+	emoji string
+	noun  string
+	verb  string
+}{
+	"star":  {"⭐", "star", "starred"},
+	"fork":  {"🍴", "fork", "forked"},
+	"watch": {"👀", "watcher", "started watching"},
+}
+
+// handleCommunityEvent 處理 star / fork / watch
+// 有設定 batch interval 時先累計，定期發一則摘要（"⭐ 5 new stars"）；否則每個事件發一則精簡訊息
+func (app *App) handleCommunityEvent(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	// 取消 star 不通知；watch 只有 started
+	if ghEvent == "star" && payload.Action != "created" {
+		return nil
+	}
+
+	repoFullName := payload.Repository.FullName
+	if app.community != nil {
+		app.community.add(repoFullName, ghEvent, payload.Sender.Login)
+		return nil
+	}
+
+	signal := communitySignals[ghEvent]
+	description := i18n.Tf("%s [@%s](%s) %s **%s**", signal.emoji, payload.Sender.Login, payload.Sender.HTMLURL, i18n.T(signal.verb), repoFullName)
+	if ghEvent == "star" && payload.Repository.StargazersCount > 0 {
+		description += i18n.Tf(" (%d stars)", payload.Repository.StargazersCount)
+	}
+
+	message := discord.ThreadMessage{
+		Embeds: []discord.Embed{{Description: description, Color: discord.ColorGray}},
+	}
+	return app.postActivity(ctx, repoFullName, message)
+}
+
+// postActivity 發訊息到 repo 的 activity thread
+func (app *App) postActivity(ctx context.Context, repoFullName string, message discord.ThreadMessage) error {
+	ctx = withDryRunRepo(ctx, repoFullName)
+	threadID, err := app.ensureActivityThread(ctx, repoFullName)
+	if err != nil {
+		return err
+	}
+	return app.postMessage(ctx, threadID, message)
+}
+
+// communityBatcher 累計 star / fork / watch 次數，每個 interval 發一則摘要
+// 計數只存在記憶體，重啟時尚未發送的計數會遺失（這類通知不需要精準）
+type communityBatcher struct {
+	app      *App
+	interval time.Duration
+
+	mu     sync.Mutex
+	counts map[string]map[string]int      // repo → event → 次數
+	users  map[string]map[string][]string // repo → event → 使用者（最多記錄幾個，摘要用）
+}
+
+const communityBatchMaxUsers = 5
+
+func newCommunityBatcher(app *App, interval time.Duration) *communityBatcher {
+	return &communityBatcher{
+		app:      app,
+		interval: interval,
+		counts:   make(map[string]map[string]int),
+		users:    make(map[string]map[string][]string),
+	}
+}
+
+func (b *communityBatcher) add(repoFullName, event, login string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.counts[repoFullName] == nil {
+		b.counts[repoFullName] = make(map[string]int)
+		b.users[repoFullName] = make(map[string][]string)
+	}
+	b.counts[repoFullName][event]++
+	if len(b.users[repoFullName][event]) < communityBatchMaxUsers {
+		b.users[repoFullName][event] = append(b.users[repoFullName][event], login)
+	}
+}
+
+// run 每個 interval flush 一次，ctx 結束時最後 flush 一次
+func (b *communityBatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.flush(context.Background())
+			return
+		case <-ticker.C:
+			b.flush(ctx)
+		}
+	}
+}
+
+func (b *communityBatcher) flush(ctx context.Context) {
+	b.mu.Lock()
+	counts, users := b.counts, b.users
+	b.counts = make(map[string]map[string]int)
+	b.users = make(map[string]map[string][]string)
+	b.mu.Unlock()
+
+	for repoFullName, events := range counts {
+		message := formatCommunitySummary(repoFullName, events, users[repoFullName], b.interval)
+		if err := b.app.postActivity(ctx, repoFullName, message); err != nil {
+			applogger.Log.Error("Failed to post community summary", "repo", repoFullName, "error", err)
+		}
+	}
+}
+
+// formatCommunitySummary 組出摘要訊息，例如 "⭐ 5 new stars (@a, @b, …)"
+func formatCommunitySummary(repoFullName string, counts map[string]int, users map[string][]string, interval time.Duration) discord.ThreadMessage {
+	events := make([]string, 0, len(counts))
+	for event := range counts {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	var lines []string
+	for _, event := range events {
+		signal := communitySignals[event]
+		n := counts[event]
+
+		noun := signal.noun
+		if n > 1 {
+			noun += "s"
+		}
+
+		var mentions []string
+		for _, login := range users[event] {
+			mentions = append(mentions, "@"+login)
+		}
+		if n > len(mentions) {
+			mentions = append(mentions, "…")
+		}
+
+		lines = append(lines, i18n.Tf("%s %d new %s (%s)", signal.emoji, n, i18n.T(noun), strings.Join(mentions, ", ")))
+	}
+
+	return discord.ThreadMessage{
+		Embeds: []discord.Embed{
+			{
+				Title:       i18n.Tf("📈 %s community activity (last %s)", repoFullName, interval),
+				Description: strings.Join(lines, "\n"),
+				URL:         github.WebURL(repoFullName),
+				Color:       discord.ColorGray,
+				Timestamp:   time.Now().Format(time.RFC3339),
+			},
+		},
+	}
+}
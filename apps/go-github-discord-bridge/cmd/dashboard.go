@@ -0,0 +1,101 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dashboardHTML /dashboard 的頁面：瀏覽器輸入 ADMIN_TOKEN 後定期呼叫 /admin/dashboard 更新
+//
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+// handleDashboard GET /dashboard：頁面本身不含資料，資料由頁面帶 token 向 /admin/dashboard 取得；ADMIN_TOKEN 沒有設定時回 404
+func handleDashboard(c *gin.Context) {
+	if config.Current().AdminToken == "" {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", dashboardHTML)
+}
+
+// dashboardRepo dashboard 每個 repo 一列
+type dashboardRepo struct {
+	Repo     string `json:"repo"`
+	Mappings int    `json:"mappings"`
+	repoStat
+	Disabled bool `json:"disabled"`
+}
+
+// handleAdminDashboard GET /admin/dashboard：dashboard 需要的資料（最近的 delivery、失敗、queue、每個 repo 的 mapping 數和最後的錯誤）
+func (app *App) handleAdminDashboard(c *gin.Context) {
+	mappings, err := app.store.ListStale(time.Now().Add(time.Hour))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	deadLetters, err := app.store.ListDeadLetters()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats := app.stats.snapshot()
+	disabled := app.repoSwitches.list()
+	repos := make(map[string]*dashboardRepo)
+	row := func(repo string) *dashboardRepo {
+		r := repos[repo]
+		if r == nil {
+			r = &dashboardRepo{Repo: repo, repoStat: stats[repo]}
+			_, r.Disabled = disabled[repo]
+			repos[repo] = r
+		}
+		return r
+	}
+	for _, m := range mappings {
+		if repo, _, ok := strings.Cut(m.Key, "#"); ok {
+			row(strings.ToLower(repo)).Mappings++
+		}
+	}
+	for repo := range stats {
+		row(repo)
+	}
+	for repo := range disabled {
+		row(repo)
+	}
+	rows := make([]*dashboardRepo, 0, len(repos))
+	for _, r := range repos {
+		rows = append(rows, r)
+	}
+	slices.SortFunc(rows, func(a, b *dashboardRepo) int { return strings.Compare(a.Repo, b.Repo) })
+
+	recent := app.stats.recentDeliveries()
+	failures := []deliveryRecord{}
+	for _, d := range recent {
+		if d.Failed {
+			failures = append(failures, d)
+		}
+	}
+
+	resp := gin.H{
+		"generated_at":  time.Now().UTC(),
+		"repos":         rows,
+		"recent":        recent,
+		"failures":      failures,
+		"dead_letters":  len(deadLetters),
+		"mapping_total": len(mappings),
+	}
+	if app.queue != nil {
+		resp["queue"] = gin.H{"depth": app.queue.Len(), "capacity": app.queue.Cap()}
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/metrics"
+	"dizzycode1112/github-discord-bridge/internal/queue"
+	"dizzycode1112/github-discord-bridge/internal/storage"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// deadLetter queue 重試後仍失敗的事件連同原始 payload 存進 storage，之後用 replay-dead-letters 重新處理
+func (app *App) deadLetter(job queue.Job, attempts int, err error) {
+	log := applogger.Log
+
+	var d queuedDelivery
+	if jsonErr := json.Unmarshal(job.Data, &d); jsonErr != nil {
+		log.Error("Failed to decode queued event for dead letter", "ghEvent", job.Name, "error", jsonErr)
+		return
+	}
+	if d.DeliveryID == "" {
+		d.DeliveryID = fmt.Sprintf("local-%d", time.Now().UnixNano())
+	}
+
+	dl := storage.DeadLetter{
+		DeliveryID: d.DeliveryID,
+		Event:      d.Event,
+		Body:       d.Body,
+		Error:      err.Error(),
+		Attempts:   attempts,
+		FailedAt:   time.Now().UTC(),
+	}
+	// 重新處理後又失敗時累計次數；讀不到之前的紀錄時只記這次的次數，仍然要存下來
+	prev, exists, getErr := app.store.GetDeadLetter(dl.DeliveryID)
+	if getErr != nil {
+		log.Warn("Failed to read previous dead letter, attempts will not include earlier replays", "deliveryID", dl.DeliveryID, "error", getErr)
+	} else if exists {
+		dl.Attempts += prev.Attempts
+	}
+	var payload github.WebhookPayload
+	if json.Unmarshal(d.Body, &payload) == nil {
+		dl.Repo = payload.Repository.FullName
+	}
+
+	if err := app.store.AddDeadLetter(dl); err != nil {
+		log.Error("Failed to store dead letter, event is lost", "deliveryID", dl.DeliveryID, "ghEvent", dl.Event, "error", err)
+		return
+	}
+	metrics.DeadLetters.Inc(dl.Event)
+	log.Warn("Moved event to dead letters", "deliveryID", dl.DeliveryID, "ghEvent", dl.Event, "repo", dl.Repo)
+}
+
+// replayDeadLetter 重新處理一個 dead letter，成功時刪除；失敗時更新錯誤和次數後保留
+func (app *App) replayDeadLetter(ctx context.Context, dl storage.DeadLetter) error {
+	if err := app.store.ReleaseDelivery(dl.DeliveryID); err != nil {
+		return err
+	}
+	err := app.webhooks.Process(ctx, github.Delivery{ID: dl.DeliveryID, Event: dl.Event, Body: dl.Body})
+	if err != nil {
+		dl.Error = err.Error()
+		dl.Attempts++
+		dl.FailedAt = time.Now().UTC()
+		if storeErr := app.store.AddDeadLetter(dl); storeErr != nil {
+			applogger.Log.Warn("Failed to update dead letter", "deliveryID", dl.DeliveryID, "error", storeErr)
+		}
+		return err
+	}
+	return app.store.DeleteDeadLetter(dl.DeliveryID)
+}
+
+// runDeadLetters `main dead-letters [--id ID]`：列出 dead letter；指定 --id 時輸出完整內容（含 payload）的 JSON
+func runDeadLetters(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("dead-letters", flag.ContinueOnError)
+	id := fs.String("id", "", "print the dead letter with this delivery ID as JSON, including its payload")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := newStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if *id != "" {
+		dl, exists, err := store.GetDeadLetter(*id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("dead letter %s not found", *id)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(dl)
+	}
+
+	letters, err := store.ListDeadLetters()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DELIVERY ID\tEVENT\tREPO\tATTEMPTS\tFAILED AT\tERROR")
+	for _, dl := range letters {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", dl.DeliveryID, dl.Event, dl.Repo, dl.Attempts,
+			dl.FailedAt.Local().Format(time.DateTime), oneLine(dl.Error, 80))
+	}
+	return w.Flush()
+}
+
+// runReplayDeadLetters `main replay-dead-letters --id ID[,ID...] | --all`：依失敗的順序重新處理 dead letter，成功的刪除
+// --discard 直接刪除不處理。處理在這個 process 裡進行，bolt / sqlite backend 請先停止 server（檔案只能被一個 process 開啟）
+func runReplayDeadLetters(cfg *config.Config, args []string) error {
+	log := applogger.Log
+
+	fs := flag.NewFlagSet("replay-dead-letters", flag.ContinueOnError)
+	ids := fs.String("id", "", "comma-separated delivery IDs to replay")
+	all := fs.Bool("all", false, "replay every dead letter")
+	discard := fs.Bool("discard", false, "delete the selected dead letters without replaying them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (*ids == "") == !*all {
+		return errors.New("exactly one of --id or --all is required")
+	}
+
+	app, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.store.Close()
+	app.newWebhookHandler(cfg)
+
+	var letters []storage.DeadLetter
+	if *all {
+		if letters, err = app.store.ListDeadLetters(); err != nil {
+			return err
+		}
+	} else {
+		for _, id := range strings.Split(*ids, ",") {
+			id = strings.TrimSpace(id)
+			dl, exists, err := app.store.GetDeadLetter(id)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("dead letter %s not found", id)
+			}
+			letters = append(letters, dl)
+		}
+	}
+
+	var replayed, failed int
+	for _, dl := range letters {
+		if *discard {
+			if err := app.store.DeleteDeadLetter(dl.DeliveryID); err != nil {
+				return err
+			}
+			log.Info("Discarded dead letter", "deliveryID", dl.DeliveryID, "ghEvent", dl.Event)
+			continue
+		}
+		if err := app.replayDeadLetter(context.Background(), dl); err != nil {
+			failed++
+			log.Error("Replay failed", "deliveryID", dl.DeliveryID, "ghEvent", dl.Event, "error", err)
+			continue
+		}
+		replayed++
+		log.Info("Replayed dead letter", "deliveryID", dl.DeliveryID, "ghEvent", dl.Event, "repo", dl.Repo)
+	}
+
+	if *discard {
+		log.Info("Discard finished", "discarded", len(letters))
+		return nil
+	}
+	log.Info("Replay finished", "replayed", replayed, "failed", failed)
+	if failed > 0 {
+		return fmt.Errorf("%d dead letter(s) failed to replay", failed)
+	}
+	return nil
+}
+
+// oneLine 把錯誤訊息壓成一行並截斷，列表才不會被 Discord 回傳的 body 撐爆
+func oneLine(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return s
+}
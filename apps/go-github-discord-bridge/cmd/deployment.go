@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// handleDeployment 開始部署時通知（"deploying to production…"）
+func (app *App) handleDeployment(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	d := payload.Deployment
+	if d == nil || payload.Action != "created" {
+		return nil
+	}
+
+	message := app.render(ctx, "deployment.created", discord.FormatDeployment(d, payload.Repository.FullName))
+	if err := app.postDeploymentMessage(ctx, payload.Repository.FullName, d.Environment, message); err != nil {
+		return err
+	}
+
+	app.announce(ctx, "deployment.created", message)
+	return nil
+}
+
+// handleDeploymentStatus 部署完成 / 失敗時通知，pending、in_progress 等中間狀態不通知
+func (app *App) handleDeploymentStatus(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+
+	status := payload.DeploymentStatus
+	if status == nil {
+		return nil
+	}
+
+	switch status.State {
+	case "success", "failure", "error":
+	default:
+		log.Info("Skipping deployment_status notification", "state", status.State)
+		return nil
+	}
+
+	environment := status.Environment
+	if environment == "" && payload.Deployment != nil {
+		environment = payload.Deployment.Environment
+	}
+
+	message := app.render(ctx, "deployment_status."+status.State, discord.FormatDeploymentStatus(status, payload.Deployment, payload.Repository.FullName))
+	if err := app.postDeploymentMessage(ctx, payload.Repository.FullName, environment, message); err != nil {
+		return err
+	}
+
+	app.announce(ctx, "deployment_status."+status.State, message)
+	return nil
+}
+
+// postDeploymentMessage 依 environment 決定發到哪裡：
+// DISCORD_DEPLOYMENT_CHANNELS 有對應（或 "*"）時發到該 channel，否則發到 repo 的 activity thread
+func (app *App) postDeploymentMessage(ctx context.Context, repoFullName, environment string, message discord.ThreadMessage) error {
+	channels := config.Current().DeploymentChannels
+
+	channelID, ok := channels[environment]
+	if !ok {
+		channelID = channels["*"]
+	}
+
+	if channelID == "" {
+		threadID, err := app.ensureActivityThread(ctx, repoFullName)
+		if err != nil {
+			return err
+		}
+		channelID = threadID
+	}
+
+	_, err := app.discordClient.PostChannelMessage(ctx, channelID, withNonce(ctx, channelID, message))
+	return err
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/cron"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/event"
+	"dizzycode1112/github-discord-bridge/internal/scheduler"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+const (
+	digestMaxEvents = 50 // 每個 repo 最多保留幾個事件（次數照算）
+	digestMaxLines  = 15 // 摘要最多列出幾個事件
+)
+
+// digestOff DISCORD_REPO_DIGEST_SCHEDULES 裡用來關閉某個 repo 的值
+const digestOff = "off"
+
+// digester 把低優先事件（star、push、comment 等）暫存起來，依 cron 排程每個 repo 發一則摘要
+// 和 communityBatcher 一樣只存在記憶體，重啟時尚未發送的事件會遺失
+type digester struct {
+	app       *App
+	events    map[string]bool           // DISCORD_DIGEST_EVENTS，event key / type
+	fallback  string                    // DISCORD_DIGEST_SCHEDULE
+	repos     map[string]string         // DISCORD_REPO_DIGEST_SCHEDULES，repo / owner / "*" → cron
+	schedules map[string]*cron.Schedule // cron 表示式 → 解析結果
+
+	mu      sync.Mutex
+	buffers map[string]*digestBuffer // repo → 暫存的事件
+}
+
+type digestBuffer struct {
+	since  time.Time
+	events []event.Event
+	counts map[string]int // event key → 次數
+}
+
+// newDigester 解析所有排程，沒有任何排程時回傳 nil（不啟用 digest）
+func newDigester(app *App, cfg *config.Config) (*digester, error) {
+	d := &digester{
+		app:       app,
+		events:    cfg.DigestEvents,
+		fallback:  cfg.DigestSchedule,
+		repos:     cfg.RepoDigestSchedules,
+		schedules: make(map[string]*cron.Schedule),
+		buffers:   make(map[string]*digestBuffer),
+	}
+
+	exprs := []string{cfg.DigestSchedule}
+	for _, expr := range cfg.RepoDigestSchedules {
+		exprs = append(exprs, expr)
+	}
+	for _, expr := range exprs {
+		if expr == "" || expr == digestOff || d.schedules[expr] != nil {
+			continue
+		}
+		schedule, err := cron.Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid digest schedule: %w", err)
+		}
+		d.schedules[expr] = schedule
+	}
+	if len(d.schedules) == 0 {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// scheduleFor repo 使用的 cron 表示式，空字串 = 這個 repo 不用 digest
+func (d *digester) scheduleFor(repoFullName string) string {
+	expr, ok := lookupRepo(d.repos, repoFullName)
+	if !ok {
+		expr = d.fallback
+	}
+	if expr == digestOff {
+		return ""
+	}
+	return expr
+}
+
+// add 事件屬於 digest 時暫存起來並回傳 true，呼叫端就不再即時處理；d 為 nil 時一律回傳 false
+func (d *digester) add(ev event.Event) bool {
+	if d == nil || ev.Repo == "" || d.scheduleFor(ev.Repo) == "" {
+		return false
+	}
+	if !d.events[ev.Key()] && !d.events[ev.Type] {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	repo := strings.ToLower(ev.Repo)
+	buf := d.buffers[repo]
+	if buf == nil {
+		buf = &digestBuffer{since: time.Now(), counts: make(map[string]int)}
+		d.buffers[repo] = buf
+	}
+	buf.counts[ev.Key()]++
+	if len(buf.events) < digestMaxEvents {
+		buf.events = append(buf.events, ev)
+	}
+	return true
+}
+
+// schedule 每個排程註冊一個 job（digest:<cron>），到時間時 flush 使用該排程的 repo
+func (d *digester) schedule(s *scheduler.Scheduler) error {
+	for expr, schedule := range d.schedules {
+		err := s.Add(scheduler.Job{
+			Name:     "digest:" + expr,
+			Schedule: schedule,
+			Run: func(ctx context.Context) error {
+				return d.flush(ctx, func(repo string) bool { return d.scheduleFor(repo) == expr })
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run ctx 結束時 flush 全部，shutdown 時不丟掉暫存的事件
+func (d *digester) run(ctx context.Context) {
+	<-ctx.Done()
+	d.flush(context.Background(), func(string) bool { return true })
+}
+
+// flush 發送 match 的 repo 暫存的事件
+func (d *digester) flush(ctx context.Context, match func(repo string) bool) error {
+	d.mu.Lock()
+	due := make(map[string]*digestBuffer)
+	for repo, buf := range d.buffers {
+		if match(repo) {
+			due[repo] = buf
+			delete(d.buffers, repo)
+		}
+	}
+	d.mu.Unlock()
+
+	var failed int
+	for _, buf := range due {
+		repoFullName := buf.events[0].Repo
+		message := discord.FormatDigest(repoFullName, buf.events, buf.counts, buf.since, digestMaxLines)
+		if err := d.app.postActivity(ctx, repoFullName, message); err != nil {
+			applogger.Log.Error("Failed to post digest", "repo", repoFullName, "error", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to post %d of %d digest(s)", failed, len(due))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// handleDiscussion discussion 建立時開 thread（套用 repo tag 和 category tag），標記解答時貼出 answer
+func (app *App) handleDiscussion(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+
+	discussion := payload.Discussion
+	if discussion == nil {
+		log.Warn("No discussion in payload, ignoring")
+		return nil
+	}
+
+	discussionID := payload.GetPRIdentifier()
+	repoFullName := payload.Repository.FullName
+
+	switch payload.Action {
+	case "created":
+		return app.handleDiscussionCreated(ctx, discussionID, discussion, repoFullName)
+	case "answered":
+		threadID, exists, err := app.store.Get(discussionID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.Info("No thread for discussion, skipping answer", "discussionID", discussionID)
+			return nil
+		}
+		return app.postMessage(ctx, threadID, app.render(ctx, "discussion.answered", discord.FormatDiscussionAnswered(discussion, payload.Answer)))
+	default:
+		log.Info("Ignoring discussion action", "action", payload.Action)
+		return nil
+	}
+}
+
+func (app *App) handleDiscussionCreated(ctx context.Context, discussionID string, discussion *github.Discussion, repoFullName string) error {
+	log := applogger.Log
+
+	if existingThreadID, exists, _ := app.store.Get(discussionID); exists {
+		log.Info("Thread already exists", "discussionID", discussionID, "threadID", existingThreadID)
+		return nil
+	}
+
+	tagIDs := app.repoTagIDs(ctx, repoFullName)
+	if category := discussion.Category.Name; category != "" {
+		if tagID, err := app.forum(repoFullName).GetOrCreateTag(ctx, category, discord.TagOptions{}); err != nil {
+			log.Warn("Failed to get/create category tag", "category", category, "error", err)
+		} else {
+			tagIDs = append(tagIDs, tagID)
+		}
+	}
+
+	title := discord.PrefixThreadName(threadEmoji("discussion", nil), discord.FormatThreadTitle(discussion.Number, discussion.Title, repoFullName))
+	message := app.render(ctx, "discussion.created", discord.FormatDiscussionCreated(discussion))
+
+	threadID, err := app.forum(repoFullName).CreateThread(ctx, title, message, tagIDs...)
+	if err != nil {
+		return fmt.Errorf("failed to create thread: %w", err)
+	}
+
+	if err := app.store.Set(discussionID, threadID); err != nil {
+		return fmt.Errorf("failed to save mapping: %w", err)
+	}
+
+	log.Info("Created discussion thread", "discussionID", discussionID, "threadID", threadID)
+
+	app.addThreadMembers(ctx, threadID, discussion.User)
+	app.seedReaction(ctx, threadID, threadID, "discussion.created")
+	app.announce(ctx, "discussion.created", message)
+	return nil
+}
+
+// handleDiscussionComment 把 discussion 的新留言貼到已存在的 thread
+func (app *App) handleDiscussionComment(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+
+	if payload.Action != "created" || payload.Discussion == nil || payload.Comment == nil {
+		return nil
+	}
+
+	discussionID := payload.GetPRIdentifier()
+	threadID, exists, err := app.store.Get(discussionID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		log.Info("No thread for discussion, skipping comment", "discussionID", discussionID)
+		return nil
+	}
+
+	return app.postMessage(ctx, threadID, app.render(ctx, "discussion_comment.created", discord.FormatIssueComment(payload.Comment, payload.Discussion.Number)))
+}
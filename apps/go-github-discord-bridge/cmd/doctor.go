@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+)
+
+// doctorTimeout 每個檢查項目預設最多等多久
+const doctorTimeout = 10 * time.Second
+
+// doctor 檢查結果的狀態
+const (
+	doctorOK   = "ok"
+	doctorWarn = "warn"
+	doctorFail = "FAIL"
+	doctorSkip = "skip"
+)
+
+// doctorResult 一個檢查項目的結果
+type doctorResult struct {
+	status string
+	check  string
+	detail string
+}
+
+// doctorReport 依序收集檢查結果
+type doctorReport struct {
+	timeout time.Duration
+	results []doctorResult
+}
+
+func (r *doctorReport) add(status, check, format string, args ...any) {
+	r.results = append(r.results, doctorResult{status: status, check: check, detail: fmt.Sprintf(format, args...)})
+}
+
+func (r *doctorReport) count(status string) int {
+	n := 0
+	for _, res := range r.results {
+		if res.status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *doctorReport) print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tCHECK\tDETAIL")
+	for _, res := range r.results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", res.status, res.check, res.detail)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%d ok, %d warning(s), %d failed, %d skipped\n",
+		r.count(doctorOK), r.count(doctorWarn), r.count(doctorFail), r.count(doctorSkip))
+}
+
+// runDoctor `main doctor`：部署前或出問題時檢查設定和外部依賴，印出報告，有 FAIL 時結束碼為 1
+// 檢查對外連線、Discord token、forum channel（是不是 forum、bot 的權限）、webhook secret 和 storage；不會寫入 Discord 或 storage
+func runDoctor(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	timeout := fs.Duration("timeout", doctorTimeout, "timeout for each check")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	r := &doctorReport{timeout: *timeout}
+
+	r.checkNetwork(ctx, cfg)
+	if cfg.IngestMode == config.IngestReceiver {
+		r.add(doctorSkip, "discord", "INGEST_MODE=receiver does not call Discord")
+		r.add(doctorSkip, "storage", "INGEST_MODE=receiver does not use storage")
+	} else {
+		r.checkDiscord(ctx, cfg)
+		r.checkStore(ctx, cfg)
+	}
+	if cfg.IngestMode == config.IngestPublisher {
+		r.add(doctorSkip, "webhook secret", "INGEST_MODE=publisher does not receive webhooks")
+	} else {
+		r.checkWebhookSecret(cfg)
+	}
+
+	r.print(os.Stdout)
+	if n := r.count(doctorFail); n > 0 {
+		return fmt.Errorf("%d check(s) failed", n)
+	}
+	return nil
+}
+
+// checkNetwork 確認連得到 Discord、GitHub API、broker 和 OTLP collector（只看連線，HTTP 狀態碼不影響結果）
+func (r *doctorReport) checkNetwork(ctx context.Context, cfg *config.Config) {
+	type target struct {
+		name     string
+		url      string
+		required bool
+	}
+	needsGitHub := cfg.GitHubToken != "" || cfg.GitHubAppID != "" || cfg.GitHubIPAllowlist
+	targets := []target{
+		{"network: discord", cfg.DiscordAPIBaseURL, cfg.IngestMode != config.IngestReceiver},
+	}
+	if needsGitHub {
+		targets = append(targets, target{"network: github api", cfg.GitHubAPIURL, true})
+	} else {
+		r.add(doctorSkip, "network: github api", "no GITHUB_TOKEN / GITHUB_APP_ID / GITHUB_IP_ALLOWLIST configured")
+	}
+	if cfg.IngestMode != "" {
+		targets = append(targets, target{"network: broker", cfg.BrokerURL, true})
+	}
+	if cfg.OTelEndpoint != "" {
+		targets = append(targets, target{"network: otlp", cfg.OTelEndpoint, false})
+	}
+
+	for _, t := range targets {
+		elapsed, err := r.reach(ctx, t.url)
+		switch {
+		case err == nil:
+			r.add(doctorOK, t.name, "%s reachable in %dms", redactURL(t.url), elapsed.Milliseconds())
+		case t.required:
+			r.add(doctorFail, t.name, "%s unreachable: %v", redactURL(t.url), err)
+		default:
+			r.add(doctorWarn, t.name, "%s unreachable: %v", redactURL(t.url), err)
+		}
+	}
+}
+
+// reach http(s) URL 送 GET（任何 HTTP 回應都算連得到），其他 scheme（nats://）只建立 TCP 連線
+func (r *doctorReport) reach(ctx context.Context, rawURL string) (time.Duration, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return 0, fmt.Errorf("invalid URL %q", redactURL(rawURL))
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	if u.Scheme != "http" && u.Scheme != "https" {
+		host := u.Host
+		if u.Port() == "" && u.Scheme == "nats" {
+			host = net.JoinHostPort(u.Hostname(), "4222")
+		}
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return 0, err
+		}
+		conn.Close()
+		return time.Since(start), nil
+	}
+
+	u.User = nil
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return time.Since(start), nil
+}
+
+// checkDiscord 確認 token 有效，再檢查每個 forum channel（DISCORD_FORUM_CHANNEL_ID、DISCORD_REPO_FORUM_CHANNELS）
+// 和其他設定的 channel（announcement、security、low priority）
+func (r *doctorReport) checkDiscord(ctx context.Context, cfg *config.Config) {
+	client := discord.NewClient(cfg.DiscordBotToken, cfg.DiscordForumChID,
+		discord.WithBaseURL(cfg.DiscordAPIBaseURL),
+		discord.WithAPIVersion(cfg.DiscordAPIVersion),
+		discord.WithTimeout(min(cfg.DiscordHTTPTimeout, r.timeout)),
+	)
+
+	tokenCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	bot, err := client.GetCurrentUser(tokenCtx)
+	cancel()
+	switch {
+	case errors.Is(err, discord.ErrUnauthorized):
+		r.add(doctorFail, "discord token", "DISCORD_BOT_TOKEN is invalid (401), regenerate it in the Developer Portal")
+	case err != nil:
+		r.add(doctorFail, "discord token", "%v", err)
+	default:
+		r.add(doctorOK, "discord token", "authenticated as %s (%s)", bot.Username, bot.ID)
+	}
+	if err != nil {
+		r.add(doctorSkip, "discord channels", "requires a valid DISCORD_BOT_TOKEN")
+		return
+	}
+
+	r.checkForum(ctx, client, "DISCORD_FORUM_CHANNEL_ID", cfg.DiscordForumChID, bot.ID)
+	seen := map[string]bool{cfg.DiscordForumChID: true}
+	for _, repo := range slices.Sorted(maps.Keys(cfg.RepoForumChannels)) {
+		if channelID := cfg.RepoForumChannels[repo]; !seen[channelID] {
+			seen[channelID] = true
+			r.checkForum(ctx, client, "DISCORD_REPO_FORUM_CHANNELS["+repo+"]", channelID, bot.ID)
+		}
+	}
+
+	for _, c := range []struct{ key, id string }{
+		{"DISCORD_ANNOUNCEMENT_CHANNEL_ID", cfg.DiscordAnnouncementChID},
+		{"DISCORD_SECURITY_CHANNEL_ID", cfg.SecurityChannelID},
+		{"DISCORD_LOW_PRIORITY_CHANNEL_ID", cfg.LowPriorityChannelID},
+	} {
+		if c.id == "" {
+			continue
+		}
+		if channel, ok := r.getChannel(ctx, client, c.key, c.id); ok {
+			r.add(doctorOK, c.key, "#%s (%s)", channel.Name, channel.ID)
+		}
+	}
+}
+
+// checkForum 確認 channel 是 forum 而且 bot 有需要的權限
+func (r *doctorReport) checkForum(ctx context.Context, client *discord.Client, key, channelID, botID string) {
+	channel, ok := r.getChannel(ctx, client, key, channelID)
+	if !ok {
+		return
+	}
+	if channel.Type != discord.ChannelTypeGuildForum {
+		r.add(doctorFail, key, "#%s (%s) is not a forum channel (type %d)", channel.Name, channel.ID, channel.Type)
+		return
+	}
+	r.add(doctorOK, key, "#%s (%s) is a forum channel", channel.Name, channel.ID)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	missing, err := client.MissingPermissions(ctx, channel, botID)
+	check := "permissions: " + key
+	switch {
+	case err != nil:
+		r.add(doctorFail, check, "%v", err)
+	case len(missing) > 0:
+		names := make([]string, len(missing))
+		for i, p := range missing {
+			names[i] = fmt.Sprintf("%s（%s）", p.Name, p.Why)
+		}
+		r.add(doctorFail, check, "missing %s", strings.Join(names, ", "))
+	default:
+		r.add(doctorOK, check, "all required permissions granted")
+	}
+}
+
+// getChannel 取得 channel，失敗時記一筆 FAIL 並回傳 false
+func (r *doctorReport) getChannel(ctx context.Context, client *discord.Client, key, channelID string) (*discord.Channel, bool) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	channel, err := client.GetChannel(ctx, channelID)
+	switch {
+	case errors.Is(err, discord.ErrNotFound):
+		r.add(doctorFail, key, "channel %s not found (or the bot is not in its server)", channelID)
+	case errors.Is(err, discord.ErrMissingPermissions):
+		r.add(doctorFail, key, "bot cannot view channel %s (needs VIEW_CHANNEL)", channelID)
+	case err != nil:
+		r.add(doctorFail, key, "%v", err)
+	default:
+		return channel, true
+	}
+	return nil, false
+}
+
+// minWebhookSecretLength 比這個短的 secret 只給警告（GitHub 建議用高 entropy 的隨機字串）
+const minWebhookSecretLength = 16
+
+// checkWebhookSecret 確認有設定 webhook secret（沒有時不驗證簽名，任何人都能送假的 webhook）
+func (r *doctorReport) checkWebhookSecret(cfg *config.Config) {
+	if cfg.GitHubWebhookSecret == "" && len(cfg.GitHubWebhookRepoSecrets) == 0 {
+		r.add(doctorWarn, "webhook secret", "GITHUB_WEBHOOK_SECRET is not set, webhook signatures are not verified")
+		return
+	}
+
+	var short []string
+	if cfg.GitHubWebhookSecret != "" && len(cfg.GitHubWebhookSecret) < minWebhookSecretLength {
+		short = append(short, "GITHUB_WEBHOOK_SECRET")
+	}
+	if cfg.GitHubWebhookSecondarySecret != "" && len(cfg.GitHubWebhookSecondarySecret) < minWebhookSecretLength {
+		short = append(short, "GITHUB_WEBHOOK_SECRET_SECONDARY")
+	}
+	for _, repo := range slices.Sorted(maps.Keys(cfg.GitHubWebhookRepoSecrets)) {
+		if len(cfg.GitHubWebhookRepoSecrets[repo]) < minWebhookSecretLength {
+			short = append(short, "GITHUB_WEBHOOK_REPO_SECRETS["+repo+"]")
+		}
+	}
+
+	detail := "set"
+	if cfg.GitHubWebhookSecret == "" {
+		detail = "only repo-specific secrets set, webhooks from other repos are rejected"
+	}
+	if cfg.GitHubWebhookSecondarySecret != "" {
+		detail += ", secondary secret set"
+	}
+	if n := len(cfg.GitHubWebhookRepoSecrets); n > 0 && cfg.GitHubWebhookSecret != "" {
+		detail += fmt.Sprintf(", %d repo-specific secret(s)", n)
+	}
+	if len(short) > 0 {
+		r.add(doctorWarn, "webhook secret", "%s; shorter than %d characters: %s", detail, minWebhookSecretLength, strings.Join(short, ", "))
+		return
+	}
+	r.add(doctorOK, "webhook secret", "%s", detail)
+}
+
+// checkStore 開啟 STORAGE_BACKEND 並 Ping（sqlite / bolt 的檔案不存在時會建立）
+func (r *doctorReport) checkStore(ctx context.Context, cfg *config.Config) {
+	check := "storage: " + cfg.StorageBackend
+	target := map[string]string{
+		"redis":    redactURL(cfg.RedisURL),
+		"postgres": redactURL(cfg.PostgresURL),
+		"sqlite":   cfg.SQLitePath,
+		"bolt":     cfg.BoltPath,
+	}[cfg.StorageBackend]
+
+	// 不用 newStore：doctor 不寫入 storage，舊版 record 的搬移留給 server
+	store, err := openStore(cfg)
+	if err != nil {
+		r.add(doctorFail, check, "cannot open %s: %v", target, err)
+		return
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	if err := store.Ping(ctx); err != nil {
+		r.add(doctorFail, check, "%s: %v", target, err)
+		return
+	}
+	r.add(doctorOK, check, "%s", target)
+}
+
+// redactURL 把 URL 裡的密碼換成 xxxxx，不是 URL 時原樣回傳
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	return u.Redacted()
+}
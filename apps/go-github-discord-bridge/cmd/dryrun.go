@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/storage"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// dryRunLimit admin API 保留最近幾個 dry-run 的 request
+const dryRunLimit = 100
+
+// dryRunEnabled DRY_RUN 或 repo 符合 DRY_RUN_REPOS 時不送出 Discord 的寫入 request
+func dryRunEnabled(repoFullName string) bool {
+	cfg := config.Current()
+	return cfg.DryRun || (repoFullName != "" && matchAny(cfg.DryRunRepos, strings.ToLower(repoFullName)))
+}
+
+// dryRunRepoKey 不是直接由 webhook 觸發的訊息（digest、burst 摘要等）所屬的 repo，見 withDryRunRepo
+type dryRunRepoKey struct{}
+
+// withDryRunRepo 標記 ctx 所屬的 repo，讓 DRY_RUN_REPOS 也套用到批次發送的摘要
+func withDryRunRepo(ctx context.Context, repoFullName string) context.Context {
+	return context.WithValue(ctx, dryRunRepoKey{}, repoFullName)
+}
+
+// dryRunRepo ctx 所屬的 repo：withDryRunRepo 標記的優先，其次是正在處理的 event
+func dryRunRepo(ctx context.Context) string {
+	if repo, ok := ctx.Value(dryRunRepoKey{}).(string); ok {
+		return repo
+	}
+	if current, ok := ctx.Value(eventContextKey{}).(eventContext); ok {
+		return current.ev.Repo
+	}
+	return ""
+}
+
+// dryRunRecord 一個沒有送出的 Discord request；建立 thread 時另外列出標題和 tag 名稱方便確認
+type dryRunRecord struct {
+	At         time.Time       `json:"at"`
+	DeliveryID string          `json:"delivery_id,omitempty"`
+	Event      string          `json:"event,omitempty"`
+	Repo       string          `json:"repo,omitempty"`
+	Method     string          `json:"method"`
+	Path       string          `json:"path"`
+	Title      string          `json:"title,omitempty"`
+	Tags       []string        `json:"tags,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+}
+
+// dryRunLog 最近 dry-run 的 request（環狀 buffer，只在記憶體）
+type dryRunLog struct {
+	mu      sync.Mutex
+	records []dryRunRecord
+	next    int
+}
+
+func (l *dryRunLog) add(rec dryRunRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.records) < dryRunLimit {
+		l.records = append(l.records, rec)
+	} else {
+		l.records[l.next] = rec
+	}
+	l.next = (l.next + 1) % dryRunLimit
+}
+
+// list 新的在前；repo 不為空字串時只列這個 repo 的
+func (l *dryRunLog) list(repo string) []dryRunRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]dryRunRecord, 0, len(l.records))
+	for i := range len(l.records) {
+		rec := l.records[(l.next-1-i+len(l.records))%len(l.records)]
+		if repo == "" || strings.EqualFold(rec.Repo, repo) {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// dryRunRequest 實作 discord.DryRunFunc：dry-run 的 repo 的寫入 request 不送出，記 log 並保留給 GET /admin/dry-run
+// GET（forum 的 available_tags 等）照常送出，選到的 tag 才和實際相符；dry-run 建立的假 thread / message 則一律不送
+func (app *App) dryRunRequest(ctx context.Context, req discord.DryRunRequest) bool {
+	repo := dryRunRepo(ctx)
+	if !dryRunEnabled(repo) {
+		return false
+	}
+	if req.Method == http.MethodGet && !strings.Contains(req.Path, "/dry-run-") {
+		return false
+	}
+
+	rec := dryRunRecord{At: time.Now().UTC(), Repo: repo, Method: req.Method, Path: req.Path, Body: req.Body}
+	if current, ok := ctx.Value(eventContextKey{}).(eventContext); ok {
+		rec.DeliveryID, rec.Event = current.ev.DeliveryID, current.ev.Key()
+	}
+	if req.Method == http.MethodPost && req.Route == "/channels/:id/threads" {
+		var thread struct {
+			Name        string   `json:"name"`
+			AppliedTags []string `json:"applied_tags"`
+		}
+		if json.Unmarshal(req.Body, &thread) == nil {
+			forumID, _, _ := strings.Cut(strings.TrimPrefix(req.Path, "/channels/"), "/")
+			forum := app.discordClient.ForForum(forumID)
+			rec.Title = thread.Name
+			for _, id := range thread.AppliedTags {
+				if name := forum.TagName(id); name != "" {
+					id = name
+				}
+				rec.Tags = append(rec.Tags, id)
+			}
+		}
+	}
+	app.dryRuns.add(rec)
+
+	applogger.Log.Info("Dry run: Discord request not sent",
+		"method", rec.Method, "path", rec.Path, "repo", rec.Repo, "ghEvent", rec.Event, "deliveryID", rec.DeliveryID,
+		"title", rec.Title, "tags", rec.Tags, "body", string(rec.Body))
+	return true
+}
+
+// dryRunStore dry-run 建立的假 thread / message 的 mapping 只放在記憶體，不寫進 backend
+// 關掉 dry-run 後這些 mapping 就失效，之後的事件照常建立真的 thread；其他方法直接交給 backend
+type dryRunStore struct {
+	storage.Store
+
+	mu       sync.Mutex
+	mappings map[string]string // key → dry-run 的 thread / message ID
+}
+
+func newDryRunStore(store storage.Store) *dryRunStore {
+	return &dryRunStore{Store: store, mappings: make(map[string]string)}
+}
+
+// lookup 記憶體裡的 mapping；key 所屬的 repo 已經不是 dry-run 時刪掉
+func (s *dryRunStore) lookup(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.mappings[key]
+	if !ok {
+		return "", false
+	}
+	if repo, _, _ := strings.Cut(key, "#"); !dryRunEnabled(repo) {
+		delete(s.mappings, key)
+		return "", false
+	}
+	return id, true
+}
+
+// forget 刪除記憶體裡的 mapping，回傳原本是否存在
+func (s *dryRunStore) forget(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.mappings[key]
+	delete(s.mappings, key)
+	return ok
+}
+
+func (s *dryRunStore) Set(key, threadID string) error {
+	if discord.IsDryRunID(threadID) {
+		s.mu.Lock()
+		s.mappings[key] = threadID
+		s.mu.Unlock()
+		return nil
+	}
+	s.forget(key)
+	return s.Store.Set(key, threadID)
+}
+
+func (s *dryRunStore) Get(key string) (string, bool, error) {
+	if id, ok := s.lookup(key); ok {
+		return id, true, nil
+	}
+	return s.Store.Get(key)
+}
+
+func (s *dryRunStore) Delete(key string) error {
+	if s.forget(key) {
+		return nil
+	}
+	return s.Store.Delete(key)
+}
+
+func (s *dryRunStore) MarkAsClosed(key string) error {
+	if _, ok := s.lookup(key); ok {
+		return nil
+	}
+	return s.Store.MarkAsClosed(key)
+}
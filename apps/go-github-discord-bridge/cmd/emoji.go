@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+)
+
+// threadEmoji thread 名稱要加的 emoji：依 label 順序第一個有設定 "label:<name>" 的 label 優先，其次是 event type
+func threadEmoji(eventType string, labels []github.Label) string {
+	emojis := config.Current().ThreadEmojis
+	for _, label := range labels {
+		if emoji, ok := emojis["label:"+strings.ToLower(label.Name)]; ok {
+			return emoji
+		}
+	}
+	return emojis[eventType]
+}
+
+// prThreadTitle PR thread 名稱（含 DISCORD_THREAD_EMOJIS 的前綴），建立和改名共用，兩邊才會一致
+func prThreadTitle(pr *github.PullRequest, repoFullName string) string {
+	return discord.PrefixThreadName(threadEmoji("pull_request", pr.Labels), discord.FormatThreadTitle(pr.Number, pr.Title, repoFullName))
+}
+
+// issueThreadTitle issue thread 名稱（含 DISCORD_THREAD_EMOJIS 的前綴）
+func issueThreadTitle(issue *github.Issue, repoFullName string) string {
+	return discord.PrefixThreadName(threadEmoji("issues", issue.Labels), discord.FormatIssueThreadTitle(issue.Number, issue.Title, repoFullName))
+}
+
+// handleLabelEmoji label 有設定 emoji 時，依目前的 label 重新計算 thread 名稱並改名（加上 / 移除 label 都可能換 emoji）
+func (app *App) handleLabelEmoji(ctx context.Context, itemID string, label *github.Label, name string) error {
+	if label == nil {
+		return nil
+	}
+	if _, ok := config.Current().ThreadEmojis["label:"+strings.ToLower(label.Name)]; !ok {
+		return nil
+	}
+	return app.renameThread(ctx, itemID, name, fmt.Sprintf("Label %s changed on %s", label.Name, itemID))
+}
+
+// applyEmbedEmoji 依 event key 或 event type（key 優先）換掉第一個 embed 標題的 emoji
+func applyEmbedEmoji(key string, message discord.ThreadMessage) discord.ThreadMessage {
+	emojis := config.Current().EmbedEmojis
+	if len(emojis) == 0 || len(message.Embeds) == 0 || message.Embeds[0].Title == "" {
+		return message
+	}
+	emoji, ok := emojis[key]
+	if !ok {
+		eventType, _, _ := strings.Cut(key, ".")
+		if emoji, ok = emojis[eventType]; !ok {
+			return message
+		}
+	}
+
+	embeds := append([]discord.Embed(nil), message.Embeds...)
+	embeds[0].Title = discord.ReplaceTitleEmoji(embeds[0].Title, emoji)
+	message.Embeds = embeds
+	return message
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// pullRequestFiles 透過 GitHub API 取得 PR 改了哪些檔案（payload 只有行數）
+// 沒有設定 GitHub token / App 或 API 失敗時回傳 nil，訊息照常發送，只是少了檔案清單
+func (app *App) pullRequestFiles(ctx context.Context, repoFullName string, number int) []github.PullRequestFile {
+	if app.githubAPI == nil {
+		return nil
+	}
+
+	files, err := app.githubAPI.ListPullRequestFiles(ctx, repoFullName, number)
+	if err != nil {
+		applogger.Log.Warn("Failed to fetch pull request files", "repo", repoFullName, "number", number, "error", err)
+		return nil
+	}
+	return files
+}
+
+// postPullRequestDiff 小 PR（additions + deletions 不超過 DISCORD_PR_DIFF_MAX_CHANGES）在 thread 貼出 diff 的前幾個 hunk
+// 沒有設定 DISCORD_PR_DIFF_HUNKS、沒有 GitHub API token 或抓取失敗時略過（只記 log，不影響 thread 建立）
+func (app *App) postPullRequestDiff(ctx context.Context, threadID string, pr *github.PullRequest, repoFullName string) {
+	cfg := config.Current()
+	if app.githubAPI == nil || cfg.PRDiffHunks <= 0 || pr.Additions+pr.Deletions > cfg.PRDiffMaxChanges {
+		return
+	}
+	log := applogger.Log
+
+	diff, err := app.githubAPI.GetPullRequestDiff(ctx, repoFullName, pr.Number, discord.MaxAttachmentSize)
+	if errors.Is(err, github.ErrDiffTooLarge) {
+		log.Info("Pull request diff too large to post", "repo", repoFullName, "number", pr.Number)
+		return
+	}
+	if err != nil {
+		log.Warn("Failed to fetch pull request diff", "repo", repoFullName, "number", pr.Number, "error", err)
+		return
+	}
+
+	message, ok := discord.FormatPRDiff(diff, pr.Number, pr.HTMLURL, cfg.PRDiffHunks)
+	if !ok {
+		return
+	}
+	if err := app.postMessage(ctx, threadID, message); err != nil {
+		log.Warn("Failed to post pull request diff", "repo", repoFullName, "number", pr.Number, "error", err)
+	}
+}
+
+// withBodyImages 把 issue / PR 內文或留言裡的截圖帶進 embed（DISCORD_BODY_IMAGES_MAX，超過 4 張時只顯示前 4 張）
+func withBodyImages(message discord.ThreadMessage, body string) discord.ThreadMessage {
+	return discord.WithImages(message, discord.ExtractImageURLs(body, config.Current().BodyImagesMax))
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/i18n"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// enterpriseActions GHES 專屬 enterprise event 的 action 說明
+var enterpriseActions = map[string]string{
+	"anonymous_access_enabled":  "🔓 Anonymous Git read access enabled",
+	"anonymous_access_disabled": "🔒 Anonymous Git read access disabled",
+}
+
+// handleEnterprise 處理 GitHub Enterprise Server 的 site admin 事件（只有 GHES 的 global webhook 會送）
+// 事件沒有 repository，有設定共用 activity thread 時才發
+func (app *App) handleEnterprise(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	threadID := config.Current().DiscordActivityThreadID
+	if threadID == "" {
+		applogger.Log.Info("Skipping enterprise event: no shared activity thread", "action", payload.Action)
+		return nil
+	}
+
+	description, ok := enterpriseActions[payload.Action]
+	if ok {
+		description = i18n.T(description)
+	} else {
+		description = i18n.Tf("🏢 Enterprise %s", payload.Action)
+	}
+
+	message := discord.ThreadMessage{
+		Embeds: []discord.Embed{{
+			Description: i18n.Tf("%s by @%s", description, payload.Sender.Login),
+			Color:       discord.ColorGray,
+		}},
+	}
+	return app.postMessage(ctx, threadID, message)
+}
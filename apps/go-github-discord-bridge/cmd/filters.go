@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/event"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// repoAllowed 依 GITHUB_REPO_ALLOWLIST / GITHUB_REPO_BLOCKLIST 判斷要不要處理這個 repo（不分大小寫）
+// 符合 blocklist 一律略過；allowlist 有設定時必須符合其中一個 pattern
+func repoAllowed(repoFullName string) bool {
+	cfg := config.Current()
+	name := strings.ToLower(repoFullName)
+
+	if matchAny(cfg.RepoBlocklist, name) {
+		applogger.Log.Info("Skipping blocklisted repository", "repo", repoFullName)
+		return false
+	}
+	if len(cfg.RepoAllowlist) > 0 && !matchAny(cfg.RepoAllowlist, name) {
+		applogger.Log.Info("Skipping repository not in allowlist", "repo", repoFullName)
+		return false
+	}
+	return true
+}
+
+// repoEnabled 給 WebhookHandler 的 repo filter：admin API 暫停的 repo 略過，其他依 repoAllowed 判斷
+func (app *App) repoEnabled(repoFullName string) bool {
+	if app.repoSwitches.isDisabled(repoFullName) {
+		applogger.Log.Info("Skipping repository disabled via admin API", "repo", repoFullName)
+		return false
+	}
+	return repoAllowed(repoFullName)
+}
+
+// branchAllowed 依 DISCORD_BRANCH_FILTERS 判斷 push / CI 事件的 branch 要不要通知
+// 比對順序：完整 repo 名稱 → owner → "*"；都沒設定時不過濾
+func branchAllowed(repoFullName, branch string) bool {
+	filters := config.Current().BranchFilters
+	if len(filters) == 0 || branch == "" {
+		return true
+	}
+
+	if patterns, ok := lookupRepo(filters, repoFullName); ok {
+		return matchAny(patterns, branch)
+	}
+	return true
+}
+
+// lookupRepo 依 repo 找設定值，比對順序：完整 repo 名稱 → owner → "*"（key 需為小寫）
+func lookupRepo[T any](m map[string]T, repoFullName string) (T, bool) {
+	name := strings.ToLower(repoFullName)
+	owner, _, _ := strings.Cut(name, "/")
+	for _, key := range []string{name, owner, "*"} {
+		if v, ok := m[key]; ok {
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// senderClass sender 過濾的結果
+type senderClass int
+
+const (
+	senderNormal      senderClass = iota
+	senderIgnored                 // 直接丟掉
+	senderLowPriority             // 改發精簡訊息到 low-priority channel
+)
+
+// senderPriority 依 DISCORD_IGNORED_SENDERS / DISCORD_LOW_PRIORITY_SENDERS 分類 sender（不分大小寫）
+// 清單裡的 "*[bot]" 代表所有 bot 帳號；low-priority 但沒有設定 channel 時視同 ignored
+func senderPriority(login string) senderClass {
+	cfg := config.Current()
+	login = strings.ToLower(login)
+
+	if senderListed(cfg.IgnoredSenders, login) {
+		return senderIgnored
+	}
+	if senderListed(cfg.LowPrioritySenders, login) {
+		if cfg.LowPriorityChannelID == "" {
+			return senderIgnored
+		}
+		return senderLowPriority
+	}
+	return senderNormal
+}
+
+// senderListed login 是否在清單中（bot 的 login 帶 "[bot]"，用 glob 比對會被當成字元集合，所以這裡只支援完全比對和 "*[bot]"）
+func senderListed(senders map[string]bool, login string) bool {
+	if login == "" {
+		return false
+	}
+	return senders[login] || (senders["*[bot]"] && strings.HasSuffix(login, "[bot]"))
+}
+
+// postLowPriority 把事件改成一行摘要發到 low-priority channel，不建立 / 更新 forum thread
+func (app *App) postLowPriority(ctx context.Context, ev event.Event) error {
+	channelID := config.Current().LowPriorityChannelID
+	message := discord.FormatLowPriorityEvent(ev)
+	_, err := app.discordClient.PostChannelMessage(ctx, channelID, withNonce(ctx, channelID, message))
+	return err
+}
+
+// matchAny name 是否符合任一個 glob pattern
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// collectGarbage 刪掉不再需要的 mapping，讓 store 的大小跟著實際開著的 thread 走：
+//   - 關閉超過 GC_CLOSED_RETENTION 的（不等 ClosedPRTTL）
+//   - 指向的 thread 已被刪除的（只檢查存 thread ID 的 key；milestone / status 存的是訊息 ID）
+func (app *App) collectGarbage(ctx context.Context) error {
+	log := applogger.Log
+
+	// ListStale 給未來的時間 = 全部
+	mappings, err := app.store.ListStale(time.Now().Add(time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to list mappings for GC: %w", err)
+	}
+
+	closedBefore := time.Now().Add(-config.Current().GCClosedRetention)
+	var removed int
+	for _, m := range mappings {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		reason := ""
+		switch {
+		case m.Closed && m.UpdatedAt.Before(closedBefore):
+			reason = "closed"
+		case holdsThreadID(m.Key):
+			if _, err := app.discordClient.GetThread(ctx, m.ThreadID); errors.Is(err, discord.ErrNotFound) {
+				reason = "thread deleted"
+			} else if err != nil {
+				log.Warn("Failed to check thread for GC", "key", m.Key, "threadID", m.ThreadID, "error", err)
+			}
+		}
+		if reason == "" {
+			continue
+		}
+
+		if err := app.store.Delete(m.Key); err != nil {
+			log.Warn("Failed to delete mapping", "key", m.Key, "error", err)
+			continue
+		}
+		log.Info("GC removed mapping", "key", m.Key, "threadID", m.ThreadID, "reason", reason)
+		removed++
+	}
+
+	log.Info("GC finished", "mappings", len(mappings), "removed", removed)
+	return nil
+}
+
+// collectTags 移除每個 forum（DISCORD_FORUM_CHANNEL_ID 和 DISCORD_REPO_FORUM_CHANNELS）上沒有任何 thread 使用的 tag
+// repo / label / discussion category 的 tag 是自動建立的，累積到 Discord 的 20 個上限後就無法再建立；TAG_GC_KEEP 列出的不移除
+// 之後再用到被移除的 tag 時 GetOrCreateTag 會重新建立
+func (app *App) collectTags(ctx context.Context) error {
+	log := applogger.Log
+	cfg := config.Current()
+
+	forums := []*discord.Client{app.discordClient}
+	seen := make(map[*discord.Client]bool)
+	for _, channelID := range cfg.RepoForumChannels {
+		if forum := app.discordClient.ForForum(channelID); forum != app.discordClient && !seen[forum] {
+			seen[forum] = true
+			forums = append(forums, forum)
+		}
+	}
+
+	var errs []error
+	for _, forum := range forums {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		threads, err := forum.ListForumThreads(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		used := make(map[string]bool)
+		for _, thread := range threads {
+			for _, id := range thread.AppliedTags {
+				used[id] = true
+			}
+		}
+		removed, err := forum.RemoveTags(ctx, func(tag discord.ForumTag) bool {
+			return !used[tag.ID] && !slices.Contains(cfg.TagGCKeep, tag.Name)
+		}, "Remove forum tags not used by any thread")
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		log.Info("Tag GC finished", "threads", len(threads), "removed", removed)
+	}
+	return errors.Join(errs...)
+}
+
+// holdsThreadID key 的值是不是 thread ID：issue / PR / discussion、activity、release 是；
+// milestone（訊息 ID）不是
+func holdsThreadID(key string) bool {
+	if _, _, ok := issueMappingKey(key); ok {
+		return true
+	}
+	return strings.HasSuffix(key, "#activity") || strings.Contains(key, "@")
+}
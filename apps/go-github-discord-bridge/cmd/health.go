@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	readinessTimeout = 3 * time.Second
+
+	// discordTokenCheckTTL token 檢查結果快取多久，probe 每幾秒打一次，不需要每次都呼叫 Discord API
+	discordTokenCheckTTL = time.Minute
+)
+
+// readinessCheck readiness 的一個檢查項目，回傳 nil = 正常
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// tokenCheck 快取 Discord token 的檢查結果
+type tokenCheck struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// readinessChecks /readyz 要跑的檢查
+func (app *App) readinessChecks() []readinessCheck {
+	// receiver 只依賴 broker（沒有 store 和 Discord client）
+	if app.broker != nil {
+		return []readinessCheck{{name: "broker", check: app.broker.Ping}}
+	}
+	checks := []readinessCheck{
+		{name: "store", check: app.store.Ping},
+		{name: "discord_token", check: app.checkDiscordToken},
+		{name: "discord_api", check: app.checkDiscordBreaker},
+	}
+	if app.queue != nil {
+		checks = append(checks, readinessCheck{name: "queue", check: app.checkQueue})
+	}
+	return checks
+}
+
+// checkDiscordToken 呼叫 /users/@me 確認 token 有效，結果快取 discordTokenCheckTTL
+func (app *App) checkDiscordToken(ctx context.Context) error {
+	app.token.mu.Lock()
+	defer app.token.mu.Unlock()
+
+	if !app.token.checkedAt.IsZero() && time.Since(app.token.checkedAt) < discordTokenCheckTTL {
+		return app.token.err
+	}
+	_, err := app.discordClient.GetCurrentUser(ctx)
+	if errors.Is(err, discord.ErrUnauthorized) {
+		err = errors.New("DISCORD_BOT_TOKEN is invalid (401)")
+	}
+	app.token.checkedAt, app.token.err = time.Now(), err
+	return err
+}
+
+// checkDiscordBreaker circuit breaker open 時 Discord API 呼叫都會直接失敗，這段期間視為 not ready
+func (app *App) checkDiscordBreaker(context.Context) error {
+	if state := app.discordClient.BreakerState(); state == discord.BreakerOpen {
+		return errors.New("circuit breaker is open")
+	}
+	return nil
+}
+
+// handleHealthz process 還活著就回 200（liveness probe），不檢查外部依賴
+func handleHealthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// handleReadyz 跑所有 readiness 檢查，全部通過回 200，否則回 503 並列出失敗的項目
+func (app *App) handleReadyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := app.readinessChecks()
+	results := make(map[string]string, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ready := true
+	for _, rc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := rc.check(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				results[rc.name] = err.Error()
+				ready = false
+			} else {
+				results[rc.name] = "ok"
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "checks": results})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": results})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// newGitHubApp 依設定建立 GitHub App 認證，private key 優先讀 GITHUB_APP_PRIVATE_KEY，沒有時讀 GITHUB_APP_PRIVATE_KEY_PATH
+func newGitHubApp(cfg *config.Config) (*github.AppAuth, error) {
+	key := []byte(cfg.GitHubAppPrivateKey)
+	if len(key) == 0 {
+		if cfg.GitHubAppPrivateKeyPath == "" {
+			return nil, errors.New("GITHUB_APP_PRIVATE_KEY or GITHUB_APP_PRIVATE_KEY_PATH is required when GITHUB_APP_ID is set")
+		}
+		data, err := os.ReadFile(cfg.GitHubAppPrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		key = data
+	}
+	return github.NewAppAuth(cfg.GitHubAppID, key, cfg.GitHubAPIURL)
+}
+
+// handleInstallation 處理 GitHub App 的 installation / installation_repositories
+// App 被移除或停用時丟掉快取的 installation token；有設定共用 activity thread 時發通知，否則只記 log
+func (app *App) handleInstallation(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+
+	if payload.Installation == nil {
+		return nil
+	}
+
+	installationID := payload.Installation.ID
+	if app.githubApp != nil && (payload.Action == "deleted" || payload.Action == "suspend") {
+		app.githubApp.Forget(installationID)
+	}
+
+	log.Info("GitHub App installation changed", "ghEvent", ghEvent, "action", payload.Action, "installationID", installationID,
+		"added", len(payload.ReposAdded), "removed", len(payload.ReposRemoved))
+
+	// installation 事件沒有 repository，只能發到共用的 activity thread
+	threadID := config.Current().DiscordActivityThreadID
+	if threadID == "" {
+		return nil
+	}
+	return app.postMessage(ctx, threadID, discord.FormatInstallation(ghEvent, payload))
+}
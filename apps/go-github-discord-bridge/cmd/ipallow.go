@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/metrics"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ipAllowlistRetry 取得 GitHub meta 失敗時多久後重試（還沒取得過時 webhook 一律回 503）
+const ipAllowlistRetry = time.Minute
+
+// githubIPAllowlist GITHUB_IP_ALLOWLIST：只接受 GitHub 送 webhook 的來源 IP（GET /meta 的 hooks），定期更新
+// 更新失敗時沿用上一次的範圍
+type githubIPAllowlist struct {
+	apiURL string
+	ranges atomic.Pointer[[]netip.Prefix] // nil = 還沒取得過
+}
+
+func newGitHubIPAllowlist(apiURL string) *githubIPAllowlist {
+	return &githubIPAllowlist{apiURL: apiURL}
+}
+
+// refresh 重新取得 hook 的 IP 範圍
+func (a *githubIPAllowlist) refresh(ctx context.Context) error {
+	ranges, err := github.FetchHookRanges(ctx, a.apiURL)
+	if err != nil {
+		return err
+	}
+	if prev := a.ranges.Load(); prev == nil || !slices.Equal(*prev, ranges) {
+		applogger.Log.Info("Updated GitHub webhook IP ranges", "count", len(ranges))
+	}
+	a.ranges.Store(&ranges)
+	return nil
+}
+
+// run 每 interval 更新一次（啟動時先在 runServer 取得第一次）；失敗時 ipAllowlistRetry 後重試
+func (a *githubIPAllowlist) run(ctx context.Context, interval time.Duration) {
+	wait := interval
+	if a.ranges.Load() == nil {
+		wait = min(interval, ipAllowlistRetry)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait = interval
+		if err := a.refresh(ctx); err != nil {
+			applogger.Log.Error("Failed to refresh GitHub webhook IP ranges", "error", err)
+			wait = min(interval, ipAllowlistRetry)
+		}
+	}
+}
+
+// middleware 來源 IP 不在 GitHub 的範圍或 GITHUB_IP_ALLOWLIST_EXTRA 裡時回 403，還沒取得範圍時回 503
+// 有設定 TRUSTED_PROXIES 時依 X-Forwarded-For（gin 的 ClientIP）判斷，否則用連線的 IP，避免偽造的 header 繞過
+func (a *githubIPAllowlist) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := config.Current()
+		ip := c.RemoteIP()
+		if len(cfg.TrustedProxies) > 0 {
+			ip = c.ClientIP()
+		}
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			metrics.WebhooksBlocked.Inc("invalid_ip")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "source IP is not allowed"})
+			return
+		}
+		addr = addr.Unmap()
+
+		if containsAddr(cfg.GitHubIPAllowlistExtra, addr) {
+			c.Next()
+			return
+		}
+		ranges := a.ranges.Load()
+		if ranges == nil {
+			metrics.WebhooksBlocked.Inc("ranges_unavailable")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "GitHub IP ranges are not available yet"})
+			return
+		}
+		if !containsAddr(*ranges, addr) {
+			metrics.WebhooksBlocked.Inc("not_allowed")
+			applogger.Log.Warn("Rejected webhook from non-GitHub IP", "clientIP", addr.String())
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "source IP is not allowed"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	return slices.ContainsFunc(prefixes, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// handleIssues issue 開啟時建立 thread（和 PR 共用 "owner/repo#123" 的 store key）
+func (app *App) handleIssues(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+
+	issue := payload.Issue
+	if issue == nil {
+		log.Warn("No issue in payload, ignoring", "ghEvent", ghEvent, "action", payload.Action)
+		return nil
+	}
+
+	switch payload.Action {
+	case "opened":
+		return app.handleIssueOpened(ctx, payload.GetPRIdentifier(), issue, payload.Repository.FullName)
+	case "labeled", "unlabeled":
+		if err := app.handleLabelChange(ctx, payload.GetPRIdentifier(), payload.Label, payload.Action == "labeled"); err != nil {
+			return err
+		}
+		return app.handleLabelEmoji(ctx, payload.GetPRIdentifier(), payload.Label, issueThreadTitle(issue, payload.Repository.FullName))
+	case "edited":
+		return app.handleTitleEdited(ctx, payload.GetPRIdentifier(), issueThreadTitle(issue, payload.Repository.FullName), payload.Changes)
+	case "milestoned", "demilestoned":
+		return app.handleIssueMilestoned(ctx, payload)
+	case "closed", "reopened":
+		if err := app.handleIssueStateChanged(ctx, payload.GetPRIdentifier(), issue, payload.Sender.Login, payload.Repository.FullName, payload.Action == "closed"); err != nil {
+			return err
+		}
+		// 關閉 / 重開會改變 milestone 的完成數
+		if issue.Milestone != nil {
+			return app.updateMilestoneStatus(ctx, payload.Repository.FullName, issue.Milestone)
+		}
+		return nil
+	default:
+		log.Info("Ignoring issues action", "action", payload.Action)
+		return nil
+	}
+}
+
+func (app *App) handleIssueOpened(ctx context.Context, issueID string, issue *github.Issue, repoFullName string) error {
+	log := applogger.Log
+
+	if existingThreadID, exists, _ := app.store.Get(issueID); exists {
+		log.Info("Thread already exists", "issueID", issueID, "threadID", existingThreadID)
+		return nil
+	}
+
+	title := issueThreadTitle(issue, repoFullName)
+	message := withBodyImages(app.render(ctx, "issues.opened", discord.FormatIssueOpened(issue)), issue.Body)
+
+	threadID, err := app.forum(repoFullName).CreateThread(ctx, title, app.withReplyButton(message, issueID), app.threadTagIDs(ctx, repoFullName, issue.Labels)...)
+	if err != nil {
+		return fmt.Errorf("failed to create thread: %w", err)
+	}
+
+	if err := app.store.Set(issueID, threadID); err != nil {
+		return fmt.Errorf("failed to save mapping: %w", err)
+	}
+
+	log.Info("Created issue thread", "issueID", issueID, "threadID", threadID)
+
+	app.addThreadMembers(ctx, threadID, issue.User)
+	app.seedReaction(ctx, threadID, threadID, "issues.opened")
+	app.announce(ctx, "issues.opened", message)
+	return nil
+}
+
+// handleTitleEdited issue / PR 改標題時同步 thread 名稱（name 已依 FormatThreadTitle 截斷）
+// 只改標題以外的欄位時不做事
+func (app *App) handleTitleEdited(ctx context.Context, itemID, name string, changes *github.Changes) error {
+	if changes == nil || changes.Title == nil {
+		return nil
+	}
+	return app.renameThread(ctx, itemID, name, fmt.Sprintf("Title of %s changed on GitHub", itemID))
+}
+
+// renameThread 把 itemID 對應的 thread 改名為 name（和同一個 thread 其他的改名 / tag 變更合併，見 threadEdits）
+// 沒有 thread、thread 已刪除、已 archive（改名會把 thread 重新打開）或名稱相同時不做事
+func (app *App) renameThread(ctx context.Context, itemID, name, reason string) error {
+	return app.editThread(ctx, itemID, reason, func(edit *threadEdit) { edit.name = name })
+}
+
+// ensureIssueThread 取得 issue 對應的 thread ID，和 PR 的 ensureThread 相同：
+// mapping 不存在、或 mapping 指向的 thread 已被刪除時補建，後續事件都回到同一個 thread
+func (app *App) ensureIssueThread(ctx context.Context, issueID string, issue *github.Issue, repoFullName string) (string, error) {
+	log := applogger.Log
+
+	threadID, exists, err := app.store.Get(issueID)
+	if err != nil {
+		return "", err
+	}
+
+	if exists {
+		_, err := app.discordClient.GetThread(ctx, threadID)
+		if err == nil {
+			return threadID, nil
+		}
+		if !errors.Is(err, discord.ErrNotFound) {
+			log.Warn("Failed to verify thread, using stored mapping", "issueID", issueID, "threadID", threadID, "error", err)
+			return threadID, nil
+		}
+
+		log.Warn("Stored thread no longer exists, recreating", "issueID", issueID, "threadID", threadID)
+		if err := app.store.Delete(issueID); err != nil {
+			return "", fmt.Errorf("failed to delete stale mapping: %w", err)
+		}
+	}
+
+	log.Info("Thread not found, auto-creating", "issueID", issueID)
+	if err := app.handleIssueOpened(ctx, issueID, issue, repoFullName); err != nil {
+		return "", fmt.Errorf("failed to auto-create thread: %w", err)
+	}
+
+	threadID, exists, err = app.store.Get(issueID)
+	if err != nil || !exists {
+		return "", fmt.Errorf("failed to get thread after creation")
+	}
+
+	return threadID, nil
+}
+
+// handleIssueStateChanged issue 關閉 / 重開時貼到原本的 thread，關閉時依 DISCORD_AUTO_ARCHIVE archive
+// 關閉後 mapping 保留 7 天；重開時重新 Set 清掉 TTL，之後的事件才不會因為 mapping 過期而開新 thread
+func (app *App) handleIssueStateChanged(ctx context.Context, issueID string, issue *github.Issue, sender, repoFullName string, closed bool) error {
+	log := applogger.Log
+
+	threadID, err := app.ensureIssueThread(ctx, issueID, issue, repoFullName)
+	if err != nil {
+		return err
+	}
+
+	if !closed {
+		if err := app.store.Set(issueID, threadID); err != nil {
+			log.Error("Failed to reopen mapping", "issueID", issueID, "error", err)
+		}
+		return app.postMessage(ctx, threadID, app.render(ctx, "issues.reopened", discord.FormatIssueReopened(issue, sender)))
+	}
+
+	if err := app.postMessage(ctx, threadID, withArchiveFooter(app.render(ctx, "issues.closed", discord.FormatIssueClosed(issue, sender)))); err != nil {
+		return err
+	}
+	app.archiveThread(ctx, threadID, fmt.Sprintf("Issue %s was closed by %s", issueID, sender))
+
+	if err := app.store.MarkAsClosed(issueID); err != nil {
+		log.Error("Failed to mark as closed", "issueID", issueID, "error", err)
+	}
+	return nil
+}
+
+// handleIssueComment 把 issue / PR 的新留言貼到原本的 thread
+// issue 沒有 thread 時補建；PR 的留言（issue_comment 不帶完整的 pull_request）沒有 thread 時略過
+func (app *App) handleIssueComment(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+
+	if payload.Action != "created" {
+		log.Info("Ignoring issue_comment action", "action", payload.Action)
+		return nil
+	}
+	if payload.Issue == nil || payload.Comment == nil {
+		log.Warn("No issue or comment in payload, ignoring")
+		return nil
+	}
+	if app.isBridgeReply(payload.Comment) {
+		log.Info("Ignoring comment posted from Discord", "commentURL", payload.Comment.HTMLURL)
+		return nil
+	}
+
+	issueID := payload.GetPRIdentifier()
+	threadID, exists, err := app.store.Get(issueID)
+	if err != nil {
+		return err
+	}
+	if payload.Issue.PullRequest == nil {
+		threadID, err = app.ensureIssueThread(ctx, issueID, payload.Issue, payload.Repository.FullName)
+		if err != nil {
+			return err
+		}
+	} else if !exists {
+		log.Info("No thread for pull request, skipping comment", "issueID", issueID)
+		return nil
+	}
+
+	message := withBodyImages(app.render(ctx, "issue_comment.created", discord.FormatIssueComment(payload.Comment, payload.Issue.Number)), payload.Comment.Body)
+	return app.postMessage(ctx, threadID, message)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// labelTagID 取得 GitHub label 對應的 forum tag ID，label 沒有設定在 DISCORD_LABEL_TAG_MAP 時回傳空字串
+func (app *App) labelTagID(ctx context.Context, repoFullName, label string) string {
+	tagName, ok := config.Current().LabelTagMap[label]
+	if !ok || tagName == "" {
+		return ""
+	}
+
+	tagID, err := app.forum(repoFullName).GetOrCreateTag(ctx, tagName, discord.TagOptions{})
+	if err != nil {
+		applogger.Log.Warn("Failed to get/create label tag", "label", label, "tag", tagName, "error", err)
+		return ""
+	}
+	return tagID
+}
+
+// threadTagIDs 建立 thread 時要套用的 tag：repo tag + 有對應的 label tag
+func (app *App) threadTagIDs(ctx context.Context, repoFullName string, labels []github.Label) []string {
+	tagIDs := app.repoTagIDs(ctx, repoFullName)
+	for _, label := range labels {
+		if tagID := app.labelTagID(ctx, repoFullName, label.Name); tagID != "" && !slices.Contains(tagIDs, tagID) {
+			tagIDs = append(tagIDs, tagID)
+		}
+	}
+	if len(tagIDs) > discord.MaxThreadTags {
+		tagIDs = tagIDs[:discord.MaxThreadTags]
+	}
+	return tagIDs
+}
+
+// handleLabelChange issue / PR 加上或移除 label 時同步更新 thread 的 applied_tags（和改名合併，見 threadEdits）
+// 沒有對應 thread，或 label 沒有對應 tag 時不做事
+func (app *App) handleLabelChange(ctx context.Context, itemID string, label *github.Label, added bool) error {
+	if label == nil {
+		return nil
+	}
+
+	repoFullName, _, _ := strings.Cut(itemID, "#")
+	tagID := app.labelTagID(ctx, repoFullName, label.Name)
+	if tagID == "" {
+		return nil
+	}
+
+	verb := "added to"
+	if !added {
+		verb = "removed from"
+	}
+	reason := fmt.Sprintf("Label %s %s %s", label.Name, verb, itemID)
+	return app.editThread(ctx, itemID, reason, func(edit *threadEdit) {
+		edit.tags = append(edit.tags, tagChange{tagID: tagID, added: added})
+	})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/metrics"
+	"dizzycode1112/github-discord-bridge/internal/storage"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// leaderLease HA_MODE 所有 replica 競爭的 lease 名稱
+const leaderLease = "leader"
+
+// leaderElector HA_MODE 時透過 store 的 lease 在多個 replica 之間選出一個 leader
+// 只有 leader 跑 reconcile、GC 這類掃描整個 store 的背景工作；webhook 每個 replica 都收，由 ClaimDelivery 保證只處理一次
+// nil = 沒有啟用 HA_MODE，isLeader 一律為 true
+type leaderElector struct {
+	leaser storage.Leaser
+	id     string
+	ttl    time.Duration
+	leader atomic.Bool
+}
+
+// newLeaderElector store 必須實作 storage.Leaser（Redis、Postgres）
+func newLeaderElector(store storage.Store, cfg *config.Config) (*leaderElector, error) {
+	if dry, ok := store.(*dryRunStore); ok {
+		store = dry.Store
+	}
+	leaser, ok := store.(storage.Leaser)
+	if !ok {
+		return nil, fmt.Errorf("HA_MODE is not supported by STORAGE_BACKEND=%s", cfg.StorageBackend)
+	}
+	id := cfg.HAReplicaID
+	if id == "" {
+		id = defaultReplicaID()
+	}
+	e := &leaderElector{leaser: leaser, id: id, ttl: cfg.HALeaseTTL}
+	metrics.HALeader.Set(func() float64 {
+		if e.isLeader() {
+			return 1
+		}
+		return 0
+	})
+	return e, nil
+}
+
+// defaultReplicaID hostname（Kubernetes 為 pod 名稱）加上隨機字尾，同一台機器上的多個 process 也不會重複
+func defaultReplicaID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "replica"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+// isLeader 目前是否為 leader（沒有啟用 HA_MODE 時一律為 true）
+func (e *leaderElector) isLeader() bool {
+	return e == nil || e.leader.Load()
+}
+
+// run 每 ttl/3 取得或續約 lease，ctx 結束（shutdown）時釋放，讓其他 replica 立即接手（rolling update 不用等 ttl）
+func (e *leaderElector) run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	e.elect()
+	for {
+		select {
+		case <-ctx.Done():
+			if e.leader.Swap(false) {
+				if err := e.leaser.ReleaseLease(leaderLease, e.id); err != nil {
+					applogger.Log.Warn("Failed to release leadership", "replica", e.id, "error", err)
+				} else {
+					applogger.Log.Info("Released leadership", "replica", e.id)
+				}
+			}
+			return
+		case <-ticker.C:
+			e.elect()
+		}
+	}
+}
+
+// elect 續約失敗（store 暫時無法使用）時立即視為失去 leader，寧可暫停背景工作也不要兩個 leader 同時跑
+func (e *leaderElector) elect() {
+	log := applogger.Log
+	acquired, err := e.leaser.AcquireLease(leaderLease, e.id, e.ttl)
+	if err != nil {
+		log.Error("Failed to renew leader lease", "replica", e.id, "error", err)
+		acquired = false
+	}
+	if was := e.leader.Swap(acquired); was != acquired {
+		if acquired {
+			log.Info("Became leader", "replica", e.id)
+		} else {
+			log.Warn("Lost leadership", "replica", e.id)
+		}
+	}
+}
+
+// handleAdminHA GET /admin/ha：這個 replica 的 ID 和是否為 leader
+func (app *App) handleAdminHA(c *gin.Context) {
+	if app.leader == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "replica": app.leader.id, "leader": app.leader.isLeader()})
+}
@@ -1,137 +1,268 @@
 package main
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"io"
+	"net/http"
+	"os"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	_ "time/tzdata" // DISCORD_TIMEZONE 在沒有 zoneinfo 的 container image 也能載入
 
+	"dizzycode1112/github-discord-bridge/internal/broker"
 	"dizzycode1112/github-discord-bridge/internal/config"
 	"dizzycode1112/github-discord-bridge/internal/discord"
 	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/i18n"
+	"dizzycode1112/github-discord-bridge/internal/queue"
 	"dizzycode1112/github-discord-bridge/internal/storage"
+	"dizzycode1112/github-discord-bridge/internal/tracing"
 	"dizzycode1112/github-discord-bridge/pkg/applogger"
-
-	"github.com/gin-gonic/gin"
 )
 
 type App struct {
 	store         storage.Store
 	discordClient *discord.Client
-	githubSecret  string
+	interactions  *discord.InteractionRouter
+	community     *communityBatcher             // nil = star / fork / watch 即時通知
+	digest        *digester                     // nil = 沒有設定 digest 排程
+	throttle      *repoThrottle                 // nil = 不限制（子命令）
+	threadEdits   *threadEdits                  // nil = 改名和 tag 變更立即送出（子命令）
+	queue         *queue.Pool                   // nil = webhook 同步處理
+	webhooks      *github.WebhookHandler        // queue 的 worker 和重啟後的 restore 透過它處理事件
+	githubApp     *github.AppAuth               // nil = 沒有設定 GitHub App
+	githubAPI     *github.APIClient             // nil = 沒有 token，不呼叫 GitHub API 補資料
+	styles        atomic.Pointer[messageStyles] // template 和顏色，reload 時整組換掉
+	token         tokenCheck                    // /readyz 的 Discord token 檢查快取
+	stats         repoStats                     // 每個 repo 的處理統計（admin API）
+	repoSwitches  repoSwitches                  // admin API 暫停的 repo
+	dryRuns       dryRunLog                     // 最近 dry-run 沒有送出的 Discord request（admin API）
+	recorder      payloadRecorder               // RECORD_PAYLOADS_DIR：保存原始的 webhook
+	leader        *leaderElector                // nil = 沒有啟用 HA_MODE
+	broker        broker.Publisher              // INGEST_MODE=receiver 時發布 webhook 的 broker，其他模式為 nil
+	statusMu      sync.Mutex                    // 同一個 commit 的多個 status 幾乎同時送達，rollup 的讀寫要序列化
+}
+
+// subcommands `main <command> [flags]` 可用的子命令
+var subcommands = map[string]func(cfg *config.Config, args []string) error{
+	"serve":               runServer,
+	"backfill":            runBackfill,
+	"export-mappings":     runExportMappings,
+	"import-mappings":     runImportMappings,
+	"dead-letters":        runDeadLetters,
+	"replay-dead-letters": runReplayDeadLetters,
+	"send":                runSend,
+	"doctor":              runDoctor,
+	"replay-payloads":     runReplayPayloads,
 }
 
 func main() {
-	config.Load()
-	cfg := config.AppConfig
+	// 全域 flag 要放在子命令前面，例如 `main --config prod.env backfill --repo owner/name`
+	fs := flag.NewFlagSet("main", flag.ExitOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "env file to load before reading environment variables (default .env if it exists)")
+	fs.Parse(os.Args[1:])
+
+	if err := config.Load(*configFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	cfg := config.Current()
 
 	// 初始化 logger
 	applogger.Init(cfg.Env)
 	log := applogger.Log
 	defer log.Flush()
 
-	// 初始化 storage
-	store, err := storage.NewRedisStore(cfg.RedisURL)
-	if err != nil {
-		log.Error("Failed to connect to Redis", "error", err)
-		panic(err)
+	github.SetBaseURL(cfg.GitHubBaseURL)
+
+	// 沒有子命令時啟動 server；子命令（backfill 等）跑完就結束
+	command, args := "serve", fs.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	run, ok := subcommands[command]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", command)
+		os.Exit(2)
 	}
-	defer store.Close()
+	if err := run(cfg, args); err != nil {
+		log.Error("Command failed", "command", command, "error", err)
+		log.Flush()
+		os.Exit(1)
+	}
+}
 
-	// 初始化 Discord client
-	discordClient := discord.NewClient(cfg.DiscordBotToken, cfg.DiscordForumChID)
+// newApp 建立 storage、Discord client 和 GitHub API client（server 和子命令共用）
+// discordOpts 附加在 Discord client 的設定之後（例如 `main send --local` 的 dry-run 會取代 DRY_RUN 的判斷）
+func newApp(cfg *config.Config, discordOpts ...discord.Option) (*App, error) {
+	if err := i18n.SetLocale(cfg.Locale); err != nil {
+		return nil, fmt.Errorf("invalid DISCORD_LOCALE: %w", err)
+	}
+	if err := discord.SetTimeFormat(cfg.TimeStyle, cfg.Timezone, cfg.TimeLayout); err != nil {
+		return nil, fmt.Errorf("invalid time format settings: %w", err)
+	}
 
-	app := &App{
-		store:         store,
-		discordClient: discordClient,
-		githubSecret:  cfg.GitHubWebhookSecret,
+	store, err := newStore(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage %s: %w", cfg.StorageBackend, err)
 	}
 
-	// 設定 Gin router
-	r := gin.Default()
+	// DRY_RUN / DRY_RUN_REPOS 可以 reload，所以一律掛上 dry-run 的判斷（見 dryRunRequest）
+	app := &App{store: newDryRunStore(store)}
+	app.discordClient = discord.NewClient(cfg.DiscordBotToken, cfg.DiscordForumChID, append([]discord.Option{
+		discord.WithBaseURL(cfg.DiscordAPIBaseURL),
+		discord.WithAPIVersion(cfg.DiscordAPIVersion),
+		discord.WithTimeout(cfg.DiscordHTTPTimeout),
+		discord.WithCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		discord.WithDryRun(app.dryRunRequest),
+	}, discordOpts...)...)
 
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	if cfg.GitHubAppID != "" {
+		githubApp, err := newGitHubApp(cfg)
+		if err != nil {
+			store.Close()
+			return nil, fmt.Errorf("failed to initialize GitHub App authentication: %w", err)
+		}
+		app.githubApp = githubApp
+		applogger.Log.Info("GitHub App authentication enabled", "appID", cfg.GitHubAppID)
+	}
 
-	r.POST("/webhook/github", app.handleGitHubWebhook)
+	app.githubAPI = github.NewAPIClient(cfg.GitHubAPIURL, cfg.GitHubToken, app.githubApp)
 
-	log.Info("Server starting", "port", cfg.Port)
-	if err := r.Run(":" + cfg.Port); err != nil {
-		log.Error("Failed to start server", "error", err)
-		panic(err)
+	styles, err := newMessageStyles(cfg)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	app.styles.Store(styles)
+	if styles.templates != nil {
+		applogger.Log.Info("Loaded message templates", "count", styles.templates.Len())
 	}
+	return app, nil
 }
 
-func (app *App) handleGitHubWebhook(c *gin.Context) {
-	log := applogger.Log
+// deliveryClaimTTL 處理中的 delivery 佔用多久；instance 處理到一半掛掉時，過了這段時間 redeliver 才能重試
+const deliveryClaimTTL = 5 * time.Minute
 
-	body, err := io.ReadAll(c.Request.Body)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "failed to read body"})
-		return
-	}
+// logEvent 包一層記錄處理失敗的錯誤（每個 request 的 log 見 requestLogger），並套用 DISCORD_EVENT_ACTION_FILTERS、delivery 去重和 sender 過濾
+func (app *App) logEvent(handler github.EventHandler) github.EventHandler {
+	return func(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+		log := applogger.Log
+		ev := payload.Normalize(ctx, ghEvent)
+
+		// route span：過濾、去重、digest 等決定事件怎麼處理，outcome 記錄結果
+		ctx, span := tracing.Start(ctx, "route "+ev.Key(), tracing.KindInternal)
+		defer span.End()
+		span.SetAttr("github.repository", ev.Repo)
+		span.SetAttr("route.outcome", "handled")
+
+		ctx = withEvent(ctx, ev, payload)
 
-	// 驗證 webhook signature
-	if app.githubSecret != "" {
-		signature := c.GetHeader("X-Hub-Signature-256")
-		if signature == "" {
-			c.JSON(401, gin.H{"error": "missing signature"})
-			return
+		if filter, ok := config.Current().EventActionFilters[ev.Type]; ok && !filter.Allows(ev.Action) {
+			log.Info("Skipping event filtered by action rule", "ghEvent", ev.Type, "action", ev.Action)
+			span.SetAttr("route.outcome", "filtered")
+			return nil
+		}
+
+		// GitHub redeliver（自動重送或手動 replay）時 delivery ID 不變，已處理過的直接略過
+		// 用 claim 而不是先查再寫，多個 instance 同時收到同一個 delivery 時只有一個會處理
+		claimed := false
+		if ev.DeliveryID != "" {
+			ok, err := app.store.ClaimDelivery(ev.DeliveryID, deliveryClaimTTL)
+			if err != nil {
+				log.Warn("Failed to claim delivery", "deliveryID", ev.DeliveryID, "error", err)
+			} else if !ok {
+				log.Info("Skipping already processed delivery", "ghEvent", ev.Type, "deliveryID", ev.DeliveryID)
+				span.SetAttr("route.outcome", "duplicate")
+				return nil
+			}
+			claimed = ok
 		}
 
-		if !verifySignature(body, signature, app.githubSecret) {
-			c.JSON(401, gin.H{"error": "invalid signature"})
-			return
+		var err error
+		switch senderPriority(ev.Actor.Login) {
+		case senderIgnored:
+			log.Info("Skipping event from ignored sender", "ghEvent", ev.Type, "sender", ev.Actor.Login)
+			span.SetAttr("route.outcome", "ignored_sender")
+			return nil
+		case senderLowPriority:
+			span.SetAttr("route.outcome", "low_priority")
+			err = app.postLowPriority(ctx, ev)
+		default:
+			if app.digest.add(ev) {
+				log.Info("Buffered event for digest", "ghEvent", ev.Type, "repo", ev.Repo)
+				span.SetAttr("route.outcome", "digest")
+			} else if app.throttle.add(ev) {
+				log.Debug("Repository over rate limit, combining event into burst summary", "ghEvent", ev.Type, "repo", ev.Repo)
+				span.SetAttr("route.outcome", "coalesced")
+			} else {
+				err = handler(ctx, ghEvent, payload)
+			}
 		}
+		app.recordEvent(ev, err)
+		span.SetError(err)
+		if err != nil {
+			log.Error("Failed to handle event", "ghEvent", ghEvent, "action", payload.Action, "error", err)
+			if claimed {
+				if err := app.store.ReleaseDelivery(ev.DeliveryID); err != nil {
+					log.Warn("Failed to release delivery", "deliveryID", ev.DeliveryID, "error", err)
+				}
+			}
+			return err
+		}
+
+		// 只記錄成功的 delivery，失敗的讓 GitHub redeliver 時可以重試
+		if ev.DeliveryID != "" {
+			if err := app.store.MarkDelivered(ev.DeliveryID, config.Current().DeliveryDedupTTL); err != nil {
+				log.Warn("Failed to record delivery", "deliveryID", ev.DeliveryID, "error", err)
+			}
+		}
+		return nil
 	}
+}
 
-	// 處理 ping event（GitHub 建立 webhook 時發送）
-	ghEvent := c.GetHeader("X-GitHub-Event")
-	if ghEvent == "ping" {
-		log.Info("Received GitHub ping")
-		c.JSON(200, gin.H{"status": "pong"})
-		return
+// handleWorkflowRun workflow_run 獨立處理（payload 不一定有 pull_request，不走 handleEvent）
+// handleWorkflowRunCompleted 內部對個別 PR 的失敗用 continue 跳過，
+// 這裡的 err 只處理整體性錯誤（例如 workflow_run 欄位缺失），回 500 讓 GitHub retry。
+func (app *App) handleWorkflowRun(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	if payload.Action != "completed" {
+		return nil
 	}
+	return app.handleWorkflowRunCompleted(ctx, payload)
+}
 
-	// 解析 webhook payload（body 已被 ReadAll 消耗，用 json.Unmarshal）
-	var payload github.WebhookPayload
-	if err := json.Unmarshal(body, &payload); err != nil {
-		log.Error("Failed to parse webhook payload", "error", err)
-		c.JSON(400, gin.H{"error": "invalid payload"})
+// respondProcessError 事件處理失敗時的回應
+// Discord circuit breaker 開啟時回 503 + Retry-After，讓 request 快速結束而不是卡在 timeout
+// queue 已滿或 shutdown 中也回 503，之後從 GitHub 的 Recent Deliveries redeliver
+func respondProcessError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if errors.Is(err, queue.ErrFull) || errors.Is(err, queue.ErrClosed) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "event queue unavailable"})
 		return
 	}
-
-	log.Info("Received GitHub event", "ghEvent", ghEvent, "action", payload.Action)
-
-	// check_suite 獨立處理（payload 不一定有 pull_request，不走 handleEvent）
-	// handleCheckSuiteCompleted 內部對個別 PR 的失敗用 continue 跳過，
-	// 這裡的 err 只處理整體性錯誤（例如 check_suite 欄位缺失），回 500 讓 GitHub retry。
-	if ghEvent == "workflow_run" {
-		if payload.Action == "completed" {
-			if err := app.handleWorkflowRunCompleted(&payload); err != nil {
-				log.Error("Failed to handle workflow_run", "error", err)
-				c.JSON(500, gin.H{"error": "failed to process event"})
-				return
-			}
-		}
-		c.JSON(200, gin.H{"status": "processed"})
+	if errors.Is(err, errBrokerUnavailable) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "message broker unavailable"})
 		return
 	}
-
-	if err := app.handleEvent(ghEvent, &payload); err != nil {
-		log.Error("Failed to handle event", "ghEvent", ghEvent, "action", payload.Action, "error", err)
-		c.JSON(500, gin.H{"error": "failed to process event"})
+	if errors.Is(err, discord.ErrCircuitOpen) {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(config.Current().BreakerCooldown.Seconds())))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "discord unavailable"})
 		return
 	}
-
-	c.JSON(200, gin.H{"status": "processed"})
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]string{"error": "failed to process event"})
 }
 
-func (app *App) handleEvent(ghEvent string, payload *github.WebhookPayload) error {
+func (app *App) handleEvent(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
 	log := applogger.Log
 
 	pr := payload.PullRequest
@@ -151,20 +282,33 @@ func (app *App) handleEvent(ghEvent string, payload *github.WebhookPayload) erro
 	case "pull_request":
 		switch payload.Action {
 		case "opened":
-			return app.handlePROpened(prID, pr, repoFullName)
+			return app.handlePROpened(ctx, prID, pr, repoFullName)
 		case "synchronize":
-			return app.handlePRUpdated(prID, pr, repoFullName)
+			return app.handlePRUpdated(ctx, prID, pr, repoFullName)
 		case "closed":
 			if pr.Merged {
-				return app.handlePRMerged(prID, pr, payload.Sender.Login, repoFullName)
+				return app.handlePRMerged(ctx, prID, pr, payload.Sender.Login, repoFullName)
 			}
-			return app.handlePRClosed(prID, pr, payload.Sender.Login, repoFullName)
+			return app.handlePRClosed(ctx, prID, pr, payload.Sender.Login, repoFullName)
 		case "reopened":
-			return app.handlePRReopened(prID, pr, repoFullName)
+			return app.handlePRReopened(ctx, prID, pr, repoFullName)
+		case "ready_for_review", "converted_to_draft":
+			return app.handlePRDraftChanged(ctx, prID, pr, payload.Sender.Login, repoFullName)
 		case "review_requested":
-			return app.handleReviewRequested(prID, pr, payload.RequestedReviewer, payload.Sender.Login, repoFullName)
-		case "review_request_removed", "edited", "labeled", "unlabeled", "assigned", "unassigned":
-			return nil
+			return app.handleReviewRequested(ctx, prID, pr, payload.RequestedReviewer, payload.Sender.Login, repoFullName)
+		case "assigned":
+			return app.handleThreadMemberChange(ctx, prID, pr, payload.Assignee, true)
+		case "unassigned":
+			return app.handleThreadMemberChange(ctx, prID, pr, payload.Assignee, false)
+		case "review_request_removed":
+			return app.handleThreadMemberChange(ctx, prID, pr, payload.RequestedReviewer, false)
+		case "labeled", "unlabeled":
+			if err := app.handleLabelChange(ctx, prID, payload.Label, payload.Action == "labeled"); err != nil {
+				return err
+			}
+			return app.handleLabelEmoji(ctx, prID, payload.Label, prThreadTitle(pr, repoFullName))
+		case "edited":
+			return app.handleTitleEdited(ctx, prID, prThreadTitle(pr, repoFullName), payload.Changes)
 		default:
 			log.Warn("Unhandled pull_request action", "action", payload.Action)
 			return nil
@@ -174,17 +318,20 @@ func (app *App) handleEvent(ghEvent string, payload *github.WebhookPayload) erro
 			log.Info("Ignoring pull_request_review action", "action", payload.Action)
 			return nil
 		}
-		return app.handlePRReviewed(prID, pr, payload.Review, repoFullName)
-	case "issue_comment", "pull_request_review_comment":
-		log.Info("Ignoring comment event", "ghEvent", ghEvent)
-		return nil
+		return app.handlePRReviewed(ctx, prID, pr, payload.Review, repoFullName)
+	case "pull_request_review_comment":
+		if payload.Action != "created" || payload.Comment == nil {
+			log.Info("Ignoring pull_request_review_comment action", "action", payload.Action)
+			return nil
+		}
+		return app.handleReviewComment(ctx, prID, pr, payload.Comment, repoFullName)
 	default:
 		log.Warn("Unhandled GitHub event", "ghEvent", ghEvent)
 		return nil
 	}
 }
 
-func (app *App) handlePROpened(prID string, pr *github.PullRequest, repoFullName string) error {
+func (app *App) handlePROpened(ctx context.Context, prID string, pr *github.PullRequest, repoFullName string) error {
 	log := applogger.Log
 
 	if existingThreadID, exists, _ := app.store.Get(prID); exists {
@@ -192,23 +339,13 @@ func (app *App) handlePROpened(prID string, pr *github.PullRequest, repoFullName
 		return nil
 	}
 
-	title := discord.FormatThreadTitle(pr.Number, pr.Title, repoFullName)
-	message := discord.FormatPROpened(pr)
-
-	// 取得或建立 repo 對應的 forum tag
-	repoName := repoFullName
-	if idx := strings.LastIndex(repoFullName, "/"); idx >= 0 {
-		repoName = repoFullName[idx+1:]
+	title := prThreadTitle(pr, repoFullName)
+	message := withBodyImages(app.render(ctx, "pull_request.opened", discord.FormatPROpened(pr)), pr.Body)
+	if files := app.pullRequestFiles(ctx, repoFullName, pr.Number); len(files) > 0 {
+		message = discord.WithChangedFiles(message, files, config.Current().PRFilesMax)
 	}
 
-	var tagIDs []string
-	if tagID, err := app.discordClient.GetOrCreateRepoTag(repoName); err != nil {
-		log.Warn("Failed to get/create repo tag, creating thread without tag", "repo", repoName, "error", err)
-	} else {
-		tagIDs = append(tagIDs, tagID)
-	}
-
-	threadID, err := app.discordClient.CreateThread(title, message, tagIDs...)
+	threadID, err := app.forum(repoFullName).CreateThread(ctx, title, app.withReplyButton(message, prID), app.threadTagIDs(ctx, repoFullName, pr.Labels)...)
 	if err != nil {
 		return fmt.Errorf("failed to create thread: %w", err)
 	}
@@ -218,37 +355,56 @@ func (app *App) handlePROpened(prID string, pr *github.PullRequest, repoFullName
 	}
 
 	log.Info("Created thread", "prID", prID, "threadID", threadID)
+
+	members := append([]github.User{pr.User}, pr.Assignees...)
+	members = append(members, pr.RequestedReviewers...)
+	app.addThreadMembers(ctx, threadID, members...)
+
+	// forum post 的開頭訊息 ID 和 thread ID 相同
+	app.seedReaction(ctx, threadID, threadID, "pull_request.opened")
+	app.postPullRequestDiff(ctx, threadID, pr, repoFullName)
+
+	app.announce(ctx, "pull_request.opened", message)
+	app.crossLinkPullRequest(ctx, pr, repoFullName)
 	return nil
 }
 
-func (app *App) handlePRUpdated(prID string, pr *github.PullRequest, repoFullName string) error {
+func (app *App) handlePRUpdated(ctx context.Context, prID string, pr *github.PullRequest, repoFullName string) error {
+	threadID, err := app.ensureThread(ctx, prID, pr, repoFullName)
+	if err != nil {
+		return err
+	}
+
+	message := app.render(ctx, "pull_request.synchronize", discord.FormatPRUpdated(pr))
+	return app.postMessage(ctx, threadID, message)
+}
+
+func (app *App) handleReviewRequested(ctx context.Context, prID string, pr *github.PullRequest, reviewer *github.User, requestedBy string, repoFullName string) error {
 	log := applogger.Log
 
-	threadID, exists, err := app.store.Get(prID)
+	if reviewer == nil {
+		log.Warn("No requested_reviewer in payload", "prID", prID)
+		return nil
+	}
+
+	threadID, err := app.ensureThread(ctx, prID, pr, repoFullName)
 	if err != nil {
 		return err
 	}
 
-	if !exists {
-		log.Info("Thread not found, auto-creating", "prID", prID)
-		if err := app.handlePROpened(prID, pr, repoFullName); err != nil {
-			return fmt.Errorf("failed to auto-create thread: %w", err)
-		}
-		threadID, exists, err = app.store.Get(prID)
-		if err != nil || !exists {
-			return fmt.Errorf("failed to get thread after creation")
-		}
-	}
+	app.addThreadMembers(ctx, threadID, *reviewer)
 
-	message := discord.FormatPRUpdated(pr)
-	return app.discordClient.PostMessage(threadID, message)
+	message := app.render(ctx, "pull_request.review_requested", discord.FormatReviewRequested(reviewer, requestedBy, pr.Number, pr.HTMLURL, app.mentionMap(reviewer.Login)))
+	return app.postMessage(ctx, threadID, message)
 }
 
-func (app *App) handleReviewRequested(prID string, pr *github.PullRequest, reviewer *github.User, requestedBy string, repoFullName string) error {
+// handleThreadMemberChange assignee / reviewer 異動時同步 thread 成員
+// thread 不存在時不補建（成員異動不值得開新 thread）
+func (app *App) handleThreadMemberChange(ctx context.Context, prID string, pr *github.PullRequest, user *github.User, added bool) error {
 	log := applogger.Log
 
-	if reviewer == nil {
-		log.Warn("No requested_reviewer in payload", "prID", prID)
+	if user == nil {
+		log.Warn("No user in member change payload", "prID", prID)
 		return nil
 	}
 
@@ -256,142 +412,296 @@ func (app *App) handleReviewRequested(prID string, pr *github.PullRequest, revie
 	if err != nil {
 		return err
 	}
-
 	if !exists {
-		log.Info("Thread not found, auto-creating", "prID", prID)
-		if err := app.handlePROpened(prID, pr, repoFullName); err != nil {
-			return fmt.Errorf("failed to auto-create thread: %w", err)
+		return nil
+	}
+
+	if added {
+		app.addThreadMembers(ctx, threadID, *user)
+		return nil
+	}
+
+	// PR 作者不因為被移除 reviewer / assignee 而離開自己的 thread
+	if user.Login == pr.User.Login {
+		return nil
+	}
+
+	app.removeThreadMember(ctx, threadID, *user)
+	return nil
+}
+
+// addThreadMembers 把 GitHub 使用者對應的 Discord 使用者加入 thread，沒有對應的直接略過
+// 失敗只 log：成員同步是附加功能，不該讓整個事件 retry
+func (app *App) addThreadMembers(ctx context.Context, threadID string, users ...github.User) {
+	log := applogger.Log
+
+	if !config.Current().AddThreadMembers {
+		return
+	}
+
+	added := make(map[string]bool)
+	for _, user := range users {
+		discordID, ok := app.discordUserID(user.Login)
+		if !ok || added[discordID] {
+			continue
 		}
-		threadID, exists, err = app.store.Get(prID)
-		if err != nil || !exists {
-			return fmt.Errorf("failed to get thread after creation")
+		added[discordID] = true
+
+		if err := app.discordClient.AddThreadMember(ctx, threadID, discordID); err != nil {
+			log.Warn("Failed to add thread member", "threadID", threadID, "githubUser", user.Login, "error", err)
 		}
 	}
+}
+
+// removeThreadMember 把 GitHub 使用者對應的 Discord 使用者移出 thread
+func (app *App) removeThreadMember(ctx context.Context, threadID string, user github.User) {
+	log := applogger.Log
+
+	if !config.Current().AddThreadMembers {
+		return
+	}
 
-	message := discord.FormatReviewRequested(reviewer, requestedBy, pr.Number, pr.HTMLURL, config.AppConfig.GitHubDiscordUserMap)
-	return app.discordClient.PostMessage(threadID, message)
+	discordID, ok := app.discordUserID(user.Login)
+	if !ok {
+		return
+	}
+
+	reason := fmt.Sprintf("%s is no longer assigned to or reviewing this PR", user.Login)
+	if err := app.discordClient.RemoveThreadMember(ctx, threadID, discordID, reason); err != nil {
+		log.Warn("Failed to remove thread member", "threadID", threadID, "githubUser", user.Login, "error", err)
+	}
 }
 
-func (app *App) handlePRReviewed(prID string, pr *github.PullRequest, review *github.Review, repoFullName string) error {
+func (app *App) handlePRReviewed(ctx context.Context, prID string, pr *github.PullRequest, review *github.Review, repoFullName string) error {
 	log := applogger.Log
 
-	threadID, exists, err := app.store.Get(prID)
+	if review == nil {
+		log.Warn("No review in payload", "prID", prID)
+		return nil
+	}
+
+	// 只留 inline comment 時 GitHub 也會送一個沒有內容的 commented review，留言本身會由 review comment 事件發送
+	if review.State == "commented" && strings.TrimSpace(review.Body) == "" {
+		log.Info("Skipping empty commented review", "prID", prID)
+		return nil
+	}
+
+	threadID, err := app.ensureThread(ctx, prID, pr, repoFullName)
 	if err != nil {
 		return err
 	}
 
-	if !exists {
-		log.Info("Thread not found, auto-creating", "prID", prID)
-		if err := app.handlePROpened(prID, pr, repoFullName); err != nil {
-			return fmt.Errorf("failed to auto-create thread: %w", err)
-		}
-		threadID, exists, err = app.store.Get(prID)
-		if err != nil || !exists {
-			return fmt.Errorf("failed to get thread after creation")
-		}
+	message := app.render(ctx, "pull_request_review."+review.State, discord.FormatPRReview(review, pr.Number, pr.HTMLURL, pr.User.Login, app.mentionMap(pr.User.Login)))
+	if err := app.postMessage(ctx, threadID, message); err != nil {
+		return err
 	}
 
-	message := discord.FormatPRReview(review, pr.Number, pr.HTMLURL, pr.User.Login, config.AppConfig.GitHubDiscordUserMap)
-	return app.discordClient.PostMessage(threadID, message)
+	app.announce(ctx, "pull_request_review."+review.State, message)
+	return nil
+}
+
+// handleReviewComment 把 PR 程式碼上的 review 留言貼到 PR thread
+func (app *App) handleReviewComment(ctx context.Context, prID string, pr *github.PullRequest, comment *github.Comment, repoFullName string) error {
+	threadID, err := app.ensureThread(ctx, prID, pr, repoFullName)
+	if err != nil {
+		return err
+	}
+
+	message := app.render(ctx, "pull_request_review_comment.created", discord.FormatReviewComment(comment, pr.Number))
+	return app.postMessage(ctx, threadID, message)
 }
 
-func (app *App) handlePRMerged(prID string, pr *github.PullRequest, mergedBy string, repoFullName string) error {
+func (app *App) handlePRMerged(ctx context.Context, prID string, pr *github.PullRequest, mergedBy string, repoFullName string) error {
 	log := applogger.Log
 
-	threadID, exists, err := app.store.Get(prID)
+	threadID, err := app.ensureThread(ctx, prID, pr, repoFullName)
 	if err != nil {
 		return err
 	}
 
-	if !exists {
-		log.Info("Thread not found, auto-creating before merge notification", "prID", prID)
-		if err := app.handlePROpened(prID, pr, repoFullName); err != nil {
-			return fmt.Errorf("failed to auto-create thread: %w", err)
-		}
-		threadID, exists, err = app.store.Get(prID)
-		if err != nil || !exists {
-			return fmt.Errorf("failed to get thread after creation")
-		}
+	message := app.render(ctx, "pull_request.merged", discord.FormatPRMerged(pr, mergedBy))
+	if err := app.postMessage(ctx, threadID, withArchiveFooter(message)); err != nil {
+		return err
+	}
+
+	app.announce(ctx, "pull_request.merged", message)
+	app.archiveThread(ctx, threadID, fmt.Sprintf("PR %s was merged by %s", prID, mergedBy))
+
+	if err := app.store.MarkAsClosed(prID); err != nil {
+		log.Error("Failed to mark as closed", "prID", prID, "error", err)
 	}
 
-	message := discord.FormatPRMerged(pr, mergedBy)
-	if err := app.discordClient.PostMessage(threadID, message); err != nil {
+	log.Info("PR merged", "prID", prID, "archived", config.Current().AutoArchiveThreads)
+	return nil
+}
+
+func (app *App) handlePRClosed(ctx context.Context, prID string, pr *github.PullRequest, closedBy string, repoFullName string) error {
+	log := applogger.Log
+
+	threadID, err := app.ensureThread(ctx, prID, pr, repoFullName)
+	if err != nil {
 		return err
 	}
 
-	if err := app.discordClient.ArchiveThread(threadID); err != nil {
-		log.Error("Failed to archive thread", "prID", prID, "threadID", threadID, "error", err)
+	message := app.render(ctx, "pull_request.closed", discord.FormatPRClosed(pr, closedBy))
+	if err := app.postMessage(ctx, threadID, withArchiveFooter(message)); err != nil {
+		return err
 	}
 
+	app.announce(ctx, "pull_request.closed", message)
+	app.archiveThread(ctx, threadID, fmt.Sprintf("PR %s was closed by %s", prID, closedBy))
+
 	if err := app.store.MarkAsClosed(prID); err != nil {
 		log.Error("Failed to mark as closed", "prID", prID, "error", err)
 	}
 
-	log.Info("PR merged and thread archived", "prID", prID)
+	log.Info("PR closed", "prID", prID, "archived", config.Current().AutoArchiveThreads)
 	return nil
 }
 
-func (app *App) handlePRClosed(prID string, pr *github.PullRequest, closedBy string, repoFullName string) error {
+// archiveThread DISCORD_AUTO_ARCHIVE 開啟時 archive thread
+// 失敗只 log：結束訊息已經貼出，不值得讓整個事件 retry（retry 會重貼一次）
+func (app *App) archiveThread(ctx context.Context, threadID, reason string) {
+	if !config.Current().AutoArchiveThreads {
+		return
+	}
+	if err := app.discordClient.ArchiveThread(ctx, threadID, reason); err != nil {
+		applogger.Log.Error("Failed to archive thread", "threadID", threadID, "reason", reason, "error", err)
+	}
+}
+
+// withArchiveFooter 關閉 auto-archive 時拿掉結束訊息上「Thread will be archived soon」的 footer
+func withArchiveFooter(message discord.ThreadMessage) discord.ThreadMessage {
+	if config.Current().AutoArchiveThreads {
+		return message
+	}
+	message.Embeds = slices.Clone(message.Embeds)
+	for i := range message.Embeds {
+		message.Embeds[i].Footer = nil
+	}
+	return message
+}
+
+// forum 回傳 repo 要發到的 forum channel client（DISCORD_REPO_FORUM_CHANNELS），沒有設定時用預設 forum
+// org 層級的 webhook 只要設定一次，各 repo 的 thread 和 tag 就會落在各自的 forum
+func (app *App) forum(repoFullName string) *discord.Client {
+	if channelID, ok := lookupRepo(config.Current().RepoForumChannels, repoFullName); ok {
+		return app.discordClient.ForForum(channelID)
+	}
+	return app.discordClient
+}
+
+// repoTagIDs 取得或建立 repo 對應的 forum tag，失敗時回傳空的 tag 清單（thread 照樣建立，只是沒有 tag）
+func (app *App) repoTagIDs(ctx context.Context, repoFullName string) []string {
+	repoName := repoFullName
+	if idx := strings.LastIndex(repoFullName, "/"); idx >= 0 {
+		repoName = repoFullName[idx+1:]
+	}
+
+	tagOpts := discord.TagOptions{
+		Emoji:     config.Current().RepoTagEmojiMap[repoName],
+		Moderated: config.Current().RepoTagModerated,
+	}
+	tagID, err := app.forum(repoFullName).GetOrCreateRepoTag(ctx, repoName, tagOpts)
+	if err != nil {
+		applogger.Log.Warn("Failed to get/create repo tag, creating thread without tag", "repo", repoName, "error", err)
+		return nil
+	}
+	return []string{tagID}
+}
+
+// ensureThread 取得 PR 對應的 thread ID
+// mapping 不存在、或 mapping 指向的 thread 已被刪除時，自動補建 thread（見 PRD「自動補建機制」）
+func (app *App) ensureThread(ctx context.Context, prID string, pr *github.PullRequest, repoFullName string) (string, error) {
 	log := applogger.Log
 
 	threadID, exists, err := app.store.Get(prID)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if !exists {
-		log.Info("Thread not found, auto-creating before close notification", "prID", prID)
-		if err := app.handlePROpened(prID, pr, repoFullName); err != nil {
-			return fmt.Errorf("failed to auto-create thread: %w", err)
+	if exists {
+		_, err := app.discordClient.GetThread(ctx, threadID)
+		if err == nil {
+			return threadID, nil
 		}
-		threadID, exists, err = app.store.Get(prID)
-		if err != nil || !exists {
-			return fmt.Errorf("failed to get thread after creation")
+		if !errors.Is(err, discord.ErrNotFound) {
+			// 查詢失敗不代表 thread 不存在，沿用 mapping，由後續的 PostMessage 決定成敗
+			log.Warn("Failed to verify thread, using stored mapping", "prID", prID, "threadID", threadID, "error", err)
+			return threadID, nil
 		}
-	}
 
-	message := discord.FormatPRClosed(pr, closedBy)
-	if err := app.discordClient.PostMessage(threadID, message); err != nil {
-		return err
+		log.Warn("Stored thread no longer exists, recreating", "prID", prID, "threadID", threadID)
+		if err := app.store.Delete(prID); err != nil {
+			return "", fmt.Errorf("failed to delete stale mapping: %w", err)
+		}
 	}
 
-	if err := app.discordClient.ArchiveThread(threadID); err != nil {
-		log.Error("Failed to archive thread", "prID", prID, "threadID", threadID, "error", err)
+	log.Info("Thread not found, auto-creating", "prID", prID)
+	if err := app.handlePROpened(ctx, prID, pr, repoFullName); err != nil {
+		return "", fmt.Errorf("failed to auto-create thread: %w", err)
 	}
 
-	if err := app.store.MarkAsClosed(prID); err != nil {
-		log.Error("Failed to mark as closed", "prID", prID, "error", err)
+	threadID, exists, err = app.store.Get(prID)
+	if err != nil || !exists {
+		return "", fmt.Errorf("failed to get thread after creation")
 	}
 
-	log.Info("PR closed and thread archived", "prID", prID)
-	return nil
+	return threadID, nil
 }
 
-func (app *App) handlePRReopened(prID string, pr *github.PullRequest, repoFullName string) error {
-	threadID, exists, err := app.store.Get(prID)
+func (app *App) handlePRReopened(ctx context.Context, prID string, pr *github.PullRequest, repoFullName string) error {
+	threadID, err := app.ensureThread(ctx, prID, pr, repoFullName)
 	if err != nil {
 		return err
 	}
 
-	if !exists {
-		return app.handlePROpened(prID, pr, repoFullName)
+	// 關閉時 mapping 被設了 7 天 TTL，重開後重新 Set 清掉，之後的事件才會繼續回到這個 thread
+	if err := app.store.Set(prID, threadID); err != nil {
+		applogger.Log.Error("Failed to reopen mapping", "prID", prID, "error", err)
 	}
 
 	message := discord.ThreadMessage{
 		Embeds: []discord.Embed{
 			{
-				Title:       "🔄 PR Reopened",
-				Description: fmt.Sprintf("**%s** has been reopened", pr.Title),
+				Title:       i18n.T("🔄 PR Reopened"),
+				Description: i18n.Tf("**%s** has been reopened", pr.Title),
 				URL:         pr.HTMLURL,
 				Color:       discord.ColorYellow,
 			},
 		},
 	}
 
-	return app.discordClient.PostMessage(threadID, message)
+	return app.postMessage(ctx, threadID, app.render(ctx, "pull_request.reopened", message))
+}
+
+// draftEventKey draft 切換對應的 event key（template / announcement 用）
+func draftEventKey(pr *github.PullRequest) string {
+	if pr.Draft {
+		return "pull_request.converted_to_draft"
+	}
+	return "pull_request.ready_for_review"
+}
+
+// handlePRDraftChanged PR 在 draft 和 ready for review 之間切換
+func (app *App) handlePRDraftChanged(ctx context.Context, prID string, pr *github.PullRequest, changedBy string, repoFullName string) error {
+	threadID, err := app.ensureThread(ctx, prID, pr, repoFullName)
+	if err != nil {
+		return err
+	}
+
+	message := app.render(ctx, draftEventKey(pr), discord.FormatPRDraftChanged(pr, changedBy))
+	if err := app.postMessage(ctx, threadID, message); err != nil {
+		return err
+	}
+
+	if !pr.Draft {
+		app.announce(ctx, "pull_request.ready_for_review", message)
+	}
+	return nil
 }
 
-func (app *App) handleWorkflowRunCompleted(payload *github.WebhookPayload) error {
+func (app *App) handleWorkflowRunCompleted(ctx context.Context, payload *github.WebhookPayload) error {
 	log := applogger.Log
 
 	wr := payload.WorkflowRun
@@ -400,15 +710,47 @@ func (app *App) handleWorkflowRunCompleted(payload *github.WebhookPayload) error
 		return nil
 	}
 
+	if !branchAllowed(payload.Repository.FullName, wr.HeadBranch) {
+		log.Info("Skipping CI notification for filtered branch", "branch", wr.HeadBranch, "workflow", wr.Name)
+		return nil
+	}
+
 	// 只通知 success 和 failure，其他（cancelled、timed_out 等）不發送
 	if wr.Conclusion != "success" && wr.Conclusion != "failure" {
 		log.Info("Skipping CI notification", "conclusion", wr.Conclusion, "workflow", wr.Name)
 		return nil
 	}
+	if wr.Conclusion == "success" && config.Current().CIFailuresOnly {
+		log.Info("Skipping successful CI run", "workflow", wr.Name)
+		return nil
+	}
+
+	message := app.render(ctx, "workflow_run."+wr.Conclusion, discord.FormatWorkflowRunResult(wr))
+
+	// 沒有關聯 PR 的 run（例如 push 到 main）發到 repo 的 activity thread
+	if len(wr.PullRequests) == 0 {
+		threadID, err := app.ensureActivityThread(ctx, payload.Repository.FullName)
+		if err != nil {
+			return err
+		}
+		if err := app.postMessage(ctx, threadID, message); err != nil {
+			return err
+		}
+	}
+
+	app.postToPRThreads(ctx, payload.Repository.FullName, wr.PullRequests, message)
+
+	app.announce(ctx, "workflow_run."+wr.Conclusion, message)
+	return nil
+}
+
+// postToPRThreads 把 CI 類通知發到關聯 PR 已存在的 thread（沒有 thread 的 PR 不補建）
+// 個別 PR 失敗只 log 並繼續處理下一個
+func (app *App) postToPRThreads(ctx context.Context, repoFullName string, prs []github.WorkflowRunPR, message discord.ThreadMessage) {
+	log := applogger.Log
 
-	// 只通知有關聯 PR 的 workflow run
-	for _, wrPR := range wr.PullRequests {
-		prID := fmt.Sprintf("%s#%d", payload.Repository.FullName, wrPR.Number)
+	for _, wrPR := range prs {
+		prID := fmt.Sprintf("%s#%d", repoFullName, wrPR.Number)
 
 		threadID, exists, err := app.store.Get(prID)
 		if err != nil {
@@ -420,24 +762,86 @@ func (app *App) handleWorkflowRunCompleted(payload *github.WebhookPayload) error
 			continue
 		}
 
-		message := discord.FormatWorkflowRunResult(wr)
-		if err := app.discordClient.PostMessage(threadID, message); err != nil {
+		if err := app.postMessage(ctx, threadID, message); err != nil {
 			log.Error("Failed to post CI notification", "prID", prID, "error", err)
 		}
 	}
+}
 
-	return nil
+// announce 把設定為重要的事件額外發到 announcement channel，並視設定 crosspost
+// eventKey 格式為 "event.action"（例如 "pull_request.merged"），設定中寫 event 或完整 key 都會命中
+// 失敗只 log，不影響 forum thread 的主流程
+func (app *App) announce(ctx context.Context, eventKey string, message discord.ThreadMessage) {
+	log := applogger.Log
+	cfg := config.Current()
+
+	if cfg.DiscordAnnouncementChID == "" || isBackfill(ctx) {
+		return
+	}
+
+	if !cfg.AnnouncementEvents[eventKey] && !cfg.AnnouncementEvents[eventType(eventKey)] {
+		return
+	}
+
+	messageID, err := app.discordClient.PostChannelMessage(ctx, cfg.DiscordAnnouncementChID, withNonce(ctx, cfg.DiscordAnnouncementChID, message))
+	if err != nil {
+		log.Error("Failed to post announcement", "event", eventKey, "error", err)
+		return
+	}
+
+	if cfg.AnnouncementCrosspost {
+		if err := app.discordClient.CrosspostMessage(ctx, cfg.DiscordAnnouncementChID, messageID); err != nil {
+			log.Error("Failed to crosspost announcement", "event", eventKey, "messageID", messageID, "error", err)
+		}
+	}
+
+	app.seedReaction(ctx, cfg.DiscordAnnouncementChID, messageID, eventKey)
 }
 
-func verifySignature(payload []byte, signature, secret string) bool {
-	if secret == "" {
-		return true
+// seedReaction 依 DISCORD_EVENT_REACTIONS 在訊息上加上事件對應的 reaction，讓大家可以直接按 reaction 投票
+// 完整的 "event.action" 優先於 event 名稱；失敗只 log
+func (app *App) seedReaction(ctx context.Context, channelID, messageID, eventKey string) {
+	reactions := config.Current().EventReactions
+
+	emoji, ok := reactions[eventKey]
+	if !ok {
+		emoji = reactions[eventType(eventKey)]
+	}
+	if emoji == "" {
+		return
 	}
 
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write(payload)
-	expectedMAC := hex.EncodeToString(mac.Sum(nil))
-	expectedSignature := "sha256=" + expectedMAC
+	if err := app.discordClient.AddReaction(ctx, channelID, messageID, emoji); err != nil {
+		applogger.Log.Warn("Failed to add reaction", "event", eventKey, "messageID", messageID, "emoji", emoji, "error", err)
+	}
+}
 
-	return hmac.Equal([]byte(signature), []byte(expectedSignature))
+// eventType 取出 event key 的 event 名稱（"pull_request.merged" → "pull_request"）
+func eventType(eventKey string) string {
+	if idx := strings.Index(eventKey, "."); idx >= 0 {
+		return eventKey[:idx]
+	}
+	return eventKey
+}
+
+// postMessage 在 thread 發送訊息，帶上由 GitHub delivery ID 產生的 nonce
+// GitHub redeliver 同一個 webhook 時 nonce 相同，Discord client 會略過重複的訊息
+func (app *App) postMessage(ctx context.Context, threadID string, message discord.ThreadMessage) error {
+	return app.discordClient.PostMessage(ctx, threadID, withNonce(ctx, threadID, message))
+}
+
+// withNonce 依 delivery ID + channel + 訊息標題設定 nonce，沒有 delivery ID 時原樣回傳
+func withNonce(ctx context.Context, channelID string, message discord.ThreadMessage) discord.ThreadMessage {
+	deliveryID := github.DeliveryIDFromContext(ctx)
+	if deliveryID == "" {
+		return message
+	}
+
+	// 同一個 delivery 可能在同一個 channel 發多則訊息（例如 thread 內 + announcement），用標題區分
+	key := deliveryID + ":" + channelID
+	if len(message.Embeds) > 0 {
+		key += ":" + message.Embeds[0].Title
+	}
+	message.Nonce = discord.MessageNonce(key)
+	return message
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/storage"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// mappingExportVersion 匯出檔的格式版本，格式不相容時遞增
+const mappingExportVersion = 1
+
+// mappingExport export-mappings 輸出的 JSON
+type mappingExport struct {
+	Version    int               `json:"version"`
+	Backend    string            `json:"backend"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Mappings   []storage.Mapping `json:"mappings"`
+}
+
+// runExportMappings `main export-mappings --out mappings.json`：把目前 STORAGE_BACKEND 的所有 mapping 匯出成 JSON
+// delivery 去重紀錄不匯出（只是短期快取）
+func runExportMappings(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export-mappings", flag.ContinueOnError)
+	out := fs.String("out", "", "file to write the JSON export to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("--out is required")
+	}
+
+	store, err := newStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// ListStale 給未來的時間 = 全部
+	mappings, err := store.ListStale(time.Now().Add(time.Hour))
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(mappingExport{
+		Version:    mappingExportVersion,
+		Backend:    cfg.StorageBackend,
+		ExportedAt: time.Now().UTC(),
+		Mappings:   mappings,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o600); err != nil {
+		return err
+	}
+
+	applogger.Log.Info("Exported mappings", "backend", cfg.StorageBackend, "count", len(mappings), "file", *out)
+	return nil
+}
+
+// runImportMappings `main import-mappings --in mappings.json`：把 export-mappings 的輸出寫進目前 STORAGE_BACKEND
+// 用來在 backend 之間搬資料或災難復原；預設不覆寫已存在的 key，--overwrite 時以檔案為準
+// closed 的 mapping 匯入後重新計算 7 天 TTL
+func runImportMappings(cfg *config.Config, args []string) error {
+	log := applogger.Log
+
+	fs := flag.NewFlagSet("import-mappings", flag.ContinueOnError)
+	in := fs.String("in", "", "JSON file produced by export-mappings")
+	overwrite := fs.Bool("overwrite", false, "replace mappings that already exist in the store")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return errors.New("--in is required")
+	}
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		return err
+	}
+	var export mappingExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", *in, err)
+	}
+	if export.Version != mappingExportVersion {
+		return fmt.Errorf("unsupported export version %d", export.Version)
+	}
+
+	store, err := newStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var imported, skipped int
+	for _, m := range export.Mappings {
+		if m.Key == "" || m.ThreadID == "" {
+			continue
+		}
+		if !*overwrite {
+			if _, exists, err := store.Get(m.Key); err != nil {
+				return err
+			} else if exists {
+				skipped++
+				continue
+			}
+		}
+
+		if err := store.Set(m.Key, m.ThreadID); err != nil {
+			return err
+		}
+		if m.Closed {
+			if err := store.MarkAsClosed(m.Key); err != nil {
+				return err
+			}
+		}
+		imported++
+	}
+
+	log.Info("Imported mappings", "from", export.Backend, "to", cfg.StorageBackend, "imported", imported, "skipped", skipped)
+	return nil
+}
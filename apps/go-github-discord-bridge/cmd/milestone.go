@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// milestoneStatusKey 釘選的 milestone 狀態訊息在 store 裡的 key（格式："owner/repo#milestone-3"）
+func milestoneStatusKey(repoFullName string, number int) string {
+	return fmt.Sprintf("%s#milestone-%d", repoFullName, number)
+}
+
+// handleMilestone milestone 建立 / 關閉時在 activity thread 發進度摘要，其他變更只更新釘選的狀態訊息
+func (app *App) handleMilestone(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	milestone := payload.Milestone
+	if milestone == nil {
+		return nil
+	}
+
+	repoFullName := payload.Repository.FullName
+	switch payload.Action {
+	case "created", "closed":
+		message := app.render(ctx, "milestone."+payload.Action, discord.FormatMilestone(milestone, repoFullName))
+		if err := app.postActivity(ctx, repoFullName, message); err != nil {
+			return err
+		}
+		app.announce(ctx, "milestone."+payload.Action, message)
+	}
+
+	return app.updateMilestoneStatus(ctx, repoFullName, milestone)
+}
+
+// handleIssueMilestoned issue / PR 加入或移出 milestone 時更新釘選的狀態訊息
+func (app *App) handleIssueMilestoned(ctx context.Context, payload *github.WebhookPayload) error {
+	if payload.Milestone == nil {
+		return nil
+	}
+	return app.updateMilestoneStatus(ctx, payload.Repository.FullName, payload.Milestone)
+}
+
+// updateMilestoneStatus 更新 activity thread 裡釘選的 milestone 狀態訊息（DISCORD_MILESTONE_PIN=true 才啟用）
+// 第一次時發一則新訊息並釘選，之後都編輯同一則
+func (app *App) updateMilestoneStatus(ctx context.Context, repoFullName string, milestone *github.Milestone) error {
+	log := applogger.Log
+
+	if !config.Current().MilestonePin {
+		return nil
+	}
+
+	threadID, err := app.ensureActivityThread(ctx, repoFullName)
+	if err != nil {
+		return err
+	}
+
+	key := milestoneStatusKey(repoFullName, milestone.Number)
+	message := discord.FormatMilestone(milestone, repoFullName)
+
+	messageID, exists, err := app.store.Get(key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err := app.discordClient.EditMessage(ctx, threadID, messageID, message)
+		if err == nil || !errors.Is(err, discord.ErrNotFound) {
+			return err
+		}
+		log.Warn("Pinned milestone message no longer exists, posting a new one", "milestone", key, "messageID", messageID)
+	}
+
+	messageID, err = app.discordClient.PostChannelMessage(ctx, threadID, message)
+	if err != nil {
+		return err
+	}
+	if err := app.store.Set(key, messageID); err != nil {
+		return fmt.Errorf("failed to save mapping: %w", err)
+	}
+	if err := app.discordClient.PinMessage(ctx, threadID, messageID, fmt.Sprintf("Milestone status for %s %s", repoFullName, milestone.Title)); err != nil {
+		log.Warn("Failed to pin milestone message", "milestone", key, "error", err)
+	}
+	return nil
+}
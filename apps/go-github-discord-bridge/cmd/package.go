@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+)
+
+// handlePackage package / registry_package 發布時發到 repo 的 activity thread
+func (app *App) handlePackage(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	if payload.Action != "published" {
+		return nil
+	}
+
+	pkg := payload.Package
+	if pkg == nil {
+		pkg = payload.RegistryPackage
+	}
+	if pkg == nil {
+		return nil
+	}
+
+	message := app.render(ctx, "package.published", discord.FormatPackagePublished(pkg, payload.Repository.FullName, payload.Sender))
+	if err := app.postActivity(ctx, payload.Repository.FullName, message); err != nil {
+		return err
+	}
+
+	app.announce(ctx, "package.published", message)
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// handlePing GitHub 建立 webhook 時送的 ping，DISCORD_PING_CONFIRMATION=true 時發確認訊息
+func (app *App) handlePing(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+	cfg := config.Current()
+
+	if !cfg.PingConfirmation {
+		return nil
+	}
+
+	message := discord.FormatPing(payload)
+
+	if payload.Repository.FullName != "" {
+		return app.postActivity(ctx, payload.Repository.FullName, message)
+	}
+
+	// org / app 層級的 webhook 沒有 repository，只能發到共用的 activity thread
+	if cfg.DiscordActivityThreadID == "" {
+		log.Info("Skipping ping confirmation: no repository and no shared activity thread", "hookID", payload.HookID)
+		return nil
+	}
+	return app.postMessage(ctx, cfg.DiscordActivityThreadID, message)
+}
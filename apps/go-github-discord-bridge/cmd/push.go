@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// handlePush 把 push 的 commit 清單發到 repo 的 activity thread
+// tag push 交給 release 事件處理，branch 刪除不通知
+func (app *App) handlePush(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+
+	if payload.IsTagPush() {
+		log.Info("Ignoring tag push", "ref", payload.Ref)
+		return nil
+	}
+	if payload.Deleted {
+		log.Info("Ignoring branch deletion", "ref", payload.Ref)
+		return nil
+	}
+	if !branchAllowed(payload.Repository.FullName, payload.RefName()) {
+		log.Info("Ignoring push to filtered branch", "ref", payload.Ref)
+		return nil
+	}
+	// 沒有 commit 的 push（例如只建立 branch）交給 create 事件處理
+	if len(payload.Commits) == 0 {
+		return nil
+	}
+
+	threadID, err := app.ensureActivityThread(ctx, payload.Repository.FullName)
+	if err != nil {
+		return err
+	}
+
+	message := app.render(ctx, "push", discord.FormatPush(payload, config.Current().PushMaxCommits))
+	if err := app.postMessage(ctx, threadID, message); err != nil {
+		return err
+	}
+
+	app.announce(ctx, "push", message)
+	app.crossLinkCommits(ctx, payload)
+	return nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/metrics"
+	"dizzycode1112/github-discord-bridge/internal/queue"
+	"dizzycode1112/github-discord-bridge/internal/tracing"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// queueSaturation queue 用量超過這個比例時 /readyz 回 not ready，讓 load balancer 先把流量導到其他 instance
+const queueSaturation = 0.9
+
+// queuedDelivery 寫進 queue journal 的內容（重啟後用來重新處理）
+type queuedDelivery struct {
+	Event      string          `json:"event"`
+	DeliveryID string          `json:"delivery_id"`
+	Body       json.RawMessage `json:"body"`
+	// Traceparent 收到 webhook 時的 trace，重啟後 / broker 另一端的 publisher 處理時接在同一個 trace 底下
+	Traceparent string `json:"traceparent,omitempty"`
+}
+
+// encodeDelivery delivery 編成 queuedDelivery 的 JSON，帶上 ctx 的 trace
+func encodeDelivery(ctx context.Context, delivery github.Delivery) ([]byte, error) {
+	return json.Marshal(queuedDelivery{Event: delivery.Event, DeliveryID: delivery.ID, Body: delivery.Body, Traceparent: tracing.Traceparent(ctx)})
+}
+
+// context 處理 queuedDelivery 用的 ctx（接在收到 webhook 時的 trace 底下）
+func (d queuedDelivery) context() context.Context {
+	return tracing.WithTraceparent(context.Background(), d.Traceparent)
+}
+
+// newEventQueue QUEUE_WORKERS > 0 時建立 worker pool，webhook 放進 queue 後立即回 202；0 = 同步處理（回應前跑完 handler）
+// 有設定 QUEUE_PATH 時 queue 同時寫到磁碟（回傳的 journal 由呼叫端 Close），重啟後用 restoreQueue 接著處理
+// 重試後仍失敗的事件存進 dead letter（見 deadLetter）
+func (app *App) newEventQueue(cfg *config.Config) (*queue.Pool, *queue.BoltJournal, error) {
+	if cfg.QueueWorkers <= 0 {
+		return nil, nil, nil
+	}
+	log := applogger.Log
+
+	opts := queue.Options{
+		Workers:     cfg.QueueWorkers,
+		Size:        cfg.QueueSize,
+		MaxAttempts: cfg.QueueMaxAttempts,
+		Backoff:     cfg.QueueRetryBackoff,
+		Retryable:   retryableError,
+		Wait:        outageWait,
+		OnRetry: func(job queue.Job, attempt int, err error) {
+			metrics.QueueRetries.Inc(job.Name)
+			log.Warn("Retrying event", "ghEvent", job.Name, "key", job.Key, "attempt", attempt, "error", err)
+		},
+		OnDone: func(job queue.Job, attempts int, err error) {
+			if err != nil {
+				metrics.QueueJobs.Inc(job.Name, "failed")
+				log.Error("Giving up on event", "ghEvent", job.Name, "key", job.Key, "attempts", attempts, "error", err)
+				app.deadLetter(job, attempts, err)
+				return
+			}
+			metrics.QueueJobs.Inc(job.Name, "processed")
+		},
+	}
+
+	var journal *queue.BoltJournal
+	if cfg.QueuePath != "" {
+		var err error
+		if journal, err = queue.OpenBoltJournal(cfg.QueuePath); err != nil {
+			return nil, nil, err
+		}
+		opts.Journal = journal
+	}
+
+	pool := queue.New(opts)
+	metrics.QueueDepth.Set(func() float64 { return float64(pool.Len()) })
+	return pool, journal, nil
+}
+
+// dispatchEvent 實作 github.Dispatcher：依 issue / PR（沒有時依 repo）決定排序 key，同一個 issue 的事件依收到的順序處理
+// 原始 body 一起放進 job，有 QUEUE_PATH 時寫到磁碟
+func (app *App) dispatchEvent(ctx context.Context, delivery github.Delivery) error {
+	data, err := encodeDelivery(ctx, delivery)
+	if err != nil {
+		return err
+	}
+	return app.queue.Enqueue(app.deliveryJob(ctx, delivery, data))
+}
+
+// deliveryJob 處理 delivery 的 job，data 為 queuedDelivery 的 JSON（寫到 journal / dead letter）
+func (app *App) deliveryJob(ctx context.Context, delivery github.Delivery, data []byte) queue.Job {
+	return queue.Job{
+		Key:  orderingKey(delivery.Event, delivery.Payload),
+		Name: delivery.Event,
+		Data: data,
+		Run:  func() error { return app.webhooks.Process(ctx, delivery) },
+	}
+}
+
+// restoreQueue 把上次結束時還沒處理完的事件放回 queue（在 server 開始接受 webhook 之前呼叫，維持原本的順序）
+// 上次處理到一半的 delivery 已經被 claim，先 release 才不會被當成重複的 delivery 略過
+func (app *App) restoreQueue() {
+	log := applogger.Log
+	restored, err := app.queue.Restore(func(rec queue.Record) (func() error, error) {
+		var d queuedDelivery
+		if err := json.Unmarshal(rec.Data, &d); err != nil {
+			return nil, err
+		}
+		if d.DeliveryID != "" {
+			if err := app.store.ReleaseDelivery(d.DeliveryID); err != nil {
+				log.Warn("Failed to release delivery", "deliveryID", d.DeliveryID, "error", err)
+			}
+		}
+		delivery := github.Delivery{ID: d.DeliveryID, Event: d.Event, Body: d.Body}
+		return func() error { return app.webhooks.Process(d.context(), delivery) }, nil
+	})
+	if err != nil {
+		log.Error("Failed to restore some queued events", "error", err)
+	}
+	if restored > 0 {
+		log.Info("Restored queued events from disk", "count", restored, "path", config.Current().QueuePath)
+	}
+}
+
+// orderingKey 同一個 issue / PR / discussion 的事件用同一個 key；其他事件（push、release 等）以 repo 為單位
+func orderingKey(event string, payload *github.WebhookPayload) string {
+	if id := payload.GetPRIdentifier(); id != "" {
+		return strings.ToLower(id)
+	}
+	if repo := payload.Repository.FullName; repo != "" {
+		return strings.ToLower(repo)
+	}
+	return event
+}
+
+// retryableError 只重試暫時性的錯誤：Discord 5xx / 429、circuit breaker 開啟、網路錯誤和逾時
+// payload 格式錯誤、Discord 回 4xx（找不到 thread、缺少權限等）這類重試也不會成功的直接放棄
+func retryableError(err error) bool {
+	var apiErr *discord.DiscordAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	if errors.Is(err, discord.ErrCircuitOpen) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// outageWait Discord 暫時無法使用（circuit breaker 開啟、被 rate limit）時等它恢復再送，不計入 QUEUE_MAX_ATTEMPTS
+// 這段期間後續的事件留在 queue（有 QUEUE_PATH 時在磁碟上），恢復後依序送出
+func outageWait(err error) time.Duration {
+	if errors.Is(err, discord.ErrCircuitOpen) {
+		return config.Current().BreakerCooldown
+	}
+	var apiErr *discord.DiscordAPIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+		return max(apiErr.RetryAfter, time.Second)
+	}
+	return 0
+}
+
+// checkQueue queue 快滿時視為 not ready
+func (app *App) checkQueue(context.Context) error {
+	if depth, capacity := app.queue.Len(), app.queue.Cap(); float64(depth) >= queueSaturation*float64(capacity) {
+		return fmt.Errorf("event queue is saturated (%d/%d)", depth, capacity)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/storage"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// reconcile 比對每個 issue / PR mapping 和 GitHub、Discord 的實際狀態，修正服務停機或漏收 webhook 造成的落差：
+//   - thread 已被刪除：清掉 mapping，下次有事件時 ensureThread 會重建
+//   - GitHub 上已關閉但 mapping 還是 open：archive thread 並 MarkAsClosed
+//   - GitHub 上已重開但 mapping 是 closed：重新 Set 清掉 TTL
+//
+// 只處理 "owner/repo#123" 這種 key；activity / release / milestone 等 key 不比對
+func (app *App) reconcile(ctx context.Context) error {
+	log := applogger.Log
+
+	if app.githubAPI == nil {
+		log.Warn("Skipping reconcile: no GitHub credentials")
+		return nil
+	}
+
+	mappings, err := app.store.ListStale(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to list mappings for reconcile: %w", err)
+	}
+
+	installations := make(map[string]int64)
+	var fixed int
+	for _, m := range mappings {
+		repo, number, ok := issueMappingKey(m.Key)
+		if !ok {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		repoCtx, err := app.repoContext(ctx, repo, installations)
+		if err != nil {
+			log.Warn("Failed to resolve installation for reconcile", "repo", repo, "error", err)
+			continue
+		}
+
+		changed, err := app.reconcileMapping(repoCtx, m, repo, number)
+		if err != nil {
+			log.Warn("Failed to reconcile mapping", "key", m.Key, "error", err)
+			continue
+		}
+		if changed {
+			fixed++
+		}
+	}
+
+	log.Info("Reconcile finished", "mappings", len(mappings), "fixed", fixed)
+	return nil
+}
+
+// reconcileMapping 修正單一 mapping，回傳是否有變動
+func (app *App) reconcileMapping(ctx context.Context, m storage.Mapping, repo string, number int) (bool, error) {
+	log := applogger.Log
+
+	if _, err := app.discordClient.GetThread(ctx, m.ThreadID); errors.Is(err, discord.ErrNotFound) {
+		log.Info("Reconcile: thread deleted, clearing mapping", "key", m.Key, "threadID", m.ThreadID)
+		return true, app.store.Delete(m.Key)
+	} else if err != nil {
+		return false, err
+	}
+
+	issue, err := app.githubAPI.GetIssue(ctx, repo, number)
+	if errors.Is(err, github.ErrNotFound) {
+		// issue 被刪除或 repo 沒有權限，不確定是哪一種，先不動
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case issue.State == "closed" && !m.Closed:
+		log.Info("Reconcile: closed on GitHub, archiving thread", "key", m.Key, "threadID", m.ThreadID)
+		app.archiveThread(ctx, m.ThreadID, m.Key+" was closed on GitHub (reconciled)")
+		return true, app.store.MarkAsClosed(m.Key)
+	case issue.State == "open" && m.Closed:
+		log.Info("Reconcile: reopened on GitHub, restoring mapping", "key", m.Key, "threadID", m.ThreadID)
+		return true, app.store.Set(m.Key, m.ThreadID)
+	}
+	return false, nil
+}
+
+// repoContext 使用 GitHub App 時帶上 repo 的 installation ID（同一輪 reconcile 內快取）
+func (app *App) repoContext(ctx context.Context, repo string, installations map[string]int64) (context.Context, error) {
+	if app.githubApp == nil {
+		return ctx, nil
+	}
+
+	installationID, ok := installations[repo]
+	if !ok {
+		id, err := app.githubApp.RepoInstallationID(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+		installationID = id
+		installations[repo] = id
+	}
+	return github.WithInstallationID(ctx, installationID), nil
+}
+
+// issueMappingKey 從 "owner/repo#123" 拆出 repo 和編號，其他形式的 key 回傳 false
+func issueMappingKey(key string) (string, int, bool) {
+	repo, rest, ok := strings.Cut(key, "#")
+	if !ok || !strings.Contains(repo, "/") {
+		return "", 0, false
+	}
+	number, err := strconv.Atoi(rest)
+	if err != nil || number <= 0 {
+		return "", 0, false
+	}
+	return repo, number, true
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// recordedPayload RECORD_PAYLOADS_DIR 裡的一個檔案：原始的 webhook body 和 header
+type recordedPayload struct {
+	DeliveryID string            `json:"delivery_id"`
+	Event      string            `json:"event"`
+	Repo       string            `json:"repo,omitempty"`
+	Action     string            `json:"action,omitempty"`
+	ReceivedAt time.Time         `json:"received_at"`
+	Headers    map[string]string `json:"headers"`
+	Body       json.RawMessage   `json:"body"`
+
+	file string // 讀取時的檔名
+}
+
+// recordedHeaderSkip 不寫進檔案的 header（proxy 帶的認證資訊）
+var recordedHeaderSkip = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+// recordPruneEvery 每寫入幾個檔案檢查一次 RECORD_PAYLOADS_MAX（每次都列目錄在檔案多時太慢）
+const recordPruneEvery = 50
+
+// payloadRecorder 把 delivery 寫進 RECORD_PAYLOADS_DIR（可以 reload），超過 RECORD_PAYLOADS_MAX 時刪除最舊的
+type payloadRecorder struct {
+	mu     sync.Mutex
+	writes int // 上次 prune 之後寫入的檔案數
+}
+
+// record 實作 github.Recorder；寫入失敗只記 log，不影響 webhook 的處理
+func (r *payloadRecorder) record(_ context.Context, header http.Header, d github.Delivery) {
+	cfg := config.Current()
+	if cfg.RecordPayloadsDir == "" {
+		return
+	}
+
+	body, err := redactRecordedBody(d.Event, d.Body)
+	if err != nil {
+		applogger.Log.Warn("Failed to redact webhook payload, not recording it", "deliveryID", d.ID, "ghEvent", d.Event, "error", err)
+		return
+	}
+
+	rec := recordedPayload{
+		DeliveryID: d.ID,
+		Event:      d.Event,
+		ReceivedAt: time.Now().UTC(),
+		Headers:    make(map[string]string, len(header)),
+		Body:       body,
+	}
+	if d.Payload != nil {
+		rec.Repo, rec.Action = d.Payload.Repository.FullName, d.Payload.Action
+	}
+	for key, values := range header {
+		if !recordedHeaderSkip[key] {
+			rec.Headers[key] = strings.Join(values, ", ")
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := writeRecordedPayload(cfg.RecordPayloadsDir, rec); err != nil {
+		applogger.Log.Warn("Failed to record webhook payload", "deliveryID", d.ID, "ghEvent", d.Event, "error", err)
+		return
+	}
+	r.writes++
+	if cfg.RecordPayloadsMax > 0 && r.writes >= recordPruneEvery {
+		r.writes = 0
+		if err := pruneRecordedPayloads(cfg.RecordPayloadsDir, cfg.RecordPayloadsMax); err != nil {
+			applogger.Log.Warn("Failed to prune recorded payloads", "dir", cfg.RecordPayloadsDir, "error", err)
+		}
+	}
+}
+
+// redactRecordedBody secret_scanning_alert 的 alert.secret 是外洩的 secret 明文，寫進檔案前換成 github.RedactSecret 的結果
+// 其他 event 原樣回傳；改過的 body 和記下的 X-Hub-Signature-256 對不上，replay-payloads 本來就不驗證簽名
+func redactRecordedBody(event string, body []byte) ([]byte, error) {
+	if event != "secret_scanning_alert" {
+		return body, nil
+	}
+
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	var alert map[string]json.RawMessage
+	if err := json.Unmarshal(payload["alert"], &alert); err != nil {
+		return nil, err
+	}
+	var secret string
+	if raw, ok := alert["secret"]; !ok {
+		return body, nil
+	} else if err := json.Unmarshal(raw, &secret); err != nil {
+		return nil, err
+	}
+
+	redacted, err := json.Marshal(github.RedactSecret(secret))
+	if err != nil {
+		return nil, err
+	}
+	alert["secret"] = redacted
+	if payload["alert"], err = json.Marshal(alert); err != nil {
+		return nil, err
+	}
+	return json.Marshal(payload)
+}
+
+// writeRecordedPayload 檔名為 "<收到的時間>-<event>-<delivery ID>.json"，依檔名排序就是收到的順序
+func writeRecordedPayload(dir string, rec recordedPayload) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s-%s.json", rec.ReceivedAt.Format("20060102T150405.000000Z"), safeFileName(rec.Event), safeFileName(rec.DeliveryID))
+	tmp := filepath.Join(dir, "."+name)
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, name))
+}
+
+// safeFileName 只保留英數字、"-"、"_" 和 "."，header 的值不能拿來組出其他路徑
+func safeFileName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// recordedFiles dir 裡的紀錄檔，依檔名（收到的時間）排序
+func recordedFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if name := entry.Name(); !entry.IsDir() && !strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".json") {
+			files = append(files, name)
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// pruneRecordedPayloads 只留下最新的 limit 個檔案
+func pruneRecordedPayloads(dir string, limit int) error {
+	files, err := recordedFiles(dir)
+	if err != nil || len(files) <= limit {
+		return err
+	}
+	var errs []error
+	for _, name := range files[:len(files)-limit] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// loadRecordedPayloads 讀取 dir 裡的所有紀錄，依收到的順序
+func loadRecordedPayloads(dir string) ([]recordedPayload, error) {
+	files, err := recordedFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	payloads := make([]recordedPayload, 0, len(files))
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var rec recordedPayload
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return nil, fmt.Errorf("invalid recorded payload %s: %w", name, err)
+		}
+		rec.file = name
+		payloads = append(payloads, rec)
+	}
+	return payloads, nil
+}
+
+// runReplayPayloads `main replay-payloads [--id ID[,ID...]] [--event E] [--repo R] [--last N] [--list] [--local]`：
+// 把 RECORD_PAYLOADS_DIR 裡保存的 webhook 依收到的順序重新處理一次（不驗證簽名，先釋放 delivery 的去重紀錄）
+// --local 不寫入設定的 storage、不送到 Discord，只印出會送出的 request（同 `main send --local`），用來檢查少見事件的格式
+// 不加 --local 時在這個 process 裡處理，bolt / sqlite backend 請先停止 server（檔案只能被一個 process 開啟）
+func runReplayPayloads(cfg *config.Config, args []string) error {
+	log := applogger.Log
+
+	fs := flag.NewFlagSet("replay-payloads", flag.ContinueOnError)
+	dir := fs.String("dir", cfg.RecordPayloadsDir, "directory with recorded payloads")
+	ids := fs.String("id", "", "comma-separated delivery IDs to replay")
+	event := fs.String("event", "", "only replay this event (e.g. issues, or issues.opened)")
+	repo := fs.String("repo", "", "only replay events of this repository")
+	last := fs.Int("last", 0, "only replay the N most recent matching payloads")
+	list := fs.Bool("list", false, "list the matching payloads without replaying them")
+	local := fs.Bool("local", false, "print the Discord requests instead of sending them, without touching the configured storage")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return errors.New("--dir or RECORD_PAYLOADS_DIR is required")
+	}
+
+	payloads, err := loadRecordedPayloads(*dir)
+	if err != nil {
+		return err
+	}
+	wanted := make(map[string]bool)
+	for _, id := range strings.Split(*ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			wanted[id] = true
+		}
+	}
+	payloads = slices.DeleteFunc(payloads, func(rec recordedPayload) bool {
+		key := rec.Event
+		if rec.Action != "" {
+			key += "." + rec.Action
+		}
+		return (len(wanted) > 0 && !wanted[rec.DeliveryID]) ||
+			(*event != "" && *event != rec.Event && *event != key) ||
+			(*repo != "" && !strings.EqualFold(*repo, rec.Repo))
+	})
+	if *last > 0 && len(payloads) > *last {
+		payloads = payloads[len(payloads)-*last:]
+	}
+	if len(payloads) == 0 {
+		return fmt.Errorf("no recorded payloads match in %s", *dir)
+	}
+
+	if *list {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RECEIVED AT\tDELIVERY ID\tEVENT\tACTION\tREPO\tFILE")
+		for _, rec := range payloads {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", rec.ReceivedAt.Local().Format(time.DateTime), rec.DeliveryID, rec.Event, rec.Action, rec.Repo, rec.file)
+		}
+		return w.Flush()
+	}
+
+	deliveries := make([]github.Delivery, 0, len(payloads))
+	for _, rec := range payloads {
+		deliveries = append(deliveries, github.Delivery{ID: rec.DeliveryID, Event: rec.Event, Body: rec.Body})
+	}
+	if *local {
+		return processLocal(cfg, deliveries...)
+	}
+
+	app, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.store.Close()
+	app.newWebhookHandler(cfg)
+
+	failed := 0
+	for _, d := range deliveries {
+		if d.ID != "" {
+			if err := app.store.ReleaseDelivery(d.ID); err != nil {
+				return err
+			}
+		}
+		if err := app.webhooks.Process(context.Background(), d); err != nil {
+			failed++
+			log.Error("Replay failed", "deliveryID", d.ID, "ghEvent", d.Event, "error", err)
+			continue
+		}
+		log.Info("Replayed recorded payload", "deliveryID", d.ID, "ghEvent", d.Event)
+	}
+	log.Info("Replay finished", "replayed", len(deliveries)-failed, "failed", failed)
+	if failed > 0 {
+		return fmt.Errorf("%d recorded payload(s) failed to replay", failed)
+	}
+	return nil
+}
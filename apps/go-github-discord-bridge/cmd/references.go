@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// crossLinkCommits push 的 commit message 提到 "#123" / "fixes #123" 時，在被提到的 issue / PR thread 發一則參照通知
+func (app *App) crossLinkCommits(ctx context.Context, payload *github.WebhookPayload) {
+	repoFullName := payload.Repository.FullName
+	for _, commit := range payload.Commits {
+		// 已經 push 過的 commit（例如 merge 進其他 branch）不重複通知
+		if !commit.Distinct {
+			continue
+		}
+		for _, ref := range github.ParseIssueReferences(commit.Message, repoFullName) {
+			app.postReference(ctx, ref, discord.FormatCommitReference(commit, repoFullName, ref.Closes))
+		}
+	}
+}
+
+// crossLinkPullRequest PR 內文提到其他 issue / PR 時，在對方的 thread 發參照通知（PR 自己除外）；backfill 時不發
+func (app *App) crossLinkPullRequest(ctx context.Context, pr *github.PullRequest, repoFullName string) {
+	if isBackfill(ctx) {
+		return
+	}
+	for _, ref := range github.ParseIssueReferences(pr.Body, repoFullName) {
+		if strings.EqualFold(ref.Repo, repoFullName) && ref.Number == pr.Number {
+			continue
+		}
+		app.postReference(ctx, ref, discord.FormatPRReference(pr, repoFullName, ref.Closes))
+	}
+}
+
+// postReference 被參照的 issue / PR 有 thread 時才發，沒有就略過（不為了參照建立新 thread）；失敗只 log
+func (app *App) postReference(ctx context.Context, ref github.IssueReference, message discord.ThreadMessage) {
+	log := applogger.Log
+
+	threadID, exists, err := app.store.Get(ref.Key())
+	if err != nil {
+		log.Warn("Failed to look up referenced thread", "ref", ref.Key(), "error", err)
+		return
+	}
+	if !exists {
+		return
+	}
+
+	if err := app.postMessage(ctx, threadID, message); err != nil {
+		log.Warn("Failed to post reference", "ref", ref.Key(), "threadID", threadID, "error", err)
+	}
+}
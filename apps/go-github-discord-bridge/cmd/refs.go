@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// handleRefChanged 處理 create / delete（branch、tag 建立或刪除），依設定過濾後發到 activity thread
+func (app *App) handleRefChanged(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	if !refEventEnabled(payload.RefType, payload.Ref) {
+		applogger.Log.Info("Ignoring ref event", "ghEvent", ghEvent, "refType", payload.RefType, "ref", payload.Ref)
+		return nil
+	}
+
+	created := ghEvent == "create"
+	message := app.render(ctx, ghEvent+"."+payload.RefType, discord.FormatRefChanged(payload.RefType, payload.Ref, payload.Repository.FullName, payload.Sender, created))
+	if err := app.postActivity(ctx, payload.Repository.FullName, message); err != nil {
+		return err
+	}
+
+	app.announce(ctx, ghEvent+"."+payload.RefType, message)
+	return nil
+}
+
+// refEventEnabled 依 DISCORD_REF_EVENT_TYPES（branch / tag）和 DISCORD_REF_EVENT_PATTERNS（glob，例如 release/*）判斷要不要通知
+// patterns 為空時不過濾名稱
+func refEventEnabled(refType, ref string) bool {
+	cfg := config.Current()
+
+	if !cfg.RefEventTypes[refType] {
+		return false
+	}
+	return len(cfg.RefEventPatterns) == 0 || matchAny(cfg.RefEventPatterns, ref)
+}
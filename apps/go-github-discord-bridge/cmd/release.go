@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// releaseThreadKey release thread 在 store 裡的 key（格式："owner/repo@v1.2.0"）
+func releaseThreadKey(repoFullName, tagName string) string {
+	return repoFullName + "@" + tagName
+}
+
+// handleRelease release 發布時建立 release thread（可關閉），並發到 announcement channel
+func (app *App) handleRelease(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+
+	release := payload.Release
+	if release == nil {
+		log.Warn("No release in payload, ignoring")
+		return nil
+	}
+	if payload.Action != "published" {
+		log.Info("Ignoring release action", "action", payload.Action)
+		return nil
+	}
+
+	repoFullName := payload.Repository.FullName
+	message := app.render(ctx, "release.published", discord.FormatRelease(release, repoFullName))
+
+	if config.Current().ReleaseThreads {
+		if err := app.createReleaseThread(ctx, repoFullName, release, message); err != nil {
+			return err
+		}
+	}
+
+	app.announce(ctx, "release.published", message)
+	return nil
+}
+
+func (app *App) createReleaseThread(ctx context.Context, repoFullName string, release *github.Release, message discord.ThreadMessage) error {
+	log := applogger.Log
+
+	key := releaseThreadKey(repoFullName, release.TagName)
+	if existingThreadID, exists, _ := app.store.Get(key); exists {
+		log.Info("Thread already exists", "release", key, "threadID", existingThreadID)
+		return nil
+	}
+
+	title := discord.PrefixThreadName(threadEmoji("release", nil), discord.FormatReleaseThreadTitle(release, repoFullName))
+	threadID, err := app.forum(repoFullName).CreateThread(ctx, title, message, app.repoTagIDs(ctx, repoFullName)...)
+	if err != nil {
+		return fmt.Errorf("failed to create thread: %w", err)
+	}
+
+	if err := app.store.Set(key, threadID); err != nil {
+		return fmt.Errorf("failed to save mapping: %w", err)
+	}
+
+	log.Info("Created release thread", "release", key, "threadID", threadID)
+	app.seedReaction(ctx, threadID, threadID, "release.published")
+	return nil
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"syscall"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/i18n"
+	"dizzycode1112/github-discord-bridge/internal/templates"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// messageStyles 由設定編譯出來、render 時使用的 template 和顏色
+type messageStyles struct {
+	templates *templates.Engine // nil = 沒有自訂 template，全部用內建格式
+	colors    map[string]int    // DISCORD_EVENT_COLORS，event key / type → 顏色
+}
+
+func newMessageStyles(cfg *config.Config) (*messageStyles, error) {
+	colors, err := newEventColors(cfg)
+	if err != nil {
+		return nil, err
+	}
+	engine, err := newTemplates(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &messageStyles{templates: engine, colors: colors}, nil
+}
+
+// restartOnlySettings 啟動時就決定、reload 不會生效的設定（server、storage、client 和背景工作）
+// 其他設定每個 request 都從 config.Current() 讀取，reload 後的下一個 request 就會用新值
+// （Discord bot token 和 webhook secret 由 applySecrets 換進 client / handler）
+var restartOnlySettings = map[string]func(cfg *config.Config) any{
+	"PORT":                             func(c *config.Config) any { return c.Port },
+	"WEBHOOK_MAX_BODY_SIZE":            func(c *config.Config) any { return c.WebhookMaxBodySize },
+	"HTTP_READ_HEADER_TIMEOUT":         func(c *config.Config) any { return c.HTTPReadHeaderTimeout },
+	"HTTP_READ_TIMEOUT":                func(c *config.Config) any { return c.HTTPReadTimeout },
+	"HTTP_WRITE_TIMEOUT":               func(c *config.Config) any { return c.HTTPWriteTimeout },
+	"HTTP_IDLE_TIMEOUT":                func(c *config.Config) any { return c.HTTPIdleTimeout },
+	"GITHUB_IP_ALLOWLIST":              func(c *config.Config) any { return c.GitHubIPAllowlist },
+	"GITHUB_IP_ALLOWLIST_REFRESH":      func(c *config.Config) any { return c.GitHubIPAllowlistRefresh },
+	"TRUSTED_PROXIES":                  func(c *config.Config) any { return c.TrustedProxies },
+	"TLS_AUTOCERT_DOMAINS":             func(c *config.Config) any { return c.TLSAutocertDomains },
+	"TLS_AUTOCERT_EMAIL":               func(c *config.Config) any { return c.TLSAutocertEmail },
+	"TLS_AUTOCERT_CACHE_DIR":           func(c *config.Config) any { return c.TLSAutocertCacheDir },
+	"TLS_AUTOCERT_DIRECTORY_URL":       func(c *config.Config) any { return c.TLSAutocertDirectoryURL },
+	"TLS_HTTP_PORT":                    func(c *config.Config) any { return c.TLSHTTPPort },
+	"HA_MODE":                          func(c *config.Config) any { return c.HAMode },
+	"INGEST_MODE":                      func(c *config.Config) any { return c.IngestMode },
+	"BROKER":                           func(c *config.Config) any { return c.Broker },
+	"BROKER_URL":                       func(c *config.Config) any { return c.BrokerURL },
+	"BROKER_TOPIC":                     func(c *config.Config) any { return c.BrokerTopic },
+	"BROKER_STREAM":                    func(c *config.Config) any { return c.BrokerStream },
+	"BROKER_GROUP":                     func(c *config.Config) any { return c.BrokerGroup },
+	"BROKER_BATCH":                     func(c *config.Config) any { return c.BrokerBatch },
+	"HA_REPLICA_ID":                    func(c *config.Config) any { return c.HAReplicaID },
+	"HA_LEASE_TTL":                     func(c *config.Config) any { return c.HALeaseTTL },
+	"ENV":                              func(c *config.Config) any { return c.Env },
+	"STORAGE_BACKEND":                  func(c *config.Config) any { return c.StorageBackend },
+	"REDIS_URL":                        func(c *config.Config) any { return c.RedisURL },
+	"POSTGRES_URL":                     func(c *config.Config) any { return c.PostgresURL },
+	"SQLITE_PATH":                      func(c *config.Config) any { return c.SQLitePath },
+	"BOLT_PATH":                        func(c *config.Config) any { return c.BoltPath },
+	"DISCORD_FORUM_CHANNEL_ID":         func(c *config.Config) any { return c.DiscordForumChID },
+	"DISCORD_API_BASE_URL":             func(c *config.Config) any { return c.DiscordAPIBaseURL },
+	"DISCORD_API_VERSION":              func(c *config.Config) any { return c.DiscordAPIVersion },
+	"DISCORD_HTTP_TIMEOUT":             func(c *config.Config) any { return c.DiscordHTTPTimeout },
+	"DISCORD_BREAKER_THRESHOLD":        func(c *config.Config) any { return c.BreakerThreshold },
+	"DISCORD_PUBLIC_KEY":               func(c *config.Config) any { return c.DiscordPublicKey },
+	"DISCORD_APPLICATION_ID":           func(c *config.Config) any { return c.DiscordApplicationID },
+	"DISCORD_GUILD_ID":                 func(c *config.Config) any { return c.DiscordGuildID },
+	"DISCORD_COMMANDS_FILE":            func(c *config.Config) any { return c.DiscordCommandsFile },
+	"GITHUB_WEBHOOK_LEGACY_SIGNATURE":  func(c *config.Config) any { return c.GitHubLegacySignature },
+	"GITHUB_BASE_URL":                  func(c *config.Config) any { return c.GitHubBaseURL },
+	"GITHUB_API_URL":                   func(c *config.Config) any { return c.GitHubAPIURL },
+	"GITHUB_TOKEN":                     func(c *config.Config) any { return c.GitHubToken },
+	"GITHUB_APP_ID":                    func(c *config.Config) any { return c.GitHubAppID },
+	"DISCORD_COMMUNITY_BATCH_INTERVAL": func(c *config.Config) any { return c.CommunityBatchInterval },
+	"RECONCILE_INTERVAL":               func(c *config.Config) any { return c.ReconcileInterval },
+	"GC_INTERVAL":                      func(c *config.Config) any { return c.GCInterval },
+	"RECONCILE_SCHEDULE":               func(c *config.Config) any { return c.ReconcileSchedule },
+	"GC_SCHEDULE":                      func(c *config.Config) any { return c.GCSchedule },
+	"STALE_PR_SCHEDULE":                func(c *config.Config) any { return c.StalePRSchedule },
+	"TAG_GC_SCHEDULE":                  func(c *config.Config) any { return c.TagGCSchedule },
+	"SCHEDULER_JITTER":                 func(c *config.Config) any { return c.SchedulerJitter },
+	"DISCORD_DIGEST_SCHEDULE":          func(c *config.Config) any { return c.DigestSchedule },
+	"DISCORD_REPO_DIGEST_SCHEDULES":    func(c *config.Config) any { return c.RepoDigestSchedules },
+	"DISCORD_DIGEST_EVENTS":            func(c *config.Config) any { return c.DigestEvents },
+	"CONFIG_WATCH_INTERVAL":            func(c *config.Config) any { return c.ConfigWatchInterval },
+	"SECRETS_REFRESH_INTERVAL":         func(c *config.Config) any { return c.SecretsRefreshInterval },
+	"QUEUE_WORKERS":                    func(c *config.Config) any { return c.QueueWorkers },
+	"QUEUE_SIZE":                       func(c *config.Config) any { return c.QueueSize },
+	"QUEUE_MAX_ATTEMPTS":               func(c *config.Config) any { return c.QueueMaxAttempts },
+	"QUEUE_RETRY_BACKOFF":              func(c *config.Config) any { return c.QueueRetryBackoff },
+	"REPO_RATE_FLUSH_INTERVAL":         func(c *config.Config) any { return c.RepoRateFlushInterval },
+	"QUEUE_PATH":                       func(c *config.Config) any { return c.QueuePath },
+	"SHUTDOWN_TIMEOUT":                 func(c *config.Config) any { return c.ShutdownTimeout },
+	"OTEL_EXPORTER_OTLP_ENDPOINT":      func(c *config.Config) any { return c.OTelEndpoint },
+	"OTEL_EXPORTER_OTLP_HEADERS":       func(c *config.Config) any { return c.OTelHeaders },
+	"OTEL_SERVICE_NAME":                func(c *config.Config) any { return c.OTelServiceName },
+	"OTEL_TRACES_SAMPLER_ARG":          func(c *config.Config) any { return c.OTelSampleRatio },
+}
+
+// reloadConfig 重讀設定檔和環境變數：路由、過濾規則、template 等立即生效，處理中的 request 用原本的設定跑完
+// 新設定有錯時（驗證失敗、template 編譯失敗等）保留目前的設定
+func (app *App) reloadConfig(reason string) error {
+	log := applogger.Log
+
+	cfg, err := config.Reload()
+	if err != nil {
+		return err
+	}
+	styles, err := newMessageStyles(cfg)
+	if err != nil {
+		return err
+	}
+	// 全部檢查通過才套用，避免只換了語系、時間格式卻沒換
+	if err := i18n.CheckLocale(cfg.Locale); err != nil {
+		return fmt.Errorf("invalid DISCORD_LOCALE: %w", err)
+	}
+	if err := discord.CheckTimeFormat(cfg.TimeStyle, cfg.Timezone, cfg.TimeLayout); err != nil {
+		return fmt.Errorf("invalid time format settings: %w", err)
+	}
+
+	old := config.Swap(cfg)
+	i18n.SetLocale(cfg.Locale)
+	discord.SetTimeFormat(cfg.TimeStyle, cfg.Timezone, cfg.TimeLayout)
+	app.styles.Store(styles)
+	app.applySecrets(old, cfg)
+
+	var ignored []string
+	for key, get := range restartOnlySettings {
+		if !reflect.DeepEqual(get(old), get(cfg)) {
+			ignored = append(ignored, key)
+		}
+	}
+	if len(ignored) > 0 {
+		sort.Strings(ignored)
+		log.Warn("Some changed settings only take effect after a restart", "settings", ignored)
+	}
+
+	templateCount := 0
+	if styles.templates != nil {
+		templateCount = styles.templates.Len()
+	}
+	log.Info("Reloaded configuration", "reason", reason, "templates", templateCount)
+	return nil
+}
+
+// applySecrets Discord bot token 或 webhook secret 有變更時（例如 secrets backend 輪替）換進 client / handler
+func (app *App) applySecrets(old, cfg *config.Config) {
+	log := applogger.Log
+	if app.discordClient != nil && cfg.DiscordBotToken != old.DiscordBotToken {
+		app.discordClient.SetToken(cfg.DiscordBotToken)
+		log.Info("Discord bot token updated")
+	}
+	if app.webhooks != nil && (cfg.GitHubWebhookSecret != old.GitHubWebhookSecret ||
+		cfg.GitHubWebhookSecondarySecret != old.GitHubWebhookSecondarySecret ||
+		!reflect.DeepEqual(cfg.GitHubWebhookRepoSecrets, old.GitHubWebhookRepoSecrets)) {
+		app.webhooks.SetSecrets(cfg.GitHubWebhookSecret, cfg.GitHubWebhookSecondarySecret, cfg.GitHubWebhookRepoSecrets)
+		log.Info("GitHub webhook secrets updated", "repoSecrets", len(cfg.GitHubWebhookRepoSecrets))
+	}
+}
+
+// watchConfig 收到 SIGHUP 時 reload；interval > 0 時另外每隔 interval 檢查設定檔的修改時間，有變更就 reload
+// secretsInterval > 0 時每隔 secretsInterval 重新讀取 secrets backend 並 reload（值沒變時不影響任何東西）
+func (app *App) watchConfig(ctx context.Context, interval, secretsInterval time.Duration) {
+	log := applogger.Log
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	var secretsTick <-chan time.Time
+	if secretsInterval > 0 {
+		ticker := time.NewTicker(secretsInterval)
+		defer ticker.Stop()
+		secretsTick = ticker.C
+	}
+
+	reload := func(reason string) {
+		if err := app.reloadConfig(reason); err != nil {
+			log.Error("Failed to reload configuration, keeping current settings", "reason", reason, "error", err)
+		}
+	}
+
+	modTimes := configModTimes()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reload("SIGHUP")
+			modTimes = configModTimes()
+		case <-tick:
+			latest := configModTimes()
+			if !reflect.DeepEqual(latest, modTimes) {
+				modTimes = latest
+				reload("file changed")
+			}
+		case <-secretsTick:
+			reload("secrets refresh")
+		}
+	}
+}
+
+// configModTimes 設定檔和 DISCORD_TEMPLATES_DIR 裡每個檔案的修改時間
+func configModTimes() map[string]time.Time {
+	times := make(map[string]time.Time)
+	for _, path := range config.Files() {
+		if info, err := os.Stat(path); err == nil {
+			times[path] = info.ModTime()
+		}
+	}
+	if dir := config.Current().TemplatesDir; dir != "" {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				times[path] = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return times
+}
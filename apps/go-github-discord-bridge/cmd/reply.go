@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/i18n"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// replyCommand 訊息右鍵選單的 message command 名稱（見 discord.DefaultCommands）
+const replyCommand = "Reply to GitHub"
+
+// replyPrefix thread 開頭訊息的「Reply to GitHub」button 和它的 modal 共用的 custom_id 前綴："reply:owner/repo#123"
+const replyPrefix = "reply"
+
+// replyInputID modal 裡填回覆內容的 text input
+const replyInputID = "body"
+
+// replyMaxLength Discord text input 的上限（GitHub 留言上限 65536，不會先碰到）
+const replyMaxLength = 4000
+
+// replyTimeout 貼到 GitHub 並更新 interaction 回應的時間上限（interaction token 15 分鐘內有效）
+const replyTimeout = 30 * time.Second
+
+// githubReplyMarker 從 Discord 貼到 GitHub 的留言結尾加上的 HTML 註解（GitHub 上看不到）
+// issue_comment webhook 看到時略過，內容已經在 thread 裡了，不要再貼回來
+const githubReplyMarker = "<!-- github-discord-bridge:reply -->"
+
+// replyAuthorKey record 記錄 bot 發表回覆用的 GitHub 帳號（PAT 的擁有者或 "<app>[bot]"），issue_comment 只略過這個帳號的留言
+const replyAuthorKey = "github-reply-author"
+
+// mentionPattern GitHub 的 @user / @org/team mention（前面不能是英數字，避免動到 email）
+var mentionPattern = regexp.MustCompile(`(^|[^A-Za-z0-9_])@([A-Za-z0-9])`)
+
+// githubMarkdownEscaper 跳脫 Discord 名稱裡會被 GitHub 當成 markdown / HTML 的字元
+var githubMarkdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"(", `\(`, ")", `\)`, "<", "&lt;", ">", "&gt;", "#", `\#`, "!", `\!`, "|", `\|`, "~", `\~`,
+)
+
+// githubReply 要貼到 GitHub 的一則回覆
+type githubReply struct {
+	repo   string // 空值 = 由 channel 反查（message command）
+	number int
+	body   string
+
+	author  *discord.DiscordUser // 寫這段內容的人
+	poster  *discord.DiscordUser // 觸發 interaction 的人（message command 可能和 author 不同）
+	link    string               // Discord 上原本訊息的連結，modal 沒有
+	repost  bool                 // 內容原本不在 Discord 上（modal），成功後在 thread 貼一份
+	channel string               // interaction 所在的 thread
+}
+
+// withReplyButton DISCORD_REPLY_TO_GITHUB 開啟時在 thread 的開頭訊息加上「Reply to GitHub」button
+// 沒有 interactions endpoint 或 GitHub 認證時不加（按了也無法處理）
+func (app *App) withReplyButton(message discord.ThreadMessage, key string) discord.ThreadMessage {
+	customID := replyPrefix + ":" + key
+	if !config.Current().ReplyToGitHub || app.interactions == nil || app.githubAPI == nil || len(customID) > 100 {
+		return message
+	}
+	message.Components = append(slices.Clone(message.Components),
+		discord.ActionRow(discord.NewButton(customID, i18n.T("Reply to GitHub"))))
+	return message
+}
+
+// replyDenied 沒開放回覆或使用者沒有權限時，回傳要回給使用者的訊息（預設拒絕，留言用的是 bot 的寫入權限）
+// 有設定 DISCORD_REPLY_ROLES 時要有其中一個 role，否則要有對應的 GitHub 帳號（GITHUB_DISCORD_USER_MAP 或驗證過的 /github link）
+func (app *App) replyDenied(interaction *discord.Interaction) *discord.InteractionResponse {
+	cfg := config.Current()
+	if !cfg.ReplyToGitHub || app.githubAPI == nil {
+		return discord.EphemeralReply(i18n.T("Replying to GitHub is disabled."))
+	}
+	invoker := interaction.Invoker()
+	if invoker == nil {
+		return discord.EphemeralReply(i18n.T("Could not identify the Discord user."))
+	}
+	if len(cfg.ReplyRoles) > 0 {
+		if interaction.Member != nil && slices.ContainsFunc(interaction.Member.Roles, func(role string) bool {
+			return slices.Contains(cfg.ReplyRoles, role)
+		}) {
+			return nil
+		}
+		return discord.EphemeralReply(i18n.T("You don't have a role that is allowed to reply to GitHub."))
+	}
+	if _, ok := app.linkedGitHubLogin(invoker.ID); ok {
+		return nil
+	}
+	return discord.EphemeralReply(i18n.T("Link your GitHub account with `/github link` before replying to GitHub."))
+}
+
+// handleReplyComponent 點「Reply to GitHub」button 時開 modal，modal 送出後把內容貼成 GitHub 留言
+func (app *App) handleReplyComponent(interaction *discord.Interaction) (*discord.InteractionResponse, error) {
+	if resp := app.replyDenied(interaction); resp != nil {
+		return resp, nil
+	}
+	_, key, _ := strings.Cut(interaction.Data.CustomID, ":")
+	repo, number, ok := issueMappingKey(key)
+	if !ok {
+		return discord.EphemeralReply(i18n.T("This thread is not linked to a GitHub issue or pull request.")), nil
+	}
+
+	if interaction.Type == discord.InteractionTypeMessageComponent {
+		input := discord.NewParagraphInput(replyInputID, i18n.T("Comment"), i18n.T("Posted to GitHub with your Discord name"), replyMaxLength)
+		return discord.ModalResponse(interaction.Data.CustomID, i18n.Tf("Reply to #%d", number), input), nil
+	}
+
+	body := strings.TrimSpace(interaction.Data.SubmittedValue(replyInputID))
+	if body == "" {
+		return discord.EphemeralReply(i18n.T("The reply is empty.")), nil
+	}
+	invoker := interaction.Invoker()
+	go app.postReply(interaction, githubReply{
+		repo: repo, number: number, body: body,
+		author: invoker, poster: invoker,
+		repost: true, channel: interaction.ChannelID,
+	})
+	return discord.DeferredReply(true), nil
+}
+
+// handleReplyCommand 訊息右鍵選單「Reply to GitHub」：把 bridged thread 裡的一則訊息貼成對應 issue / PR 的 GitHub 留言
+func (app *App) handleReplyCommand(interaction *discord.Interaction) (*discord.InteractionResponse, error) {
+	if resp := app.replyDenied(interaction); resp != nil {
+		return resp, nil
+	}
+	msg := interaction.Data.TargetMessage()
+	if msg == nil {
+		return discord.EphemeralReply(i18n.T("Could not read the selected message.")), nil
+	}
+	if msg.Author != nil && msg.Author.Bot {
+		return discord.EphemeralReply(i18n.T("Only messages written by people can be posted to GitHub.")), nil
+	}
+	body := strings.TrimSpace(msg.Content)
+	if body == "" {
+		return discord.EphemeralReply(i18n.T("The message has no text to post.")), nil
+	}
+
+	author := msg.Author
+	if author == nil {
+		author = interaction.Invoker()
+	}
+	// thread 對應哪個 issue / PR 要掃過 mapping 才知道（Redis 上可能超過 3 秒），在 postReply 裡反查
+	go app.postReply(interaction, githubReply{
+		body:   body,
+		author: author, poster: interaction.Invoker(),
+		link:    fmt.Sprintf("https://discord.com/channels/%s/%s/%s", interaction.GuildID, interaction.ChannelID, msg.ID),
+		channel: interaction.ChannelID,
+	})
+	return discord.DeferredReply(true), nil
+}
+
+// mappingKeyForThread 反查 thread 對應的 issue / PR mapping key，沒有時回傳空字串
+// 只在使用者操作時呼叫，直接掃過所有 mapping（和 GC / dashboard 相同）
+func (app *App) mappingKeyForThread(threadID string) (string, error) {
+	mappings, err := app.store.ListStale(time.Now().Add(time.Hour))
+	if err != nil {
+		return "", fmt.Errorf("failed to list mappings: %w", err)
+	}
+	for _, m := range mappings {
+		if _, _, ok := issueMappingKey(m.Key); ok && m.ThreadID == threadID {
+			return m.Key, nil
+		}
+	}
+	return "", nil
+}
+
+// postReply 貼 GitHub 留言後更新 interaction 的回應；在 interaction handler 之外執行（先回 DeferredReply），Discord 要求 3 秒內回應
+func (app *App) postReply(interaction *discord.Interaction, reply githubReply) {
+	log := applogger.Log
+	ctx, cancel := context.WithTimeout(context.Background(), replyTimeout)
+	defer cancel()
+
+	edit := func(content string) {
+		if err := app.discordClient.EditOriginalResponse(ctx, interaction.ApplicationID, interaction.Token, discord.ThreadMessage{Content: content}); err != nil {
+			log.Warn("Failed to update reply interaction response", "threadID", reply.channel, "error", err)
+		}
+	}
+
+	if reply.repo == "" {
+		key, err := app.mappingKeyForThread(reply.channel)
+		if err != nil {
+			log.Error("Failed to look up thread mapping for reply", "threadID", reply.channel, "error", err)
+			edit(i18n.T("❌ Failed to post to GitHub, please try again later."))
+			return
+		}
+		repo, number, ok := issueMappingKey(key)
+		if !ok {
+			edit(i18n.T("This thread is not linked to a GitHub issue or pull request."))
+			return
+		}
+		reply.repo, reply.number = repo, number
+	}
+
+	key := fmt.Sprintf("%s#%d", reply.repo, reply.number)
+	comment, err := app.createReplyComment(ctx, reply)
+	var content string
+	if err != nil {
+		log.Error("Failed to post Discord reply to GitHub", "key", key, "discordUser", reply.poster.ID, "error", err)
+		content = i18n.T("❌ Failed to post to GitHub, please try again later.")
+		if reply.repost {
+			// modal 的內容只存在這裡，附上讓使用者可以複製重試
+			content += "\n>>> " + truncateText(reply.body, 1500)
+		}
+	} else {
+		log.Info("Posted Discord reply to GitHub", "key", key, "discordUser", reply.poster.ID, "commentURL", comment.HTMLURL)
+		content = i18n.Tf("Posted to GitHub: %s", comment.HTMLURL)
+		if reply.repost {
+			app.repostReply(ctx, reply, comment)
+		}
+	}
+
+	edit(content)
+}
+
+// createReplyComment 以 bot 的 GitHub 身分留言，內文附上 Discord 作者
+func (app *App) createReplyComment(ctx context.Context, reply githubReply) (*github.Comment, error) {
+	repoCtx, err := app.repoContext(ctx, reply.repo, make(map[string]int64))
+	if err != nil {
+		return nil, err
+	}
+	comment, err := app.githubAPI.CreateIssueComment(repoCtx, reply.repo, reply.number, formatGitHubReply(reply))
+	if err != nil {
+		return nil, err
+	}
+	app.rememberReplyAuthor(comment.User.Login)
+	return comment, nil
+}
+
+// rememberReplyAuthor 記下 bot 留言用的 GitHub 帳號，給 isBridgeReply 判斷
+func (app *App) rememberReplyAuthor(login string) {
+	if login == "" {
+		return
+	}
+	if current, _, err := app.store.GetRecord(replyAuthorKey); err == nil && strings.EqualFold(current, login) {
+		return
+	}
+	if err := app.store.SetRecord(replyAuthorKey, login, 0); err != nil {
+		applogger.Log.Warn("Failed to save GitHub reply author", "login", login, "error", err)
+	}
+}
+
+// isBridgeReply 留言是不是 bridge 從 Discord 貼過去的：要有 githubReplyMarker，而且作者是 bot 自己的帳號
+// 其他人貼上 marker 也不會被略過
+func (app *App) isBridgeReply(comment *github.Comment) bool {
+	if !strings.Contains(comment.Body, githubReplyMarker) {
+		return false
+	}
+	author, exists, err := app.store.GetRecord(replyAuthorKey)
+	if err != nil {
+		applogger.Log.Warn("Failed to look up GitHub reply author", "error", err)
+		return false
+	}
+	return exists && strings.EqualFold(author, comment.User.Login)
+}
+
+// formatGitHubReply 組出 GitHub 留言：原文 + 小字的 Discord 作者（和轉貼的人）+ githubReplyMarker
+// 留言以 bot 的身分發表，原文的 @mention 插入 zero-width joiner 不通知任何人，Discord 名稱跳脫 markdown
+func formatGitHubReply(reply githubReply) string {
+	attribution := i18n.Tf("Posted from Discord by **%s** (%s)", escapeGitHubMarkdown(reply.author.DisplayName()), escapeGitHubMarkdown(reply.author.Username))
+	if reply.poster != nil && reply.poster.ID != reply.author.ID {
+		attribution += i18n.Tf(", shared by **%s** (%s)", escapeGitHubMarkdown(reply.poster.DisplayName()), escapeGitHubMarkdown(reply.poster.Username))
+	}
+	if reply.link != "" {
+		attribution += fmt.Sprintf(" · [%s](%s)", i18n.T("view message"), reply.link)
+	}
+	body := mentionPattern.ReplaceAllString(reply.body, "$1@\u200d$2")
+	return fmt.Sprintf("%s\n\n<sub>💬 %s</sub>\n%s", body, attribution, githubReplyMarker)
+}
+
+// escapeGitHubMarkdown 讓 Discord 名稱在 GitHub 上照原樣顯示（不能變成連結、HTML 或 mention）
+func escapeGitHubMarkdown(s string) string {
+	return mentionPattern.ReplaceAllString(githubMarkdownEscaper.Replace(s), "$1@\u200d$2")
+}
+
+// repostReply modal 送出的回覆成功貼到 GitHub 後，也在 thread 貼一份讓其他人看到
+func (app *App) repostReply(ctx context.Context, reply githubReply, comment *github.Comment) {
+	message := discord.ThreadMessage{
+		Embeds: []discord.Embed{{
+			Author:      &discord.EmbedAuthor{Name: reply.author.DisplayName()},
+			Title:       i18n.T("💬 Replied on GitHub"),
+			URL:         comment.HTMLURL,
+			Description: reply.body,
+			Color:       discord.ColorGray,
+		}},
+	}
+	if err := app.discordClient.PostMessage(ctx, reply.channel, message); err != nil {
+		applogger.Log.Warn("Failed to post reply to thread", "threadID", reply.channel, "error", err)
+	}
+}
+
+// truncateText 以字元為單位截斷，超過 max 時加上 "…"
+func truncateText(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "…"
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// handleRepository 處理 repository 建立 / 改名 / 封存 / 轉移
+// 改名或轉移時先搬移 store 裡的 mapping 和 forum tag，之後的事件才能找到原本的 thread
+func (app *App) handleRepository(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+
+	var previous string
+	switch payload.Action {
+	case "created", "archived", "unarchived":
+	case "renamed", "transferred":
+		previous = payload.PreviousFullName()
+		if previous != "" {
+			app.migrateRepository(ctx, previous, payload.Repository.FullName)
+		}
+	default:
+		log.Info("Ignoring repository action", "action", payload.Action)
+		return nil
+	}
+
+	message := app.render(ctx, "repository."+payload.Action, discord.FormatRepositoryEvent(payload.Action, payload.Repository, previous, payload.Sender))
+	if err := app.postActivity(ctx, payload.Repository.FullName, message); err != nil {
+		return err
+	}
+
+	app.announce(ctx, "repository."+payload.Action, message)
+	return nil
+}
+
+// migrateRepository 把舊 repo 名稱的 mapping（"owner/repo#123"、"owner/repo@v1.0.0"）改成新名稱，並改 forum tag 名稱
+// 失敗只 log：最壞情況是之後的事件會自動補建新的 thread
+func (app *App) migrateRepository(ctx context.Context, oldFullName, newFullName string) {
+	log := applogger.Log
+
+	for _, sep := range []string{"#", "@"} {
+		n, err := app.store.RenamePrefix(oldFullName+sep, newFullName+sep)
+		if err != nil {
+			log.Error("Failed to migrate mappings", "from", oldFullName, "to", newFullName, "error", err)
+			continue
+		}
+		log.Info("Migrated mappings", "from", oldFullName+sep, "to", newFullName+sep, "count", n)
+	}
+
+	oldName := oldFullName[strings.LastIndex(oldFullName, "/")+1:]
+	newName := newFullName[strings.LastIndex(newFullName, "/")+1:]
+	if oldName != newName {
+		if err := app.forum(oldFullName).RenameTag(ctx, oldName, newName); err != nil {
+			log.Error("Failed to rename repo tag", "from", oldName, "to", newName, "error", err)
+		}
+	}
+}
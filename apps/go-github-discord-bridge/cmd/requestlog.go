@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quietPaths probe 和 metrics 每隔幾秒就會被打一次，只記 debug log
+var quietPaths = map[string]bool{
+	"/health":  true,
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// requestLogger 每個 request 結束後用 applogger 記一筆結構化 log（status、處理時間）
+// GitHub webhook 另外帶 delivery ID、event、repo、action 和處理結果（由 WebhookHandler 寫進 DeliveryInfo）
+// 5xx 記 error、4xx 記 warn，其他記 info
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		ctx, info := github.WithDeliveryInfo(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		log := applogger.Log
+		status := c.Writer.Status()
+		fields := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"durationMs", time.Since(start).Milliseconds(),
+			"clientIP", c.ClientIP(),
+		}
+		if info.Event != "" {
+			fields = append(fields,
+				"deliveryID", info.DeliveryID,
+				"ghEvent", info.Event,
+				"action", info.Action,
+				"repo", info.Repo,
+				"result", info.Result,
+			)
+		}
+		if info.TraceID != "" {
+			fields = append(fields, "traceID", info.TraceID)
+		}
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			log.Error("HTTP request failed", fields...)
+		case status >= http.StatusBadRequest:
+			log.Warn("HTTP request rejected", fields...)
+		case quietPaths[c.Request.URL.Path]:
+			log.Debug("HTTP request", fields...)
+		default:
+			log.Info("HTTP request", fields...)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// sampleBuilder 產生一種 event 的範例 payload（不含 action / repository / sender，由 samplePayload 補上）
+type sampleBuilder struct {
+	action string // 沒有指定 --action 時的預設值，空字串 = 這個 event 沒有 action
+	build  func(repo string, s sampleValues) map[string]any
+}
+
+// sampleValues 範例裡共用的值
+type sampleValues struct {
+	user   map[string]any
+	now    string
+	number int
+}
+
+// samplePayloads `main send` 支援的 event
+var samplePayloads = map[string]sampleBuilder{
+	"issues": {action: "opened", build: func(repo string, s sampleValues) map[string]any {
+		return map[string]any{"issue": map[string]any{
+			"number": s.number, "title": "Sample issue from bridge send", "body": "This issue was generated by `main send`.",
+			"state": "open", "html_url": fmt.Sprintf("https://github.com/%s/issues/%d", repo, s.number),
+			"user": s.user, "labels": []any{map[string]any{"name": "bug", "color": "d73a4a"}}, "created_at": s.now,
+		}}
+	}},
+	"pull_request": {action: "opened", build: func(repo string, s sampleValues) map[string]any {
+		return map[string]any{"number": s.number, "pull_request": map[string]any{
+			"number": s.number, "title": "Sample pull request from bridge send", "body": "This pull request was generated by `main send`.",
+			"state": "open", "html_url": fmt.Sprintf("https://github.com/%s/pull/%d", repo, s.number),
+			"diff_url": fmt.Sprintf("https://github.com/%s/pull/%d.diff", repo, s.number),
+			"user":     s.user, "base": map[string]any{"ref": "main", "sha": "0000000000000000000000000000000000000001"},
+			"head":      map[string]any{"ref": "feature/sample", "sha": "0000000000000000000000000000000000000002"},
+			"additions": 42, "deletions": 7, "created_at": s.now, "updated_at": s.now,
+		}}
+	}},
+	"issue_comment": {action: "created", build: func(repo string, s sampleValues) map[string]any {
+		return map[string]any{
+			"issue": map[string]any{
+				"number": s.number, "title": "Sample issue from bridge send", "state": "open",
+				"html_url": fmt.Sprintf("https://github.com/%s/issues/%d", repo, s.number), "user": s.user, "created_at": s.now,
+			},
+			"comment": map[string]any{
+				"id": 1, "body": "Sample comment generated by `main send`.", "user": s.user, "created_at": s.now,
+				"html_url": fmt.Sprintf("https://github.com/%s/issues/%d#issuecomment-1", repo, s.number),
+			},
+		}
+	}},
+	"pull_request_review": {action: "submitted", build: func(repo string, s sampleValues) map[string]any {
+		return map[string]any{
+			"pull_request": map[string]any{
+				"number": s.number, "title": "Sample pull request from bridge send", "state": "open",
+				"html_url": fmt.Sprintf("https://github.com/%s/pull/%d", repo, s.number), "user": s.user,
+			},
+			"review": map[string]any{
+				"id": 1, "user": s.user, "body": "Looks good!", "state": "approved", "submitted_at": s.now,
+				"html_url": fmt.Sprintf("https://github.com/%s/pull/%d#pullrequestreview-1", repo, s.number),
+			},
+		}
+	}},
+	"push": {build: func(repo string, s sampleValues) map[string]any {
+		commit := map[string]any{
+			"id": "0000000000000000000000000000000000000002", "message": "Sample commit from bridge send",
+			"url": fmt.Sprintf("https://github.com/%s/commit/0000000000000000000000000000000000000002", repo), "timestamp": s.now,
+			"author": map[string]any{"name": "Octocat", "email": "octocat@example.com", "username": s.user["login"]}, "distinct": true,
+		}
+		return map[string]any{
+			"ref": "refs/heads/main", "before": "0000000000000000000000000000000000000001", "after": commit["id"],
+			"compare": fmt.Sprintf("https://github.com/%s/compare/0000000...0000000", repo),
+			"commits": []any{commit}, "head_commit": commit,
+		}
+	}},
+	"release": {action: "published", build: func(repo string, s sampleValues) map[string]any {
+		return map[string]any{"release": map[string]any{
+			"id": 1, "tag_name": "v0.0.1", "name": "v0.0.1", "body": "## Changes\n- Sample release generated by `main send`",
+			"html_url": fmt.Sprintf("https://github.com/%s/releases/tag/v0.0.1", repo), "author": s.user, "published_at": s.now,
+		}}
+	}},
+	"workflow_run": {action: "completed", build: func(repo string, s sampleValues) map[string]any {
+		return map[string]any{"workflow_run": map[string]any{
+			"id": 1, "name": "CI", "head_sha": "0000000000000000000000000000000000000002", "status": "completed",
+			"conclusion": "failure", "html_url": fmt.Sprintf("https://github.com/%s/actions/runs/1", repo),
+			"pull_requests": []any{map[string]any{"number": s.number}}, "head_branch": "feature/sample", "event": "pull_request",
+			"run_number": 1, "run_started_at": s.now, "updated_at": s.now,
+		}}
+	}},
+	"discussion": {action: "created", build: func(repo string, s sampleValues) map[string]any {
+		return map[string]any{"discussion": map[string]any{
+			"number": s.number, "title": "Sample discussion from bridge send", "body": "This discussion was generated by `main send`.",
+			"html_url": fmt.Sprintf("https://github.com/%s/discussions/%d", repo, s.number), "user": s.user,
+			"category": map[string]any{"name": "Q&A", "slug": "q-a", "is_answerable": true}, "created_at": s.now,
+		}}
+	}},
+	"star":  {action: "created", build: func(string, sampleValues) map[string]any { return map[string]any{} }},
+	"fork":  {build: func(string, sampleValues) map[string]any { return map[string]any{} }},
+	"watch": {action: "started", build: func(string, sampleValues) map[string]any { return map[string]any{} }},
+	"ping": {build: func(repo string, s sampleValues) map[string]any {
+		return map[string]any{"zen": "Keep it logically awesome.", "hook_id": 1}
+	}},
+}
+
+// samplePayload 產生 event 的範例 payload；action 為空字串時用 event 的預設 action
+func samplePayload(event, action, repo string, number int) (map[string]any, error) {
+	sample, ok := samplePayloads[event]
+	if !ok {
+		return nil, fmt.Errorf("no built-in sample for event %q (available: %s), use --file", event, strings.Join(slices.Sorted(maps.Keys(samplePayloads)), ", "))
+	}
+	values := sampleValues{
+		user:   map[string]any{"login": "octocat", "avatar_url": "https://github.com/octocat.png", "html_url": "https://github.com/octocat"},
+		now:    time.Now().UTC().Format(time.RFC3339),
+		number: number,
+	}
+
+	payload := sample.build(repo, values)
+	if action == "" {
+		action = sample.action
+	}
+	if action != "" {
+		payload["action"] = action
+	}
+	payload["sender"] = values.user
+	payload["repository"] = sampleRepository(repo)
+	return payload, nil
+}
+
+func sampleRepository(repo string) map[string]any {
+	_, name, _ := strings.Cut(repo, "/")
+	return map[string]any{"name": name, "full_name": repo, "html_url": "https://github.com/" + repo}
+}
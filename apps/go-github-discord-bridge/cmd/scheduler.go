@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/cron"
+	"dizzycode1112/github-discord-bridge/internal/scheduler"
+)
+
+// newScheduler 註冊設定中啟用的週期性工作，cron 表示式以 DISCORD_TIMEZONE 計算
+// reconcile / GC 可以用 *_INTERVAL（固定間隔）或 *_SCHEDULE（cron）；HA_MODE 時 digest 以外的 job 只在 leader 上執行，
+// digest 暫存的事件在各自的 replica 上，每個 replica 都要 flush
+func (app *App) newScheduler(cfg *config.Config) (*scheduler.Scheduler, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DISCORD_TIMEZONE %q: %w", cfg.Timezone, err)
+	}
+	s := scheduler.New(location, cfg.SchedulerJitter)
+
+	jobs := []struct {
+		name     string
+		interval time.Duration
+		expr     string
+		run      func(ctx context.Context) error
+	}{
+		{"reconcile", cfg.ReconcileInterval, cfg.ReconcileSchedule, app.reconcile},
+		{"gc", cfg.GCInterval, cfg.GCSchedule, app.collectGarbage},
+		{"stale_prs", 0, cfg.StalePRSchedule, app.remindStalePRs},
+		{"tag_gc", 0, cfg.TagGCSchedule, app.collectTags},
+	}
+	for _, job := range jobs {
+		var schedule scheduler.Schedule
+		switch {
+		case job.expr != "":
+			parsed, err := cron.Parse(job.expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s schedule: %w", job.name, err)
+			}
+			schedule = parsed
+		case job.interval > 0:
+			schedule = scheduler.Every(job.interval)
+		default:
+			continue
+		}
+		err := s.Add(scheduler.Job{
+			Name:     job.name,
+			Schedule: schedule,
+			Run:      job.run,
+			Skip:     func() bool { return !app.leader.isLeader() },
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if app.digest != nil {
+		if err := app.digest.schedule(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
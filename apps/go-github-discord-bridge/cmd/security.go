@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+)
+
+// handleDependabotAlert dependabot alert 發到 security channel，critical 時 mention security role
+func (app *App) handleDependabotAlert(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	alert := payload.Alert
+	if alert == nil {
+		return nil
+	}
+
+	switch payload.Action {
+	case "created", "reopened", "auto_reopened", "fixed", "dismissed", "auto_dismissed":
+	default:
+		return nil
+	}
+
+	var mention string
+	if payload.Action != "fixed" && payload.Action != "dismissed" && payload.Action != "auto_dismissed" {
+		mention = securityRoleMention(alert.Severity())
+	}
+
+	message := discord.FormatDependabotAlert(alert, payload.Action, payload.Repository.FullName, mention)
+	return app.postSecurity(ctx, ghEvent+"."+payload.Action, payload.Repository.FullName, message)
+}
+
+// handleCodeScanningAlert code scanning alert 發到 security channel
+func (app *App) handleCodeScanningAlert(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	alert := payload.Alert
+	if alert == nil {
+		return nil
+	}
+
+	var mention string
+	switch payload.Action {
+	case "created", "reopened", "appeared_in_branch":
+		mention = securityRoleMention(alert.Severity())
+	case "fixed", "closed_by_user":
+	default:
+		return nil
+	}
+
+	message := discord.FormatCodeScanningAlert(alert, payload.Action, payload.Repository.FullName, mention)
+	return app.postSecurity(ctx, ghEvent+"."+payload.Action, payload.Repository.FullName, message)
+}
+
+// handleSecretScanningAlert secret scanning alert 發到 security channel（secret 值會被遮蔽）
+func (app *App) handleSecretScanningAlert(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	alert := payload.Alert
+	if alert == nil {
+		return nil
+	}
+
+	var mention string
+	switch payload.Action {
+	case "created", "reopened":
+		mention = securityRoleMention(alert.Severity())
+	case "resolved":
+	default:
+		return nil
+	}
+
+	message := discord.FormatSecretScanningAlert(alert, payload.Action, payload.Repository.FullName, mention)
+	return app.postSecurity(ctx, ghEvent+"."+payload.Action, payload.Repository.FullName, message)
+}
+
+// securityRoleMention critical 的 alert 才 mention DISCORD_SECURITY_ROLE_ID
+func securityRoleMention(severity string) string {
+	roleID := config.Current().SecurityRoleID
+	if roleID == "" || !strings.EqualFold(severity, "critical") {
+		return ""
+	}
+	return fmt.Sprintf("<@&%s>", roleID)
+}
+
+// postSecurity 安全性通知發到 DISCORD_SECURITY_CHANNEL_ID，沒設定時發到 repo 的 activity thread；
+// eventKey（例如 "dependabot_alert.created"）在 ANNOUNCEMENT_EVENTS 時也發到公告頻道
+func (app *App) postSecurity(ctx context.Context, eventKey, repoFullName string, message discord.ThreadMessage) error {
+	channelID := config.Current().SecurityChannelID
+	if channelID == "" {
+		if err := app.postActivity(ctx, repoFullName, message); err != nil {
+			return err
+		}
+	} else if _, err := app.discordClient.PostChannelMessage(ctx, channelID, withNonce(ctx, channelID, message)); err != nil {
+		return err
+	}
+
+	app.announce(ctx, eventKey, message)
+	return nil
+}
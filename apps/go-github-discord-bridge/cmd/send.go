@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+)
+
+// runSend `main send --event issues [--action opened] [--repo owner/name] [--file payload.json]`：
+// 送一個簽好名的 webhook 到執行中的 bridge（--url），測試 template、路由和 Discord 的設定不用真的在 GitHub 上操作
+// --local 不經過 HTTP，直接在這個 process 裡處理並印出會送到 Discord 的 request（不會真的送出，mapping 寫到暫存的 bolt 檔，見 processLocal）
+func runSend(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("send", flag.ContinueOnError)
+	event := fs.String("event", "", "GitHub event name, e.g. issues, pull_request, push (required)")
+	action := fs.String("action", "", "payload action (default depends on the event, e.g. opened)")
+	repo := fs.String("repo", "", "repository full name (default octocat/hello-world, or the one in --file)")
+	number := fs.Int("number", 1, "issue / pull request / discussion number used by the built-in samples")
+	file := fs.String("file", "", "send this JSON payload instead of the built-in sample")
+	url := fs.String("url", "http://localhost:"+cfg.Port+"/webhook/github", "webhook endpoint of the running bridge")
+	secret := fs.String("secret", cfg.GitHubWebhookSecret, "webhook secret used to sign the payload")
+	local := fs.Bool("local", false, "process the payload in this process and print the Discord requests instead of sending them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *event == "" {
+		return errors.New("--event is required")
+	}
+
+	body, err := buildSendPayload(*event, *action, *repo, *number, *file)
+	if err != nil {
+		return err
+	}
+	deliveryID := newSampleDeliveryID()
+
+	if *local {
+		return processLocal(cfg, github.Delivery{ID: deliveryID, Event: *event, Body: body})
+	}
+
+	req, err := http.NewRequest(http.MethodPost, *url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GitHub-Hookshot/bridge-send")
+	req.Header.Set("X-GitHub-Event", *event)
+	req.Header.Set("X-GitHub-Delivery", deliveryID)
+	if *secret != "" {
+		req.Header.Set("X-Hub-Signature-256", github.Sign(body, *secret))
+	}
+
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	fmt.Printf("%s %s (delivery %s)\n", resp.Status, *url, deliveryID)
+	if len(bytes.TrimSpace(respBody)) > 0 {
+		fmt.Println(string(bytes.TrimSpace(respBody)))
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("bridge responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// buildSendPayload 讀 --file 或產生範例 payload；--file 搭配 --action / --repo 時覆寫檔案裡的值
+func buildSendPayload(event, action, repo string, number int, file string) ([]byte, error) {
+	if file == "" {
+		if repo == "" {
+			repo = "octocat/hello-world"
+		}
+		payload, err := samplePayload(event, action, repo, number)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(payload)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if action == "" && repo == "" {
+		return data, nil
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("invalid payload %s: %w", file, err)
+	}
+	if action != "" {
+		payload["action"] = action
+	}
+	if repo != "" {
+		payload["repository"] = sampleRepository(repo)
+	}
+	return json.Marshal(payload)
+}
+
+// newSampleDeliveryID 和 GitHub 一樣的 GUID 格式，每次都不同才不會被 delivery 去重略過
+func newSampleDeliveryID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// processLocal 用暫存的 bolt store 和 dry-run 的 Discord client 依序處理 delivery，印出每個會送到 Discord 的 request
+// 一開始沒有 mapping，所以第一個留言、review 等事件會在新的（假的）thread 上處理
+func processLocal(cfg *config.Config, deliveries ...github.Delivery) error {
+	dir, err := os.MkdirTemp("", "bridge-send-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	local := *cfg
+	local.StorageBackend = "bolt"
+	local.BoltPath = filepath.Join(dir, "bridge.db")
+
+	sent := 0
+	app, err := newApp(&local, discord.WithDryRun(func(_ context.Context, req discord.DryRunRequest) bool {
+		sent++
+		fmt.Printf("%s %s\n", req.Method, req.Path)
+		if len(req.Body) > 0 {
+			var out bytes.Buffer
+			if json.Indent(&out, req.Body, "", "  ") == nil {
+				fmt.Println(out.String())
+			}
+		}
+		fmt.Println()
+		return true
+	}))
+	if err != nil {
+		return err
+	}
+	defer app.store.Close()
+
+	webhooks := app.newWebhookHandler(&local)
+	for _, delivery := range deliveries {
+		if len(deliveries) > 1 {
+			fmt.Printf("=== %s %s\n\n", delivery.Event, delivery.ID)
+		}
+		sent = 0
+		if err := webhooks.Process(context.Background(), delivery); err != nil {
+			return err
+		}
+		if sent == 0 {
+			fmt.Println("No Discord requests (the event was filtered, buffered or has no handler)")
+			fmt.Println()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/broker"
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/metrics"
+	"dizzycode1112/github-discord-bridge/internal/tracing"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// runServer 啟動 webhook server（沒有子命令時的預設行為），初始化或啟動失敗時回傳 error
+func runServer(cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %v", args)
+	}
+	log := applogger.Log
+	if cfg.IngestMode == config.IngestReceiver {
+		return runReceiver(cfg)
+	}
+
+	app, err := newApp(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer app.store.Close()
+	discordClient := app.discordClient
+
+	// OpenTelemetry tracing：webhook 接收 → 驗證 → 解析 → 路由 → render → Discord / GitHub API 呼叫
+	shutdownTracing := tracing.Setup(tracing.Options{
+		Endpoint:    cfg.OTelEndpoint,
+		ServiceName: cfg.OTelServiceName,
+		SampleRatio: cfg.OTelSampleRatio,
+		Headers:     cfg.OTelHeaders,
+	})
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownTracing(ctx)
+	}()
+	if cfg.OTelEndpoint != "" {
+		log.Info("Tracing enabled", "endpoint", cfg.OTelEndpoint, "sampleRatio", cfg.OTelSampleRatio)
+	}
+
+	// 啟動前檢查 token、forum channel 和 bot 權限，有問題直接停止啟動
+	if cfg.DiscordPreflight {
+		if err := discordClient.Preflight(context.Background()); err != nil {
+			return fmt.Errorf("discord preflight check failed: %w", err)
+		}
+	}
+
+	// 設定 forum channel 的 default reaction，失敗不影響啟動
+	if cfg.DiscordDefaultReaction != "" {
+		if err := discordClient.SetDefaultReactionEmoji(context.Background(), cfg.DiscordDefaultReaction); err != nil {
+			log.Warn("Failed to set forum default reaction emoji", "emoji", cfg.DiscordDefaultReaction, "error", err)
+		}
+	}
+
+	// 收到 SIGTERM / SIGINT 時 ctx 結束，開始 graceful shutdown（見 serve）
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	workers := &backgroundWorkers{ctx: ctx}
+
+	// star / fork / watch 批次摘要
+	if cfg.CommunityBatchInterval > 0 {
+		app.community = newCommunityBatcher(app, cfg.CommunityBatchInterval)
+		workers.start(app.community.run)
+	}
+
+	// 低優先事件依 cron 排程發摘要
+	if app.digest, err = newDigester(app, cfg); err != nil {
+		return err
+	} else if app.digest != nil {
+		workers.start(app.digest.run)
+	}
+
+	// 每個 repo 的速率限制（REPO_RATE_LIMIT 可以 reload，所以一律啟動）
+	app.throttle = newRepoThrottle(app, cfg.RepoRateFlushInterval)
+	workers.start(app.throttle.run)
+
+	// 合併同一個 thread 的改名和 tag 變更（DISCORD_THREAD_EDIT_DEBOUNCE 可以 reload，所以一律啟動）
+	app.threadEdits = newThreadEdits(app)
+	workers.start(app.threadEdits.run)
+
+	// SIGHUP、設定檔變更或定期重新讀取 secrets backend 時 reload 設定
+	if cfg.SecretsBackend != "" {
+		log.Info("Loaded secrets from secrets backend", "backend", cfg.SecretsBackend, "keys", config.SecretKeys(), "refresh", cfg.SecretsRefreshInterval.String())
+	}
+	workers.start(func(ctx context.Context) { app.watchConfig(ctx, cfg.ConfigWatchInterval, cfg.SecretsRefreshInterval) })
+
+	// HA_MODE：reconcile、GC 等排程 job 只在 leader 上跑；shutdown 時釋放 lease，其他 replica 立即接手
+	if cfg.HAMode {
+		if app.leader, err = newLeaderElector(app.store, cfg); err != nil {
+			return err
+		}
+		workers.start(app.leader.run)
+		log.Info("High-availability mode enabled", "replica", app.leader.id, "leaseTTL", cfg.HALeaseTTL.String())
+	}
+
+	// 排程 job：GitHub ↔ Discord 狀態比對、清理 mapping、stale PR 提醒、清理沒用到的 tag、digest
+	jobs, err := app.newScheduler(cfg)
+	if err != nil {
+		return err
+	}
+	if jobs.Len() > 0 {
+		workers.start(jobs.Run)
+	}
+
+	r, err := app.newRouter(cfg)
+	if err != nil {
+		return err
+	}
+
+	// GitHub webhook：驗證簽名後依 X-GitHub-Event 分派
+	pool, journal, err := app.newEventQueue(cfg)
+	if err != nil {
+		return err
+	}
+	if journal != nil {
+		defer journal.Close()
+	}
+	// RECORD_PAYLOADS_DIR 可以 reload，所以一律掛上 recorder（沒有設定時不做事）
+	webhookOpts := []github.WebhookOption{github.WithRecorder(app.recorder.record)}
+	if app.queue = pool; app.queue != nil {
+		webhookOpts = append(webhookOpts, github.WithDispatcher(app.dispatchEvent))
+		log.Info("Processing webhooks asynchronously", "workers", cfg.QueueWorkers, "queueSize", app.queue.Cap(), "persistent", journal != nil)
+	}
+	webhooks := app.newWebhookHandler(cfg, webhookOpts...)
+	webhooks.OnError(respondProcessError)
+	if cfg.IngestMode == config.IngestPublisher {
+		// webhook 由 receiver 收，這裡從 broker 取得後放進 queue
+		consumer, err := broker.NewConsumer(ctx, brokerOptions(cfg))
+		if err != nil {
+			return fmt.Errorf("failed to connect to message broker: %w", err)
+		}
+		workers.start(func(ctx context.Context) { app.consumeBroker(ctx, consumer) })
+		log.Info("Consuming webhooks from message broker", "broker", cfg.Broker, "topic", cfg.BrokerTopic, "group", cfg.BrokerGroup)
+	} else {
+		mountWebhook(ctx, cfg, r, workers, webhooks)
+	}
+
+	// 維運用的 admin API（ADMIN_TOKEN 有設定才啟用）
+	app.registerAdminRoutes(r.Group("/admin"))
+	r.GET("/dashboard", handleDashboard)
+
+	// Discord interactions（button、slash command），有設定 public key 才啟用
+	if cfg.DiscordPublicKey != "" {
+		interactions, err := discord.NewInteractionRouter(cfg.DiscordPublicKey)
+		if err != nil {
+			return fmt.Errorf("invalid DISCORD_PUBLIC_KEY: %w", err)
+		}
+		app.interactions = interactions
+		interactions.HandleCommand("github", app.handleGitHubCommand)
+		interactions.HandleCommand(replyCommand, app.handleReplyCommand)
+		interactions.HandleComponent(replyPrefix, app.handleReplyComponent)
+		r.POST("/interactions", gin.WrapH(interactions))
+	}
+
+	// 註冊 guild slash commands
+	if cfg.DiscordApplicationID != "" && cfg.DiscordGuildID != "" {
+		commands, err := discord.LoadCommands(cfg.DiscordCommandsFile)
+		if err != nil {
+			return fmt.Errorf("failed to load slash command definitions: %w", err)
+		}
+		if !cfg.ReplyToGitHub {
+			// 沒開放回覆時不要在訊息選單出現「Reply to GitHub」
+			commands = slices.DeleteFunc(slices.Clone(commands), func(c discord.ApplicationCommand) bool {
+				return c.Type == discord.ApplicationCommandTypeMessage && c.Name == replyCommand
+			})
+		}
+		if registered, err := discordClient.RegisterGuildCommands(context.Background(), cfg.DiscordApplicationID, cfg.DiscordGuildID, commands); err != nil {
+			log.Error("Failed to register slash commands", "error", err)
+		} else {
+			log.Info("Registered slash commands", "count", len(registered), "guildID", cfg.DiscordGuildID)
+		}
+	}
+
+	if app.queue != nil {
+		app.restoreQueue()
+	}
+
+	srv := newHTTPServer(cfg, r)
+	challenge := configureTLS(cfg, srv)
+	log.Info("Server starting", "port", cfg.Port, "tls", srv.TLSConfig != nil)
+	return serve(ctx, srv, challenge, workers, app.queue, cfg.ShutdownTimeout)
+}
+
+// newRouter 設定 Gin router 和 probe / metrics：request log 用 applogger 輸出（見 requestLogger），不用 gin 預設的文字 logger
+func (app *App) newRouter(cfg *config.Config) (*gin.Engine, error) {
+	r := gin.New()
+	r.HandleMethodNotAllowed = true // 例如 GET /webhook/github 回 405 而不是 404
+	if len(cfg.TrustedProxies) > 0 {
+		if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+		}
+	}
+	r.Use(gin.Recovery(), requestLogger())
+
+	// liveness / readiness probe；/health 保留給舊的設定
+	r.GET("/health", handleHealthz)
+	r.GET("/healthz", handleHealthz)
+	r.GET("/readyz", app.handleReadyz)
+
+	// Prometheus metrics（webhook、Discord API、thread 建立等，見 internal/metrics）
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	return r, nil
+}
+
+// mountWebhook GitHub webhook：驗證簽名後依 X-GitHub-Event 分派；GITHUB_IP_ALLOWLIST 時先檢查來源 IP
+func mountWebhook(ctx context.Context, cfg *config.Config, r *gin.Engine, workers *backgroundWorkers, webhooks *github.WebhookHandler) {
+	log := applogger.Log
+	webhookGroup := r.Group("/webhook")
+	if cfg.GitHubIPAllowlist {
+		allowlist := newGitHubIPAllowlist(cfg.GitHubAPIURL)
+		// 取得失敗不停止啟動（GitHub API 暫時無法使用），webhook 在取得之前回 503
+		if err := allowlist.refresh(ctx); err != nil {
+			log.Error("Failed to fetch GitHub webhook IP ranges, rejecting webhooks until they are available", "error", err)
+		}
+		workers.start(func(ctx context.Context) { allowlist.run(ctx, cfg.GitHubIPAllowlistRefresh) })
+		webhookGroup.Use(allowlist.middleware())
+		log.Info("Restricting webhooks to GitHub hook IP ranges", "refresh", cfg.GitHubIPAllowlistRefresh.String(), "extra", len(cfg.GitHubIPAllowlistExtra))
+	}
+	webhookGroup.POST("/github", gin.WrapH(webhooks))
+}
+
+// newHTTPServer PORT 上的 HTTP server，timeout 見 HTTP_*_TIMEOUT
+func newHTTPServer(cfg *config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		MaxHeaderBytes:    64 << 10, // GitHub 的 header 只有幾 KB
+	}
+}
+
+// newWebhookHandler 建立 webhook handler 並註冊所有事件的處理（server 和 replay-dead-letters 共用），同時設為 app.webhooks
+// extra 為額外的選項（例如 server 的 Dispatcher）
+func (app *App) newWebhookHandler(cfg *config.Config, extra ...github.WebhookOption) *github.WebhookHandler {
+	webhookOpts := []github.WebhookOption{
+		github.WithSecondarySecret(cfg.GitHubWebhookSecondarySecret),
+		github.WithRepoSecrets(cfg.GitHubWebhookRepoSecrets),
+		github.WithRepoFilter(app.repoEnabled),
+		github.WithMaxBodySize(cfg.WebhookMaxBodySize),
+	}
+	if cfg.GitHubLegacySignature {
+		webhookOpts = append(webhookOpts, github.WithLegacySignature())
+	}
+	webhooks := github.NewWebhookHandler(cfg.GitHubWebhookSecret, append(webhookOpts, extra...)...)
+	app.webhooks = webhooks
+
+	webhooks.On("ping", app.logEvent(app.handlePing))
+	webhooks.On("enterprise", app.logEvent(app.handleEnterprise))
+	webhooks.On("installation", app.logEvent(app.handleInstallation))
+	webhooks.On("installation_repositories", app.logEvent(app.handleInstallation))
+	webhooks.On("workflow_run", app.logEvent(app.handleWorkflowRun))
+	webhooks.On("push", app.logEvent(app.handlePush))
+	webhooks.On("issues", app.logEvent(app.handleIssues))
+	webhooks.On("issue_comment", app.logEvent(app.handleIssueComment))
+	webhooks.On("release", app.logEvent(app.handleRelease))
+	webhooks.On("check_run", app.logEvent(app.handleCheckRun))
+	webhooks.On("check_suite", app.logEvent(app.handleCheckSuite))
+	webhooks.On("status", app.logEvent(app.handleStatus))
+	webhooks.On("deployment", app.logEvent(app.handleDeployment))
+	webhooks.On("deployment_status", app.logEvent(app.handleDeploymentStatus))
+	webhooks.On("milestone", app.logEvent(app.handleMilestone))
+	webhooks.On("dependabot_alert", app.logEvent(app.handleDependabotAlert))
+	webhooks.On("code_scanning_alert", app.logEvent(app.handleCodeScanningAlert))
+	webhooks.On("secret_scanning_alert", app.logEvent(app.handleSecretScanningAlert))
+	webhooks.On("repository", app.logEvent(app.handleRepository))
+	webhooks.On("gollum", app.logEvent(app.handleWiki))
+	webhooks.On("package", app.logEvent(app.handlePackage))
+	webhooks.On("registry_package", app.logEvent(app.handlePackage))
+	webhooks.On("create", app.logEvent(app.handleRefChanged))
+	webhooks.On("delete", app.logEvent(app.handleRefChanged))
+	webhooks.On("discussion", app.logEvent(app.handleDiscussion))
+	webhooks.On("discussion_comment", app.logEvent(app.handleDiscussionComment))
+	for _, event := range []string{"star", "fork", "watch"} {
+		webhooks.On(event, app.logEvent(app.handleCommunityEvent))
+	}
+	webhooks.OnDefault(app.logEvent(app.handleEvent))
+	return webhooks
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/queue"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// backgroundWorkers 跟著 server 一起跑的背景工作（批次摘要、digest、reconcile、GC 等）
+// ctx 在收到 SIGTERM / SIGINT 時結束，shutdown 時等所有工作收尾（例如 flush 暫存的摘要）
+type backgroundWorkers struct {
+	ctx context.Context
+	wg  sync.WaitGroup
+}
+
+// start 在背景執行 fn，fn 應該在 ctx 結束後盡快 return
+func (w *backgroundWorkers) start(fn func(ctx context.Context)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		fn(w.ctx)
+	}()
+}
+
+// wait 等所有背景工作結束，ctx 先到期時回傳 ctx.Err()
+func (w *backgroundWorkers) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// serve 啟動 HTTP server 直到 ctx 結束（收到 SIGTERM / SIGINT），接著依序：
+//  1. 停止接受新的連線，等處理中的 webhook 跑完（challenge 為 autocert 的 HTTP server，nil = 沒有）
+//  2. 處理完 queue 裡剩下的事件（pool 為 nil = 同步處理，沒有 queue）
+//  3. 等背景工作收尾（flush community / digest 暫存的事件）
+//  4. 關閉 store（回到 runServer 的 defer）
+//
+// 全部共用 SHUTDOWN_TIMEOUT 的期限，超過時放棄等待直接結束
+func serve(ctx context.Context, srv, challenge *http.Server, workers *backgroundWorkers, pool *queue.Pool, timeout time.Duration) error {
+	log := applogger.Log
+
+	servers := []*http.Server{srv}
+	if challenge != nil {
+		servers = append(servers, challenge)
+	}
+	serverErr := make(chan error, len(servers))
+	for _, s := range servers {
+		go func() {
+			// 有 TLSConfig（autocert）時憑證由 GetCertificate 提供，不需要檔案
+			if s.TLSConfig != nil {
+				serverErr <- s.ListenAndServeTLS("", "")
+				return
+			}
+			serverErr <- s.ListenAndServe()
+		}()
+	}
+
+	running := len(servers)
+	select {
+	case err := <-serverErr:
+		running--
+		for _, s := range servers {
+			s.Close()
+		}
+		for range running {
+			<-serverErr
+		}
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Info("Shutting down, draining in-flight webhooks", "timeout", timeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, s := range servers {
+		if err := s.Shutdown(shutdownCtx); err != nil {
+			log.Warn("HTTP server did not drain before the shutdown deadline", "addr", s.Addr, "error", err)
+		}
+	}
+	for range running {
+		if err := <-serverErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn("HTTP server stopped with error", "error", err)
+		}
+	}
+	if pool != nil {
+		log.Info("Draining event queue", "pending", pool.Len())
+		if err := pool.Close(shutdownCtx); err != nil {
+			log.Warn("Event queue did not drain before the shutdown deadline", "pending", pool.Len(), "error", err)
+		}
+	}
+	if err := workers.wait(shutdownCtx); err != nil {
+		log.Warn("Background workers did not finish before the shutdown deadline", "error", err)
+	}
+	log.Info("Shutdown complete")
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// staleReminderSuffix 上次提醒的時間（unix 秒）存成 "<mapping key>#reminded" 的 record，STALE_PR_AFTER 後過期
+const staleReminderSuffix = "#reminded"
+
+// remindStalePRs 在超過 STALE_PR_AFTER 沒有更新的 open PR（不含 draft）的 thread 貼提醒，mention 還沒 review 的 reviewer
+// 只查 mapping 也超過 STALE_PR_AFTER 沒有變動的（剛建立 thread 的 PR 不會 stale）；提醒過的 PR 要再經過 STALE_PR_AFTER 才會再提醒
+func (app *App) remindStalePRs(ctx context.Context) error {
+	log := applogger.Log
+
+	if app.githubAPI == nil {
+		log.Warn("Skipping stale PR reminders: no GitHub credentials")
+		return nil
+	}
+
+	after := config.Current().StalePRAfter
+	mappings, err := app.store.ListStale(time.Now().Add(-after))
+	if err != nil {
+		return fmt.Errorf("failed to list mappings for stale PR reminders: %w", err)
+	}
+
+	installations := make(map[string]int64)
+	var reminded int
+	for _, m := range mappings {
+		repo, number, ok := issueMappingKey(m.Key)
+		if !ok || m.Closed {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		remindedKey := m.Key + staleReminderSuffix
+		if last, ok := app.lastReminded(remindedKey); ok && time.Since(last) < after {
+			continue
+		}
+
+		repoCtx, err := app.repoContext(ctx, repo, installations)
+		if err != nil {
+			log.Warn("Failed to resolve installation for stale PR reminder", "repo", repo, "error", err)
+			continue
+		}
+		pr, err := app.githubAPI.GetPullRequest(repoCtx, repo, number)
+		if errors.Is(err, github.ErrNotFound) {
+			// issue（不是 PR）或沒有權限
+			continue
+		}
+		if err != nil {
+			log.Warn("Failed to get pull request for stale PR reminder", "key", m.Key, "error", err)
+			continue
+		}
+		if pr.State != "open" {
+			if err := app.store.Delete(remindedKey); err != nil {
+				log.Warn("Failed to delete stale PR reminder time", "key", remindedKey, "error", err)
+			}
+			continue
+		}
+		if pr.Draft || time.Since(pr.UpdatedAt) < after {
+			continue
+		}
+
+		logins := []string{pr.User.Login}
+		for _, reviewer := range pr.RequestedReviewers {
+			logins = append(logins, reviewer.Login)
+		}
+		message := discord.FormatStalePR(pr, app.mentionMap(logins...))
+		if err := app.discordClient.PostMessage(ctx, m.ThreadID, message); err != nil {
+			log.Warn("Failed to post stale PR reminder", "key", m.Key, "threadID", m.ThreadID, "error", err)
+			continue
+		}
+		if err := app.store.SetRecord(remindedKey, strconv.FormatInt(time.Now().Unix(), 10), after); err != nil {
+			log.Warn("Failed to save stale PR reminder time", "key", remindedKey, "error", err)
+		}
+		reminded++
+	}
+
+	log.Info("Stale PR reminders finished", "mappings", len(mappings), "reminded", reminded)
+	return nil
+}
+
+// lastReminded 讀取上次提醒的時間；沒有紀錄或讀取失敗時 ok 為 false（照常提醒）
+func (app *App) lastReminded(key string) (time.Time, bool) {
+	value, exists, err := app.store.GetRecord(key)
+	if err != nil || !exists {
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/event"
+)
+
+// recentDeliveryLimit dashboard 保留最近幾個 delivery
+const recentDeliveryLimit = 100
+
+// repoStats 每個 repo 的處理統計和最近處理的 delivery，給 admin API 和 dashboard 使用
+// 只在記憶體，重啟後歸零，多個 instance 各自統計
+type repoStats struct {
+	mu     sync.Mutex
+	repos  map[string]*repoStat
+	recent []deliveryRecord // 環狀 buffer，next 為下一個要寫入的位置
+	next   int
+}
+
+// deliveryRecord 一個 delivery 的處理結果
+type deliveryRecord struct {
+	DeliveryID string    `json:"delivery_id"`
+	Event      string    `json:"event"`
+	Repo       string    `json:"repo"`
+	Subject    string    `json:"subject"`
+	Actor      string    `json:"actor"`
+	Failed     bool      `json:"failed"`
+	Error      string    `json:"error,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// repoStat 一個 repo 的統計
+type repoStat struct {
+	Handled     int       `json:"handled"`
+	Failed      int       `json:"failed"`
+	LastEventAt time.Time `json:"last_event_at"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitzero"`
+}
+
+// record 記錄一個 event 的處理結果
+func (s *repoStats) record(ev event.Event, handleErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	rec := deliveryRecord{DeliveryID: ev.DeliveryID, Event: ev.Key(), Repo: ev.Repo, Subject: ev.Subject(), Actor: ev.Actor.Login, At: now}
+	if handleErr != nil {
+		rec.Failed, rec.Error = true, handleErr.Error()
+	}
+	if len(s.recent) < recentDeliveryLimit {
+		s.recent = append(s.recent, rec)
+	} else {
+		s.recent[s.next] = rec
+	}
+	s.next = (s.next + 1) % recentDeliveryLimit
+
+	if ev.Repo == "" {
+		return
+	}
+	if s.repos == nil {
+		s.repos = make(map[string]*repoStat)
+	}
+	repo := strings.ToLower(ev.Repo)
+	stat := s.repos[repo]
+	if stat == nil {
+		stat = &repoStat{}
+		s.repos[repo] = stat
+	}
+	stat.LastEventAt = now
+	if handleErr != nil {
+		stat.Failed++
+		stat.LastError = handleErr.Error()
+		stat.LastErrorAt = now
+		return
+	}
+	stat.Handled++
+}
+
+// snapshot 複製目前的統計
+func (s *repoStats) snapshot() map[string]repoStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]repoStat, len(s.repos))
+	for repo, stat := range s.repos {
+		out[repo] = *stat
+	}
+	return out
+}
+
+// recentDeliveries 最近處理的 delivery，新的在前
+func (s *repoStats) recentDeliveries() []deliveryRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]deliveryRecord, 0, len(s.recent))
+	for i := range len(s.recent) {
+		out = append(out, s.recent[(s.next-1-i+len(s.recent))%len(s.recent)])
+	}
+	return out
+}
+
+// repoSwitches admin API 在執行期間暫停的 repo（不分大小寫）；只在記憶體，重啟後全部恢復
+type repoSwitches struct {
+	mu       sync.RWMutex
+	disabled map[string]time.Time // repo → 暫停的時間
+}
+
+// set 暫停（disabled = true）或恢復 repo
+func (s *repoSwitches) set(repo string, disabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabled == nil {
+		s.disabled = make(map[string]time.Time)
+	}
+	if disabled {
+		s.disabled[strings.ToLower(repo)] = time.Now().UTC()
+	} else {
+		delete(s.disabled, strings.ToLower(repo))
+	}
+}
+
+// isDisabled repo 是否被暫停
+func (s *repoSwitches) isDisabled(repo string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.disabled[strings.ToLower(repo)]
+	return ok
+}
+
+// list 所有被暫停的 repo
+func (s *repoSwitches) list() map[string]time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]time.Time, len(s.disabled))
+	maps.Copy(out, s.disabled)
+	return out
+}
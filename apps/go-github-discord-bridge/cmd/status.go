@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// statusRollup 一個 commit 的 status 摘要，以 JSON 存成 store 的 record
+type statusRollup struct {
+	MessageID string                `json:"message_id"`
+	Statuses  []github.CommitStatus `json:"statuses"`
+}
+
+// statusRollupKey commit status 摘要的 record key（格式："owner/repo#status-<sha>"）
+func statusRollupKey(repoFullName, sha string) string {
+	return fmt.Sprintf("%s#status-%s", repoFullName, sha)
+}
+
+// statusRollupTTL 摘要最後一次更新後保留多久，不用永久保存每個 commit（之後的 status 會另發新訊息）
+const statusRollupTTL = 7 * 24 * time.Hour
+
+// handleStatus 處理 commit status event
+// 同一個 commit 的所有 context 合成 activity thread 裡的一則訊息，之後的 status 都編輯那則訊息而不是另發新訊息
+func (app *App) handleStatus(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	log := applogger.Log
+
+	if payload.SHA == "" || payload.Context == "" {
+		return nil
+	}
+
+	if len(payload.Branches) > 0 && !anyBranchAllowed(payload.Repository.FullName, payload.Branches) {
+		log.Info("Skipping status for filtered branches", "sha", payload.SHA, "context", payload.Context)
+		return nil
+	}
+
+	app.statusMu.Lock()
+	defer app.statusMu.Unlock()
+
+	repoFullName := payload.Repository.FullName
+	key := statusRollupKey(repoFullName, payload.SHA)
+
+	var rollup statusRollup
+	raw, exists, err := app.store.GetRecord(key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := json.Unmarshal([]byte(raw), &rollup); err != nil {
+			log.Warn("Ignoring corrupt status rollup", "key", key, "error", err)
+			rollup = statusRollup{}
+		}
+	}
+	rollup.Statuses = upsertStatus(rollup.Statuses, payload.CommitStatus())
+
+	branch := ""
+	if len(payload.Branches) > 0 {
+		branch = payload.Branches[0].Name
+	}
+	message := discord.FormatStatusRollup(repoFullName, payload.SHA, branch, rollup.Statuses)
+
+	threadID, err := app.ensureActivityThread(ctx, repoFullName)
+	if err != nil {
+		return err
+	}
+
+	posted := false
+	if rollup.MessageID != "" {
+		err := app.discordClient.EditMessage(ctx, threadID, rollup.MessageID, message)
+		if err != nil && !errors.Is(err, discord.ErrNotFound) {
+			return err
+		}
+		posted = err == nil
+	}
+	if !posted {
+		rollup.MessageID, err = app.discordClient.PostChannelMessage(ctx, threadID, message)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(rollup)
+	if err != nil {
+		return err
+	}
+	if err := app.store.SetRecord(key, string(data), statusRollupTTL); err != nil {
+		return fmt.Errorf("failed to save status rollup: %w", err)
+	}
+	return nil
+}
+
+// anyBranchAllowed commit 是任一個符合 branch 過濾的 branch 的 head 就處理
+func anyBranchAllowed(repoFullName string, branches []github.StatusBranch) bool {
+	for _, b := range branches {
+		if branchAllowed(repoFullName, b.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// upsertStatus 更新同名 context 的狀態，新的 context 依收到順序加在最後
+func upsertStatus(statuses []github.CommitStatus, status github.CommitStatus) []github.CommitStatus {
+	for i := range statuses {
+		if statuses[i].Context == status.Context {
+			statuses[i] = status
+			return statuses
+		}
+	}
+	return append(statuses, status)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/event"
+	"dizzycode1112/github-discord-bridge/internal/storage"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// newStore 依 STORAGE_BACKEND 建立 mapping store，並把舊版存在 mapping 裡的 record 搬出來（見 migrateLegacyRecords）
+func newStore(cfg *config.Config) (storage.Store, error) {
+	store, err := openStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateLegacyRecords(store); err != nil {
+		applogger.Log.Warn("Failed to move legacy records out of the mapping store", "error", err)
+	}
+	return store, nil
+}
+
+// openStore 只開啟 store，不搬移 record（doctor 用，不寫入 storage）
+func openStore(cfg *config.Config) (storage.Store, error) {
+	switch cfg.StorageBackend {
+	case "redis":
+		return storage.NewRedisStore(cfg.RedisURL)
+	case "sqlite":
+		return storage.NewSQLiteStore(cfg.SQLitePath)
+	case "bolt":
+		return storage.NewBoltStore(cfg.BoltPath)
+	case "postgres":
+		return storage.NewPostgresStore(cfg.PostgresURL)
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q", cfg.StorageBackend)
+	}
+}
+
+// migrateLegacyRecords 舊版用 Set 把 /github link 綁定、commit status 摘要等資料存在 mapping 裡，會出現在 ListStale、/admin mappings 和匯出
+// 啟動時搬到 SetRecord，搬過的不會再被 ListStale 列出，所以之後每次啟動都只是一次 ListStale
+// Redis 的 ListStale 本來就不列沒有 "/" 的 key，那些由 RedisStore.GetRecord 讀到時搬移
+func migrateLegacyRecords(store storage.Store) error {
+	mappings, err := store.ListStale(time.Now().Add(time.Hour))
+	if err != nil {
+		return err
+	}
+	moved := 0
+	for _, m := range mappings {
+		ttl, ok := legacyRecordTTL(m.Key)
+		if !ok {
+			continue
+		}
+		if err := store.SetRecord(m.Key, m.ThreadID, ttl); err != nil {
+			return err
+		}
+		if err := store.Delete(m.Key); err != nil {
+			return err
+		}
+		moved++
+	}
+	if moved > 0 {
+		applogger.Log.Info("Moved legacy records out of the mapping store", "count", moved)
+	}
+	return nil
+}
+
+// legacyRecordTTL key 是不是舊版存在 mapping 裡的 record，是的話回傳搬過去時的 TTL（0 = 不過期）
+func legacyRecordTTL(key string) (time.Duration, bool) {
+	switch {
+	case strings.HasPrefix(key, userLinkKeyPrefix), strings.HasPrefix(key, linkedLoginKeyPrefix), key == replyAuthorKey:
+		return 0, true
+	case strings.HasPrefix(key, pendingLinkKeyPrefix):
+		return linkVerifyTTL, true
+	case strings.Contains(key, "#status-"):
+		return statusRollupTTL, true
+	case strings.HasSuffix(key, staleReminderSuffix):
+		if after := config.Current().StalePRAfter; after > 0 {
+			return after, true
+		}
+		return storage.ClosedPRTTL, true // 沒有啟用提醒時也不永久保存
+	}
+	return 0, false
+}
+
+// recordEvent 更新每個 repo 的統計（見 repoStats），backend 支援時（Postgres）另外保存 event 的處理結果，失敗只記 log 不影響回應
+func (app *App) recordEvent(ev event.Event, handleErr error) {
+	app.stats.record(ev, handleErr)
+
+	store := app.store
+	if dry, ok := store.(*dryRunStore); ok {
+		store = dry.Store
+	}
+	recorder, ok := store.(storage.EventRecorder)
+	if !ok {
+		return
+	}
+
+	status := storage.EventHandled
+	if handleErr != nil {
+		status = storage.EventFailed
+	}
+	if err := recorder.RecordEvent(ev, status, handleErr); err != nil {
+		applogger.Log.Warn("Failed to record event", "ghEvent", ev.Type, "deliveryID", ev.DeliveryID, "error", err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/event"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/templates"
+	"dizzycode1112/github-discord-bridge/internal/tracing"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// newTemplates 載入 DISCORD_TEMPLATES_DIR 和 DISCORD_TEMPLATES，兩者都沒設定時回傳 nil
+func newTemplates(cfg *config.Config) (*templates.Engine, error) {
+	if cfg.TemplatesInline == "" && cfg.TemplatesDir == "" {
+		return nil, nil
+	}
+
+	var inline map[string]templates.Spec
+	if cfg.TemplatesInline != "" {
+		if err := json.Unmarshal([]byte(cfg.TemplatesInline), &inline); err != nil {
+			return nil, fmt.Errorf("failed to parse DISCORD_TEMPLATES: %w", err)
+		}
+	}
+	return templates.Load(cfg.TemplatesDir, inline)
+}
+
+// eventContextKey 正在處理的 event（logEvent 放進 context），render 用來當 template 的資料
+type eventContextKey struct{}
+
+type eventContext struct {
+	ev      event.Event
+	payload *github.WebhookPayload
+}
+
+func withEvent(ctx context.Context, ev event.Event, payload *github.WebhookPayload) context.Context {
+	return context.WithValue(ctx, eventContextKey{}, eventContext{ev: ev, payload: payload})
+}
+
+// render 先套用 DISCORD_EVENT_COLORS 的顏色和 DISCORD_EMBED_EMOJIS 的 emoji，再套用適用於這個 repo + key 的自訂 template（分層合併，見 templates 套件）
+// 沒有 template、不是從 webhook 觸發（backfill 等）或 template 執行失敗時只套用顏色
+// template 的 {{.Default}} 是套用顏色 / emoji 後的 embed，template 自己有給 color 時以 template 為準
+// 最後依 DISCORD_TIME_STYLE 調整 embed timestamp 的顯示（見 discord.LocalizeTimestamps）
+func (app *App) render(ctx context.Context, key string, message discord.ThreadMessage) discord.ThreadMessage {
+	ctx, span := tracing.Start(ctx, "render "+key, tracing.KindInternal)
+	defer span.End()
+
+	message = applyEmbedEmoji(key, app.applyEventColor(key, message))
+	return discord.LocalizeTimestamps(app.applyTemplate(ctx, key, message))
+}
+
+// applyTemplate 套用適用於這個 repo + key 的自訂 template，沒有或失敗時回傳原本的 message
+func (app *App) applyTemplate(ctx context.Context, key string, message discord.ThreadMessage) discord.ThreadMessage {
+	engine := app.styles.Load().templates
+	if engine == nil {
+		return message
+	}
+	current, ok := ctx.Value(eventContextKey{}).(eventContext)
+	if !ok {
+		return message
+	}
+	tmpl, ok := engine.Lookup(current.ev.Repo, key)
+	if !ok {
+		return message
+	}
+
+	rendered, err := tmpl.Apply(message, templates.Data{Event: current.ev, Payload: current.payload})
+	if err != nil {
+		applogger.Log.Warn("Failed to render template, using default format", "key", key, "error", err)
+		return message
+	}
+	return rendered
+}
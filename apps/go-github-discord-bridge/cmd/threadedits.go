@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// threadEdit 一個 thread 等待送出的變更
+type threadEdit struct {
+	name    string      // 最後一次要求的名稱，空字串 = 不改名
+	tags    []tagChange // 依收到的順序套用
+	reasons []string    // 寫進 audit log
+	timer   *time.Timer
+}
+
+// tagChange 加上（added）或移除一個 tag
+type tagChange struct {
+	tagID string
+	added bool
+}
+
+// threadEdits 把同一個 issue / PR 的改名（標題修改、label 的 emoji）和 label tag 變更合併成一次 PATCH：
+// 等 DISCORD_THREAD_EDIT_DEBOUNCE 沒有新的變更才送出。Discord 限制每個 thread 10 分鐘只能修改 2 次，
+// 一次加好幾個 label（每個 label 各觸發一次換 tag / 改名）時不合併很容易被 rate limit
+// 暫存的變更只在記憶體，shutdown 時立即送出
+type threadEdits struct {
+	app *App
+
+	mu      sync.Mutex
+	closed  bool
+	pending map[string]*threadEdit // item ID → 等待送出的變更
+}
+
+func newThreadEdits(app *App) *threadEdits {
+	return &threadEdits{app: app, pending: make(map[string]*threadEdit)}
+}
+
+// editThread 把 update 的變更排進 itemID 的 thread；沒有 debounce（子命令、DISCORD_THREAD_EDIT_DEBOUNCE=0、shutdown 中）時立即送出
+func (app *App) editThread(ctx context.Context, itemID, reason string, update func(edit *threadEdit)) error {
+	e := app.threadEdits
+	delay := config.Current().ThreadEditDebounce
+	if e == nil || delay <= 0 {
+		edit := &threadEdit{reasons: []string{reason}}
+		update(edit)
+		return app.applyThreadEdit(ctx, itemID, edit)
+	}
+
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		edit := &threadEdit{reasons: []string{reason}}
+		update(edit)
+		return app.applyThreadEdit(ctx, itemID, edit)
+	}
+	edit, ok := e.pending[itemID]
+	if ok {
+		edit.timer.Reset(delay)
+	} else {
+		edit = &threadEdit{}
+		edit.timer = time.AfterFunc(delay, func() { e.flush(itemID, edit) })
+		e.pending[itemID] = edit
+	}
+	update(edit)
+	edit.reasons = append(edit.reasons, reason)
+	e.mu.Unlock()
+	return nil
+}
+
+// flush timer 到期時送出 itemID 暫存的變更（已經被 run 送出或換成新的變更時不做事）
+func (e *threadEdits) flush(itemID string, edit *threadEdit) {
+	e.mu.Lock()
+	if e.pending[itemID] != edit {
+		e.mu.Unlock()
+		return
+	}
+	delete(e.pending, itemID)
+	e.mu.Unlock()
+
+	e.apply(context.Background(), itemID, edit)
+}
+
+// run ctx 結束時立即送出所有暫存的變更，之後的變更不再 debounce
+func (e *threadEdits) run(ctx context.Context) {
+	<-ctx.Done()
+
+	e.mu.Lock()
+	e.closed = true
+	pending := e.pending
+	e.pending = make(map[string]*threadEdit)
+	e.mu.Unlock()
+
+	for itemID, edit := range pending {
+		edit.timer.Stop()
+		e.apply(context.Background(), itemID, edit)
+	}
+}
+
+func (e *threadEdits) apply(ctx context.Context, itemID string, edit *threadEdit) {
+	repoFullName, _, _ := strings.Cut(itemID, "#")
+	if err := e.app.applyThreadEdit(withDryRunRepo(ctx, repoFullName), itemID, edit); err != nil {
+		applogger.Log.Error("Failed to update thread", "itemID", itemID, "error", err)
+	}
+}
+
+// applyThreadEdit 依 thread 目前的名稱和 tag 算出要改的欄位，有變更時用一次 PATCH 送出
+// 沒有 thread、thread 已刪除時不做事；已 archive 的 thread 不改名（改名會把 thread 重新打開）
+func (app *App) applyThreadEdit(ctx context.Context, itemID string, edit *threadEdit) error {
+	log := applogger.Log
+
+	threadID, exists, err := app.store.Get(itemID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		log.Info("No thread to update", "itemID", itemID)
+		return nil
+	}
+
+	thread, err := app.discordClient.GetThread(ctx, threadID)
+	if errors.Is(err, discord.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	name := ""
+	if edit.name != "" && edit.name != thread.Name {
+		if thread.IsArchived() {
+			log.Info("Thread archived, skipping rename", "itemID", itemID, "threadID", threadID)
+		} else {
+			name = edit.name
+		}
+	}
+
+	var tags []string
+	if len(edit.tags) > 0 {
+		next := slices.Clone(thread.AppliedTags)
+		for _, change := range edit.tags {
+			has := slices.Contains(next, change.tagID)
+			switch {
+			case change.added && !has:
+				if len(next) >= discord.MaxThreadTags {
+					log.Warn("Thread already has the maximum number of tags", "itemID", itemID, "tagID", change.tagID)
+					continue
+				}
+				next = append(next, change.tagID)
+			case !change.added && has:
+				next = slices.DeleteFunc(next, func(id string) bool { return id == change.tagID })
+			}
+		}
+		if !slices.Equal(next, thread.AppliedTags) {
+			tags = append([]string{}, next...)
+		}
+	}
+
+	if name == "" && tags == nil {
+		return nil
+	}
+	log.Info("Updating thread", "itemID", itemID, "threadID", threadID, "name", name, "tags", tags, "changes", len(edit.reasons))
+	return app.discordClient.EditThread(ctx, threadID, name, tags, strings.Join(edit.reasons, "; "))
+}
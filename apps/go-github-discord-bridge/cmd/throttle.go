@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/event"
+	"dizzycode1112/github-discord-bridge/internal/metrics"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// repoThrottle 每個 repo 一個 token bucket（REPO_RATE_LIMIT / REPO_RATE_BURST），
+// 避免單一 repo 的大量事件（force-push、大量修改 label）佔滿 worker 或觸發 Discord rate limit
+// 超過限制時 REPO_RATE_COALESCE_EVENTS 的事件合併成摘要，每 REPO_RATE_FLUSH_INTERVAL 每個 repo 發一則；
+// 其他事件（開 PR、關 issue 等會改變 thread 狀態的）照常處理，不會被合併
+type repoThrottle struct {
+	app      *App
+	interval time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket  // repo → bucket
+	buffers map[string]*digestBuffer // repo → 合併中的事件
+}
+
+// tokenBucket 每分鐘補充 perMinute 個 token，最多 burst 個
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill 依經過的時間補充 token
+func (b *tokenBucket) refill(now time.Time, perMinute, burst int) {
+	b.tokens = min(float64(burst), b.tokens+now.Sub(b.last).Minutes()*float64(perMinute))
+	b.last = now
+}
+
+// take 補充後取一個 token，不夠時回傳 false
+func (b *tokenBucket) take(now time.Time, perMinute, burst int) bool {
+	b.refill(now, perMinute, burst)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func newRepoThrottle(app *App, interval time.Duration) *repoThrottle {
+	return &repoThrottle{
+		app:      app,
+		interval: interval,
+		buckets:  make(map[string]*tokenBucket),
+		buffers:  make(map[string]*digestBuffer),
+	}
+}
+
+// add 事件超過 repo 的速率限制且可以合併時暫存起來並回傳 true，呼叫端就不再即時處理；t 為 nil 或沒有限制時一律回傳 false
+func (t *repoThrottle) add(ev event.Event) bool {
+	if t == nil || ev.Repo == "" {
+		return false
+	}
+	cfg := config.Current()
+	if cfg.RepoRateLimit <= 0 {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	repo := strings.ToLower(ev.Repo)
+	now := time.Now()
+	bucket := t.buckets[repo]
+	if bucket == nil {
+		bucket = &tokenBucket{tokens: float64(cfg.RepoRateBurst), last: now}
+		t.buckets[repo] = bucket
+	}
+	if bucket.take(now, cfg.RepoRateLimit, cfg.RepoRateBurst) {
+		return false
+	}
+	if !cfg.RepoRateCoalesceEvents[ev.Key()] && !cfg.RepoRateCoalesceEvents[ev.Type] {
+		return false
+	}
+
+	buf := t.buffers[repo]
+	if buf == nil {
+		buf = &digestBuffer{since: now, counts: make(map[string]int)}
+		t.buffers[repo] = buf
+	}
+	buf.counts[ev.Key()]++
+	if len(buf.events) < digestMaxEvents {
+		buf.events = append(buf.events, ev)
+	}
+	metrics.EventsCoalesced.Inc(ev.Type)
+	return true
+}
+
+// run 每個 interval 發送合併的事件；ctx 結束時把剩下的發完
+func (t *repoThrottle) run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.flush(context.Background())
+			return
+		case <-ticker.C:
+			t.flush(ctx)
+		}
+	}
+}
+
+// flush 每個有合併事件的 repo 發一則摘要，並清掉已經補滿的 bucket（等同沒有用過，不必保留；reload 關閉限制後全部清掉）
+func (t *repoThrottle) flush(ctx context.Context) {
+	cfg := config.Current()
+	now := time.Now()
+
+	t.mu.Lock()
+	due := t.buffers
+	t.buffers = make(map[string]*digestBuffer)
+	for repo, bucket := range t.buckets {
+		bucket.refill(now, cfg.RepoRateLimit, cfg.RepoRateBurst)
+		if cfg.RepoRateLimit <= 0 || bucket.tokens >= float64(cfg.RepoRateBurst) {
+			delete(t.buckets, repo)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, buf := range due {
+		repoFullName := buf.events[0].Repo
+		applogger.Log.Info("Posting burst summary for rate-limited repository", "repo", repoFullName, "events", len(buf.events))
+		message := discord.FormatBurst(repoFullName, buf.events, buf.counts, buf.since, digestMaxLines)
+		if err := t.app.postActivity(ctx, repoFullName, message); err != nil {
+			applogger.Log.Error("Failed to post burst summary", "repo", repoFullName, "error", err)
+		}
+	}
+}
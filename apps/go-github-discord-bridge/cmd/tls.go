@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// configureTLS TLS_AUTOCERT_DOMAINS 有設定時，srv 改為用 Let's Encrypt 自動取得 / 更新的憑證提供 HTTPS（只接受清單裡的 domain）
+// 憑證存在 TLS_AUTOCERT_CACHE_DIR，重啟後沿用；TLS_HTTP_PORT 有設定時回傳處理 HTTP-01 challenge、其他 request 轉到 HTTPS 的 server
+// 沒有設定時不修改 srv，回傳 nil
+func configureTLS(cfg *config.Config, srv *http.Server) *http.Server {
+	if len(cfg.TLSAutocertDomains) == 0 {
+		return nil
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.TLSAutocertDomains...),
+		Cache:      autocert.DirCache(cfg.TLSAutocertCacheDir),
+		Email:      cfg.TLSAutocertEmail,
+	}
+	if cfg.TLSAutocertDirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: cfg.TLSAutocertDirectoryURL}
+	}
+	srv.TLSConfig = manager.TLSConfig()
+	applogger.Log.Info("Serving HTTPS with automatic certificates", "domains", cfg.TLSAutocertDomains, "cacheDir", cfg.TLSAutocertCacheDir)
+
+	if cfg.TLSHTTPPort == "" {
+		return nil
+	}
+	return &http.Server{
+		Addr:              ":" + cfg.TLSHTTPPort,
+		Handler:           manager.HTTPHandler(nil),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
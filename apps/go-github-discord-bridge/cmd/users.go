@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/config"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/i18n"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// userLinkKeyPrefix /github link 綁定的 record："discord-user:<小寫 login>" → Discord user ID
+const userLinkKeyPrefix = "discord-user:"
+
+// githubLoginPattern GitHub 帳號規則：英數和 -，最多 39 字元
+var githubLoginPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,38})$`)
+
+// discordUserID 查 GitHub 帳號對應的 Discord user ID（不分大小寫）
+// GITHUB_DISCORD_USER_MAP 優先，沒有時查 /github link 自助綁定的紀錄（只認 /github verify 驗證過的，加入驗證前的舊綁定不算）
+func (app *App) discordUserID(login string) (string, bool) {
+	login = strings.ToLower(login)
+	if id, ok := config.Current().GitHubDiscordUserMap[login]; ok {
+		return id, true
+	}
+	if !config.Current().SelfServiceLinking || login == "" {
+		return "", false
+	}
+
+	id, exists, err := app.store.GetRecord(userLinkKeyPrefix + login)
+	if err != nil {
+		applogger.Log.Warn("Failed to look up linked Discord user", "githubUser", login, "error", err)
+		return "", false
+	}
+	if !exists {
+		return "", false
+	}
+	verified, _, err := app.store.GetRecord(linkedLoginKeyPrefix + id)
+	if err != nil {
+		applogger.Log.Warn("Failed to look up linked GitHub user", "discordUser", id, "error", err)
+		return "", false
+	}
+	return id, verified == login
+}
+
+// mentionMap 給 formatter 用的 login（小寫）→ Discord ID，只包含查得到的帳號
+func (app *App) mentionMap(logins ...string) map[string]string {
+	m := make(map[string]string, len(logins))
+	for _, login := range logins {
+		if id, ok := app.discordUserID(login); ok {
+			m[strings.ToLower(login)] = id
+		}
+	}
+	return m
+}
+
+// linkVerifyTTL /github link 之後多久內要完成 /github verify
+const linkVerifyTTL = time.Hour
+
+// linkVerifyTimeout /github verify 查 GitHub gist 的時間上限（interaction 要在 3 秒內回應）
+const linkVerifyTimeout = 2500 * time.Millisecond
+
+// pendingLinkKeyPrefix 等待驗證的綁定："discord-link-pending:<Discord user ID>" → pendingLink（JSON），linkVerifyTTL 後過期
+const pendingLinkKeyPrefix = "discord-link-pending:"
+
+// linkedLoginKeyPrefix 驗證過的反向對應 record："github-login:<Discord user ID>" → 小寫 login（回覆到 GitHub 時確認身分）
+const linkedLoginKeyPrefix = "github-login:"
+
+// pendingLink 等待 /github verify 的綁定
+type pendingLink struct {
+	Login     string    `json:"login"`
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// handleGitHubCommand /github link login:<帳號>、/github verify 和 /github unlink login:<帳號>
+// link 只產生驗證碼，使用者在該 GitHub 帳號建立 description 含驗證碼的 public gist 後用 verify 完成綁定，避免冒用別人的帳號
+// 需要 DISCORD_SELF_SERVICE_LINKING=true 和 GitHub API 認證（查 gist）才開放
+func (app *App) handleGitHubCommand(interaction *discord.Interaction) (*discord.InteractionResponse, error) {
+	if !config.Current().SelfServiceLinking {
+		return discord.EphemeralReply(i18n.T("Self-service linking is disabled. Ask an admin to add you to GITHUB_DISCORD_USER_MAP.")), nil
+	}
+	if app.githubAPI == nil {
+		return discord.EphemeralReply(i18n.T("Linking requires GitHub API credentials (GITHUB_TOKEN or a GitHub App).")), nil
+	}
+
+	invoker := interaction.Invoker()
+	if invoker == nil {
+		return discord.EphemeralReply(i18n.T("Could not identify the Discord user.")), nil
+	}
+
+	sub, options := interaction.SubCommand()
+	if sub == "verify" {
+		return app.verifyGitHubLink(invoker)
+	}
+
+	login := strings.TrimPrefix(strings.TrimSpace(discord.OptionString(options, "login")), "@")
+	if !githubLoginPattern.MatchString(login) {
+		return discord.EphemeralReply(i18n.Tf("`%s` is not a valid GitHub username.", login)), nil
+	}
+	key := userLinkKeyPrefix + strings.ToLower(login)
+
+	switch sub {
+	case "link":
+		if id, ok := config.Current().GitHubDiscordUserMap[strings.ToLower(login)]; ok && id != invoker.ID {
+			return discord.EphemeralReply(i18n.Tf("`%s` is already mapped to another Discord user by an admin.", login)), nil
+		}
+		if id, ok := app.discordUserID(login); ok && id != invoker.ID {
+			return discord.EphemeralReply(i18n.Tf("`%s` is already linked to another Discord user.", login)), nil
+		}
+
+		code, err := newLinkCode()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(pendingLink{Login: login, Code: code, ExpiresAt: time.Now().Add(linkVerifyTTL)})
+		if err != nil {
+			return nil, err
+		}
+		if err := app.store.SetRecord(pendingLinkKeyPrefix+invoker.ID, string(data), linkVerifyTTL); err != nil {
+			return nil, err
+		}
+		applogger.Log.Info("Started GitHub link verification", "githubUser", login, "discordUser", invoker.ID)
+		return discord.EphemeralReply(i18n.Tf("To prove you own `%s`, create a public gist on that account with the description `%s`, then run `/github verify` within an hour. You can delete the gist afterwards.", login, code)), nil
+
+	case "unlink":
+		id, exists, err := app.store.GetRecord(key)
+		if err != nil {
+			return nil, err
+		}
+		if !exists || id != invoker.ID {
+			return discord.EphemeralReply(i18n.Tf("`%s` is not linked to you.", login)), nil
+		}
+		if err := app.store.DeleteRecord(key); err != nil {
+			return nil, err
+		}
+		if err := app.store.DeleteRecord(linkedLoginKeyPrefix + invoker.ID); err != nil {
+			return nil, err
+		}
+		applogger.Log.Info("Unlinked GitHub user", "githubUser", login, "discordUser", invoker.ID)
+		return discord.EphemeralReply(i18n.Tf("Unlinked GitHub `%s`.", login)), nil
+
+	default:
+		return discord.EphemeralReply(i18n.T("Unknown subcommand.")), nil
+	}
+}
+
+// verifyGitHubLink /github verify：找到 description 含驗證碼的 public gist 才寫入綁定
+func (app *App) verifyGitHubLink(invoker *discord.DiscordUser) (*discord.InteractionResponse, error) {
+	pendingKey := pendingLinkKeyPrefix + invoker.ID
+	data, exists, err := app.store.GetRecord(pendingKey)
+	if err != nil {
+		return nil, err
+	}
+	var pending pendingLink
+	if !exists || json.Unmarshal([]byte(data), &pending) != nil || time.Now().After(pending.ExpiresAt) {
+		return discord.EphemeralReply(i18n.T("No pending link. Run `/github link` first.")), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), linkVerifyTimeout)
+	defer cancel()
+	gists, err := app.githubAPI.ListUserGists(ctx, pending.Login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gists of %s: %w", pending.Login, err)
+	}
+	if !slices.ContainsFunc(gists, func(g github.Gist) bool { return strings.Contains(g.Description, pending.Code) }) {
+		return discord.EphemeralReply(i18n.Tf("No public gist of `%s` has the description `%s` yet.", pending.Login, pending.Code)), nil
+	}
+
+	login := strings.ToLower(pending.Login)
+	if id, ok := app.discordUserID(login); ok && id != invoker.ID {
+		return discord.EphemeralReply(i18n.Tf("`%s` is already linked to another Discord user.", pending.Login)), nil
+	}
+	if err := app.store.SetRecord(userLinkKeyPrefix+login, invoker.ID, 0); err != nil {
+		return nil, err
+	}
+	if err := app.store.SetRecord(linkedLoginKeyPrefix+invoker.ID, login, 0); err != nil {
+		return nil, err
+	}
+	if err := app.store.DeleteRecord(pendingKey); err != nil {
+		applogger.Log.Warn("Failed to delete pending GitHub link", "discordUser", invoker.ID, "error", err)
+	}
+	applogger.Log.Info("Linked GitHub user", "githubUser", pending.Login, "discordUser", invoker.ID)
+	return discord.EphemeralReply(i18n.Tf("Linked GitHub `%s` to you. Review requests and reviews will mention you.", pending.Login)), nil
+}
+
+// linkedGitHubLogin Discord 使用者對應的 GitHub 帳號（小寫）：GITHUB_DISCORD_USER_MAP 或驗證過的 /github link
+func (app *App) linkedGitHubLogin(discordUserID string) (string, bool) {
+	for login, id := range config.Current().GitHubDiscordUserMap {
+		if id == discordUserID {
+			return login, true
+		}
+	}
+	if !config.Current().SelfServiceLinking {
+		return "", false
+	}
+	login, exists, err := app.store.GetRecord(linkedLoginKeyPrefix + discordUserID)
+	if err != nil {
+		applogger.Log.Warn("Failed to look up linked GitHub user", "discordUser", discordUserID, "error", err)
+		return "", false
+	}
+	return login, exists
+}
+
+// newLinkCode 產生 /github link 的驗證碼
+func newLinkCode() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "github-discord-bridge-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/github"
+)
+
+// handleWiki wiki（gollum）頁面建立 / 修改時發到 repo 的 activity thread
+func (app *App) handleWiki(ctx context.Context, ghEvent string, payload *github.WebhookPayload) error {
+	if len(payload.Pages) == 0 {
+		return nil
+	}
+
+	message := app.render(ctx, "gollum", discord.FormatWikiUpdate(payload.Pages, payload.Repository.FullName, payload.Sender))
+	if err := app.postActivity(ctx, payload.Repository.FullName, message); err != nil {
+		return err
+	}
+
+	app.announce(ctx, "gollum", message)
+	return nil
+}
@@ -0,0 +1,305 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// jsAckWait publisher 沒有 ack 多久後 JetStream 重送；處理中每 jsProgressInterval 送 +WPI 延長
+	jsAckWait          = 2 * time.Minute
+	jsProgressInterval = 30 * time.Second
+	// jsFetchExpires 每次 pull 最多等多久（沒有訊息時回 408）
+	jsFetchExpires = 5 * time.Second
+
+	// jsErrStreamNotFound JetStream API 的 err_code
+	jsErrStreamNotFound = 10059
+)
+
+// jsAPIError JetStream API 回應的 error
+type jsAPIError struct {
+	Code        int    `json:"code"`
+	ErrCode     int    `json:"err_code"`
+	Description string `json:"description"`
+}
+
+func (e *jsAPIError) Error() string {
+	return fmt.Sprintf("JetStream error %d (%d): %s", e.Code, e.ErrCode, e.Description)
+}
+
+// jsRequest 呼叫 JetStream API（$JS.API.*）或發布到 stream，解析回應的 JSON
+func jsRequest(ctx context.Context, c *natsConn, subject string, data []byte, resp any) error {
+	msg, err := c.request(ctx, subject, data)
+	if err != nil {
+		return err
+	}
+	if msg.Status == "503" {
+		return fmt.Errorf("no JetStream responder for %s (is JetStream enabled on the NATS server?)", subject)
+	}
+	var envelope struct {
+		Error *jsAPIError `json:"error"`
+	}
+	if err := json.Unmarshal(msg.Data, &envelope); err != nil {
+		return fmt.Errorf("invalid JetStream response for %s: %w", subject, err)
+	}
+	if envelope.Error != nil {
+		return envelope.Error
+	}
+	if resp != nil {
+		return json.Unmarshal(msg.Data, resp)
+	}
+	return nil
+}
+
+// ensureStream stream 不存在時建立（file storage，只收 subject）；已存在時不修改它的設定
+// retention 用 workqueue：ack 後就從 stream 刪除，不會無限累積（同一個 stream 只能有一個 durable consumer）
+func ensureStream(ctx context.Context, c *natsConn, stream, subject string) error {
+	err := jsRequest(ctx, c, "$JS.API.STREAM.INFO."+stream, nil, nil)
+	var apiErr *jsAPIError
+	if !errors.As(err, &apiErr) || apiErr.ErrCode != jsErrStreamNotFound {
+		return err
+	}
+	config, _ := json.Marshal(map[string]any{
+		"name":      stream,
+		"subjects":  []string{subject},
+		"storage":   "file",
+		"retention": "workqueue",
+	})
+	if err := jsRequest(ctx, c, "$JS.API.STREAM.CREATE."+stream, config, nil); err != nil {
+		return fmt.Errorf("failed to create JetStream stream %s: %w", stream, err)
+	}
+	return nil
+}
+
+// jetStreamPublisher 發布到 JetStream，斷線時下一次 Publish 重新連線
+type jetStreamPublisher struct {
+	opts Options
+
+	mu   sync.Mutex
+	conn *natsConn
+}
+
+func newJetStreamPublisher(ctx context.Context, opts Options) (*jetStreamPublisher, error) {
+	p := &jetStreamPublisher{opts: opts}
+	if _, err := p.connection(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *jetStreamPublisher) connection(ctx context.Context) (*natsConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn != nil && !p.conn.closed() {
+		return p.conn, nil
+	}
+	conn, err := dialNATS(ctx, p.opts.URL, p.opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureStream(ctx, conn, p.opts.Stream, p.opts.Topic); err != nil {
+		conn.close()
+		return nil, err
+	}
+	p.conn = conn
+	return conn, nil
+}
+
+// Publish 等 JetStream 的 PubAck（已寫入 stream）才回傳
+func (p *jetStreamPublisher) Publish(ctx context.Context, msg Message) error {
+	conn, err := p.connection(ctx)
+	if err != nil {
+		return err
+	}
+	var ack struct {
+		Stream string `json:"stream"`
+		Seq    uint64 `json:"seq"`
+	}
+	if err := jsRequest(ctx, conn, p.opts.Topic, msg.Data, &ack); err != nil {
+		return fmt.Errorf("failed to publish to JetStream: %w", err)
+	}
+	return nil
+}
+
+func (p *jetStreamPublisher) Ping(ctx context.Context) error {
+	conn, err := p.connection(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.ping(ctx)
+}
+
+func (p *jetStreamPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	return p.conn.close()
+}
+
+// jetStreamConsumer 以 durable pull consumer 取得訊息（ack_policy explicit），同一個 Group 的 publisher 分攤
+// JetStream 沒有 partition，Message.Key 不使用：多個 publisher 時同一個 issue 的事件可能被不同 publisher 同時處理、順序不保證
+type jetStreamConsumer struct {
+	opts Options
+	conn *natsConn
+}
+
+func newJetStreamConsumer(ctx context.Context, opts Options) (*jetStreamConsumer, error) {
+	c := &jetStreamConsumer{opts: opts}
+	if err := c.connect(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// connect 連線並建立 stream 和 durable consumer（已存在且設定相同時不影響）
+func (c *jetStreamConsumer) connect(ctx context.Context) error {
+	conn, err := dialNATS(ctx, c.opts.URL, c.opts.TLS)
+	if err != nil {
+		return err
+	}
+	if err := ensureStream(ctx, conn, c.opts.Stream, c.opts.Topic); err != nil {
+		conn.close()
+		return err
+	}
+	config, _ := json.Marshal(map[string]any{
+		"stream_name": c.opts.Stream,
+		"config": map[string]any{
+			"durable_name":    c.opts.Group,
+			"deliver_policy":  "all",
+			"ack_policy":      "explicit",
+			"ack_wait":        jsAckWait.Nanoseconds(),
+			"max_deliver":     -1,
+			"filter_subject":  c.opts.Topic,
+			"max_ack_pending": 1000,
+		},
+	})
+	if err := jsRequest(ctx, conn, "$JS.API.CONSUMER.DURABLE.CREATE."+c.opts.Stream+"."+c.opts.Group, config, nil); err != nil {
+		conn.close()
+		return fmt.Errorf("failed to create JetStream consumer %s: %w", c.opts.Group, err)
+	}
+	c.conn = conn
+	return nil
+}
+
+// Consume 每次 pull 最多 Batch 則，handle 回傳 nil 時逐則 +ACK，error 時 -NAK 讓 JetStream 重送
+// 連線中斷時回傳 error，下一次呼叫重新連線
+func (c *jetStreamConsumer) Consume(ctx context.Context, handle Handler) error {
+	if c.conn == nil || c.conn.closed() {
+		if err := c.connect(ctx); err != nil {
+			return err
+		}
+	}
+	conn := c.conn
+	inbox := newInbox()
+	sid, replies, err := conn.subscribe(inbox, c.opts.batch()*2)
+	if err != nil {
+		return err
+	}
+	defer conn.unsubscribe(sid)
+
+	// 有訊息時以 no_wait 立即取得目前可用的（最多 Batch 則）；沒有訊息時 long poll 等下一則，避免單則訊息要等到 expires 才處理
+	next := "$JS.API.CONSUMER.MSG.NEXT." + c.opts.Stream + "." + c.opts.Group
+	available, _ := json.Marshal(map[string]any{"batch": c.opts.batch(), "no_wait": true})
+	wait, _ := json.Marshal(map[string]any{"batch": 1, "expires": jsFetchExpires.Nanoseconds()})
+	idle := false
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		request := available
+		if idle {
+			request = wait
+		}
+		if err := conn.publish(next, inbox, request); err != nil {
+			return err
+		}
+
+		limit := c.opts.batch()
+		if idle {
+			limit = 1
+		}
+		var batch []natsMsg
+		timeout := time.NewTimer(jsFetchExpires + time.Second)
+	collect:
+		for len(batch) < limit {
+			select {
+			case msg, ok := <-replies:
+				if !ok {
+					timeout.Stop()
+					return conn.err
+				}
+				switch msg.Status {
+				case "":
+					batch = append(batch, msg)
+				case "404", "408", "409":
+					// 沒有訊息 / pull 到期 / consumer 設定變更，這一輪結束
+					break collect
+				default:
+					timeout.Stop()
+					return fmt.Errorf("JetStream pull failed with status %s", msg.Status)
+				}
+			case <-timeout.C:
+				break collect
+			case <-ctx.Done():
+				timeout.Stop()
+				ackAll(conn, batch, "-NAK")
+				return ctx.Err()
+			}
+		}
+		timeout.Stop()
+		idle = len(batch) == 0
+		if idle {
+			continue
+		}
+
+		msgs := make([]Message, len(batch))
+		for i, m := range batch {
+			msgs[i] = Message{Data: m.Data}
+		}
+		if err := c.handle(ctx, conn, batch, msgs, handle); err != nil {
+			ackAll(conn, batch, "-NAK")
+			continue
+		}
+		ackAll(conn, batch, "+ACK")
+	}
+}
+
+// handle 執行 handle，處理期間定期送 +WPI（work in progress），避免處理較久時（Discord 暫停服務）超過 ack_wait 被重送
+func (c *jetStreamConsumer) handle(ctx context.Context, conn *natsConn, batch []natsMsg, msgs []Message, handle Handler) error {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(jsProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				ackAll(conn, batch, "+WPI")
+			}
+		}
+	}()
+	defer close(done)
+	return handle(ctx, msgs)
+}
+
+func ackAll(conn *natsConn, batch []natsMsg, ack string) {
+	for _, m := range batch {
+		if m.Reply != "" {
+			conn.publish(m.Reply, "", []byte(ack))
+		}
+	}
+}
+
+func (c *jetStreamConsumer) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.close()
+}
@@ -0,0 +1,372 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeJetStream 一個 stream、一個 durable pull consumer 的 JetStream API（跑在 fakeNATS 上）
+type fakeJetStream struct {
+	*fakeNATS
+	stream, topic, group string
+
+	mu           sync.Mutex
+	streamConfig map[string]any // nil = stream 不存在
+	consumer     map[string]any
+	messages     []string     // 依 seq（index + 1）
+	inFlight     map[int]bool // 已送出還沒 ack 的 seq
+	acked        map[int]bool // +ACK 過的 seq
+	acks         []string     // 收到的 ack（"<seq> +ACK"）
+	waiting      []fakePull   // long poll 中的 pull request
+	noAck        bool         // true = publish 不回 PubAck
+}
+
+type fakePull struct {
+	conn  *fakeNATSConn
+	inbox string
+}
+
+func newFakeJetStream(t *testing.T) *fakeJetStream {
+	js := &fakeJetStream{stream: "WEBHOOKS", topic: "github.webhooks", group: "bridge", inFlight: make(map[int]bool), acked: make(map[int]bool)}
+	js.fakeNATS = newFakeNATS(t, func(s *fakeNATS) { s.onPub = js.handle })
+	return js
+}
+
+func (js *fakeJetStream) options() Options {
+	return Options{Kind: "nats", URL: js.url(), Topic: js.topic, Stream: js.stream, Group: js.group, Batch: 3}
+}
+
+func jsReply(c *fakeNATSConn, reply string, v any) {
+	data, _ := json.Marshal(v)
+	c.deliver(reply, "", data)
+}
+
+func (js *fakeJetStream) handle(c *fakeNATSConn, subject, reply string, data []byte) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	switch {
+	case subject == "$JS.API.STREAM.INFO."+js.stream:
+		if js.streamConfig == nil {
+			jsReply(c, reply, map[string]any{"error": map[string]any{"code": 404, "err_code": jsErrStreamNotFound, "description": "stream not found"}})
+			return
+		}
+		jsReply(c, reply, map[string]any{"config": js.streamConfig})
+	case subject == "$JS.API.STREAM.CREATE."+js.stream:
+		json.Unmarshal(data, &js.streamConfig)
+		jsReply(c, reply, map[string]any{"config": js.streamConfig})
+	case subject == "$JS.API.CONSUMER.DURABLE.CREATE."+js.stream+"."+js.group:
+		json.Unmarshal(data, &js.consumer)
+		jsReply(c, reply, map[string]any{"name": js.group})
+	case subject == js.topic:
+		if js.noAck {
+			return
+		}
+		js.messages = append(js.messages, string(data))
+		jsReply(c, reply, map[string]any{"stream": js.stream, "seq": len(js.messages)})
+		// 交給還在等的 long poll（略過已斷線的連線上的）
+		for len(js.waiting) > 0 {
+			pull := js.waiting[0]
+			js.waiting = js.waiting[1:]
+			if js.deliverLocked(pull.conn, pull.inbox, 1) > 0 {
+				break
+			}
+		}
+	case subject == "$JS.API.CONSUMER.MSG.NEXT."+js.stream+"."+js.group:
+		var req struct {
+			Batch  int  `json:"batch"`
+			NoWait bool `json:"no_wait"`
+		}
+		json.Unmarshal(data, &req)
+		sent := js.deliverLocked(c, reply, req.Batch)
+		switch {
+		case sent == req.Batch:
+		case req.NoWait:
+			// 和 server 一樣，no_wait 湊不滿 batch 時以 404 結束這次 pull
+			c.deliverStatus(reply, "404 No Messages")
+		case sent == 0:
+			js.waiting = append(js.waiting, fakePull{conn: c, inbox: reply})
+		}
+	case strings.HasPrefix(subject, "$JS.ACK."+js.stream+"."+js.group+"."):
+		var seq int
+		fmt.Sscanf(strings.TrimPrefix(subject, "$JS.ACK."+js.stream+"."+js.group+"."), "%d", &seq)
+		js.acks = append(js.acks, fmt.Sprintf("%d %s", seq, data))
+		switch string(data) {
+		case "+ACK":
+			delete(js.inFlight, seq)
+			js.acked[seq] = true
+		case "-NAK":
+			delete(js.inFlight, seq)
+		}
+	}
+}
+
+// deliverLocked 送出最多 batch 則還沒 ack、不在處理中的訊息
+func (js *fakeJetStream) deliverLocked(c *fakeNATSConn, inbox string, batch int) int {
+	sent := 0
+	for i, data := range js.messages {
+		seq := i + 1
+		if sent >= batch {
+			break
+		}
+		if js.acked[seq] || js.inFlight[seq] {
+			continue
+		}
+		if !c.deliver(inbox, fmt.Sprintf("$JS.ACK.%s.%s.%d", js.stream, js.group, seq), []byte(data)) {
+			break
+		}
+		js.inFlight[seq] = true
+		sent++
+	}
+	return sent
+}
+
+// waitAcks 等 server 收到 want 則 ack（Consume 回傳時最後的 ack 可能還在路上），回傳收到的 ack
+func (js *fakeJetStream) waitAcks(want int) []string {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		js.mu.Lock()
+		acks := slices.Clone(js.acks)
+		js.mu.Unlock()
+		if len(acks) >= want || time.Now().After(deadline) {
+			return acks
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJetStreamPublish(t *testing.T) {
+	js := newFakeJetStream(t)
+	p, err := NewPublisher(testContext(t), js.options())
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	defer p.Close()
+
+	js.mu.Lock()
+	config := js.streamConfig
+	js.mu.Unlock()
+	if config["retention"] != "workqueue" || config["storage"] != "file" || fmt.Sprint(config["subjects"]) != "[github.webhooks]" {
+		t.Errorf("created stream config = %v", config)
+	}
+
+	for _, data := range []string{"one", "two"} {
+		if err := p.Publish(testContext(t), Message{Key: "owner/repo#1", Data: []byte(data)}); err != nil {
+			t.Fatalf("Publish(%s): %v", data, err)
+		}
+	}
+	if err := p.Ping(testContext(t)); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if !slices.Equal(js.messages, []string{"one", "two"}) {
+		t.Errorf("stream has %v, want [one two]", js.messages)
+	}
+}
+
+func TestJetStreamPublishWaitsForAck(t *testing.T) {
+	js := newFakeJetStream(t)
+	p, err := NewPublisher(testContext(t), js.options())
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	defer p.Close()
+
+	js.mu.Lock()
+	js.noAck = true
+	js.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.Publish(ctx, Message{Data: []byte("lost")}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Publish without PubAck = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestJetStreamPublisherReconnects(t *testing.T) {
+	js := newFakeJetStream(t)
+	p, err := NewPublisher(testContext(t), js.options())
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	defer p.Close()
+	if err := p.Publish(testContext(t), Message{Data: []byte("before")}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	js.dropAll()
+	// read loop 發現斷線之前的 Publish 可能失敗，之後的要重新連線成功
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := p.Publish(testContext(t), Message{Data: []byte("after")})
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Publish after the connection was lost: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	if js.messages[len(js.messages)-1] != "after" {
+		t.Errorf("stream has %v, want the last message to be after", js.messages)
+	}
+}
+
+func TestJetStreamNoResponders(t *testing.T) {
+	s := newFakeNATS(t, func(s *fakeNATS) {
+		s.onPub = func(c *fakeNATSConn, subject, reply string, data []byte) {
+			c.deliverStatus(reply, "503")
+		}
+	})
+	_, err := NewPublisher(testContext(t), Options{Kind: "nats", URL: s.url(), Topic: "t", Stream: "S"})
+	if err == nil || !strings.Contains(err.Error(), "is JetStream enabled") {
+		t.Errorf("NewPublisher without JetStream = %v", err)
+	}
+}
+
+// consumeUntil 跑 Consume 直到 handle 收到 want 則訊息，回傳每次交給 handle 的批次
+func consumeUntil(t *testing.T, c Consumer, want int, handle Handler) [][]string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var batches [][]string
+	received := 0
+	err := c.Consume(ctx, func(ctx context.Context, msgs []Message) error {
+		var batch []string
+		for _, m := range msgs {
+			batch = append(batch, string(m.Data))
+		}
+		err := handle(ctx, msgs)
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, batch)
+		if err == nil {
+			if received += len(msgs); received >= want {
+				cancel()
+			}
+		}
+		return err
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Consume = %v, want %v after %d messages (got %v)", err, context.Canceled, want, batches)
+	}
+	return batches
+}
+
+func TestJetStreamConsume(t *testing.T) {
+	js := newFakeJetStream(t)
+	p, err := NewPublisher(testContext(t), js.options())
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	defer p.Close()
+	for i := range 4 {
+		p.Publish(testContext(t), Message{Data: fmt.Appendf(nil, "m%d", i)})
+	}
+
+	c, err := NewConsumer(testContext(t), js.options())
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	defer c.Close()
+
+	js.mu.Lock()
+	config, _ := js.consumer["config"].(map[string]any)
+	js.mu.Unlock()
+	if config["durable_name"] != "bridge" || config["ack_policy"] != "explicit" || config["filter_subject"] != "github.webhooks" {
+		t.Errorf("consumer config = %v", config)
+	}
+
+	// Batch = 3：先取得目前有的 3 則，再取剩下的 1 則；之後沒有訊息時 long poll 等新的訊息
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		p.Publish(context.Background(), Message{Data: []byte("late")})
+	}()
+	batches := consumeUntil(t, c, 5, func(context.Context, []Message) error { return nil })
+
+	want := [][]string{{"m0", "m1", "m2"}, {"m3"}, {"late"}}
+	if fmt.Sprint(batches) != fmt.Sprint(want) {
+		t.Errorf("batches = %v, want %v", batches, want)
+	}
+	if acks := js.waitAcks(5); fmt.Sprint(acks) != "[1 +ACK 2 +ACK 3 +ACK 4 +ACK 5 +ACK]" {
+		t.Errorf("acks = %v", acks)
+	}
+}
+
+func TestJetStreamConsumeNaksOnError(t *testing.T) {
+	js := newFakeJetStream(t)
+	p, err := NewPublisher(testContext(t), js.options())
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	defer p.Close()
+	p.Publish(testContext(t), Message{Data: []byte("flaky")})
+
+	c, err := NewConsumer(testContext(t), js.options())
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	defer c.Close()
+
+	attempts := 0
+	batches := consumeUntil(t, c, 1, func(context.Context, []Message) error {
+		if attempts++; attempts == 1 {
+			return errors.New("discord is down")
+		}
+		return nil
+	})
+
+	if fmt.Sprint(batches) != "[[flaky] [flaky]]" {
+		t.Errorf("batches = %v, want the message redelivered after -NAK", batches)
+	}
+	if acks := js.waitAcks(2); fmt.Sprint(acks) != "[1 -NAK 1 +ACK]" {
+		t.Errorf("acks = %v", acks)
+	}
+}
+
+func TestJetStreamConsumeConnectionLost(t *testing.T) {
+	js := newFakeJetStream(t)
+	c, err := NewConsumer(testContext(t), js.options())
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	defer c.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.Consume(testContext(t), func(context.Context, []Message) error { return nil })
+	}()
+	time.Sleep(50 * time.Millisecond) // Consume 在 long poll 中
+	js.dropAll()
+
+	select {
+	case err := <-errc:
+		if err == nil || errors.Is(err, context.Canceled) {
+			t.Errorf("Consume = %v, want the connection error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Consume did not return after the connection was lost")
+	}
+
+	// 下一次 Consume 重新連線
+	p, err := NewPublisher(testContext(t), js.options())
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	defer p.Close()
+	p.Publish(testContext(t), Message{Data: []byte("after")})
+	if batches := consumeUntil(t, c, 1, func(context.Context, []Message) error { return nil }); fmt.Sprint(batches) != "[[after]]" {
+		t.Errorf("batches after reconnecting = %v", batches)
+	}
+}
@@ -0,0 +1,217 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	kafkaJSON   = "application/vnd.kafka.v2+json"
+	kafkaBinary = "application/vnd.kafka.binary.v2+json"
+
+	// kafkaPollTimeout GET records 最多等多久
+	kafkaPollTimeout = 5 * time.Second
+)
+
+// kafkaHTTP REST Proxy 的 HTTP client（records 是 long poll，timeout 要比 kafkaPollTimeout 長）
+var kafkaHTTP = &http.Client{Timeout: kafkaPollTimeout + 25*time.Second}
+
+// kafkaError REST Proxy 的錯誤回應
+type kafkaError struct {
+	Status    int    `json:"-"`
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func (e *kafkaError) Error() string {
+	return fmt.Sprintf("Kafka REST Proxy error %d (%d): %s", e.Status, e.ErrorCode, e.Message)
+}
+
+// kafkaRequest 呼叫 REST Proxy，2xx 時把 body 解析到 resp（可為 nil）
+func kafkaRequest(ctx context.Context, method, url, contentType, accept string, body, resp any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", accept)
+	res, err := kafkaHTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("Kafka REST Proxy request failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		apiErr := &kafkaError{Status: res.StatusCode}
+		json.NewDecoder(res.Body).Decode(apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = res.Status
+		}
+		return apiErr
+	}
+	if resp == nil {
+		io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(resp)
+}
+
+// kafkaPublisher 透過 REST Proxy 的 POST /topics/{topic} 發布（binary 格式，key 決定 partition）
+type kafkaPublisher struct {
+	opts Options
+}
+
+func newKafkaPublisher(opts Options) *kafkaPublisher {
+	return &kafkaPublisher{opts: opts}
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, msg Message) error {
+	record := map[string]any{"value": msg.Data} // []byte 編成 base64
+	if msg.Key != "" {
+		record["key"] = []byte(msg.Key)
+	}
+	var resp struct {
+		Offsets []struct {
+			Partition int     `json:"partition"`
+			Offset    int64   `json:"offset"`
+			ErrorCode *int    `json:"error_code"`
+			Error     *string `json:"error"`
+		} `json:"offsets"`
+	}
+	target := strings.TrimRight(p.opts.URL, "/") + "/topics/" + url.PathEscape(p.opts.Topic)
+	body := map[string]any{"records": []any{record}}
+	if err := kafkaRequest(ctx, http.MethodPost, target, kafkaBinary, kafkaJSON, body, &resp); err != nil {
+		return fmt.Errorf("failed to publish to Kafka: %w", err)
+	}
+	for _, o := range resp.Offsets {
+		if o.Error != nil && *o.Error != "" {
+			return fmt.Errorf("failed to publish to Kafka: partition %d: %s", o.Partition, *o.Error)
+		}
+	}
+	return nil
+}
+
+// Ping GET /topics/{topic} 確認 REST Proxy 可以連線且 topic 存在
+func (p *kafkaPublisher) Ping(ctx context.Context) error {
+	target := strings.TrimRight(p.opts.URL, "/") + "/topics/" + url.PathEscape(p.opts.Topic)
+	return kafkaRequest(ctx, http.MethodGet, target, "", kafkaJSON, nil, nil)
+}
+
+func (p *kafkaPublisher) Close() error {
+	return nil
+}
+
+// kafkaConsumer REST Proxy 的 consumer instance（auto commit 關閉，handle 成功後才 commit）
+// instance 閒置太久（consumer.instance.timeout.ms）被 REST Proxy 刪掉時自動重建
+type kafkaConsumer struct {
+	opts    Options
+	baseURI string // consumer instance 的 URI，空值 = 還沒建立
+}
+
+func newKafkaConsumer(opts Options) *kafkaConsumer {
+	return &kafkaConsumer{opts: opts}
+}
+
+// create 建立 consumer instance 並訂閱 topic；第一次加入 group 時從最早的 offset 開始
+func (c *kafkaConsumer) create(ctx context.Context) error {
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	var created struct {
+		InstanceID string `json:"instance_id"`
+		BaseURI    string `json:"base_uri"`
+	}
+	target := strings.TrimRight(c.opts.URL, "/") + "/consumers/" + url.PathEscape(c.opts.Group)
+	body := map[string]any{
+		"name":               "bridge-" + hex.EncodeToString(suffix),
+		"format":             "binary",
+		"auto.offset.reset":  "earliest",
+		"auto.commit.enable": "false",
+	}
+	if err := kafkaRequest(ctx, http.MethodPost, target, kafkaJSON, kafkaJSON, body, &created); err != nil {
+		return fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+	subscription := map[string]any{"topics": []string{c.opts.Topic}}
+	if err := kafkaRequest(ctx, http.MethodPost, created.BaseURI+"/subscription", kafkaJSON, kafkaJSON, subscription, nil); err != nil {
+		kafkaRequest(context.Background(), http.MethodDelete, created.BaseURI, kafkaJSON, kafkaJSON, nil, nil)
+		return fmt.Errorf("failed to subscribe to Kafka topic %s: %w", c.opts.Topic, err)
+	}
+	c.baseURI = created.BaseURI
+	return nil
+}
+
+// Consume 每次 poll 一批 records，handle 回傳 nil 時 commit 這批（POST offsets 不帶 body = 目前取得的全部）
+// handle 回傳 error 時刪掉 consumer instance，重新加入 group 後從上次 commit 的位置重新取得
+func (c *kafkaConsumer) Consume(ctx context.Context, handle Handler) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if c.baseURI == "" {
+			if err := c.create(ctx); err != nil {
+				return err
+			}
+		}
+
+		var records []struct {
+			Key   []byte `json:"key"`
+			Value []byte `json:"value"`
+		}
+		target := fmt.Sprintf("%s/records?timeout=%d", c.baseURI, kafkaPollTimeout.Milliseconds())
+		if err := kafkaRequest(ctx, http.MethodGet, target, "", kafkaBinary, nil, &records); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if apiErr, ok := err.(*kafkaError); ok && apiErr.Status == http.StatusNotFound {
+				c.baseURI = "" // instance 已過期
+				continue
+			}
+			return fmt.Errorf("failed to poll Kafka: %w", err)
+		}
+		if len(records) == 0 {
+			continue
+		}
+		msgs := make([]Message, len(records))
+		for i, r := range records {
+			msgs[i] = Message{Key: string(r.Key), Data: r.Value}
+		}
+		if err := handle(ctx, msgs); err != nil {
+			c.close()
+			return err
+		}
+		if err := kafkaRequest(context.Background(), http.MethodPost, c.baseURI+"/offsets", kafkaJSON, kafkaJSON, nil, nil); err != nil {
+			return fmt.Errorf("failed to commit Kafka offsets: %w", err)
+		}
+	}
+}
+
+func (c *kafkaConsumer) close() {
+	if c.baseURI == "" {
+		return
+	}
+	kafkaRequest(context.Background(), http.MethodDelete, c.baseURI, kafkaJSON, kafkaJSON, nil, nil)
+	c.baseURI = ""
+}
+
+// Close 刪掉 consumer instance，讓 group 立即 rebalance 給其他 publisher
+func (c *kafkaConsumer) Close() error {
+	c.close()
+	return nil
+}
@@ -0,0 +1,318 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeKafka 一個 topic、一個 partition 的 REST Proxy（v2 API）
+type fakeKafka struct {
+	t      *testing.T
+	server *httptest.Server
+	topic  string
+	group  string
+
+	mu         sync.Mutex
+	records    []fakeRecord
+	committed  int                       // group commit 到的 offset
+	instances  map[string]*fakeKafkaInst // consumer instance（name → 狀態）
+	created    []map[string]any          // 建立 instance 的 request body
+	deleted    int                       // 被 DELETE 的 instance 數
+	publishErr string                    // 非空值 = publish 回應的 offset 帶這個 error
+}
+
+type fakeRecord struct {
+	Key   []byte `json:"key,omitempty"`
+	Value []byte `json:"value"`
+}
+
+type fakeKafkaInst struct {
+	subscribed bool
+	position   int // 下一次 GET records 的 offset
+}
+
+func newFakeKafka(t *testing.T) *fakeKafka {
+	k := &fakeKafka{t: t, topic: "github-webhooks", group: "bridge", instances: make(map[string]*fakeKafkaInst)}
+	k.server = httptest.NewServer(http.HandlerFunc(k.handle))
+	t.Cleanup(k.server.Close)
+	return k
+}
+
+func (k *fakeKafka) options() Options {
+	return Options{Kind: "kafka", URL: k.server.URL + "/", Topic: k.topic, Group: k.group}
+}
+
+func kafkaReply(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", kafkaJSON)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (k *fakeKafka) handle(w http.ResponseWriter, r *http.Request) {
+	instancePrefix := "/consumers/" + k.group + "/instances/"
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/topics/"+k.topic:
+		if got := r.Header.Get("Content-Type"); got != kafkaBinary {
+			k.t.Errorf("publish Content-Type = %q, want %q", got, kafkaBinary)
+		}
+		var body struct {
+			Records []fakeRecord `json:"records"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			kafkaReply(w, http.StatusUnprocessableEntity, map[string]any{"error_code": 42201, "message": err.Error()})
+			return
+		}
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		var offsets []map[string]any
+		for _, rec := range body.Records {
+			if k.publishErr != "" {
+				offsets = append(offsets, map[string]any{"partition": 0, "offset": nil, "error_code": 50002, "error": k.publishErr})
+				continue
+			}
+			offsets = append(offsets, map[string]any{"partition": 0, "offset": len(k.records)})
+			k.records = append(k.records, rec)
+		}
+		kafkaReply(w, http.StatusOK, map[string]any{"offsets": offsets})
+	case r.Method == http.MethodGet && r.URL.Path == "/topics/"+k.topic:
+		kafkaReply(w, http.StatusOK, map[string]any{"name": k.topic})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/topics/"):
+		kafkaReply(w, http.StatusNotFound, map[string]any{"error_code": 40401, "message": "Topic not found."})
+	case r.Method == http.MethodPost && r.URL.Path == "/consumers/"+k.group:
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		name, _ := body["name"].(string)
+		k.mu.Lock()
+		k.created = append(k.created, body)
+		k.instances[name] = &fakeKafkaInst{position: k.committed}
+		k.mu.Unlock()
+		kafkaReply(w, http.StatusOK, map[string]any{"instance_id": name, "base_uri": k.server.URL + instancePrefix + name})
+	case strings.HasPrefix(r.URL.Path, instancePrefix):
+		name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, instancePrefix), "/")
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		inst, ok := k.instances[name]
+		if !ok {
+			kafkaReply(w, http.StatusNotFound, map[string]any{"error_code": 40403, "message": "Consumer instance not found."})
+			return
+		}
+		switch {
+		case r.Method == http.MethodDelete && action == "":
+			delete(k.instances, name)
+			k.deleted++
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && action == "subscription":
+			var body struct {
+				Topics []string `json:"topics"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if len(body.Topics) != 1 || body.Topics[0] != k.topic {
+				k.t.Errorf("subscription topics = %v, want [%s]", body.Topics, k.topic)
+			}
+			inst.subscribed = true
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && action == "records":
+			if got := r.Header.Get("Accept"); got != kafkaBinary {
+				k.t.Errorf("records Accept = %q, want %q", got, kafkaBinary)
+			}
+			if !inst.subscribed {
+				kafkaReply(w, http.StatusConflict, map[string]any{"error_code": 40903, "message": "Consumer has no subscription."})
+				return
+			}
+			records := k.records[inst.position:]
+			inst.position = len(k.records)
+			if len(records) == 0 {
+				// 代替 long poll，避免 Consume 空轉
+				time.Sleep(10 * time.Millisecond)
+			}
+			kafkaReply(w, http.StatusOK, records)
+		case r.Method == http.MethodPost && action == "offsets":
+			// 不帶 body = commit 這個 instance 目前取得的全部
+			k.committed = inst.position
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// expireInstances 模擬 REST Proxy 刪掉閒置太久的 consumer instance
+func (k *fakeKafka) expireInstances() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	clear(k.instances)
+}
+
+func TestKafkaPublish(t *testing.T) {
+	k := newFakeKafka(t)
+	p, err := NewPublisher(testContext(t), k.options())
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	defer p.Close()
+
+	if err := p.Ping(testContext(t)); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+	if err := p.Publish(testContext(t), Message{Key: "owner/repo#1", Data: []byte(`{"action":"opened"}`)}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := p.Publish(testContext(t), Message{Data: []byte("no key")}); err != nil {
+		t.Fatalf("Publish without key: %v", err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	want := []fakeRecord{{Key: []byte("owner/repo#1"), Value: []byte(`{"action":"opened"}`)}, {Value: []byte("no key")}}
+	if fmt.Sprintf("%q", k.records) != fmt.Sprintf("%q", want) {
+		t.Errorf("records = %q, want %q", k.records, want)
+	}
+}
+
+func TestKafkaPublishErrors(t *testing.T) {
+	k := newFakeKafka(t)
+
+	k.publishErr = "Leader not available"
+	p := newKafkaPublisher(k.options())
+	if err := p.Publish(testContext(t), Message{Data: []byte("x")}); err == nil || !strings.Contains(err.Error(), "partition 0: Leader not available") {
+		t.Errorf("Publish with an offset error = %v", err)
+	}
+
+	p = newKafkaPublisher(Options{URL: k.server.URL, Topic: "missing"})
+	err := p.Ping(testContext(t))
+	var apiErr *kafkaError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound || apiErr.ErrorCode != 40401 || apiErr.Message != "Topic not found." {
+		t.Errorf("Ping for a missing topic = %#v", err)
+	}
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer down.Close()
+	p = newKafkaPublisher(Options{URL: down.URL, Topic: "t"})
+	err = p.Publish(testContext(t), Message{Data: []byte("x")})
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadGateway || apiErr.Message != "502 Bad Gateway" {
+		t.Errorf("Publish to a non-JSON error = %#v", err)
+	}
+}
+
+// consumeKafka 跑 Consume 直到 handle 成功處理 want 則訊息，回傳每次交給 handle 的批次
+func consumeKafka(t *testing.T, c Consumer, want int) [][]string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var batches [][]string
+	received := 0
+	err := c.Consume(ctx, func(ctx context.Context, msgs []Message) error {
+		var batch []string
+		for _, m := range msgs {
+			batch = append(batch, m.Key+"="+string(m.Data))
+		}
+		batches = append(batches, batch)
+		if received += len(msgs); received >= want {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Consume = %v, want %v after %d messages (got %v)", err, context.Canceled, want, batches)
+	}
+	return batches
+}
+
+func TestKafkaConsume(t *testing.T) {
+	k := newFakeKafka(t)
+	p := newKafkaPublisher(k.options())
+	for i := range 3 {
+		p.Publish(testContext(t), Message{Key: fmt.Sprintf("k%d", i), Data: fmt.Appendf(nil, "m%d", i)})
+	}
+
+	c, err := NewConsumer(testContext(t), k.options())
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	if batches := consumeKafka(t, c, 3); fmt.Sprint(batches) != "[[k0=m0 k1=m1 k2=m2]]" {
+		t.Errorf("batches = %v", batches)
+	}
+	// ctx 取消時最後一批已 commit
+	k.mu.Lock()
+	committed, created := k.committed, k.created
+	k.mu.Unlock()
+	if committed != 3 {
+		t.Errorf("committed offset = %d, want 3", committed)
+	}
+	if len(created) != 1 || created[0]["format"] != "binary" || created[0]["auto.commit.enable"] != "false" || created[0]["auto.offset.reset"] != "earliest" {
+		t.Errorf("created instances = %v", created)
+	}
+
+	c.Close()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.instances) != 0 || k.deleted != 1 {
+		t.Errorf("Close left %d instances (deleted %d)", len(k.instances), k.deleted)
+	}
+}
+
+func TestKafkaConsumeHandlerError(t *testing.T) {
+	k := newFakeKafka(t)
+	p := newKafkaPublisher(k.options())
+	p.Publish(testContext(t), Message{Key: "k", Data: []byte("flaky")})
+
+	c, err := NewConsumer(testContext(t), k.options())
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	defer c.Close()
+
+	handlerErr := errors.New("discord is down")
+	err = c.Consume(testContext(t), func(context.Context, []Message) error { return handlerErr })
+	if !errors.Is(err, handlerErr) {
+		t.Fatalf("Consume = %v, want the handler error", err)
+	}
+	k.mu.Lock()
+	instances, committed := len(k.instances), k.committed
+	k.mu.Unlock()
+	if instances != 0 || committed != 0 {
+		t.Errorf("after a handler error: %d instances, committed offset %d, want the instance deleted without a commit", instances, committed)
+	}
+
+	// 重新加入 group 後從上次 commit 的位置重新取得
+	if batches := consumeKafka(t, c, 1); fmt.Sprint(batches) != "[[k=flaky]]" {
+		t.Errorf("batches after the handler error = %v", batches)
+	}
+}
+
+func TestKafkaConsumeExpiredInstance(t *testing.T) {
+	k := newFakeKafka(t)
+	p := newKafkaPublisher(k.options())
+	p.Publish(testContext(t), Message{Data: []byte("first")})
+
+	c, err := NewConsumer(testContext(t), k.options())
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	defer c.Close()
+	consumeKafka(t, c, 1)
+
+	k.expireInstances()
+	p.Publish(testContext(t), Message{Data: []byte("second")})
+	if batches := consumeKafka(t, c, 1); fmt.Sprint(batches) != "[[=second]]" {
+		t.Errorf("batches after the instance expired = %v", batches)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.created) != 2 {
+		t.Errorf("created %d instances, want 2", len(k.created))
+	}
+}
@@ -0,0 +1,366 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsMsg 收到的 MSG / HMSG
+type natsMsg struct {
+	Subject string
+	Reply   string
+	Status  string // HMSG 的 status（例如 JetStream 的 "404" No Messages、"408" Request Timeout），一般訊息為空
+	Data    []byte
+}
+
+// natsConn NATS 文字協定的精簡 client：PUB / SUB / UNSUB、request-reply、PING / PONG、TLS
+// 不自動重連：連線中斷後 jetStreamPublisher 在下一次 Publish、consumeBroker 在 backoff 後重新連線
+type natsConn struct {
+	conn net.Conn
+
+	wmu sync.Mutex
+	w   *bufio.Writer
+
+	mu     sync.Mutex
+	subs   map[uint64]*natsSub
+	pongs  []chan struct{}
+	nextID uint64
+
+	done chan struct{} // read loop 結束（連線中斷或 close）
+	err  error         // read loop 結束的原因，done 關閉後才能讀
+}
+
+// natsSub 一個 subscription；ch 只由 read loop 寫入，連線中斷時由 read loop 關閉
+type natsSub struct {
+	ch   chan natsMsg
+	done chan struct{} // unsubscribe 時關閉，read loop 不再等這個 subscriber
+}
+
+// dialNATS 連線並送出 CONNECT；URL 的 user:pass 為帳密，只有 user 時當成 token
+// tls:// 或 server 的 INFO 要求 TLS 時在 CONNECT 之前升級成 TLS，tlsConfig 為 nil 時用系統的 CA 驗證 server 的 hostname
+func dialNATS(ctx context.Context, rawURL string, tlsConfig *tls.Config) (*natsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS URL %q (expected nats://host:4222)", rawURL)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("unsupported NATS URL scheme %q (expected nats:// or tls://)", u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+	}
+
+	r := bufio.NewReader(conn)
+	line, err := readLine(r)
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("NATS handshake failed: %q %v", line, err)
+	}
+	var info struct {
+		Headers     bool `json:"headers"`
+		TLSRequired bool `json:"tls_required"`
+	}
+	json.Unmarshal([]byte(line[len("INFO "):]), &info)
+	if u.Scheme == "tls" || info.TLSRequired {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("NATS TLS handshake failed: %w", err)
+		}
+		conn, r = tlsConn, bufio.NewReader(tlsConn)
+	}
+	if !info.Headers {
+		conn.Close()
+		return nil, errors.New("NATS server does not support headers (JetStream requires NATS 2.2+)")
+	}
+
+	connect := map[string]any{
+		"verbose": false, "pedantic": false, "lang": "go", "version": "bridge", "protocol": 1,
+		"headers": true, "no_responders": true, "name": "github-discord-bridge",
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			connect["user"], connect["pass"] = u.User.Username(), pass
+		} else {
+			connect["auth_token"] = u.User.Username()
+		}
+	}
+	data, _ := json.Marshal(connect)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", data); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("NATS handshake failed: %w", err)
+	}
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("NATS handshake failed: %w", err)
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return nil, fmt.Errorf("NATS connect rejected: %s", strings.TrimSpace(line[len("-ERR"):]))
+		}
+		if line == "PONG" {
+			break
+		}
+	}
+	conn.SetDeadline(time.Time{})
+
+	c := &natsConn{conn: conn, w: bufio.NewWriter(conn), subs: make(map[uint64]*natsSub), done: make(chan struct{})}
+	go c.readLoop(r)
+	return c, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// readLoop 解析 server 送來的訊息，分派到 subscription 的 channel；連線中斷時關閉所有 channel
+func (c *natsConn) readLoop(r *bufio.Reader) {
+	err := c.read(r)
+	c.mu.Lock()
+	c.err = err
+	for id, sub := range c.subs {
+		close(sub.ch)
+		delete(c.subs, id)
+	}
+	c.mu.Unlock()
+	close(c.done)
+	c.conn.Close()
+}
+
+func (c *natsConn) read(r *bufio.Reader) error {
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return fmt.Errorf("NATS connection lost: %w", err)
+		}
+		op, args, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(op) {
+		case "PING":
+			c.write("PONG\r\n", nil)
+		case "PONG":
+			c.mu.Lock()
+			if len(c.pongs) > 0 {
+				close(c.pongs[0])
+				c.pongs = c.pongs[1:]
+			}
+			c.mu.Unlock()
+		case "MSG", "HMSG":
+			msg, sid, err := readMsg(r, strings.ToUpper(op) == "HMSG", strings.Fields(args))
+			if err != nil {
+				return err
+			}
+			// subscriber 來不及讀時 read loop 等它（不丟訊息），server 端的 buffer 滿了會送 -ERR slow consumer 並斷線，
+			// 由呼叫端重新連線；JetStream 每次只 pull Batch 則，正常情況下不會塞滿
+			c.mu.Lock()
+			sub := c.subs[sid]
+			c.mu.Unlock()
+			if sub != nil {
+				select {
+				case sub.ch <- msg:
+				case <-sub.done:
+				}
+			}
+		case "-ERR":
+			// 權限不足等錯誤 server 會繼續連線，連線層級的錯誤之後會斷線
+			if strings.Contains(strings.ToLower(args), "permissions violation") {
+				continue
+			}
+			return fmt.Errorf("NATS error: %s", args)
+		}
+	}
+}
+
+// readMsg MSG <subject> <sid> [reply] <size> / HMSG <subject> <sid> [reply] <header size> <total size>
+func readMsg(r *bufio.Reader, headers bool, fields []string) (natsMsg, uint64, error) {
+	sizes := 1
+	if headers {
+		sizes = 2
+	}
+	if len(fields) < 2+sizes || len(fields) > 3+sizes {
+		return natsMsg{}, 0, fmt.Errorf("malformed NATS message header %q", strings.Join(fields, " "))
+	}
+	msg := natsMsg{Subject: fields[0]}
+	sid, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return natsMsg{}, 0, fmt.Errorf("malformed NATS message sid %q", fields[1])
+	}
+	if len(fields) == 3+sizes {
+		msg.Reply = fields[2]
+	}
+	total, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || total < 0 {
+		return natsMsg{}, 0, fmt.Errorf("malformed NATS message size %q", fields[len(fields)-1])
+	}
+	hdrSize := 0
+	if headers {
+		if hdrSize, err = strconv.Atoi(fields[len(fields)-2]); err != nil || hdrSize > total {
+			return natsMsg{}, 0, fmt.Errorf("malformed NATS header size %q", fields[len(fields)-2])
+		}
+	}
+
+	buf := make([]byte, total+2) // 含結尾的 \r\n
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return natsMsg{}, 0, fmt.Errorf("NATS connection lost: %w", err)
+	}
+	if headers {
+		// 第一行為 "NATS/1.0 [status [description]]"
+		first, _, _ := strings.Cut(string(buf[:hdrSize]), "\r\n")
+		if fields := strings.Fields(first); len(fields) > 1 {
+			msg.Status = fields[1]
+		}
+	}
+	msg.Data = buf[hdrSize:total]
+	return msg, sid, nil
+}
+
+func (c *natsConn) write(header string, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	select {
+	case <-c.done:
+		return c.err
+	default:
+	}
+	c.w.WriteString(header)
+	if payload != nil {
+		c.w.Write(payload)
+		c.w.WriteString("\r\n")
+	}
+	return c.w.Flush()
+}
+
+func (c *natsConn) publish(subject, reply string, data []byte) error {
+	header := "PUB " + subject + " " + strconv.Itoa(len(data)) + "\r\n"
+	if reply != "" {
+		header = "PUB " + subject + " " + reply + " " + strconv.Itoa(len(data)) + "\r\n"
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return c.write(header, data)
+}
+
+// subscribe 回傳的 channel 在連線中斷時關閉（unsubscribe 後不再收到訊息，但不會關閉）；buffer 為最多暫存幾則還沒讀的訊息
+func (c *natsConn) subscribe(subject string, buffer int) (uint64, <-chan natsMsg, error) {
+	c.mu.Lock()
+	c.nextID++
+	sid := c.nextID
+	sub := &natsSub{ch: make(chan natsMsg, buffer), done: make(chan struct{})}
+	c.subs[sid] = sub
+	c.mu.Unlock()
+	if err := c.write("SUB "+subject+" "+strconv.FormatUint(sid, 10)+"\r\n", nil); err != nil {
+		c.unsubscribe(sid)
+		return 0, nil, err
+	}
+	return sid, sub.ch, nil
+}
+
+func (c *natsConn) unsubscribe(sid uint64) {
+	c.mu.Lock()
+	sub, ok := c.subs[sid]
+	if ok {
+		delete(c.subs, sid)
+		close(sub.done)
+	}
+	c.mu.Unlock()
+	if ok {
+		c.write("UNSUB "+strconv.FormatUint(sid, 10)+"\r\n", nil)
+	}
+}
+
+// request 送出並等第一個回覆
+func (c *natsConn) request(ctx context.Context, subject string, data []byte) (natsMsg, error) {
+	inbox := newInbox()
+	sid, ch, err := c.subscribe(inbox, 1)
+	if err != nil {
+		return natsMsg{}, err
+	}
+	defer c.unsubscribe(sid)
+	if err := c.publish(subject, inbox, data); err != nil {
+		return natsMsg{}, err
+	}
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			return natsMsg{}, c.err
+		}
+		return msg, nil
+	case <-ctx.Done():
+		return natsMsg{}, fmt.Errorf("NATS request %s: %w", subject, ctx.Err())
+	}
+}
+
+// ping 送 PING 等 PONG，確認連線還活著
+func (c *natsConn) ping(ctx context.Context) error {
+	pong := make(chan struct{})
+	c.mu.Lock()
+	c.pongs = append(c.pongs, pong)
+	c.mu.Unlock()
+	if err := c.write("PING\r\n", nil); err != nil {
+		return err
+	}
+	select {
+	case <-pong:
+		return nil
+	case <-c.done:
+		return c.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *natsConn) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *natsConn) close() error {
+	err := c.conn.Close()
+	<-c.done
+	return err
+}
+
+func newInbox() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "_INBOX." + hex.EncodeToString(b)
+}
@@ -0,0 +1,504 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNATS 只實作測試需要的 NATS server 協定：INFO / CONNECT、PING / PONG、SUB / UNSUB、PUB，subject 不支援 wildcard
+type fakeNATS struct {
+	t    *testing.T
+	ln   net.Listener
+	info map[string]any
+	tls  *tls.Config // INFO 之後升級成 TLS
+
+	// onConnect 檢查 CONNECT 的內容，回傳 error 時送 -ERR 並斷線
+	onConnect func(opts map[string]any) error
+	// onPub 收到 PUB 時呼叫（在 connection 的 read goroutine 上）
+	onPub func(c *fakeNATSConn, subject, reply string, data []byte)
+
+	mu       sync.Mutex
+	conns    []*fakeNATSConn
+	connects []map[string]any
+}
+
+type fakeNATSConn struct {
+	conn net.Conn
+
+	mu   sync.Mutex
+	w    *bufio.Writer
+	subs map[string]string // subject → sid
+}
+
+// newFakeNATS setup 在開始接受連線之前設定 info、tls 和 callback（可為 nil）
+func newFakeNATS(t *testing.T, setup func(s *fakeNATS)) *fakeNATS {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeNATS{t: t, ln: ln, info: map[string]any{"server_id": "fake", "version": "2.10.0", "proto": 1, "headers": true}}
+	if setup != nil {
+		setup(s)
+	}
+	t.Cleanup(s.close)
+	go s.accept()
+	return s
+}
+
+func (s *fakeNATS) url() string {
+	return "nats://" + s.ln.Addr().String()
+}
+
+func (s *fakeNATS) close() {
+	s.ln.Close()
+	s.dropAll()
+}
+
+// dropAll 中斷所有 client 的連線
+func (s *fakeNATS) dropAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		c.conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeNATS) lastConnect() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.connects) == 0 {
+		return nil
+	}
+	return s.connects[len(s.connects)-1]
+}
+
+func (s *fakeNATS) accept() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+func (s *fakeNATS) serve(conn net.Conn) {
+	defer conn.Close()
+	info, _ := json.Marshal(s.info)
+	if _, err := fmt.Fprintf(conn, "INFO %s\r\n", info); err != nil {
+		return
+	}
+	if s.tls != nil {
+		tlsConn := tls.Server(conn, s.tls)
+		if err := tlsConn.Handshake(); err != nil {
+			return
+		}
+		conn = tlsConn
+	}
+
+	c := &fakeNATSConn{conn: conn, w: bufio.NewWriter(conn), subs: make(map[string]string)}
+	s.mu.Lock()
+	s.conns = append(s.conns, c)
+	s.mu.Unlock()
+
+	r := bufio.NewReader(conn)
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return
+		}
+		op, args, _ := strings.Cut(line, " ")
+		switch op {
+		case "CONNECT":
+			var opts map[string]any
+			json.Unmarshal([]byte(args), &opts)
+			s.mu.Lock()
+			s.connects = append(s.connects, opts)
+			s.mu.Unlock()
+			if s.onConnect != nil {
+				if err := s.onConnect(opts); err != nil {
+					c.write("-ERR '" + err.Error() + "'\r\n")
+					return
+				}
+			}
+		case "PING":
+			c.write("PONG\r\n")
+		case "PONG":
+		case "SUB":
+			fields := strings.Fields(args)
+			c.mu.Lock()
+			c.subs[fields[0]] = fields[len(fields)-1]
+			c.mu.Unlock()
+		case "UNSUB":
+			c.mu.Lock()
+			for subject, sid := range c.subs {
+				if sid == args {
+					delete(c.subs, subject)
+				}
+			}
+			c.mu.Unlock()
+		case "PUB":
+			fields := strings.Fields(args)
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return
+			}
+			reply := ""
+			if len(fields) == 3 {
+				reply = fields[1]
+			}
+			if s.onPub != nil {
+				s.onPub(c, fields[0], reply, data[:size])
+			}
+		default:
+			s.t.Errorf("fake NATS: unexpected operation %q", line)
+		}
+	}
+}
+
+func (c *fakeNATSConn) write(data string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.w.WriteString(data)
+	return c.w.Flush()
+}
+
+// deliver 送 MSG 給訂閱 subject 的 subscription；沒有訂閱或連線已斷時回傳 false
+func (c *fakeNATSConn) deliver(subject, reply string, data []byte) bool {
+	c.mu.Lock()
+	sid, ok := c.subs[subject]
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+	header := "MSG " + subject + " " + sid
+	if reply != "" {
+		header += " " + reply
+	}
+	return c.write(header+" "+strconv.Itoa(len(data))+"\r\n"+string(data)+"\r\n") == nil
+}
+
+// deliverStatus 送只有 status header 的 HMSG（例如 JetStream pull 的 "404 No Messages"）
+func (c *fakeNATSConn) deliverStatus(subject, status string) bool {
+	c.mu.Lock()
+	sid, ok := c.subs[subject]
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+	hdr := "NATS/1.0 " + status + "\r\n\r\n"
+	return c.write(fmt.Sprintf("HMSG %s %s %d %d\r\n%s\r\n", subject, sid, len(hdr), len(hdr), hdr)) == nil
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestReadMsg(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers bool
+		line    string
+		payload string
+		want    natsMsg
+		wantSID uint64
+		wantErr bool
+	}{
+		{name: "msg", line: "foo 1 5", payload: "hello\r\n", want: natsMsg{Subject: "foo", Data: []byte("hello")}, wantSID: 1},
+		{name: "msg with reply", line: "foo 2 _INBOX.x 5", payload: "hello\r\n", want: natsMsg{Subject: "foo", Reply: "_INBOX.x", Data: []byte("hello")}, wantSID: 2},
+		{name: "empty msg", line: "foo 3 0", payload: "\r\n", want: natsMsg{Subject: "foo", Data: []byte{}}, wantSID: 3},
+		{name: "payload with crlf", line: "foo 1 4", payload: "a\r\nb\r\n", want: natsMsg{Subject: "foo", Data: []byte("a\r\nb")}, wantSID: 1},
+		{
+			name: "hmsg status", headers: true, line: "_INBOX.x 4 28 28",
+			payload: "NATS/1.0 404 No Messages\r\n\r\n\r\n",
+			want:    natsMsg{Subject: "_INBOX.x", Status: "404", Data: []byte{}}, wantSID: 4,
+		},
+		{
+			name: "hmsg with headers and payload", headers: true, line: "foo 5 $JS.ACK.S.C.1.1.1 25 30",
+			payload: "NATS/1.0\r\nNats-Seq: 1\r\n\r\nhello\r\n",
+			want:    natsMsg{Subject: "foo", Reply: "$JS.ACK.S.C.1.1.1", Data: []byte("hello")}, wantSID: 5,
+		},
+		{name: "missing size", line: "foo 1", wantErr: true},
+		{name: "bad sid", line: "foo x 5", payload: "hello\r\n", wantErr: true},
+		{name: "bad size", line: "foo 1 -1", wantErr: true},
+		{name: "header larger than total", headers: true, line: "foo 1 10 5", payload: "hello\r\n", wantErr: true},
+		{name: "truncated payload", line: "foo 1 10", payload: "hello", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tt.payload))
+			msg, sid, err := readMsg(r, tt.headers, strings.Fields(tt.line))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readMsg error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if sid != tt.wantSID || msg.Subject != tt.want.Subject || msg.Reply != tt.want.Reply || msg.Status != tt.want.Status || string(msg.Data) != string(tt.want.Data) {
+				t.Errorf("readMsg = %+v (sid %d), want %+v (sid %d)", msg, sid, tt.want, tt.wantSID)
+			}
+		})
+	}
+}
+
+func TestDialNATS(t *testing.T) {
+	tests := []struct {
+		name        string
+		userinfo    string
+		info        map[string]any
+		reject      bool
+		wantConnect map[string]any
+		wantErr     string
+	}{
+		{name: "anonymous", wantConnect: map[string]any{"headers": true, "no_responders": true, "verbose": false}},
+		{name: "user and password", userinfo: "bridge:s3cret@", wantConnect: map[string]any{"user": "bridge", "pass": "s3cret"}},
+		{name: "token", userinfo: "t0ken@", wantConnect: map[string]any{"auth_token": "t0ken"}},
+		{name: "rejected", reject: true, wantErr: "NATS connect rejected: 'Authorization Violation'"},
+		{name: "no headers", info: map[string]any{"headers": false}, wantErr: "does not support headers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeNATS(t, func(s *fakeNATS) {
+				for k, v := range tt.info {
+					s.info[k] = v
+				}
+				if tt.reject {
+					s.onConnect = func(map[string]any) error { return fmt.Errorf("Authorization Violation") }
+				}
+			})
+
+			c, err := dialNATS(testContext(t), "nats://"+tt.userinfo+s.ln.Addr().String(), nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("dialNATS error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("dialNATS: %v", err)
+			}
+			defer c.close()
+
+			connect := s.lastConnect()
+			for key, want := range tt.wantConnect {
+				if connect[key] != want {
+					t.Errorf("CONNECT %s = %v, want %v", key, connect[key], want)
+				}
+			}
+			if err := c.ping(testContext(t)); err != nil {
+				t.Errorf("ping: %v", err)
+			}
+		})
+	}
+}
+
+func TestDialNATSInvalidURL(t *testing.T) {
+	for _, rawURL := range []string{"localhost:4222", "http://localhost:4222", "nats://"} {
+		if _, err := dialNATS(testContext(t), rawURL, nil); err == nil {
+			t.Errorf("dialNATS(%q) succeeded, want an error", rawURL)
+		}
+	}
+}
+
+func TestDialNATSTLS(t *testing.T) {
+	// httptest 的憑證對 127.0.0.1 有效
+	certs := httptest.NewUnstartedServer(nil)
+	certs.StartTLS()
+	defer certs.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(certs.Certificate())
+
+	tests := []struct {
+		name        string
+		scheme      string
+		tlsRequired bool
+		roots       *x509.CertPool
+		wantErr     bool
+	}{
+		{name: "tls scheme", scheme: "tls", roots: roots},
+		{name: "server requires tls", scheme: "nats", tlsRequired: true, roots: roots},
+		{name: "untrusted certificate", scheme: "tls", roots: x509.NewCertPool(), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeNATS(t, func(s *fakeNATS) {
+				s.tls = certs.TLS
+				s.info["tls_required"] = tt.tlsRequired
+			})
+
+			c, err := dialNATS(testContext(t), tt.scheme+"://"+s.ln.Addr().String(), &tls.Config{RootCAs: tt.roots})
+			if tt.wantErr {
+				if err == nil {
+					c.close()
+					t.Fatal("dialNATS succeeded with an untrusted certificate")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("dialNATS: %v", err)
+			}
+			defer c.close()
+			if _, ok := c.conn.(*tls.Conn); !ok {
+				t.Errorf("connection is %T, want *tls.Conn", c.conn)
+			}
+			if err := c.ping(testContext(t)); err != nil {
+				t.Errorf("ping over TLS: %v", err)
+			}
+		})
+	}
+}
+
+func TestNATSRequest(t *testing.T) {
+	s := newFakeNATS(t, func(s *fakeNATS) {
+		s.onPub = func(c *fakeNATSConn, subject, reply string, data []byte) {
+			if subject == "echo" {
+				c.deliver(reply, "", append([]byte("echo: "), data...))
+			}
+		}
+	})
+	c, err := dialNATS(testContext(t), s.url(), nil)
+	if err != nil {
+		t.Fatalf("dialNATS: %v", err)
+	}
+	defer c.close()
+
+	msg, err := c.request(testContext(t), "echo", []byte("hi"))
+	if err != nil || string(msg.Data) != "echo: hi" {
+		t.Errorf("request = %q, %v, want %q", msg.Data, err, "echo: hi")
+	}
+
+	// 沒有人回覆時依 ctx 到期
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.request(ctx, "nobody", nil); err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("request without responder = %v, want a deadline error", err)
+	}
+}
+
+// TestNATSSlowSubscriber subscriber 讀得比 server 送得慢時不能丟訊息
+func TestNATSSlowSubscriber(t *testing.T) {
+	const count = 100
+	s := newFakeNATS(t, func(s *fakeNATS) {
+		s.onPub = func(c *fakeNATSConn, subject, reply string, data []byte) {
+			if subject == "start" {
+				for i := range count {
+					c.deliver("events", "", []byte(strconv.Itoa(i)))
+				}
+			}
+		}
+	})
+	c, err := dialNATS(testContext(t), s.url(), nil)
+	if err != nil {
+		t.Fatalf("dialNATS: %v", err)
+	}
+	defer c.close()
+
+	_, ch, err := c.subscribe("events", 1)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if err := c.ping(testContext(t)); err != nil { // 確定 SUB 已經送到
+		t.Fatalf("ping: %v", err)
+	}
+	if err := c.publish("start", "", nil); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	for i := range count {
+		select {
+		case msg := <-ch:
+			if string(msg.Data) != strconv.Itoa(i) {
+				t.Fatalf("message %d = %q", i, msg.Data)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of %d messages", i, count)
+		}
+		if i%10 == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+}
+
+// TestNATSUnsubscribeUnblocks unsubscribe 之後 read loop 不再等沒人讀的 subscription
+func TestNATSUnsubscribeUnblocks(t *testing.T) {
+	s := newFakeNATS(t, func(s *fakeNATS) {
+		s.onPub = func(c *fakeNATSConn, subject, reply string, data []byte) {
+			if subject == "start" {
+				for range 10 {
+					c.deliver("events", "", []byte("x"))
+				}
+			}
+		}
+	})
+	c, err := dialNATS(testContext(t), s.url(), nil)
+	if err != nil {
+		t.Fatalf("dialNATS: %v", err)
+	}
+	defer c.close()
+
+	sid, _, err := c.subscribe("events", 1)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	c.ping(testContext(t))
+	c.publish("start", "", nil)
+	time.Sleep(20 * time.Millisecond) // read loop 卡在第二則訊息
+	c.unsubscribe(sid)
+
+	if err := c.ping(testContext(t)); err != nil {
+		t.Errorf("ping after unsubscribe: %v", err)
+	}
+}
+
+func TestNATSConnectionLost(t *testing.T) {
+	s := newFakeNATS(t, nil)
+	c, err := dialNATS(testContext(t), s.url(), nil)
+	if err != nil {
+		t.Fatalf("dialNATS: %v", err)
+	}
+	defer c.close()
+	_, ch, err := c.subscribe("events", 1)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	c.ping(testContext(t))
+
+	s.dropAll()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("received a message after the connection was lost")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription was not closed after the connection was lost")
+	}
+	if !c.closed() {
+		t.Error("closed() = false after the connection was lost")
+	}
+	if err := c.ping(testContext(t)); err == nil {
+		t.Error("ping succeeded after the connection was lost")
+	}
+	if err := c.publish("events", "", []byte("x")); err == nil {
+		t.Error("publish succeeded after the connection was lost")
+	}
+}
@@ -2,62 +2,843 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port                string
-	Env                 string
-	DiscordBotToken     string
-	DiscordForumChID    string
-	GitHubWebhookSecret string
-	RedisURL            string
-	GitHubDiscordUserMap map[string]string // GitHub username → Discord user ID
+	Port                 string
+	Env                  string
+	DiscordBotToken      string
+	DiscordForumChID     string
+	GitHubWebhookSecret  string
+	RedisURL             string
+	StorageBackend       string // redis、sqlite、bolt、postgres
+	SQLitePath           string
+	BoltPath             string
+	PostgresURL          string
+	GitHubDiscordUserMap map[string]string // GitHub username（小寫）→ Discord user ID
+
+	// Discord API / interactions
+	DiscordAPIBaseURL    string
+	DiscordAPIVersion    int
+	DiscordPublicKey     string // Interactions endpoint 驗證簽名用（Developer Portal 的 Public Key）
+	DiscordApplicationID string
+	DiscordGuildID       string
+	DiscordCommandsFile  string // slash command 定義（JSON），空值使用內建預設
+	DiscordHTTPTimeout   time.Duration
+	BreakerThreshold     int // 連續失敗幾次後開啟 circuit breaker，0 = 不啟用
+	BreakerCooldown      time.Duration
+	DiscordPreflight     bool // 啟動時檢查 token / channel / 權限
+
+	// Announcement channel：特定事件額外發到 announcement channel（並 crosspost）
+	DiscordAnnouncementChID string
+	AnnouncementEvents      map[string]bool // "release"、"pull_request.merged" 這類 event key
+	AnnouncementCrosspost   bool
+
+	// Forum tag 外觀：repo 名稱 → emoji（unicode 或 custom emoji ID）
+	RepoTagEmojiMap  map[string]string
+	RepoTagModerated bool
+
+	// 自動把 assignee / reviewer 對應的 Discord 使用者加入 PR thread（需設定 GITHUB_DISCORD_USER_MAP）
+	AddThreadMembers bool
+
+	// Forum post reaction：default_reaction_emoji 和依事件自動加上的 reaction（簡易投票）
+	DiscordDefaultReaction string            // 空值 = 不修改 forum channel 設定
+	EventReactions         map[string]string // event key（例如 "pull_request.opened"）→ emoji
+
+	// 各 repo 專用的 webhook secret："owner/repo" 或 "owner" → secret，沒對應到的用 GitHubWebhookSecret
+	GitHubWebhookRepoSecrets map[string]string
+	// secret 輪替期間同時接受的第二把預設 secret
+	GitHubWebhookSecondarySecret string
+
+	// Push 事件：發到 repo 的 activity thread
+	DiscordActivityThreadID string // 設定後所有 repo 的 activity 都發到這個 thread，空值 = 每個 repo 自動建一個
+	PushMaxCommits          int    // commit 清單最多列幾筆
+
+	// Release 發布時在 forum 建立 release thread（false 時只發 announcement）
+	ReleaseThreads bool
+
+	// CI（workflow_run）只通知失敗，減少成功通知的雜訊
+	CIFailuresOnly bool
+
+	// Deployment 通知：environment → channel ID，"*" 為預設；沒對應到時發到 repo 的 activity thread
+	DeploymentChannels map[string]string
+
+	// star / fork / watch：批次間隔（例如 24h），0 = 每個事件即時通知
+	CommunityBatchInterval time.Duration
+
+	// GitHub label → forum tag 名稱，只有列出的 label 會同步成 thread 的 tag
+	LabelTagMap map[string]string
+
+	// 在 repo 的 activity thread 釘選並持續更新 milestone 進度訊息
+	MilestonePin bool
+
+	// Branch / tag 建立、刪除通知的過濾條件
+	RefEventTypes    map[string]bool // "branch"、"tag"
+	RefEventPatterns []string        // glob（例如 "release/*"、"v*"），空值 = 不過濾名稱
+
+	// 安全性通知（dependabot / code scanning / secret scanning）
+	SecurityChannelID string // 空值 = 發到 repo 的 activity thread
+	SecurityRoleID    string // critical 時 mention 的 role
+
+	// 收到 ping（新增 webhook）時在 Discord 發確認訊息，方便驗證整條路徑
+	PingConfirmation bool
+
+	// 各 event 要處理 / 略過哪些 action（例如 issues 只要 opened、closed）
+	EventActionFilters map[string]ActionFilter
+
+	// 要處理的 repo（glob，例如 "myorg/*"），allowlist 空值 = 全部；blocklist 優先
+	RepoAllowlist []string
+	RepoBlocklist []string
+
+	// push / CI 事件的 branch 過濾：repo（"owner/repo"、"owner" 或 "*"）→ branch glob
+	BranchFilters map[string][]string
+
+	// sender 過濾（login 轉小寫）：ignored 直接丟掉；low-priority 改發一行摘要到另一個 channel
+	IgnoredSenders       map[string]bool
+	LowPrioritySenders   map[string]bool
+	LowPriorityChannelID string
+
+	// GitHub App 模式：以 App 身分呼叫 API（installation token），不需要 personal access token
+	GitHubAppID             string
+	GitHubAppPrivateKey     string // PEM 內容；和 path 擇一
+	GitHubAppPrivateKeyPath string
+	GitHubAPIURL            string
+
+	// GitHub API enrichment：personal access token（GitHub App 模式不需要），PR 開啟時列出的檔案數量
+	GitHubToken string
+	PRFilesMax  int
+
+	// 已處理的 GitHub delivery ID 保留多久（GitHub 最多可以 redeliver 3 天內的 delivery）
+	DeliveryDedupTTL time.Duration
+
+	// GitHub Enterprise Server：web 網址（產生連結用）和是否接受舊版 SHA-1 簽名
+	GitHubBaseURL         string
+	GitHubLegacySignature bool
+
+	// repo（"owner/repo"、"owner" 或 "*"）→ forum channel，org 層級 webhook 依 repo 分流到不同 forum
+	RepoForumChannels map[string]string
+
+	// PR 合併 / 關閉、issue 關閉時自動 archive thread（先貼「closed by」訊息再 archive）
+	AutoArchiveThreads bool
+
+	// 定期比對 GitHub 和 thread 的狀態並修正落差，0 = 不啟用
+	ReconcileInterval time.Duration
+
+	// 定期清理 mapping：thread 已被刪除的、關閉超過 GCClosedRetention 的，0 = 不啟用
+	GCInterval        time.Duration
+	GCClosedRetention time.Duration
+
+	// 改用 cron 排程（DISCORD_TIMEZONE 的時區）跑 reconcile / GC，例如 "0 3 * * *"；和 *_INTERVAL 只能設定一個
+	ReconcileSchedule string
+	GCSchedule        string
+
+	// 依 cron 排程在超過 StalePRAfter 沒有更新的 open PR thread 提醒 reviewer，空值 = 不啟用
+	StalePRSchedule string
+	StalePRAfter    time.Duration
+
+	// 依 cron 排程移除 forum 上沒有任何 thread 使用的 tag，TagGCKeep 列出的 tag 名稱不移除；空值 = 不啟用
+	TagGCSchedule string
+	TagGCKeep     []string
+
+	// 排程 job 每次執行前隨機延遲 0～SchedulerJitter，避免多個 job / instance 同時打 API
+	SchedulerJitter time.Duration
+
+	// 自訂 embed 格式：DISCORD_TEMPLATES（JSON，event key → template）和 DISCORD_TEMPLATES_DIR（<event key>.json）
+	TemplatesInline string
+	TemplatesDir    string
+
+	// 讓 Discord 使用者用 /github link 自己綁定 GitHub 帳號（補 GITHUB_DISCORD_USER_MAP 沒列到的人）
+	SelfServiceLinking bool
+
+	// bridged thread 裡的「Reply to GitHub」button / message command 把回覆貼成 GitHub 留言（需要 DISCORD_PUBLIC_KEY 和 GitHub 認證）
+	ReplyToGitHub bool
+	ReplyRoles    []string // 可以回覆到 GitHub 的 Discord role ID，空值 = 有對應 GitHub 帳號的人才可以
+
+	// event key → 顏色（"#RRGGBB" 等，見 discord.ParseColor），覆寫內建訊息的顏色
+	EventColors map[string]string
+
+	// thread 名稱的 emoji 前綴：event type（pull_request / issues / discussion / release）或 "label:<name>"（小寫）→ emoji
+	ThreadEmojis map[string]string
+	// embed 標題的 emoji（取代內建的 emoji）：event key 或 event type → emoji
+	EmbedEmojis map[string]string
+
+	// 同一個 thread 的改名和 tag 變更等這麼久沒有新的變更才一起送出（Discord 限制 10 分鐘修改 2 次），0 = 立即送出
+	ThreadEditDebounce time.Duration
+
+	// 產生訊息用的語系（en、zh-TW），見 i18n 套件
+	Locale string
+
+	// PR 開啟時在 thread 貼出 diff 的前幾個 hunk（0 = 不貼），只處理變更行數不超過 PRDiffMaxChanges 的小 PR
+	PRDiffHunks      int
+	PRDiffMaxChanges int
+
+	// issue / PR 內文和留言裡最多帶幾張圖到 embed（0 = 不帶）
+	BodyImagesMax int
+
+	// 訊息文字裡時間的顯示方式（見 discord.SetTimeFormat）
+	TimeStyle  string
+	Timezone   string
+	TimeLayout string
+
+	// 低優先事件改成依 cron 排程（DISCORD_TIMEZONE 時區）每個 repo 發一則摘要
+	// DigestSchedule 空字串 = 沒有預設排程；RepoDigestSchedules 的 key 為 repo / owner / "*"，值 "off" = 這個 repo 不用 digest
+	DigestSchedule      string
+	RepoDigestSchedules map[string]string
+	DigestEvents        map[string]bool // event key 或 type
+
+	// 設定檔有變更時自動 reload 的檢查間隔（0 = 只在收到 SIGHUP 時 reload）
+	ConfigWatchInterval time.Duration
+
+	// OpenTelemetry tracing（OTLP/HTTP），沒有設定 endpoint 時不啟用
+	OTelEndpoint    string            // OTEL_EXPORTER_OTLP_ENDPOINT
+	OTelServiceName string            // OTEL_SERVICE_NAME
+	OTelSampleRatio float64           // OTEL_TRACES_SAMPLER_ARG
+	OTelHeaders     map[string]string // OTEL_EXPORTER_OTLP_HEADERS
+
+	// 收到 SIGTERM 後最多等多久（處理中的 webhook、背景工作收尾），超過就直接結束
+	ShutdownTimeout time.Duration
+
+	// webhook worker pool：QueueWorkers > 0 時收到 webhook 立即回 202，背景處理
+	QueueWorkers      int
+	QueueSize         int
+	QueueMaxAttempts  int
+	QueueRetryBackoff time.Duration
+	QueuePath         string // queue 寫到磁碟的 bbolt 檔案，空字串 = 只在記憶體
+
+	// 每個 repo 的速率限制（token bucket）：每分鐘補充 RepoRateLimit 個（0 = 不限制），最多累積 RepoRateBurst 個
+	// 超過時 RepoRateCoalesceEvents（event key 或 type）的事件合併成一則摘要，每 RepoRateFlushInterval 發一次
+	RepoRateLimit          int
+	RepoRateBurst          int
+	RepoRateCoalesceEvents map[string]bool
+	RepoRateFlushInterval  time.Duration
+
+	// admin API 的 bearer token（/admin/*），空字串 = 不啟用
+	AdminToken string
+
+	// dry-run：照常處理事件，但不送出 Discord 的寫入 request，改記 log 並保留在 admin API（/admin/dry-run）
+	// DryRun 套用到所有 repo；DryRunRepos 為 repo pattern（同 GITHUB_REPO_ALLOWLIST，小寫）
+	DryRun      bool
+	DryRunRepos []string
+
+	// 保存每個 webhook 的原始 payload 和 header（`main replay-payloads` 重新處理），空字串 = 不保存
+	// RecordPayloadsMax 最多保留幾個檔案，超過時刪除最舊的；0 = 不限制
+	RecordPayloadsDir string
+	RecordPayloadsMax int
+
+	// 用 Let's Encrypt（ACME）自動取得憑證、直接在 PORT 提供 HTTPS；TLSAutocertDomains 為空 = 只提供 HTTP（前面有 reverse proxy）
+	// TLSHTTPPort 另外 listen 的 HTTP port（HTTP-01 challenge 和轉址到 HTTPS），空字串 = 不 listen，只用 TLS-ALPN-01
+	TLSAutocertDomains      []string
+	TLSAutocertEmail        string
+	TLSAutocertCacheDir     string
+	TLSAutocertDirectoryURL string // 空字串 = Let's Encrypt production
+	TLSHTTPPort             string
+
+	// webhook server 的保護：body 上限（超過回 413）和 net/http 的 timeout（0 = 不限制）
+	// WriteTimeout 同步處理（QUEUE_WORKERS=0）時要比處理一個事件的時間長
+	WebhookMaxBodySize    int64
+	HTTPReadHeaderTimeout time.Duration
+	HTTPReadTimeout       time.Duration
+	HTTPWriteTimeout      time.Duration
+	HTTPIdleTimeout       time.Duration
+
+	// 只接受 GitHub 的 webhook 來源 IP（定期從 GET /meta 的 hooks 更新），外加 GitHubIPAllowlistExtra（例如本機測試、GHES）
+	// TrustedProxies 前面的 reverse proxy / load balancer：有設定時依 X-Forwarded-For 判斷來源 IP，沒有設定時用連線的 IP
+	GitHubIPAllowlist        bool
+	GitHubIPAllowlistRefresh time.Duration
+	GitHubIPAllowlistExtra   []netip.Prefix
+	TrustedProxies           []string
+
+	// Secrets backend：DISCORD_BOT_TOKEN、GITHUB_WEBHOOK_SECRET 等從 Vault / AWS Secrets Manager 讀取（見 internal/secrets）
+	SecretsBackend         string        // vault、aws，空值 = 只用環境變數和設定檔
+	SecretsRefreshInterval time.Duration // 每隔多久重新讀取並 reload，0 = 只在啟動和 reload 時讀取
+
+	// HA_MODE：多個 replica 共用 store（redis / postgres），delivery 由 ClaimDelivery 保證只處理一次，
+	// reconcile、GC 等掃描整個 store 的背景工作只在 leader（透過 store 的 lease 選出）上跑
+	HAMode      bool
+	HAReplicaID string        // 空值 = hostname
+	HALeaseTTL  time.Duration // leader 沒續約多久後由其他 replica 接手
+
+	// Message broker：receiver（只驗證簽名後發到 broker）和 publisher（訂閱後處理）分成兩個 process
+	IngestMode   string // 空值 = 同一個 process 收和處理；receiver、publisher
+	Broker       string // nats（JetStream）、kafka（Confluent REST Proxy）
+	BrokerURL    string
+	BrokerTopic  string // NATS subject / Kafka topic
+	BrokerStream string // JetStream stream，不存在時自動建立
+	BrokerGroup  string // publisher 的 consumer group / durable consumer
+	BrokerBatch  int
+}
+
+// INGEST_MODE 的值
+const (
+	IngestReceiver  = "receiver"
+	IngestPublisher = "publisher"
+)
+
+// current 目前的設定；reload 時整個換掉，已經拿到舊設定的 request 照舊處理完
+var current atomic.Pointer[Config]
+
+// Current 目前的設定（Load 之前為 nil）；同一個 request 內要一致時先存成變數再用
+func Current() *Config {
+	return current.Load()
+}
+
+var (
+	reloadMu    sync.Mutex
+	loadedPath  string          // Load 時的 path，Reload 重讀同一個檔案
+	loadedFiles []string        // 實際讀到的設定檔（watch 用）
+	fileKeys    map[string]bool // 從設定檔寫入的環境變數，Reload 時先清掉再重讀
+	secretKeys  map[string]bool // 從 secrets backend 寫入的環境變數，Reload 時先清掉再重讀
+)
+
+// Load 讀取設定：先載入設定檔（.yaml / .yml 為 YAML 設定檔，其他為 env 檔），
+// path 為空字串時讀 .env 和 config.yaml（不存在也沒關係）
+// 已經存在的環境變數優先於檔案裡的值；有設定 SECRETS_BACKEND 時 secret 的值優先於檔案（但不蓋掉已經存在的環境變數），
+// 設定檔有錯或讀不到 secret 時回傳 error，
+// 缺少必填的變數或值的格式不對時回傳列出所有問題的 *ValidationError
+func Load(path string) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	loadedPath = path
+	cfg, err := load(path, fetchSecrets)
+	if err != nil {
+		return err
+	}
+	current.Store(cfg)
+
+	if cfg.Env == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	} else {
+		gin.SetMode(gin.DebugMode)
+	}
+	return nil
+}
+
+// Reload 重讀 Load 時的設定檔和環境變數，回傳新的設定但還不套用（呼叫端檢查過後再呼叫 Swap）
+func Reload() (*Config, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	// 先讀 secrets backend（用目前的連線設定），讀不到時直接回傳 error，環境變數和 secretKeys 維持原狀
+	values, err := fetchSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	// 上次從檔案寫入的值要清掉，不然會被當成已經存在的環境變數而蓋掉檔案裡的新值
+	for key := range fileKeys {
+		os.Unsetenv(key)
+	}
+	for key := range secretKeys {
+		os.Unsetenv(key)
+	}
+	return load(loadedPath, func() (map[string]string, error) { return values, nil })
+}
+
+// Swap 換成 cfg 並回傳原本的設定
+func Swap(cfg *Config) (old *Config) {
+	return current.Swap(cfg)
 }
 
-var AppConfig *Config
+// Files Load 時讀到的設定檔路徑
+func Files() []string {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	return slices.Clone(loadedFiles)
+}
+
+// setFromFile 把設定檔的值寫進環境變數，已經存在的環境變數優先
+func setFromFile(env map[string]string) {
+	for key, value := range env {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		os.Setenv(key, value)
+		fileKeys[key] = true
+	}
+}
 
-func Load() {
-	err := godotenv.Load()
+// load 讀設定檔後呼叫 secrets 取得 secret 寫進環境變數（Load 時在讀完設定檔後才連 secrets backend），再解析設定
+func load(path string, secrets func() (map[string]string, error)) (*Config, error) {
+	fileKeys = make(map[string]bool)
+	loadedFiles = nil
+	switch {
+	case IsFile(path):
+		if err := applyFile(path); err != nil {
+			return nil, err
+		}
+		loadedFiles = append(loadedFiles, path)
+	case path != "":
+		env, err := godotenv.Read(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", path, err)
+		}
+		setFromFile(env)
+		loadedFiles = append(loadedFiles, path)
+	default:
+		if env, err := godotenv.Read(); err != nil {
+			log.Println("No .env file found")
+		} else {
+			setFromFile(env)
+			loadedFiles = append(loadedFiles, ".env")
+		}
+		if _, err := os.Stat(DefaultFile); err == nil {
+			if err := applyFile(DefaultFile); err != nil {
+				return nil, err
+			}
+			loadedFiles = append(loadedFiles, DefaultFile)
+		}
+	}
+	values, err := secrets()
 	if err != nil {
-		log.Println("No .env file found")
+		return nil, err
+	}
+	applySecrets(values)
+
+	problems = nil
+
+	// receiver 只驗證簽名後發到 broker，不需要 Discord 和 storage 的設定
+	ingestMode := strings.ToLower(getEnv("INGEST_MODE", ""))
+	requireDiscord := requireEnv
+	if ingestMode == IngestReceiver {
+		requireDiscord = func(key string) string { return getEnv(key, "") }
 	}
 
-	AppConfig = &Config{
+	cfg := &Config{
 		Port:                 getEnv("PORT", "3000"),
 		Env:                  getEnv("ENV", "development"),
-		DiscordBotToken:      requireEnv("DISCORD_BOT_TOKEN"),
-		DiscordForumChID:     requireEnv("DISCORD_FORUM_CHANNEL_ID"),
+		DiscordBotToken:      requireDiscord("DISCORD_BOT_TOKEN"),
+		DiscordForumChID:     requireDiscord("DISCORD_FORUM_CHANNEL_ID"),
 		GitHubWebhookSecret:  getEnv("GITHUB_WEBHOOK_SECRET", ""),
-		RedisURL:             requireEnv("REDIS_URL"),
-		GitHubDiscordUserMap: parseUserMap(getEnv("GITHUB_DISCORD_USER_MAP", "{}")),
+		RedisURL:             getEnv("REDIS_URL", ""),
+		StorageBackend:       getEnv("STORAGE_BACKEND", "redis"),
+		SQLitePath:           getEnv("SQLITE_PATH", "data/bridge.db"),
+		BoltPath:             getEnv("BOLT_PATH", "data/bridge.bolt"),
+		PostgresURL:          getEnv("POSTGRES_URL", ""),
+		GitHubDiscordUserMap: lowerKeys(parseStringMap("GITHUB_DISCORD_USER_MAP", getEnv("GITHUB_DISCORD_USER_MAP", "{}"))),
+
+		DiscordAPIBaseURL:    getEnv("DISCORD_API_BASE_URL", "https://discord.com/api"),
+		DiscordAPIVersion:    getEnvInt("DISCORD_API_VERSION", 10),
+		DiscordPublicKey:     getEnv("DISCORD_PUBLIC_KEY", ""),
+		DiscordApplicationID: getEnv("DISCORD_APPLICATION_ID", ""),
+		DiscordGuildID:       getEnv("DISCORD_GUILD_ID", ""),
+		DiscordCommandsFile:  getEnv("DISCORD_COMMANDS_FILE", ""),
+		DiscordHTTPTimeout:   getEnvDuration("DISCORD_HTTP_TIMEOUT", 10*time.Second),
+		BreakerThreshold:     getEnvInt("DISCORD_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:      getEnvDuration("DISCORD_BREAKER_COOLDOWN", 30*time.Second),
+		DiscordPreflight:     getEnvBool("DISCORD_PREFLIGHT", true),
+
+		DiscordAnnouncementChID: getEnv("DISCORD_ANNOUNCEMENT_CHANNEL_ID", ""),
+		AnnouncementEvents:      parseSet(getEnv("DISCORD_ANNOUNCEMENT_EVENTS", "")),
+		AnnouncementCrosspost:   getEnvBool("DISCORD_ANNOUNCEMENT_CROSSPOST", true),
+
+		RepoTagEmojiMap:  parseStringMap("DISCORD_REPO_TAG_EMOJI_MAP", getEnv("DISCORD_REPO_TAG_EMOJI_MAP", "{}")),
+		RepoTagModerated: getEnvBool("DISCORD_REPO_TAG_MODERATED", false),
+
+		AddThreadMembers: getEnvBool("DISCORD_ADD_THREAD_MEMBERS", true),
+
+		DiscordDefaultReaction: getEnv("DISCORD_DEFAULT_REACTION_EMOJI", ""),
+		EventReactions:         parseStringMap("DISCORD_EVENT_REACTIONS", getEnv("DISCORD_EVENT_REACTIONS", "{}")),
+
+		GitHubWebhookRepoSecrets:     parseStringMap("GITHUB_WEBHOOK_REPO_SECRETS", getEnv("GITHUB_WEBHOOK_REPO_SECRETS", "{}")),
+		GitHubWebhookSecondarySecret: getEnv("GITHUB_WEBHOOK_SECRET_SECONDARY", ""),
+
+		DiscordActivityThreadID: getEnv("DISCORD_ACTIVITY_THREAD_ID", ""),
+		PushMaxCommits:          getEnvInt("DISCORD_PUSH_MAX_COMMITS", 10),
+
+		ReleaseThreads: getEnvBool("DISCORD_RELEASE_THREADS", true),
+
+		CIFailuresOnly: getEnvBool("DISCORD_CI_FAILURES_ONLY", false),
+
+		DeploymentChannels: parseStringMap("DISCORD_DEPLOYMENT_CHANNELS", getEnv("DISCORD_DEPLOYMENT_CHANNELS", "{}")),
+
+		CommunityBatchInterval: getEnvDuration("DISCORD_COMMUNITY_BATCH_INTERVAL", 0),
+
+		LabelTagMap: parseStringMap("DISCORD_LABEL_TAG_MAP", getEnv("DISCORD_LABEL_TAG_MAP", "{}")),
+
+		MilestonePin: getEnvBool("DISCORD_MILESTONE_PIN", false),
+
+		RefEventTypes:    parseSet(getEnv("DISCORD_REF_EVENT_TYPES", "branch,tag")),
+		RefEventPatterns: parseList(getEnv("DISCORD_REF_EVENT_PATTERNS", "")),
+
+		SecurityChannelID: getEnv("DISCORD_SECURITY_CHANNEL_ID", ""),
+		SecurityRoleID:    getEnv("DISCORD_SECURITY_ROLE_ID", ""),
+
+		PingConfirmation: getEnvBool("DISCORD_PING_CONFIRMATION", false),
+
+		EventActionFilters: parseActionFilters("DISCORD_EVENT_ACTION_FILTERS", getEnv("DISCORD_EVENT_ACTION_FILTERS", "{}")),
+
+		RepoAllowlist: parseList(strings.ToLower(getEnv("GITHUB_REPO_ALLOWLIST", ""))),
+		RepoBlocklist: parseList(strings.ToLower(getEnv("GITHUB_REPO_BLOCKLIST", ""))),
+
+		BranchFilters: parseBranchFilters("DISCORD_BRANCH_FILTERS", getEnv("DISCORD_BRANCH_FILTERS", "{}")),
+
+		IgnoredSenders:       parseSet(strings.ToLower(getEnv("DISCORD_IGNORED_SENDERS", ""))),
+		LowPrioritySenders:   parseSet(strings.ToLower(getEnv("DISCORD_LOW_PRIORITY_SENDERS", ""))),
+		LowPriorityChannelID: getEnv("DISCORD_LOW_PRIORITY_CHANNEL_ID", ""),
+
+		GitHubAppID:             getEnv("GITHUB_APP_ID", ""),
+		GitHubAppPrivateKey:     strings.ReplaceAll(getEnv("GITHUB_APP_PRIVATE_KEY", ""), `\n`, "\n"),
+		GitHubAppPrivateKeyPath: getEnv("GITHUB_APP_PRIVATE_KEY_PATH", ""),
+		GitHubAPIURL:            getEnv("GITHUB_API_URL", ""),
+
+		GitHubToken: getEnv("GITHUB_TOKEN", ""),
+		PRFilesMax:  getEnvInt("DISCORD_PR_FILES_MAX", 10),
+
+		DeliveryDedupTTL: getEnvDuration("GITHUB_DELIVERY_DEDUP_TTL", 72*time.Hour),
+
+		GitHubBaseURL:         strings.TrimRight(getEnv("GITHUB_BASE_URL", "https://github.com"), "/"),
+		GitHubLegacySignature: getEnvBool("GITHUB_WEBHOOK_LEGACY_SIGNATURE", false),
+
+		RepoForumChannels: lowerKeys(parseStringMap("DISCORD_REPO_FORUM_CHANNELS", getEnv("DISCORD_REPO_FORUM_CHANNELS", "{}"))),
+
+		AutoArchiveThreads: getEnvBool("DISCORD_AUTO_ARCHIVE", true),
+
+		ReconcileInterval: getEnvDuration("RECONCILE_INTERVAL", 0),
+
+		GCInterval:        getEnvDuration("GC_INTERVAL", 0),
+		GCClosedRetention: getEnvDuration("GC_CLOSED_RETENTION", 72*time.Hour),
+
+		ReconcileSchedule: getEnv("RECONCILE_SCHEDULE", ""),
+		GCSchedule:        getEnv("GC_SCHEDULE", ""),
+		StalePRSchedule:   getEnv("STALE_PR_SCHEDULE", ""),
+		StalePRAfter:      getEnvDuration("STALE_PR_AFTER", 7*24*time.Hour),
+		TagGCSchedule:     getEnv("TAG_GC_SCHEDULE", ""),
+		TagGCKeep:         parseList(getEnv("TAG_GC_KEEP", "")),
+		SchedulerJitter:   getEnvDuration("SCHEDULER_JITTER", 30*time.Second),
+
+		TemplatesInline: getEnv("DISCORD_TEMPLATES", ""),
+		TemplatesDir:    getEnv("DISCORD_TEMPLATES_DIR", ""),
+
+		SelfServiceLinking: getEnvBool("DISCORD_SELF_SERVICE_LINKING", false),
+
+		ReplyToGitHub: getEnvBool("DISCORD_REPLY_TO_GITHUB", false),
+		ReplyRoles:    parseList(getEnv("DISCORD_REPLY_ROLES", "")),
+
+		EventColors: parseStringMap("DISCORD_EVENT_COLORS", getEnv("DISCORD_EVENT_COLORS", "{}")),
+
+		ThreadEmojis: lowerKeys(parseStringMap("DISCORD_THREAD_EMOJIS", getEnv("DISCORD_THREAD_EMOJIS", "{}"))),
+		EmbedEmojis:  parseStringMap("DISCORD_EMBED_EMOJIS", getEnv("DISCORD_EMBED_EMOJIS", "{}")),
+
+		ThreadEditDebounce: getEnvDuration("DISCORD_THREAD_EDIT_DEBOUNCE", 10*time.Second),
+
+		Locale: getEnv("DISCORD_LOCALE", "en"),
+
+		PRDiffHunks:      getEnvInt("DISCORD_PR_DIFF_HUNKS", 0),
+		PRDiffMaxChanges: getEnvInt("DISCORD_PR_DIFF_MAX_CHANGES", 300),
+
+		BodyImagesMax: getEnvInt("DISCORD_BODY_IMAGES_MAX", 4),
+
+		TimeStyle:  getEnv("DISCORD_TIME_STYLE", "relative"),
+		Timezone:   getEnv("DISCORD_TIMEZONE", "UTC"),
+		TimeLayout: getEnv("DISCORD_TIME_LAYOUT", "2006-01-02 15:04 MST"),
+
+		DigestSchedule:      getEnv("DISCORD_DIGEST_SCHEDULE", ""),
+		RepoDigestSchedules: lowerKeys(parseStringMap("DISCORD_REPO_DIGEST_SCHEDULES", getEnv("DISCORD_REPO_DIGEST_SCHEDULES", "{}"))),
+		DigestEvents:        parseSet(getEnv("DISCORD_DIGEST_EVENTS", "star,fork,watch,push,issue_comment,pull_request_review_comment,discussion_comment")),
+
+		ConfigWatchInterval: getEnvDuration("CONFIG_WATCH_INTERVAL", 0),
+
+		OTelEndpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		OTelServiceName: getEnv("OTEL_SERVICE_NAME", "github-discord-bridge"),
+		OTelSampleRatio: getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+		OTelHeaders:     parseKeyValues("OTEL_EXPORTER_OTLP_HEADERS", os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")),
+
+		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+
+		QueueWorkers:      getEnvInt("QUEUE_WORKERS", 0),
+		QueueSize:         getEnvInt("QUEUE_SIZE", 1000),
+		QueueMaxAttempts:  getEnvInt("QUEUE_MAX_ATTEMPTS", 3),
+		QueueRetryBackoff: getEnvDuration("QUEUE_RETRY_BACKOFF", 2*time.Second),
+		QueuePath:         os.Getenv("QUEUE_PATH"),
+
+		RepoRateLimit:          getEnvInt("REPO_RATE_LIMIT", 0),
+		RepoRateBurst:          getEnvInt("REPO_RATE_BURST", 20),
+		RepoRateCoalesceEvents: parseSet(getEnv("REPO_RATE_COALESCE_EVENTS", "push,issue_comment,pull_request_review_comment,discussion_comment,pull_request.labeled,pull_request.unlabeled,issues.labeled,issues.unlabeled,pull_request.synchronize,create,delete,star,fork,watch")),
+		RepoRateFlushInterval:  getEnvDuration("REPO_RATE_FLUSH_INTERVAL", time.Minute),
+
+		AdminToken: os.Getenv("ADMIN_TOKEN"),
+
+		DryRun:      getEnvBool("DRY_RUN", false),
+		DryRunRepos: parseList(strings.ToLower(getEnv("DRY_RUN_REPOS", ""))),
+
+		RecordPayloadsDir: getEnv("RECORD_PAYLOADS_DIR", ""),
+		RecordPayloadsMax: getEnvInt("RECORD_PAYLOADS_MAX", 1000),
+
+		TLSAutocertDomains:      parseList(strings.ToLower(getEnv("TLS_AUTOCERT_DOMAINS", ""))),
+		TLSAutocertEmail:        getEnv("TLS_AUTOCERT_EMAIL", ""),
+		TLSAutocertCacheDir:     getEnv("TLS_AUTOCERT_CACHE_DIR", "./data/autocert"),
+		TLSAutocertDirectoryURL: getEnv("TLS_AUTOCERT_DIRECTORY_URL", ""),
+		TLSHTTPPort:             getEnv("TLS_HTTP_PORT", "80"),
+
+		WebhookMaxBodySize:    getEnvSize("WEBHOOK_MAX_BODY_SIZE", 25<<20),
+		HTTPReadHeaderTimeout: getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 10*time.Second),
+		HTTPReadTimeout:       getEnvDuration("HTTP_READ_TIMEOUT", 30*time.Second),
+		HTTPWriteTimeout:      getEnvDuration("HTTP_WRITE_TIMEOUT", 60*time.Second),
+		HTTPIdleTimeout:       getEnvDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+
+		GitHubIPAllowlist:        getEnvBool("GITHUB_IP_ALLOWLIST", false),
+		GitHubIPAllowlistRefresh: getEnvDuration("GITHUB_IP_ALLOWLIST_REFRESH", time.Hour),
+		GitHubIPAllowlistExtra:   parsePrefixes("GITHUB_IP_ALLOWLIST_EXTRA", getEnv("GITHUB_IP_ALLOWLIST_EXTRA", "")),
+		TrustedProxies:           parseList(getEnv("TRUSTED_PROXIES", "")),
+
+		SecretsBackend:         strings.ToLower(getEnv("SECRETS_BACKEND", "")),
+		SecretsRefreshInterval: getEnvDuration("SECRETS_REFRESH_INTERVAL", 0),
+
+		HAMode:      getEnvBool("HA_MODE", false),
+		HAReplicaID: getEnv("HA_REPLICA_ID", ""),
+		HALeaseTTL:  getEnvDuration("HA_LEASE_TTL", 15*time.Second),
+
+		IngestMode:   ingestMode,
+		Broker:       strings.ToLower(getEnv("BROKER", "")),
+		BrokerURL:    getEnv("BROKER_URL", ""),
+		BrokerTopic:  getEnv("BROKER_TOPIC", "github.webhooks"),
+		BrokerStream: getEnv("BROKER_STREAM", "GITHUB_WEBHOOKS"),
+		BrokerGroup:  getEnv("BROKER_GROUP", "github-discord-bridge"),
+		BrokerBatch:  getEnvInt("BROKER_BATCH", 10),
 	}
 
-	if AppConfig.Env == "production" {
-		gin.SetMode(gin.ReleaseMode)
-	} else {
-		gin.SetMode(gin.DebugMode)
+	switch {
+	case ingestMode == IngestReceiver:
+	case cfg.StorageBackend == "redis":
+		cfg.RedisURL = requireEnv("REDIS_URL")
+	case cfg.StorageBackend == "postgres":
+		cfg.PostgresURL = requireEnv("POSTGRES_URL")
+	}
+
+	// GHES 的 API 在 <host>/api/v3，沒有另外設定 GITHUB_API_URL 時從 GITHUB_BASE_URL 推導
+	if cfg.GitHubAPIURL == "" {
+		cfg.GitHubAPIURL = "https://api.github.com"
+		if cfg.GitHubBaseURL != "https://github.com" {
+			cfg.GitHubAPIURL = cfg.GitHubBaseURL + "/api/v3"
+		}
+	}
+
+	validate(cfg)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// parseStringMap 解析 JSON object 格式的 env（例如 GITHUB_DISCORD_USER_MAP）
+func parseStringMap(key, raw string) map[string]string {
+	m := make(map[string]string)
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		addProblem("%s is not a valid JSON object of strings: %v", key, err)
+	}
+	return m
+}
+
+// parseKeyValues 解析 "key1=value1,key2=value2"（OTEL_EXPORTER_OTLP_HEADERS 的格式，值可以 URL encode）
+func parseKeyValues(key, raw string) map[string]string {
+	m := make(map[string]string)
+	for _, pair := range parseList(raw) {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			addProblem("%s: %q is not in key=value format", key, pair)
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(strings.TrimSpace(value)); err == nil {
+			value = unescaped
+		}
+		m[strings.TrimSpace(name)] = value
 	}
+	return m
 }
 
+// ActionFilter 單一 event 的 action 過濾規則
+type ActionFilter struct {
+	Include map[string]bool // 非空時只處理這些 action
+	Exclude map[string]bool // 略過的 action
+}
+
+// Allows action 是否要處理；沒有 action 的 event（例如 push）一律處理
+func (f ActionFilter) Allows(action string) bool {
+	if action == "" {
+		return true
+	}
+	if f.Exclude[action] {
+		return false
+	}
+	return len(f.Include) == 0 || f.Include[action]
+}
+
+// parseActionFilters 解析 JSON（event → 逗號分隔的 action），"!" 開頭代表排除
+// 例如 {"issues": "opened,closed,reopened", "pull_request": "!synchronize"}
+func parseActionFilters(key, raw string) map[string]ActionFilter {
+	filters := make(map[string]ActionFilter)
+	for event, actions := range parseStringMap(key, raw) {
+		filter := ActionFilter{Include: make(map[string]bool), Exclude: make(map[string]bool)}
+		for action := range parseSet(actions) {
+			if excluded, ok := strings.CutPrefix(action, "!"); ok {
+				filter.Exclude[excluded] = true
+			} else {
+				filter.Include[action] = true
+			}
+		}
+		filters[event] = filter
+	}
+	return filters
+}
+
+// parseBranchFilters 解析 JSON（repo → 逗號分隔的 branch glob），repo key 轉小寫
+// 例如 {"myorg/api": "main,release/*", "*": "main"}
+func parseBranchFilters(key, raw string) map[string][]string {
+	filters := make(map[string][]string)
+	for repo, branches := range parseStringMap(key, raw) {
+		filters[strings.ToLower(repo)] = parseList(branches)
+	}
+	return filters
+}
+
+// parsePrefixes 逗號分隔的 CIDR 或單一 IP（視為 /32、/128）
+func parsePrefixes(key, raw string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, item := range parseList(raw) {
+		if addr, err := netip.ParseAddr(item); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			addProblem("%s entry %q is not an IP address or CIDR range", key, item)
+			continue
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
+
+// lowerKeys 把 map 的 key 轉小寫（repo 名稱比對不分大小寫）
+func lowerKeys(m map[string]string) map[string]string {
+	lowered := make(map[string]string, len(m))
+	for k, v := range m {
+		lowered[strings.ToLower(k)] = v
+	}
+	return lowered
+}
+
+// parseSet 解析逗號分隔的清單（例如 "release,pull_request.merged"）成 set
+func parseSet(raw string) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			set[item] = true
+		}
+	}
+	return set
+}
+
+// parseList 解析逗號分隔的清單，保留順序
+func parseList(raw string) []string {
+	var list []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		addProblem("%s=%q is not a valid duration (e.g. 30s, 5m, 1h)", key, value)
+		return defaultValue
+	}
+	return d
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		addProblem("%s=%q is not a valid integer", key, value)
+		return defaultValue
+	}
+	return n
+}
+
+// getEnvSize byte 數，可以加 KB / MB / GB（1024 進位）後綴，例如 512KB、25MB；不加後綴為 byte
+func getEnvSize(key string, defaultValue int64) int64 {
+	value := strings.ToUpper(strings.TrimSpace(os.Getenv(key)))
+	if value == "" {
+		return defaultValue
+	}
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value, multiplier = strings.TrimSpace(number), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		addProblem("%s=%q is not a valid size (e.g. 512KB, 25MB)", key, os.Getenv(key))
+		return defaultValue
+	}
+	return n * multiplier
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		addProblem("%s=%q is not a valid number", key, value)
+		return defaultValue
+	}
+	return f
+}
+
+// requireEnv 必填的變數，沒有設定時記錄問題
 func requireEnv(key string) string {
 	value := os.Getenv(key)
 	if value == "" {
-		log.Fatalf("Env variable %s is required but not set", key)
+		addProblem("%s is required but not set", key)
 	}
 	return value
 }
 
-func parseUserMap(raw string) map[string]string {
-	m := make(map[string]string)
-	if err := json.Unmarshal([]byte(raw), &m); err != nil {
-		log.Printf("Warning: failed to parse GITHUB_DISCORD_USER_MAP: %v", err)
+// getEnvBool 接受 true / false / 1 / 0 等 strconv.ParseBool 的格式
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
 	}
-	return m
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		addProblem("%s=%q must be true or false", key, value)
+		return defaultValue
+	}
+	return b
 }
 
 func getEnv(key, defaultValue string) string {
@@ -0,0 +1,419 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile 沒有指定 --config 時，目前目錄有這個檔案就載入
+const DefaultFile = "config.yaml"
+
+// File config.yaml 的內容，每個欄位對應一個（或一組）環境變數，沒給的欄位沿用環境變數 / 預設值
+//
+//	discord:
+//	  channels: {forum: "1234567890123456789", announcement: "2345678901234567890"}
+//	secrets:
+//	  discord_bot_token: xxx
+//	repos:
+//	  myorg/api: {forum_channel: "3456789012345678901", branches: [main, release/*]}
+//	events:
+//	  actions: {pull_request: ["!synchronize"]}
+//	env:
+//	  DISCORD_PUSH_MAX_COMMITS: "10" # 其他沒有獨立欄位的設定
+type File struct {
+	Discord   FileDiscord         `yaml:"discord"`
+	GitHub    FileGitHub          `yaml:"github"`
+	Storage   FileStorage         `yaml:"storage"`
+	Repos     map[string]FileRepo `yaml:"repos"`
+	Events    FileEvents          `yaml:"events"`
+	Templates FileTemplates       `yaml:"templates"`
+	Secrets   FileSecrets         `yaml:"secrets"`
+	Env       map[string]string   `yaml:"env"`
+	Users     map[string]string   `yaml:"users"`  // GitHub login → Discord user ID
+	Labels    map[string]string   `yaml:"labels"` // label → forum tag 名稱
+	Deploy    map[string]string   `yaml:"deployment_channels"`
+}
+
+// FileDiscord discord 區塊
+type FileDiscord struct {
+	ApplicationID string       `yaml:"application_id"`
+	GuildID       string       `yaml:"guild_id"`
+	Locale        string       `yaml:"locale"`
+	Channels      FileChannels `yaml:"channels"`
+}
+
+// FileChannels 各類訊息發到哪個 channel
+type FileChannels struct {
+	Forum          string `yaml:"forum"`
+	ActivityThread string `yaml:"activity_thread"`
+	Announcement   string `yaml:"announcement"`
+	LowPriority    string `yaml:"low_priority"`
+	Security       string `yaml:"security"`
+}
+
+// FileGitHub github 區塊
+type FileGitHub struct {
+	BaseURL   string   `yaml:"base_url"`
+	APIURL    string   `yaml:"api_url"`
+	AppID     string   `yaml:"app_id"`
+	Allowlist []string `yaml:"repo_allowlist"`
+	Blocklist []string `yaml:"repo_blocklist"`
+}
+
+// FileStorage storage 區塊
+type FileStorage struct {
+	Backend     string `yaml:"backend"`
+	RedisURL    string `yaml:"redis_url"`
+	PostgresURL string `yaml:"postgres_url"`
+	SQLitePath  string `yaml:"sqlite_path"`
+	BoltPath    string `yaml:"bolt_path"`
+}
+
+// FileRepo repos 區塊的一個項目，key 為 "owner/repo"、"owner" 或 "*"
+type FileRepo struct {
+	ForumChannel  string   `yaml:"forum_channel"`
+	WebhookSecret string   `yaml:"webhook_secret"`
+	Branches      []string `yaml:"branches"`
+	Digest        string   `yaml:"digest"`
+}
+
+// FileEvents events 區塊
+type FileEvents struct {
+	Actions            map[string][]string `yaml:"actions"` // event → 要處理的 action，"!" 開頭代表排除
+	Colors             map[string]string   `yaml:"colors"`
+	Reactions          map[string]string   `yaml:"reactions"`
+	Announcement       []string            `yaml:"announcement"`
+	Digest             []string            `yaml:"digest"`
+	DigestSchedule     string              `yaml:"digest_schedule"`
+	IgnoredSenders     []string            `yaml:"ignored_senders"`
+	LowPrioritySenders []string            `yaml:"low_priority_senders"`
+}
+
+// FileTemplates templates 區塊，inline 的格式與 DISCORD_TEMPLATES 相同
+type FileTemplates struct {
+	Dir    string                  `yaml:"dir"`
+	Inline map[string]FileTemplate `yaml:"inline"`
+}
+
+// FileTemplate 一個 template（見 templates.Spec）
+type FileTemplate struct {
+	Title       string              `yaml:"title" json:"title,omitempty"`
+	Description string              `yaml:"description" json:"description,omitempty"`
+	URL         string              `yaml:"url" json:"url,omitempty"`
+	Color       string              `yaml:"color" json:"color,omitempty"`
+	Footer      string              `yaml:"footer" json:"footer,omitempty"`
+	Fields      []FileTemplateField `yaml:"fields" json:"fields,omitempty"`
+}
+
+// FileTemplateField template 的一個 embed field
+type FileTemplateField struct {
+	Name   string `yaml:"name" json:"name"`
+	Value  string `yaml:"value" json:"value"`
+	Inline bool   `yaml:"inline" json:"inline,omitempty"`
+}
+
+// FileSecrets secrets 區塊；檔案裡有 secret 時記得限制檔案權限，也可以只放在環境變數
+type FileSecrets struct {
+	DiscordBotToken         string `yaml:"discord_bot_token"`
+	DiscordPublicKey        string `yaml:"discord_public_key"`
+	GitHubWebhookSecret     string `yaml:"github_webhook_secret"`
+	GitHubSecondarySecret   string `yaml:"github_webhook_secret_secondary"`
+	GitHubToken             string `yaml:"github_token"`
+	GitHubAppPrivateKey     string `yaml:"github_app_private_key"`
+	GitHubAppPrivateKeyPath string `yaml:"github_app_private_key_path"`
+}
+
+var (
+	repoKeyPattern = regexp.MustCompile(`^(\*|[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)?)$`)
+	envKeyPattern  = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// storageBackends STORAGE_BACKEND 可用的值
+var storageBackends = []string{"redis", "sqlite", "bolt", "postgres"}
+
+// IsFile path 是否為 YAML 設定檔（其他副檔名視為 env 檔）
+func IsFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// LoadFile 解析並驗證 YAML 設定檔；錯誤訊息會標出檔名、行號和設定的 key（例如 repos.myorg/api.forum_channel）
+// 所有驗證錯誤一次回傳
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true) // 打錯的 key 直接報錯，不默默忽略
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	lines := make(map[string]int)
+	indexLines(&root, "", lines)
+
+	var errs []error
+	for _, e := range f.validate() {
+		if line, ok := lines[e.key]; ok {
+			errs = append(errs, fmt.Errorf("%s:%d: %s: %s", path, line, e.key, e.msg))
+		} else {
+			errs = append(errs, fmt.Errorf("%s: %s: %s", path, e.key, e.msg))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &f, nil
+}
+
+// indexLines 記錄每個 key 路徑（a.b.c）在檔案裡的行號
+func indexLines(node *yaml.Node, prefix string, lines map[string]int) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			indexLines(child, prefix, lines)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			lines[key] = node.Content[i].Line
+			indexLines(node.Content[i+1], key, lines)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			key := fmt.Sprintf("%s[%d]", prefix, i)
+			lines[key] = child.Line
+			indexLines(child, key, lines)
+		}
+	}
+}
+
+type fileError struct {
+	key, msg string
+}
+
+func (f *File) validate() []fileError {
+	var errs []fileError
+	fail := func(key, format string, args ...any) {
+		errs = append(errs, fileError{key: key, msg: fmt.Sprintf(format, args...)})
+	}
+	channel := func(key, id string) {
+		if id != "" && !discord.IsSnowflake(id) {
+			fail(key, "%q is not a Discord ID", id)
+		}
+	}
+	absURL := func(key, raw string) {
+		if raw == "" {
+			return
+		}
+		if u, err := url.Parse(raw); err != nil || u.Scheme == "" || u.Host == "" {
+			fail(key, "%q is not an absolute URL", raw)
+		}
+	}
+
+	channel("discord.application_id", f.Discord.ApplicationID)
+	channel("discord.guild_id", f.Discord.GuildID)
+	channel("discord.channels.forum", f.Discord.Channels.Forum)
+	channel("discord.channels.activity_thread", f.Discord.Channels.ActivityThread)
+	channel("discord.channels.announcement", f.Discord.Channels.Announcement)
+	channel("discord.channels.low_priority", f.Discord.Channels.LowPriority)
+	channel("discord.channels.security", f.Discord.Channels.Security)
+
+	absURL("github.base_url", f.GitHub.BaseURL)
+	absURL("github.api_url", f.GitHub.APIURL)
+	if f.GitHub.AppID != "" {
+		if _, err := strconv.ParseInt(f.GitHub.AppID, 10, 64); err != nil {
+			fail("github.app_id", "%q is not a number", f.GitHub.AppID)
+		}
+	}
+
+	if b := f.Storage.Backend; b != "" && !slices.Contains(storageBackends, b) {
+		fail("storage.backend", "%q is not one of %s", b, strings.Join(storageBackends, ", "))
+	}
+	absURL("storage.redis_url", f.Storage.RedisURL)
+	absURL("storage.postgres_url", f.Storage.PostgresURL)
+
+	for _, repo := range sortedKeys(f.Repos) {
+		key := "repos." + repo
+		if !repoKeyPattern.MatchString(repo) {
+			fail(key, "must be \"owner/repo\", \"owner\" or \"*\"")
+		}
+		channel(key+".forum_channel", f.Repos[repo].ForumChannel)
+	}
+	for _, id := range sortedKeys(f.Deploy) {
+		channel("deployment_channels."+id, f.Deploy[id])
+	}
+	for _, login := range sortedKeys(f.Users) {
+		channel("users."+login, f.Users[login])
+	}
+
+	for _, event := range sortedKeys(f.Events.Actions) {
+		for i, action := range f.Events.Actions[event] {
+			if strings.TrimPrefix(action, "!") == "" || strings.Contains(action, ",") {
+				fail(fmt.Sprintf("events.actions.%s[%d]", event, i), "%q is not a valid action", action)
+			}
+		}
+	}
+	for _, key := range sortedKeys(f.Events.Colors) {
+		if f.Events.Colors[key] == "" {
+			fail("events.colors."+key, "color must not be empty")
+		}
+	}
+
+	for _, key := range sortedKeys(f.Templates.Inline) {
+		for i, field := range f.Templates.Inline[key].Fields {
+			if field.Name == "" || field.Value == "" {
+				fail(fmt.Sprintf("templates.inline.%s.fields[%d]", key, i), "name and value are required")
+			}
+		}
+	}
+
+	if f.Secrets.GitHubAppPrivateKey != "" && f.Secrets.GitHubAppPrivateKeyPath != "" {
+		fail("secrets.github_app_private_key", "set either github_app_private_key or github_app_private_key_path, not both")
+	}
+
+	for _, key := range sortedKeys(f.Env) {
+		if !envKeyPattern.MatchString(key) {
+			fail("env."+key, "is not a valid environment variable name")
+		}
+	}
+	return errs
+}
+
+// Environ 把設定檔轉成環境變數（map 類的設定轉成 JSON、清單轉成逗號分隔），沒給的欄位不列出
+func (f *File) Environ() map[string]string {
+	env := make(map[string]string)
+	set := func(key, value string) {
+		if value != "" {
+			env[key] = value
+		}
+	}
+	setList := func(key string, list []string) {
+		set(key, strings.Join(list, ","))
+	}
+	setJSON := func(key string, v any, n int) {
+		if n == 0 {
+			return
+		}
+		data, _ := json.Marshal(v)
+		env[key] = string(data)
+	}
+
+	for key, value := range f.Env {
+		set(key, value)
+	}
+
+	set("DISCORD_APPLICATION_ID", f.Discord.ApplicationID)
+	set("DISCORD_GUILD_ID", f.Discord.GuildID)
+	set("DISCORD_LOCALE", f.Discord.Locale)
+	set("DISCORD_FORUM_CHANNEL_ID", f.Discord.Channels.Forum)
+	set("DISCORD_ACTIVITY_THREAD_ID", f.Discord.Channels.ActivityThread)
+	set("DISCORD_ANNOUNCEMENT_CHANNEL_ID", f.Discord.Channels.Announcement)
+	set("DISCORD_LOW_PRIORITY_CHANNEL_ID", f.Discord.Channels.LowPriority)
+	set("DISCORD_SECURITY_CHANNEL_ID", f.Discord.Channels.Security)
+
+	set("GITHUB_BASE_URL", f.GitHub.BaseURL)
+	set("GITHUB_API_URL", f.GitHub.APIURL)
+	set("GITHUB_APP_ID", f.GitHub.AppID)
+	setList("GITHUB_REPO_ALLOWLIST", f.GitHub.Allowlist)
+	setList("GITHUB_REPO_BLOCKLIST", f.GitHub.Blocklist)
+
+	set("STORAGE_BACKEND", f.Storage.Backend)
+	set("REDIS_URL", f.Storage.RedisURL)
+	set("POSTGRES_URL", f.Storage.PostgresURL)
+	set("SQLITE_PATH", f.Storage.SQLitePath)
+	set("BOLT_PATH", f.Storage.BoltPath)
+
+	forums, secrets, branches, digests := map[string]string{}, map[string]string{}, map[string]string{}, map[string]string{}
+	for repo, r := range f.Repos {
+		if r.ForumChannel != "" {
+			forums[repo] = r.ForumChannel
+		}
+		if r.WebhookSecret != "" {
+			secrets[repo] = r.WebhookSecret
+		}
+		if len(r.Branches) > 0 {
+			branches[repo] = strings.Join(r.Branches, ",")
+		}
+		if r.Digest != "" {
+			digests[repo] = r.Digest
+		}
+	}
+	setJSON("DISCORD_REPO_FORUM_CHANNELS", forums, len(forums))
+	setJSON("GITHUB_WEBHOOK_REPO_SECRETS", secrets, len(secrets))
+	setJSON("DISCORD_BRANCH_FILTERS", branches, len(branches))
+	setJSON("DISCORD_REPO_DIGEST_SCHEDULES", digests, len(digests))
+	setJSON("DISCORD_DEPLOYMENT_CHANNELS", f.Deploy, len(f.Deploy))
+	setJSON("GITHUB_DISCORD_USER_MAP", f.Users, len(f.Users))
+	setJSON("DISCORD_LABEL_TAG_MAP", f.Labels, len(f.Labels))
+
+	actions := make(map[string]string, len(f.Events.Actions))
+	for event, list := range f.Events.Actions {
+		actions[event] = strings.Join(list, ",")
+	}
+	setJSON("DISCORD_EVENT_ACTION_FILTERS", actions, len(actions))
+	setJSON("DISCORD_EVENT_COLORS", f.Events.Colors, len(f.Events.Colors))
+	setJSON("DISCORD_EVENT_REACTIONS", f.Events.Reactions, len(f.Events.Reactions))
+	setList("DISCORD_ANNOUNCEMENT_EVENTS", f.Events.Announcement)
+	setList("DISCORD_DIGEST_EVENTS", f.Events.Digest)
+	set("DISCORD_DIGEST_SCHEDULE", f.Events.DigestSchedule)
+	setList("DISCORD_IGNORED_SENDERS", f.Events.IgnoredSenders)
+	setList("DISCORD_LOW_PRIORITY_SENDERS", f.Events.LowPrioritySenders)
+
+	set("DISCORD_TEMPLATES_DIR", f.Templates.Dir)
+	setJSON("DISCORD_TEMPLATES", f.Templates.Inline, len(f.Templates.Inline))
+
+	set("DISCORD_BOT_TOKEN", f.Secrets.DiscordBotToken)
+	set("DISCORD_PUBLIC_KEY", f.Secrets.DiscordPublicKey)
+	set("GITHUB_WEBHOOK_SECRET", f.Secrets.GitHubWebhookSecret)
+	set("GITHUB_WEBHOOK_SECRET_SECONDARY", f.Secrets.GitHubSecondarySecret)
+	set("GITHUB_TOKEN", f.Secrets.GitHubToken)
+	set("GITHUB_APP_PRIVATE_KEY", f.Secrets.GitHubAppPrivateKey)
+	set("GITHUB_APP_PRIVATE_KEY_PATH", f.Secrets.GitHubAppPrivateKeyPath)
+	return env
+}
+
+// applyFile 載入 YAML 設定檔到環境變數，已經存在的環境變數優先（整個值，map 類的設定不合併）
+func applyFile(path string) error {
+	f, err := LoadFile(path)
+	if err != nil {
+		return err
+	}
+	setFromFile(f.Environ())
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/secrets"
+)
+
+// secretsFetchTimeout 啟動 / reload 時等 secrets backend 回應的時間
+const secretsFetchTimeout = 15 * time.Second
+
+// newSecretsSource 依 SECRETS_BACKEND 建立 secrets.Source，沒有設定時回傳 nil
+// backend 的連線設定只從環境變數和設定檔讀（不能放在 secret 裡）
+func newSecretsSource() (secrets.Source, error) {
+	switch backend := strings.ToLower(os.Getenv("SECRETS_BACKEND")); backend {
+	case "":
+		return nil, nil
+	case "vault":
+		v := &secrets.Vault{
+			Addr:      os.Getenv("VAULT_ADDR"),
+			Token:     os.Getenv("VAULT_TOKEN"),
+			TokenFile: os.Getenv("VAULT_TOKEN_FILE"),
+			Namespace: os.Getenv("VAULT_NAMESPACE"),
+			Path:      os.Getenv("SECRETS_VAULT_PATH"),
+		}
+		if v.Addr == "" || v.Path == "" {
+			return nil, fmt.Errorf("SECRETS_BACKEND=vault requires VAULT_ADDR and SECRETS_VAULT_PATH")
+		}
+		return v, nil
+	case "aws":
+		a := &secrets.AWS{
+			SecretID: os.Getenv("SECRETS_AWS_SECRET_ID"),
+			Region:   getEnv("AWS_REGION", os.Getenv("AWS_DEFAULT_REGION")),
+			Endpoint: os.Getenv("SECRETS_AWS_ENDPOINT"),
+		}
+		if a.SecretID == "" || a.Region == "" {
+			return nil, fmt.Errorf("SECRETS_BACKEND=aws requires SECRETS_AWS_SECRET_ID and AWS_REGION")
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("SECRETS_BACKEND=%q must be vault or aws", backend)
+	}
+}
+
+// fetchSecrets 從 secrets backend 讀取 secret（不寫進環境變數）；沒有設定 SECRETS_BACKEND 時回傳 nil
+func fetchSecrets() (map[string]string, error) {
+	source, err := newSecretsSource()
+	if err != nil || source == nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretsFetchTimeout)
+	defer cancel()
+	values, err := source.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secrets from %s: %w", source, err)
+	}
+	return values, nil
+}
+
+// applySecrets 把 fetchSecrets 讀到的 secret 寫進環境變數：蓋掉設定檔的值，但已經存在的環境變數優先
+func applySecrets(values map[string]string) {
+	secretKeys = make(map[string]bool)
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok && !fileKeys[key] {
+			continue
+		}
+		os.Setenv(key, value)
+		delete(fileKeys, key)
+		secretKeys[key] = true
+	}
+}
+
+// SecretKeys 上次 Load / Reload 從 secrets backend 讀到的環境變數名稱（不含值）
+func SecretKeys() []string {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	return sortedKeys(secretKeys)
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/cron"
+	"dizzycode1112/github-discord-bridge/internal/discord"
+)
+
+// problems Load 過程中發現的設定問題（缺少必填的變數、格式不對的值），Load 結束時一次回傳
+var problems []string
+
+func addProblem(format string, args ...any) {
+	problems = append(problems, fmt.Sprintf(format, args...))
+}
+
+// ValidationError 列出所有有問題的環境變數
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d configuration problem(s):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// validate 檢查個別解析時看不出來的問題：值的範圍、ID / URL 格式和設定之間的相依
+func validate(cfg *Config) {
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+		addProblem("PORT=%q is not a valid port", cfg.Port)
+	}
+	if !slices.Contains(storageBackends, cfg.StorageBackend) {
+		addProblem("STORAGE_BACKEND=%q must be one of %s", cfg.StorageBackend, strings.Join(storageBackends, ", "))
+	}
+	if cfg.Env == "production" && cfg.GitHubWebhookSecret == "" {
+		addProblem("GITHUB_WEBHOOK_SECRET is required when ENV=production (webhook signatures are not verified without it)")
+	}
+	if cfg.GitHubAppID != "" && cfg.GitHubAppPrivateKey == "" && cfg.GitHubAppPrivateKeyPath == "" {
+		addProblem("GITHUB_APP_PRIVATE_KEY or GITHUB_APP_PRIVATE_KEY_PATH is required when GITHUB_APP_ID is set")
+	}
+	if cfg.DiscordGuildID != "" && cfg.DiscordApplicationID == "" {
+		addProblem("DISCORD_APPLICATION_ID is required when DISCORD_GUILD_ID is set (slash command registration)")
+	}
+
+	ids := map[string]string{
+		"DISCORD_FORUM_CHANNEL_ID":        cfg.DiscordForumChID,
+		"DISCORD_ACTIVITY_THREAD_ID":      cfg.DiscordActivityThreadID,
+		"DISCORD_ANNOUNCEMENT_CHANNEL_ID": cfg.DiscordAnnouncementChID,
+		"DISCORD_LOW_PRIORITY_CHANNEL_ID": cfg.LowPriorityChannelID,
+		"DISCORD_SECURITY_CHANNEL_ID":     cfg.SecurityChannelID,
+		"DISCORD_APPLICATION_ID":          cfg.DiscordApplicationID,
+		"DISCORD_GUILD_ID":                cfg.DiscordGuildID,
+	}
+	for _, key := range sortedKeys(ids) {
+		checkDiscordID(key, ids[key])
+	}
+	idMaps := map[string]map[string]string{
+		"DISCORD_REPO_FORUM_CHANNELS": cfg.RepoForumChannels,
+		"DISCORD_DEPLOYMENT_CHANNELS": cfg.DeploymentChannels,
+		"GITHUB_DISCORD_USER_MAP":     cfg.GitHubDiscordUserMap,
+	}
+	for _, key := range sortedKeys(idMaps) {
+		for _, name := range sortedKeys(idMaps[key]) {
+			checkDiscordID(fmt.Sprintf("%s[%q]", key, name), idMaps[key][name])
+		}
+	}
+
+	if cfg.QueueWorkers < 0 {
+		addProblem("QUEUE_WORKERS=%d must be 0 (synchronous) or a positive number of workers", cfg.QueueWorkers)
+	}
+	if cfg.QueueWorkers > 0 && cfg.QueueSize < cfg.QueueWorkers {
+		addProblem("QUEUE_SIZE=%d must be at least QUEUE_WORKERS (%d)", cfg.QueueSize, cfg.QueueWorkers)
+	}
+	if cfg.RepoRateLimit < 0 {
+		addProblem("REPO_RATE_LIMIT=%d must be 0 (unlimited) or a positive number of events per minute", cfg.RepoRateLimit)
+	}
+	if cfg.RepoRateLimit > 0 && cfg.RepoRateBurst < 1 {
+		addProblem("REPO_RATE_BURST=%d must be at least 1", cfg.RepoRateBurst)
+	}
+	if cfg.ThreadEditDebounce < 0 {
+		addProblem("DISCORD_THREAD_EDIT_DEBOUNCE=%s must be 0 (no debounce) or positive", cfg.ThreadEditDebounce)
+	}
+	if cfg.RepoRateFlushInterval <= 0 {
+		addProblem("REPO_RATE_FLUSH_INTERVAL=%s must be positive", cfg.RepoRateFlushInterval)
+	}
+	if cfg.QueuePath != "" && cfg.StorageBackend == "bolt" && filepath.Clean(cfg.QueuePath) == filepath.Clean(cfg.BoltPath) {
+		addProblem("QUEUE_PATH=%s must not be the same file as BOLT_PATH", cfg.QueuePath)
+	}
+	if cfg.WebhookMaxBodySize <= 0 {
+		addProblem("WEBHOOK_MAX_BODY_SIZE=%d must be positive", cfg.WebhookMaxBodySize)
+	}
+	timeouts := map[string]time.Duration{
+		"HTTP_READ_HEADER_TIMEOUT": cfg.HTTPReadHeaderTimeout,
+		"HTTP_READ_TIMEOUT":        cfg.HTTPReadTimeout,
+		"HTTP_WRITE_TIMEOUT":       cfg.HTTPWriteTimeout,
+		"HTTP_IDLE_TIMEOUT":        cfg.HTTPIdleTimeout,
+	}
+	for _, key := range sortedKeys(timeouts) {
+		if timeout := timeouts[key]; timeout < 0 {
+			addProblem("%s=%s must be 0 (no timeout) or positive", key, timeout)
+		}
+	}
+	if cfg.GitHubIPAllowlist && cfg.GitHubIPAllowlistRefresh <= 0 {
+		addProblem("GITHUB_IP_ALLOWLIST_REFRESH=%s must be positive", cfg.GitHubIPAllowlistRefresh)
+	}
+	for _, proxy := range cfg.TrustedProxies {
+		if _, err := netip.ParseAddr(proxy); err == nil {
+			continue
+		}
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			addProblem("TRUSTED_PROXIES entry %q is not an IP address or CIDR range", proxy)
+		}
+	}
+	switch cfg.IngestMode {
+	case "":
+	case IngestReceiver, IngestPublisher:
+		if cfg.Broker != "nats" && cfg.Broker != "kafka" {
+			addProblem("BROKER=%q must be nats or kafka when INGEST_MODE=%s", cfg.Broker, cfg.IngestMode)
+		}
+		if cfg.BrokerURL == "" {
+			addProblem("BROKER_URL is required when INGEST_MODE=%s", cfg.IngestMode)
+		}
+		if cfg.BrokerTopic == "" || cfg.BrokerGroup == "" || (cfg.Broker == "nats" && cfg.BrokerStream == "") {
+			addProblem("BROKER_TOPIC, BROKER_GROUP and BROKER_STREAM (nats) must not be empty")
+		}
+		if cfg.IngestMode == IngestPublisher && cfg.QueueWorkers <= 0 {
+			addProblem("INGEST_MODE=publisher requires QUEUE_WORKERS > 0 (messages are acknowledged after the worker pool finishes them)")
+		}
+		if cfg.BrokerBatch <= 0 || cfg.BrokerBatch > 1000 {
+			addProblem("BROKER_BATCH=%d must be between 1 and 1000", cfg.BrokerBatch)
+		}
+	default:
+		addProblem("INGEST_MODE=%q must be empty, %s or %s", cfg.IngestMode, IngestReceiver, IngestPublisher)
+	}
+	if cfg.HAMode {
+		if cfg.StorageBackend != "redis" && cfg.StorageBackend != "postgres" {
+			addProblem("HA_MODE requires STORAGE_BACKEND=redis or postgres (replicas must share the store), got %q", cfg.StorageBackend)
+		}
+		if cfg.HALeaseTTL < 3*time.Second {
+			addProblem("HA_LEASE_TTL=%s must be at least 3s", cfg.HALeaseTTL)
+		}
+	}
+	if cfg.SecretsRefreshInterval < 0 {
+		addProblem("SECRETS_REFRESH_INTERVAL=%s must be 0 (no refresh) or positive", cfg.SecretsRefreshInterval)
+	}
+	if cfg.SecretsRefreshInterval > 0 && cfg.SecretsBackend == "" {
+		addProblem("SECRETS_REFRESH_INTERVAL requires SECRETS_BACKEND")
+	}
+	if cfg.ReplyToGitHub {
+		if cfg.DiscordPublicKey == "" {
+			addProblem("DISCORD_REPLY_TO_GITHUB requires DISCORD_PUBLIC_KEY (replies arrive at the interactions endpoint)")
+		}
+		if cfg.GitHubToken == "" && cfg.GitHubAppID == "" {
+			addProblem("DISCORD_REPLY_TO_GITHUB requires GITHUB_TOKEN or GITHUB_APP_ID to post comments")
+		}
+		if len(cfg.ReplyRoles) == 0 && len(cfg.GitHubDiscordUserMap) == 0 && !cfg.SelfServiceLinking {
+			addProblem("DISCORD_REPLY_TO_GITHUB requires DISCORD_REPLY_ROLES, GITHUB_DISCORD_USER_MAP or DISCORD_SELF_SERVICE_LINKING (who may post comments as the bot)")
+		}
+	}
+	schedules := map[string]string{
+		"RECONCILE_SCHEDULE": cfg.ReconcileSchedule,
+		"GC_SCHEDULE":        cfg.GCSchedule,
+		"STALE_PR_SCHEDULE":  cfg.StalePRSchedule,
+		"TAG_GC_SCHEDULE":    cfg.TagGCSchedule,
+	}
+	for _, key := range sortedKeys(schedules) {
+		if schedules[key] == "" {
+			continue
+		}
+		if _, err := cron.Parse(schedules[key]); err != nil {
+			addProblem("%s: %v", key, err)
+		}
+	}
+	if cfg.ReconcileSchedule != "" && cfg.ReconcileInterval > 0 {
+		addProblem("RECONCILE_SCHEDULE and RECONCILE_INTERVAL cannot both be set")
+	}
+	if cfg.GCSchedule != "" && cfg.GCInterval > 0 {
+		addProblem("GC_SCHEDULE and GC_INTERVAL cannot both be set")
+	}
+	if cfg.StalePRSchedule != "" && cfg.StalePRAfter <= 0 {
+		addProblem("STALE_PR_AFTER=%s must be positive", cfg.StalePRAfter)
+	}
+	if cfg.SchedulerJitter < 0 {
+		addProblem("SCHEDULER_JITTER=%s must be 0 (no jitter) or positive", cfg.SchedulerJitter)
+	}
+	if cfg.RecordPayloadsMax < 0 {
+		addProblem("RECORD_PAYLOADS_MAX=%d must be 0 (unlimited) or a positive number of files", cfg.RecordPayloadsMax)
+	}
+	if len(cfg.TLSAutocertDomains) > 0 {
+		if cfg.TLSAutocertCacheDir == "" {
+			addProblem("TLS_AUTOCERT_CACHE_DIR is required when TLS_AUTOCERT_DOMAINS is set (certificates must survive restarts to avoid Let's Encrypt rate limits)")
+		}
+		if cfg.TLSHTTPPort != "" && cfg.TLSHTTPPort == cfg.Port {
+			addProblem("TLS_HTTP_PORT=%s must differ from PORT (PORT serves HTTPS when TLS_AUTOCERT_DOMAINS is set)", cfg.TLSHTTPPort)
+		}
+		for _, domain := range cfg.TLSAutocertDomains {
+			if strings.ContainsAny(domain, "/:*") {
+				addProblem("TLS_AUTOCERT_DOMAINS entry %q must be a plain host name (no scheme, port or wildcard)", domain)
+			}
+		}
+	}
+	if cfg.OTelSampleRatio < 0 || cfg.OTelSampleRatio > 1 {
+		addProblem("OTEL_TRACES_SAMPLER_ARG=%v must be between 0 and 1", cfg.OTelSampleRatio)
+	}
+
+	urls := map[string]string{
+		"GITHUB_BASE_URL":             cfg.GitHubBaseURL,
+		"GITHUB_API_URL":              cfg.GitHubAPIURL,
+		"DISCORD_API_BASE_URL":        cfg.DiscordAPIBaseURL,
+		"REDIS_URL":                   cfg.RedisURL,
+		"POSTGRES_URL":                cfg.PostgresURL,
+		"OTEL_EXPORTER_OTLP_ENDPOINT": cfg.OTelEndpoint,
+		"TLS_AUTOCERT_DIRECTORY_URL":  cfg.TLSAutocertDirectoryURL,
+	}
+	for _, key := range sortedKeys(urls) {
+		raw := urls[key]
+		if raw == "" {
+			continue
+		}
+		if u, err := url.Parse(raw); err != nil || u.Scheme == "" || u.Host == "" {
+			addProblem("%s=%q is not an absolute URL", key, raw)
+		}
+	}
+}
+
+// checkDiscordID Discord 的 ID（snowflake）都是數字，常見錯誤是貼成 channel 名稱或網址
+func checkDiscordID(key, id string) {
+	if id != "" && !discord.IsSnowflake(id) {
+		addProblem("%s=%q is not a Discord ID (enable Developer Mode and use \"Copy ID\")", key, id)
+	}
+}
@@ -0,0 +1,186 @@
+// Package cron 解析標準 5 欄位的 cron 表示式（分 時 日 月 星期），計算下一次觸發時間
+// 支援 *、a-b、*/n、a-b/n、逗號列表、月份 / 星期的英文縮寫（jan、mon），以及 @hourly、@daily、@weekly、@monthly
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 解析後的排程，每個欄位是允許值的 bitset
+type Schedule struct {
+	expr   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	// 日和星期都有限制時，符合其中一個就觸發（和 crontab 相同）
+	domAny, dowAny bool
+}
+
+type field struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{min: 0, max: 59}
+	hourField   = field{min: 0, max: 23}
+	domField    = field{min: 1, max: 31}
+	monthField  = field{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors 常用排程的簡寫
+var descriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Parse 解析 cron 表示式，例如 "0 9 * * 1-5"（平日 9:00）或 "@weekly"
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	spec := expr
+	if d, ok := descriptors[strings.ToLower(expr)]; ok {
+		spec = d
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields, got %d", expr, len(parts))
+	}
+
+	s := &Schedule{expr: expr}
+	var err error
+	for _, f := range []struct {
+		bits *uint64
+		raw  string
+		f    field
+	}{
+		{&s.minute, parts[0], minuteField},
+		{&s.hour, parts[1], hourField},
+		{&s.dom, parts[2], domField},
+		{&s.month, parts[3], monthField},
+		{&s.dow, parts[4], dowField},
+	} {
+		if *f.bits, err = parseField(f.raw, f.f); err != nil {
+			return nil, fmt.Errorf("cron %q: %w", expr, err)
+		}
+	}
+	// 星期的 7 和 0 都是星期日
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = parts[2] == "*" || strings.HasPrefix(parts[2], "*/")
+	s.dowAny = parts[4] == "*" || strings.HasPrefix(parts[4], "*/")
+	return s, nil
+}
+
+// String 回傳原本的表示式
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// parseField 解析單一欄位（逗號分隔的多個 range）
+func parseField(raw string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(raw, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(to); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max // "5/15" = 5 開始每 15
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value 解析單一數值或名稱並檢查範圍
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q (allowed %d-%d)", s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// maxSearchYears 找不到觸發時間（例如 "0 0 30 2 *"）時最多往後找幾年
+const maxSearchYears = 5
+
+// Next 回傳 t 之後（不含 t）第一個符合的時間，以 t 的時區計算；永遠不會觸發的排程回傳 zero time
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 日和星期都有限制時符合其中一個即可；其中一個是 "*" 或 "*/n" 時兩個都要符合（和 crontab 相同，"*/2" 仍然只有單數日）
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domOK := s.dom&(1<<uint(t.Day())) != 0
+	dowOK := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
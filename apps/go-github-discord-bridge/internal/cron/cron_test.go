@@ -0,0 +1,97 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: "* * * * *"},
+		{expr: "0 9 * * 1-5"},
+		{expr: "*/15 0-6,18-23 * jan-mar mon,wed"},
+		{expr: "5/20 * 1,15 * *"},
+		{expr: "0 0 * * 7"},
+		{expr: " @Weekly "},
+		{expr: "* * * *", wantErr: true},
+		{expr: "* * * * * *", wantErr: true},
+		{expr: "60 * * * *", wantErr: true},
+		{expr: "* 24 * * *", wantErr: true},
+		{expr: "* * 0 * *", wantErr: true},
+		{expr: "* * * 13 *", wantErr: true},
+		{expr: "* * * * 8", wantErr: true},
+		{expr: "5-1 * * * *", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "*/x * * * *", wantErr: true},
+		{expr: "* * * foo *", wantErr: true},
+		{expr: "@yearly", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if want := strings.TrimSpace(tt.expr); err == nil && s.String() != want {
+				t.Errorf("String() = %q, want %q", s.String(), want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	taipei := time.FixedZone("Asia/Taipei", 8*3600)
+	utc := func(s string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time // zero = 永遠不會觸發
+	}{
+		{name: "weekdays skip the weekend", expr: "0 9 * * 1-5", from: utc("2026-10-16 10:00"), want: utc("2026-10-19 09:00")},
+		{name: "same day", expr: "0 9 * * 1-5", from: utc("2026-10-14 08:59"), want: utc("2026-10-14 09:00")},
+		{name: "excludes from", expr: "*/15 * * * *", from: utc("2026-10-14 10:15"), want: utc("2026-10-14 10:30")},
+		{name: "step", expr: "*/15 * * * *", from: utc("2026-10-14 10:07"), want: utc("2026-10-14 10:15")},
+		{name: "step wraps to the next hour", expr: "*/15 * * * *", from: utc("2026-10-14 10:50"), want: utc("2026-10-14 11:00")},
+		{name: "seconds are truncated", expr: "* * * * *", from: utc("2026-10-14 10:07").Add(30 * time.Second), want: utc("2026-10-14 10:08")},
+		{name: "step from a start value", expr: "5/20 * * * *", from: utc("2026-10-14 10:26"), want: utc("2026-10-14 10:45")},
+		{name: "range with step", expr: "0 8-18/4 * * *", from: utc("2026-10-14 16:30"), want: utc("2026-10-15 08:00")},
+		{name: "dom or dow: day of month first", expr: "0 0 13 * fri", from: utc("2026-10-10 12:00"), want: utc("2026-10-13 00:00")},
+		{name: "dom or dow: weekday first", expr: "0 0 13 * fri", from: utc("2026-10-14 12:00"), want: utc("2026-10-16 00:00")},
+		{name: "dom step and dow must both match", expr: "0 0 */2 * mon", from: utc("2026-10-20 00:00"), want: utc("2026-11-09 00:00")},
+		{name: "dow step and dom must both match", expr: "0 0 1 * */7", from: utc("2026-10-14 00:00"), want: utc("2026-11-01 00:00")},
+		{name: "sunday as 7", expr: "0 0 * * 7", from: utc("2026-10-14 00:00"), want: utc("2026-10-18 00:00")},
+		{name: "weekly", expr: "@weekly", from: utc("2026-10-14 00:00"), want: utc("2026-10-18 00:00")},
+		{name: "monthly rolls over the year", expr: "@monthly", from: utc("2026-12-01 00:00"), want: utc("2027-01-01 00:00")},
+		{name: "month names", expr: "0 12 1 jan,jul *", from: utc("2026-10-14 00:00"), want: utc("2027-01-01 12:00")},
+		{name: "leap day", expr: "0 0 29 2 *", from: utc("2026-10-14 00:00"), want: utc("2028-02-29 00:00")},
+		{name: "31st skips short months", expr: "0 0 31 * *", from: utc("2026-10-31 00:00"), want: utc("2026-12-31 00:00")},
+		{name: "february 30th never fires", expr: "0 0 30 2 *", from: utc("2026-10-14 00:00")},
+		{name: "april 31st never fires", expr: "0 0 31 4 *", from: utc("2026-10-14 00:00")},
+		{name: "location of from", expr: "0 9 * * *", from: utc("2026-10-14 02:00").In(taipei), want: time.Date(2026, 10, 15, 9, 0, 0, 0, taipei)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package discord
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+)
+
+// MaxAttachmentSize 沒有 boost 的 server 單一附件的上限（10 MiB）
+const MaxAttachmentSize = 10 << 20
+
+// File 隨訊息上傳的附件（例如 PR 的 .diff）
+type File struct {
+	Name        string
+	ContentType string // 空字串時用 application/octet-stream
+	Data        []byte
+}
+
+// fileCarrier request payload 帶有附件時改用 multipart/form-data 送出
+type fileCarrier interface {
+	attachedFiles() []File
+}
+
+func (m ThreadMessage) attachedFiles() []File { return m.Files }
+
+func (r CreateThreadRequest) attachedFiles() []File { return r.Message.Files }
+
+// multipartBody 組出 Discord 上傳附件的 body：payload_json 放原本的 JSON，檔案依序放在 files[n]
+func multipartBody(payloadJSON []byte, files []File) (io.Reader, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="payload_json"`)
+	header.Set("Content-Type", "application/json")
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(payloadJSON); err != nil {
+		return nil, "", err
+	}
+
+	for i, f := range files {
+		contentType := f.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files[%d]"; filename=%q`, i, f.Name))
+		header.Set("Content-Type", contentType)
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(f.Data); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, w.FormDataContentType(), nil
+}
@@ -2,44 +2,362 @@ package discord
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/metrics"
+	"dizzycode1112/github-discord-bridge/internal/tracing"
 )
 
 const (
-	DiscordAPIBase = "https://discord.com/api/v10"
+	// DefaultBaseURL Discord REST API 的預設 base URL（不含版本）
+	DefaultBaseURL = "https://discord.com/api"
+	// DefaultAPIVersion 預設使用的 Discord API 版本
+	DefaultAPIVersion = 10
 )
 
 type Client struct {
-	token          string
+	token          *atomic.Pointer[string] // ForForum 建立的 client 共用，SetToken 後全部生效
 	forumChannelID string
 	httpClient     *http.Client
+	baseURL        string
+	apiVersion     int
+
+	tagCache *tagCache
+	tagMu    sync.Mutex // 序列化建立 tag 的 PATCH
+
+	breaker *CircuitBreaker // nil 表示不啟用
+	nonces  *nonceCache
+	dryRun  DryRunFunc // nil = 一律送出
+
+	forumMu sync.Mutex
+	forums  map[string]*Client // ForForum 建立過的其他 forum channel client
+}
+
+// Option 調整 Client 設定的 functional option
+type Option func(*Client)
+
+// WithBaseURL 指定 Discord API base URL（不含版本），例如測試時指向 httptest server
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithAPIVersion 指定 Discord API 版本；設為 0 時 URL 不帶版本路徑
+func WithAPIVersion(version int) Option {
+	return func(c *Client) {
+		c.apiVersion = version
+	}
+}
+
+// WithHTTPClient 使用自訂的 *http.Client（proxy、mTLS、instrumentation 等）
+// 會取代預設 client（含 10 秒 timeout），timeout 請自行在傳入的 client 設定
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithTransport 只替換底層 transport，保留目前 client 的 timeout 設定
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		hc := *c.httpClient
+		hc.Transport = transport
+		c.httpClient = &hc
+	}
+}
+
+// WithTimeout 調整每個 Discord API request 的 timeout（預設 10 秒）
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		hc := *c.httpClient
+		hc.Timeout = timeout
+		c.httpClient = &hc
+	}
+}
+
+// WithCircuitBreaker 啟用 circuit breaker：連續 threshold 次失敗（網路錯誤、5xx）後，cooldown 期間直接回 ErrCircuitOpen
+// threshold <= 0 時不啟用
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		if threshold > 0 {
+			c.breaker = NewCircuitBreaker(threshold, cooldown)
+		}
+	}
+}
+
+// WithTagCacheTTL 指定 forum available_tags 快取的存活時間
+func WithTagCacheTTL(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.tagCache = newTagCache(ttl)
+	}
 }
 
 // NewClient 建立 Discord API client
-func NewClient(token, forumChannelID string) *Client {
-	return &Client{
-		token:          token,
+func NewClient(token, forumChannelID string, opts ...Option) *Client {
+	c := &Client{
+		token:          new(atomic.Pointer[string]),
 		forumChannelID: forumChannelID,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		baseURL:    DefaultBaseURL,
+		apiVersion: DefaultAPIVersion,
+		tagCache:   newTagCache(DefaultTagCacheTTL),
+		nonces:     newNonceCache(DefaultNonceTTL),
+	}
+
+	c.token.Store(&token)
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// SetToken 換成新的 bot token（secrets backend 輪替 token 時），之後的 request 使用新的 token
+func (c *Client) SetToken(token string) {
+	c.token.Store(&token)
+}
+
+// ForForum 回傳操作另一個 forum channel 的 client（建立 thread、forum tag 用）
+// 共用 HTTP client、circuit breaker 和 nonce 快取，available_tags 快取則各 forum 分開；同一個 forum 會回傳同一個 client
+func (c *Client) ForForum(forumChannelID string) *Client {
+	if forumChannelID == "" || forumChannelID == c.forumChannelID {
+		return c
+	}
+
+	c.forumMu.Lock()
+	defer c.forumMu.Unlock()
+
+	if forum, ok := c.forums[forumChannelID]; ok {
+		return forum
+	}
+	if c.forums == nil {
+		c.forums = make(map[string]*Client)
+	}
+
+	forum := &Client{
+		token:          c.token,
+		forumChannelID: forumChannelID,
+		httpClient:     c.httpClient,
+		baseURL:        c.baseURL,
+		apiVersion:     c.apiVersion,
+		tagCache:       newTagCache(c.tagCache.ttl),
+		breaker:        c.breaker,
+		nonces:         c.nonces,
+		dryRun:         c.dryRun,
+	}
+	c.forums[forumChannelID] = forum
+	return forum
+}
+
+// endpoint 組出完整的 API URL，path 以 "/" 開頭，可帶 fmt 參數
+func (c *Client) endpoint(path string, args ...any) string {
+	base := c.baseURL
+	if c.apiVersion > 0 {
+		base = fmt.Sprintf("%s/v%d", base, c.apiVersion)
+	}
+	return base + fmt.Sprintf(path, args...)
+}
+
+// request 送出 Discord API request 的共用流程
+// payload 不為 nil 時序列化成 JSON body（帶附件時改用 multipart）；out 不為 nil 時解析回應 JSON
+// 非 2xx 一律回傳 *DiscordAPIError，呼叫端可用 errors.Is 判斷 ErrNotFound 等 sentinel error
+func (c *Client) request(ctx context.Context, method, url string, payload any, out any, opts ...requestOption) error {
+	var reqBody io.Reader
+	var jsonData []byte
+	contentType := ""
+	if payload != nil {
+		var err error
+		jsonData, err = json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody, contentType = bytes.NewBuffer(jsonData), "application/json"
+
+		if carrier, ok := payload.(fileCarrier); ok && len(carrier.attachedFiles()) > 0 {
+			if reqBody, contentType, err = multipartBody(jsonData, carrier.attachedFiles()); err != nil {
+				return fmt.Errorf("failed to encode attachments: %w", err)
+			}
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bot "+*c.token.Load())
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	route := metricRoute(req.URL.Path)
+	ctx, span := tracing.Start(ctx, "discord "+method+" "+route, tracing.KindClient)
+	defer span.End()
+	span.SetAttr("http.request.method", method)
+	span.SetAttr("http.route", route)
+
+	if c.dryRun != nil {
+		dry := DryRunRequest{Method: method, Route: route, Path: dryRunPath(req.URL.Path), Body: jsonData}
+		if c.dryRun(ctx, dry) {
+			span.SetAttr("discord.dry_run", true)
+			return dryRunResponse(dry, out)
+		}
+	}
+
+	if c.breaker != nil {
+		if err := c.breaker.Allow(); err != nil {
+			metrics.DiscordRequests.Inc(method, route, "circuit_open")
+			span.SetError(err)
+			return err
+		}
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	metrics.DiscordLatency.Observe(time.Since(start).Seconds(), method, route)
+	if err != nil {
+		metrics.DiscordRequests.Inc(method, route, "error")
+		span.SetError(err)
+		c.recordResult(false)
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	metrics.DiscordRequests.Inc(method, route, statusClass(resp.StatusCode))
+	span.SetAttr("http.response.status_code", resp.StatusCode)
+
+	// 只有 Discord 端的問題（5xx）算失敗；4xx 是 request 本身的問題，不該觸發 breaker
+	c.recordResult(resp.StatusCode < 500)
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := newAPIError(resp, body)
+		span.SetError(apiErr)
+		return apiErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// metricRoute 把 API path 轉成低基數的 route label：去掉 /api/v10 前綴，ID 換成 :id、reaction emoji 換成 :emoji、
+// interaction webhook 的 token 換成 :token
+// 例如 "/api/v10/channels/123/messages/456" → "/channels/:id/messages/:id"
+func metricRoute(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var out []string
+	for i, seg := range segments {
+		switch {
+		case len(out) == 0 && (seg == "api" || (len(seg) > 1 && seg[0] == 'v' && isDigits(seg[1:]))):
+			continue
+		case isDigits(seg):
+			seg = ":id"
+		case i > 0 && segments[i-1] == "reactions":
+			seg = ":emoji"
+		case i > 1 && segments[i-2] == "webhooks":
+			seg = ":token"
+		}
+		out = append(out, seg)
+	}
+	return "/" + strings.Join(out, "/")
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// statusClass 429 單獨列出（rate limit），其他依 2xx / 4xx / 5xx 分組
+func statusClass(code int) string {
+	if code == http.StatusTooManyRequests {
+		return "429"
+	}
+	return fmt.Sprintf("%dxx", code/100)
+}
+
+// requestOption 調整單一 request（例如加上 header）
+type requestOption func(*http.Request)
+
+// maxAuditLogReasonLength X-Audit-Log-Reason 的長度上限
+const maxAuditLogReasonLength = 512
+
+// withAuditLogReason 加上 X-Audit-Log-Reason，讓 server 管理員在 audit log 看到 bot 異動的原因
+// Discord 要求 header 值 URL encode
+func withAuditLogReason(reason string) requestOption {
+	return func(req *http.Request) {
+		if reason == "" {
+			return
+		}
+		if r := []rune(reason); len(r) > maxAuditLogReasonLength {
+			reason = string(r[:maxAuditLogReasonLength])
+		}
+		req.Header.Set("X-Audit-Log-Reason", url.PathEscape(reason))
+	}
+}
+
+func (c *Client) recordResult(success bool) {
+	if c.breaker != nil {
+		c.breaker.Record(success)
 	}
 }
 
+// BreakerState 回傳 circuit breaker 狀態，未啟用時回傳 closed
+func (c *Client) BreakerState() string {
+	if c.breaker == nil {
+		return BreakerClosed
+	}
+	return c.breaker.State()
+}
+
 // CreateThreadRequest 建立 thread 的請求結構
 type CreateThreadRequest struct {
-	Name        string        `json:"name"`                    // Thread 標題
-	Message     ThreadMessage `json:"message"`                 // 第一則訊息
-	AppliedTags []string      `json:"applied_tags,omitempty"`  // Forum tags (可選)
+	Name        string        `json:"name"`                   // Thread 標題
+	Message     ThreadMessage `json:"message"`                // 第一則訊息
+	AppliedTags []string      `json:"applied_tags,omitempty"` // Forum tags (可選)
 }
 
 type ThreadMessage struct {
 	Content string  `json:"content,omitempty"` // 純文字內容
 	Embeds  []Embed `json:"embeds,omitempty"`  // Rich embed
+
+	// Nonce 用來避免重複發送（見 MessageNonce），設定時會一併要求 Discord enforce_nonce
+	Nonce        string `json:"nonce,omitempty"`
+	EnforceNonce bool   `json:"enforce_nonce,omitempty"`
+
+	// Components button 等互動元件（見 components.go），需要 interactions endpoint 才能處理點擊
+	Components []Component `json:"components,omitempty"`
+
+	// Files 附件，有附件時 request 改用 multipart/form-data（見 multipartBody）
+	Files []File `json:"-"`
 }
 
 // Embed Discord 的 rich embed 結構
@@ -51,6 +369,9 @@ type Embed struct {
 	Fields      []EmbedField `json:"fields,omitempty"`
 	Timestamp   string       `json:"timestamp,omitempty"` // ISO 8601 format
 	Footer      *EmbedFooter `json:"footer,omitempty"`
+	Author      *EmbedAuthor `json:"author,omitempty"`
+	Image       *EmbedImage  `json:"image,omitempty"`     // 大圖（顯示在 embed 下方）
+	Thumbnail   *EmbedImage  `json:"thumbnail,omitempty"` // 縮圖（顯示在 embed 右上角）
 }
 
 type EmbedField struct {
@@ -64,6 +385,18 @@ type EmbedFooter struct {
 	IconURL string `json:"icon_url,omitempty"`
 }
 
+// EmbedAuthor 顯示在 embed 最上方的作者資訊（通常是 GitHub 頭像 + 帳號）
+type EmbedAuthor struct {
+	Name    string `json:"name"`
+	URL     string `json:"url,omitempty"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+// EmbedImage embed 的圖片（image 和 thumbnail 共用）
+type EmbedImage struct {
+	URL string `json:"url"`
+}
+
 // CreateThreadResponse Discord API 的回應
 type CreateThreadResponse struct {
 	ID   string `json:"id"`   // Thread ID
@@ -71,9 +404,33 @@ type CreateThreadResponse struct {
 }
 
 // ForumTag Discord forum channel 的 tag 結構
+// EmojiID（custom emoji）和 EmojiName（unicode emoji）二選一
+// PATCH available_tags 時要把這些欄位帶回去，否則既有 tag 的 emoji 會被清掉
 type ForumTag struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Moderated bool   `json:"moderated"`
+	EmojiID   string `json:"emoji_id,omitempty"`
+	EmojiName string `json:"emoji_name,omitempty"`
+}
+
+// TagOptions 建立新 tag 時的外觀設定
+type TagOptions struct {
+	Emoji     string // unicode emoji（例如 "🐛"）或 custom emoji ID（純數字）
+	Moderated bool   // 只有具備 MANAGE_THREADS 權限的人（包含 bot）可以套用
+}
+
+// newForumTag 依照 TagOptions 組出 ForumTag
+func newForumTag(name string, opts TagOptions) ForumTag {
+	tag := ForumTag{Name: name, Moderated: opts.Moderated}
+	if opts.Emoji != "" {
+		if IsSnowflake(opts.Emoji) {
+			tag.EmojiID = opts.Emoji
+		} else {
+			tag.EmojiName = opts.Emoji
+		}
+	}
+	return tag
 }
 
 // ForumChannelResponse Discord channel 資訊（用於取得 available_tags）
@@ -82,194 +439,443 @@ type ForumChannelResponse struct {
 }
 
 // GetOrCreateRepoTag 取得或建立 repo 對應的 forum tag，回傳 tag ID
-// 如果 forum 已有同名 tag 就直接用，沒有就建立新的
-func (c *Client) GetOrCreateRepoTag(repoName string) (string, error) {
-	// 取得 forum channel 資訊
-	url := fmt.Sprintf("%s/channels/%s", DiscordAPIBase, c.forumChannelID)
+func (c *Client) GetOrCreateRepoTag(ctx context.Context, repoName string, opts TagOptions) (string, error) {
+	return c.GetOrCreateTag(ctx, repoName, opts)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// GetOrCreateTag 取得或建立指定名稱的 forum tag（repo、discussion category、label 等），回傳 tag ID
+// 如果 forum 已有同名 tag 就直接用，沒有就用 opts 建立新的
+// available_tags 會快取 tagCacheTTL，避免每個事件都去 GET 整個 channel
+func (c *Client) GetOrCreateTag(ctx context.Context, name string, opts TagOptions) (string, error) {
+	tags, err := c.tagCache.get(func() ([]ForumTag, error) { return c.fetchForumTags(ctx) })
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", err
+	}
+	if id := findTagID(tags, name); id != "" {
+		return id, nil
 	}
-	req.Header.Set("Authorization", "Bot "+c.token)
 
-	resp, err := c.httpClient.Do(req)
+	// 建立 tag 要序列化：PATCH 是整包覆寫 available_tags，並行建立會互相蓋掉
+	c.tagMu.Lock()
+	defer c.tagMu.Unlock()
+
+	// 拿到鎖後重新抓最新的 tags（可能別的 goroutine 剛建好，或快取已過時）
+	tags, err = c.fetchForumTags(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to get channel: %w", err)
+		return "", err
 	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("discord API error (status %d): %s", resp.StatusCode, string(body))
+	c.tagCache.set(tags)
+	if id := findTagID(tags, name); id != "" {
+		return id, nil
 	}
 
-	var channel ForumChannelResponse
-	if err := json.Unmarshal(body, &channel); err != nil {
-		return "", fmt.Errorf("failed to parse channel: %w", err)
+	// 建立新 tag（透過 PATCH channel，加入新的 available_tags）
+	newTags := append(append([]ForumTag{}, tags...), newForumTag(name, opts))
+	updated, err := c.patchForumTags(ctx, newTags, fmt.Sprintf("Add forum tag %s", name))
+	if err != nil {
+		return "", err
 	}
+	c.tagCache.set(updated)
 
-	// 找已存在的 tag
-	for _, tag := range channel.AvailableTags {
-		if tag.Name == repoName {
-			return tag.ID, nil
-		}
+	if id := findTagID(updated, name); id != "" {
+		return id, nil
 	}
 
-	// 建立新 tag（透過 PATCH channel，加入新的 available_tags）
-	newTags := append(channel.AvailableTags, ForumTag{Name: repoName})
+	return "", fmt.Errorf("tag created but not found in response")
+}
 
-	type PatchBody struct {
-		AvailableTags []ForumTag `json:"available_tags"`
+// RenameTag 修改 forum tag 名稱（repo 改名時用），沿用原本的 tag ID 所以既有 thread 的 tag 不受影響
+// 找不到舊 tag 或新名稱已存在時不做事
+func (c *Client) RenameTag(ctx context.Context, oldName, newName string) error {
+	c.tagMu.Lock()
+	defer c.tagMu.Unlock()
+
+	tags, err := c.fetchForumTags(ctx)
+	if err != nil {
+		return err
+	}
+	if findTagID(tags, oldName) == "" || findTagID(tags, newName) != "" {
+		c.tagCache.set(tags)
+		return nil
+	}
+
+	renamed := append([]ForumTag{}, tags...)
+	for i := range renamed {
+		if renamed[i].Name == oldName {
+			renamed[i].Name = newName
+		}
 	}
-	patchData, err := json.Marshal(PatchBody{AvailableTags: newTags})
+
+	updated, err := c.patchForumTags(ctx, renamed, fmt.Sprintf("Rename forum tag %s to %s", oldName, newName))
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal patch: %w", err)
+		return err
 	}
+	c.tagCache.set(updated)
+	return nil
+}
+
+// RemoveTags 從 forum 的 available_tags 移除 remove 回傳 true 的 tag，回傳移除的 tag 名稱（沒有要移除的時不送 PATCH）
+// 套用在 thread 上的 tag ID 被移除後 Discord 會自動從 thread 拿掉
+func (c *Client) RemoveTags(ctx context.Context, remove func(ForumTag) bool, reason string) ([]string, error) {
+	c.tagMu.Lock()
+	defer c.tagMu.Unlock()
 
-	patchReq, err := http.NewRequest("PATCH", url, bytes.NewBuffer(patchData))
+	tags, err := c.fetchForumTags(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to create patch request: %w", err)
+		return nil, err
+	}
+	var kept []ForumTag
+	var removed []string
+	for _, tag := range tags {
+		if remove(tag) {
+			removed = append(removed, tag.Name)
+		} else {
+			kept = append(kept, tag)
+		}
+	}
+	if len(removed) == 0 {
+		c.tagCache.set(tags)
+		return nil, nil
+	}
+	if kept == nil {
+		kept = []ForumTag{}
 	}
-	patchReq.Header.Set("Authorization", "Bot "+c.token)
-	patchReq.Header.Set("Content-Type", "application/json")
 
-	patchResp, err := c.httpClient.Do(patchReq)
+	updated, err := c.patchForumTags(ctx, kept, reason)
 	if err != nil {
-		return "", fmt.Errorf("failed to patch channel: %w", err)
+		return nil, err
+	}
+	c.tagCache.set(updated)
+	return removed, nil
+}
+
+// InvalidateTagCache 清掉 available_tags 快取，下次查詢會重新向 Discord 取得
+func (c *Client) InvalidateTagCache() {
+	c.tagCache.invalidate()
+}
+
+// findTagID 依名稱找 tag ID，找不到回傳空字串
+func findTagID(tags []ForumTag, name string) string {
+	for _, tag := range tags {
+		if tag.Name == name {
+			return tag.ID
+		}
 	}
-	defer patchResp.Body.Close()
+	return ""
+}
 
-	patchBody, _ := io.ReadAll(patchResp.Body)
-	if patchResp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("discord API error on patch (status %d): %s", patchResp.StatusCode, string(patchBody))
+// fetchForumTags 取得 forum channel 目前的 available_tags（不經過快取）
+func (c *Client) fetchForumTags(ctx context.Context) ([]ForumTag, error) {
+	var channel ForumChannelResponse
+	if err := c.request(ctx, "GET", c.endpoint("/channels/%s", c.forumChannelID), nil, &channel); err != nil {
+		return nil, fmt.Errorf("failed to get channel: %w", err)
 	}
+	return channel.AvailableTags, nil
+}
 
-	// 重新解析拿到新 tag 的 ID
-	var updated ForumChannelResponse
-	if err := json.Unmarshal(patchBody, &updated); err != nil {
-		return "", fmt.Errorf("failed to parse updated channel: %w", err)
+// patchForumTags 覆寫 forum channel 的 available_tags，回傳 Discord 回應中的最新 tags（含新 tag 的 ID）
+func (c *Client) patchForumTags(ctx context.Context, tags []ForumTag, reason string) ([]ForumTag, error) {
+	type PatchBody struct {
+		AvailableTags []ForumTag `json:"available_tags"`
 	}
 
-	for _, tag := range updated.AvailableTags {
-		if tag.Name == repoName {
-			return tag.ID, nil
-		}
+	var updated ForumChannelResponse
+	err := c.request(ctx, "PATCH", c.endpoint("/channels/%s", c.forumChannelID), PatchBody{AvailableTags: tags}, &updated, withAuditLogReason(reason))
+	if err != nil {
+		// PATCH 結果未知，快取可能已經不準
+		c.tagCache.invalidate()
+		return nil, fmt.Errorf("failed to patch channel: %w", err)
 	}
 
-	return "", fmt.Errorf("tag created but not found in response")
+	return updated.AvailableTags, nil
 }
 
 // CreateThread 在 forum channel 建立新的 thread
-func (c *Client) CreateThread(title string, message ThreadMessage, tagIDs ...string) (string, error) {
-	url := fmt.Sprintf("%s/channels/%s/threads", DiscordAPIBase, c.forumChannelID)
-
+func (c *Client) CreateThread(ctx context.Context, title string, message ThreadMessage, tagIDs ...string) (string, error) {
 	reqBody := CreateThreadRequest{
 		Name:        title,
 		Message:     message,
 		AppliedTags: tagIDs,
 	}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+	var result CreateThreadResponse
+	if err := c.request(ctx, "POST", c.endpoint("/channels/%s/threads", c.forumChannelID), reqBody, &result); err != nil {
+		return "", fmt.Errorf("failed to create thread: %w", err)
 	}
+	metrics.ThreadsCreated.Inc()
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+	return result.ID, nil
+}
+
+// PostMessage 在已存在的 thread 中發送訊息
+// message.Nonce 有設定時，TTL 內重複的 nonce 不會再發送一次
+func (c *Client) PostMessage(ctx context.Context, threadID string, message ThreadMessage) error {
+	_, err := c.PostChannelMessage(ctx, threadID, message)
+	return err
+}
+
+// MessageResponse Discord 建立訊息後的回應（只取需要的欄位）
+type MessageResponse struct {
+	ID        string       `json:"id"`
+	ChannelID string       `json:"channel_id"`
+	Content   string       `json:"content,omitempty"`
+	Author    *DiscordUser `json:"author,omitempty"`
+}
+
+// PostChannelMessage 在一般 channel（例如 announcement channel）發送訊息，回傳 message ID
+// 跟 PostMessage 不同，需要 message ID 才能接著 crosspost
+func (c *Client) PostChannelMessage(ctx context.Context, channelID string, message ThreadMessage) (string, error) {
+	// 同一個 nonce 已經成功送過（例如 GitHub redeliver），直接回傳先前的 message ID
+	if message.Nonce != "" {
+		if messageID, seen := c.nonces.lookup(message.Nonce); seen {
+			return messageID, nil
+		}
+		message.EnforceNonce = true
 	}
 
-	req.Header.Set("Authorization", "Bot "+c.token)
-	req.Header.Set("Content-Type", "application/json")
+	var result MessageResponse
+	if err := c.request(ctx, "POST", c.endpoint("/channels/%s/messages", channelID), message, &result); err != nil {
+		return "", fmt.Errorf("failed to post message: %w", err)
+	}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+	if message.Nonce != "" {
+		c.nonces.remember(message.Nonce, result.ID)
 	}
-	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	return result.ID, nil
+}
 
-	if resp.StatusCode != http.StatusCreated {
-		return "", fmt.Errorf("discord API error (status %d): %s", resp.StatusCode, string(body))
+// EditMessage 修改 bot 發過的訊息內容（content、embeds 整個覆寫）
+func (c *Client) EditMessage(ctx context.Context, channelID, messageID string, message ThreadMessage) error {
+	type PatchBody struct {
+		Content string  `json:"content"`
+		Embeds  []Embed `json:"embeds"`
 	}
 
-	var result CreateThreadResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+	reqBody := PatchBody{Content: message.Content, Embeds: message.Embeds}
+	if err := c.request(ctx, "PATCH", c.endpoint("/channels/%s/messages/%s", channelID, messageID), reqBody, nil); err != nil {
+		return fmt.Errorf("failed to edit message: %w", err)
 	}
+	return nil
+}
 
-	return result.ID, nil
+// PinMessage 釘選訊息（每個 channel 最多 50 則），reason 會記錄在 audit log
+func (c *Client) PinMessage(ctx context.Context, channelID, messageID, reason string) error {
+	if err := c.request(ctx, "PUT", c.endpoint("/channels/%s/pins/%s", channelID, messageID), nil, nil, withAuditLogReason(reason)); err != nil {
+		return fmt.Errorf("failed to pin message: %w", err)
+	}
+	return nil
 }
 
-// PostMessage 在已存在的 thread 中發送訊息
-func (c *Client) PostMessage(threadID string, message ThreadMessage) error {
-	url := fmt.Sprintf("%s/channels/%s/messages", DiscordAPIBase, threadID)
+// CrosspostMessage 把 announcement channel 的訊息發布到所有 follow 這個 channel 的 server
+// 只對 announcement（news）channel 有效，一般 text channel 會回 400
+func (c *Client) CrosspostMessage(ctx context.Context, channelID, messageID string) error {
+	if err := c.request(ctx, "POST", c.endpoint("/channels/%s/messages/%s/crosspost", channelID, messageID), nil, nil); err != nil {
+		return fmt.Errorf("failed to crosspost message: %w", err)
+	}
+	return nil
+}
+
+// AddThreadMember 把 Discord 使用者加入 thread（被加入的人會收到 thread 通知）
+func (c *Client) AddThreadMember(ctx context.Context, threadID, userID string) error {
+	return c.threadMemberRequest(ctx, "PUT", threadID, userID, "")
+}
+
+// RemoveThreadMember 把 Discord 使用者移出 thread，reason 會記錄在 audit log
+func (c *Client) RemoveThreadMember(ctx context.Context, threadID, userID, reason string) error {
+	return c.threadMemberRequest(ctx, "DELETE", threadID, userID, reason)
+}
+
+func (c *Client) threadMemberRequest(ctx context.Context, method, threadID, userID, reason string) error {
+	if err := c.request(ctx, method, c.endpoint("/channels/%s/thread-members/%s", threadID, userID), nil, nil, withAuditLogReason(reason)); err != nil {
+		return fmt.Errorf("failed to update thread member: %w", err)
+	}
+	return nil
+}
 
-	jsonData, err := json.Marshal(message)
+// Channel types（只列 bridge 會用到的）
+const (
+	ChannelTypeGuildText         = 0
+	ChannelTypeGuildAnnouncement = 5
+	ChannelTypePublicThread      = 11
+	ChannelTypeGuildForum        = 15
+)
+
+// Channel Discord channel / thread 資訊（只取 bridge 需要的欄位）
+type Channel struct {
+	ID                   string                `json:"id"`
+	Type                 int                   `json:"type"`
+	GuildID              string                `json:"guild_id,omitempty"`
+	Name                 string                `json:"name"`
+	ParentID             string                `json:"parent_id,omitempty"`
+	AppliedTags          []string              `json:"applied_tags,omitempty"`
+	ThreadMetadata       *ThreadMetadata       `json:"thread_metadata,omitempty"`
+	PermissionOverwrites []PermissionOverwrite `json:"permission_overwrites,omitempty"`
+}
+
+// ThreadMetadata thread 專屬的狀態欄位
+type ThreadMetadata struct {
+	Archived         bool   `json:"archived"`
+	Locked           bool   `json:"locked"`
+	ArchiveTimestamp string `json:"archive_timestamp,omitempty"`
+}
+
+// IsArchived thread 是否已 archive（非 thread 一律回 false）
+func (ch *Channel) IsArchived() bool {
+	return ch.ThreadMetadata != nil && ch.ThreadMetadata.Archived
+}
+
+// GetChannel 取得 channel 資訊，channel 不存在（或 bot 看不到）時回傳 ErrNotFound
+func (c *Client) GetChannel(ctx context.Context, channelID string) (*Channel, error) {
+	var channel Channel
+	if err := c.request(ctx, "GET", c.endpoint("/channels/%s", channelID), nil, &channel); err != nil {
+		return nil, fmt.Errorf("failed to get channel: %w", err)
+	}
+	return &channel, nil
+}
+
+// GetThread 取得 thread 資訊（名稱、archived 狀態、applied tags）
+// 用來在 PostMessage 前確認 store 裡的 mapping 仍然有效
+func (c *Client) GetThread(ctx context.Context, threadID string) (*Channel, error) {
+	channel, err := c.GetChannel(ctx, threadID)
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return nil, err
+	}
+	if channel.ThreadMetadata == nil {
+		return nil, fmt.Errorf("channel %s is not a thread", threadID)
 	}
+	return channel, nil
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+// ListForumThreads 列出 forum channel 的所有 thread（進行中的和已 archive 的 public thread），用來統計 tag 的使用情況
+// 進行中的 thread 只能從 guild 層級查詢，再依 parent_id 篩選；archived thread 依 archive 時間分頁，每頁 100 個
+func (c *Client) ListForumThreads(ctx context.Context) ([]Channel, error) {
+	forum, err := c.GetChannel(ctx, c.forumChannelID)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, err
+	}
+
+	var active struct {
+		Threads []Channel `json:"threads"`
+	}
+	if err := c.request(ctx, "GET", c.endpoint("/guilds/%s/threads/active", forum.GuildID), nil, &active); err != nil {
+		return nil, fmt.Errorf("failed to list active threads: %w", err)
+	}
+	var threads []Channel
+	for _, thread := range active.Threads {
+		if thread.ParentID == c.forumChannelID {
+			threads = append(threads, thread)
+		}
 	}
 
-	req.Header.Set("Authorization", "Bot "+c.token)
-	req.Header.Set("Content-Type", "application/json")
+	before := ""
+	for {
+		path := c.endpoint("/channels/%s/threads/archived/public?limit=100", c.forumChannelID)
+		if before != "" {
+			path += "&before=" + url.QueryEscape(before)
+		}
+		var page struct {
+			Threads []Channel `json:"threads"`
+			HasMore bool      `json:"has_more"`
+		}
+		if err := c.request(ctx, "GET", path, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list archived threads: %w", err)
+		}
+		threads = append(threads, page.Threads...)
+		if !page.HasMore || len(page.Threads) == 0 {
+			return threads, nil
+		}
+		last := page.Threads[len(page.Threads)-1]
+		if last.ThreadMetadata == nil || last.ThreadMetadata.ArchiveTimestamp == "" || last.ThreadMetadata.ArchiveTimestamp == before {
+			return threads, nil
+		}
+		before = last.ThreadMetadata.ArchiveTimestamp
+	}
+}
 
-	resp, err := c.httpClient.Do(req)
+// ThreadExists 確認 thread 是否還存在
+func (c *Client) ThreadExists(ctx context.Context, threadID string) (bool, error) {
+	_, err := c.GetThread(ctx, threadID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return false, err
 	}
-	defer resp.Body.Close()
+	return true, nil
+}
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("discord API error (status %d): %s", resp.StatusCode, string(body))
+// SetThreadTags 覆寫 forum thread 的 applied_tags（Discord 限制每個 thread 最多 5 個 tag）
+func (c *Client) SetThreadTags(ctx context.Context, threadID string, tagIDs []string, reason string) error {
+	type PatchBody struct {
+		AppliedTags []string `json:"applied_tags"`
 	}
 
+	if len(tagIDs) > MaxThreadTags {
+		tagIDs = tagIDs[:MaxThreadTags]
+	}
+	if tagIDs == nil {
+		tagIDs = []string{}
+	}
+
+	if err := c.request(ctx, "PATCH", c.endpoint("/channels/%s", threadID), PatchBody{AppliedTags: tagIDs}, nil, withAuditLogReason(reason)); err != nil {
+		return fmt.Errorf("failed to set thread tags: %w", err)
+	}
 	return nil
 }
 
-// ArchiveThreadRequest archive thread 的請求
-type ArchiveThreadRequest struct {
-	Archived bool `json:"archived"`
-}
+// RenameThread 修改 thread 名稱（name 由呼叫端依 BuildThreadName 截斷）
+// Discord 對 channel 改名有 10 分鐘 2 次的限制；request 不重試 429，回傳的 DiscordAPIError 帶 RetryAfter，
+// 經 queue 送出時由 outageWait 等到 Retry-After 後再送
+func (c *Client) RenameThread(ctx context.Context, threadID, name, reason string) error {
+	type PatchBody struct {
+		Name string `json:"name"`
+	}
 
-// ArchiveThread 關閉並 archive 一個 thread
-func (c *Client) ArchiveThread(threadID string) error {
-	url := fmt.Sprintf("%s/channels/%s", DiscordAPIBase, threadID)
+	if err := c.request(ctx, "PATCH", c.endpoint("/channels/%s", threadID), PatchBody{Name: name}, nil, withAuditLogReason(reason)); err != nil {
+		return fmt.Errorf("failed to rename thread: %w", err)
+	}
+	return nil
+}
 
-	reqBody := ArchiveThreadRequest{
-		Archived: true,
+// EditThread 用一次 PATCH 同時修改 thread 的名稱和 applied_tags；name 為空字串或 tagIDs 為 nil 時不修改該欄位
+// 改名和換 tag 共用 channel 修改的限制，要一起改時用這個而不是分別呼叫 RenameThread / SetThreadTags
+func (c *Client) EditThread(ctx context.Context, threadID, name string, tagIDs []string, reason string) error {
+	type PatchBody struct {
+		Name        string    `json:"name,omitempty"`
+		AppliedTags *[]string `json:"applied_tags,omitempty"`
 	}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+	body := PatchBody{Name: name}
+	if tagIDs != nil {
+		if len(tagIDs) > MaxThreadTags {
+			tagIDs = tagIDs[:MaxThreadTags]
+		}
+		body.AppliedTags = &tagIDs
 	}
 
-	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if err := c.request(ctx, "PATCH", c.endpoint("/channels/%s", threadID), body, nil, withAuditLogReason(reason)); err != nil {
+		return fmt.Errorf("failed to edit thread: %w", err)
 	}
+	return nil
+}
 
-	req.Header.Set("Authorization", "Bot "+c.token)
-	req.Header.Set("Content-Type", "application/json")
+// MaxThreadTags 每個 forum thread 最多可套用的 tag 數
+const MaxThreadTags = 5
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+// ArchiveThreadRequest archive thread 的請求
+type ArchiveThreadRequest struct {
+	Archived bool `json:"archived"`
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("discord API error (status %d): %s", resp.StatusCode, string(body))
+// ArchiveThread 關閉並 archive 一個 thread，reason 會記錄在 audit log（例如 "PR owner/repo#42 was merged"）
+func (c *Client) ArchiveThread(ctx context.Context, threadID, reason string) error {
+	reqBody := ArchiveThreadRequest{
+		Archived: true,
 	}
 
+	if err := c.request(ctx, "PATCH", c.endpoint("/channels/%s", threadID), reqBody, nil, withAuditLogReason(reason)); err != nil {
+		return fmt.Errorf("failed to archive thread: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,43 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// namedColors ParseColor 接受的顏色名稱（和內建訊息用的顏色相同）
+var namedColors = map[string]int{
+	"green":   ColorGreen,
+	"yellow":  ColorYellow,
+	"red":     ColorRed,
+	"purple":  ColorPurple,
+	"gray":    ColorGray,
+	"grey":    ColorGray,
+	"orange":  ColorOrange,
+	"darkred": ColorDarkRed,
+}
+
+// ParseColor 解析 "#RRGGBB"、"#RGB"、"0xRRGGBB"、十進位整數或顏色名稱（"green"、"darkred" 等）
+func ParseColor(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if color, ok := namedColors[strings.ToLower(s)]; ok {
+		return color, nil
+	}
+
+	raw, base := s, 10
+	switch {
+	case strings.HasPrefix(s, "#"):
+		raw, base = s[1:], 16
+		if len(raw) == 3 {
+			raw = string([]byte{raw[0], raw[0], raw[1], raw[1], raw[2], raw[2]})
+		}
+	case strings.HasPrefix(s, "0x"), strings.HasPrefix(s, "0X"):
+		raw, base = s[2:], 16
+	}
+	color, err := strconv.ParseInt(raw, base, 32)
+	if err != nil || color < 0 || color > 0xFFFFFF {
+		return 0, fmt.Errorf("invalid color %q", s)
+	}
+	return int(color), nil
+}
@@ -0,0 +1,176 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Application command option 類型
+const (
+	OptionTypeSubCommand      = 1
+	OptionTypeSubCommandGroup = 2
+	OptionTypeString          = 3
+	OptionTypeInteger         = 4
+	OptionTypeBoolean         = 5
+	OptionTypeUser            = 6
+)
+
+// Application command 類型
+const (
+	ApplicationCommandTypeChatInput = 1 // slash command
+	ApplicationCommandTypeMessage   = 3 // 訊息右鍵選單的「應用程式」
+)
+
+// ApplicationCommand slash command 的定義（對應 Discord application command 結構）
+type ApplicationCommand struct {
+	ID          string                     `json:"id,omitempty"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	Type        int                        `json:"type,omitempty"` // ApplicationCommandType*，0 = slash command
+	Options     []ApplicationCommandOption `json:"options,omitempty"`
+}
+
+// ApplicationCommandOption slash command 的參數或 sub command
+type ApplicationCommandOption struct {
+	Type        int                        `json:"type"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	Required    bool                       `json:"required,omitempty"`
+	Choices     []ApplicationCommandChoice `json:"choices,omitempty"`
+	Options     []ApplicationCommandOption `json:"options,omitempty"`
+}
+
+// ApplicationCommandChoice 參數的固定選項
+type ApplicationCommandChoice struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+// DefaultCommands 沒有提供 commands 設定檔時註冊的預設 slash commands 和 message commands
+var DefaultCommands = []ApplicationCommand{
+	{
+		// message command 的名稱就是選單上顯示的文字，description 必須是空的
+		Name: "Reply to GitHub",
+		Type: ApplicationCommandTypeMessage,
+	},
+	{
+		Name:        "issue",
+		Description: "GitHub issue 操作",
+		Options: []ApplicationCommandOption{
+			{
+				Type:        OptionTypeSubCommand,
+				Name:        "status",
+				Description: "查看 issue / PR 狀態",
+				Options: []ApplicationCommandOption{
+					{Type: OptionTypeString, Name: "repo", Description: "owner/repo", Required: true},
+					{Type: OptionTypeInteger, Name: "number", Description: "Issue / PR 編號", Required: true},
+				},
+			},
+			{
+				Type:        OptionTypeSubCommand,
+				Name:        "create",
+				Description: "建立新的 issue",
+				Options: []ApplicationCommandOption{
+					{Type: OptionTypeString, Name: "repo", Description: "owner/repo", Required: true},
+					{Type: OptionTypeString, Name: "title", Description: "Issue 標題", Required: true},
+					{Type: OptionTypeString, Name: "body", Description: "Issue 內容"},
+				},
+			},
+		},
+	},
+	{
+		Name:        "github",
+		Description: "綁定 GitHub 帳號（review request 等通知會 mention 你）",
+		Options: []ApplicationCommandOption{
+			{
+				Type:        OptionTypeSubCommand,
+				Name:        "link",
+				Description: "綁定 GitHub 帳號（取得驗證碼）",
+				Options: []ApplicationCommandOption{
+					{Type: OptionTypeString, Name: "login", Description: "GitHub 帳號", Required: true},
+				},
+			},
+			{
+				Type:        OptionTypeSubCommand,
+				Name:        "verify",
+				Description: "建立含驗證碼的 gist 後完成綁定",
+			},
+			{
+				Type:        OptionTypeSubCommand,
+				Name:        "unlink",
+				Description: "解除綁定",
+				Options: []ApplicationCommandOption{
+					{Type: OptionTypeString, Name: "login", Description: "GitHub 帳號", Required: true},
+				},
+			},
+		},
+	},
+	{
+		Name:        "pr",
+		Description: "GitHub pull request 操作",
+		Options: []ApplicationCommandOption{
+			{
+				Type:        OptionTypeSubCommand,
+				Name:        "merge",
+				Description: "Merge pull request",
+				Options: []ApplicationCommandOption{
+					{Type: OptionTypeString, Name: "repo", Description: "owner/repo", Required: true},
+					{Type: OptionTypeInteger, Name: "number", Description: "PR 編號", Required: true},
+					{
+						Type:        OptionTypeString,
+						Name:        "method",
+						Description: "Merge 方式",
+						Choices: []ApplicationCommandChoice{
+							{Name: "merge", Value: "merge"},
+							{Name: "squash", Value: "squash"},
+							{Name: "rebase", Value: "rebase"},
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+// LoadCommands 從 JSON 檔讀取 slash command 定義（內容為 ApplicationCommand 陣列）
+// path 為空時回傳 DefaultCommands
+func LoadCommands(path string) ([]ApplicationCommand, error) {
+	if path == "" {
+		return DefaultCommands, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commands file: %w", err)
+	}
+
+	var commands []ApplicationCommand
+	if err := json.Unmarshal(data, &commands); err != nil {
+		return nil, fmt.Errorf("failed to parse commands file: %w", err)
+	}
+
+	for i, cmd := range commands {
+		isChatInput := cmd.Type == 0 || cmd.Type == ApplicationCommandTypeChatInput
+		if cmd.Name == "" || (isChatInput && cmd.Description == "") {
+			return nil, fmt.Errorf("command[%d]: name and description are required", i)
+		}
+		if !isChatInput && cmd.Description != "" {
+			return nil, fmt.Errorf("command[%d]: %s must not have a description (only slash commands do)", i, cmd.Name)
+		}
+	}
+
+	return commands, nil
+}
+
+// RegisterGuildCommands 用 bulk overwrite 註冊 guild 的 slash commands
+// guild command 會立即生效，不需要等 global command 的同步時間；沒列在 commands 裡的舊 command 會被刪除
+func (c *Client) RegisterGuildCommands(ctx context.Context, applicationID, guildID string, commands []ApplicationCommand) ([]ApplicationCommand, error) {
+	var registered []ApplicationCommand
+	err := c.request(ctx, "PUT", c.endpoint("/applications/%s/guilds/%s/commands", applicationID, guildID), commands, &registered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register commands: %w", err)
+	}
+	return registered, nil
+}
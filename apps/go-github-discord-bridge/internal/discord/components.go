@@ -0,0 +1,120 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+)
+
+// Message component 類型
+const (
+	ComponentTypeActionRow = 1
+	ComponentTypeButton    = 2
+	ComponentTypeTextInput = 4
+)
+
+// Button 樣式（link button 不會送 interaction，這裡用不到）
+const (
+	ButtonStylePrimary   = 1
+	ButtonStyleSecondary = 2
+)
+
+// Text input 樣式
+const (
+	TextInputStyleShort     = 1
+	TextInputStyleParagraph = 2
+)
+
+// Component 訊息或 modal 裡的互動元件：action row 包住 button / text input
+type Component struct {
+	Type     int    `json:"type"`
+	CustomID string `json:"custom_id,omitempty"`
+	Style    int    `json:"style,omitempty"`
+	Label    string `json:"label,omitempty"`
+
+	// text input 才用到的欄位；modal submit 時 Value 為使用者填的內容
+	Placeholder string `json:"placeholder,omitempty"`
+	MinLength   int    `json:"min_length,omitempty"`
+	MaxLength   int    `json:"max_length,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Value       string `json:"value,omitempty"`
+
+	Components []Component `json:"components,omitempty"` // action row 的子元件
+}
+
+// ActionRow 把 button（最多 5 個）或一個 text input 包成一列
+func ActionRow(components ...Component) Component {
+	return Component{Type: ComponentTypeActionRow, Components: components}
+}
+
+// NewButton 建立一般樣式的 button，點擊時送出 customID 的 component interaction
+func NewButton(customID, label string) Component {
+	return Component{Type: ComponentTypeButton, CustomID: customID, Style: ButtonStyleSecondary, Label: label}
+}
+
+// NewParagraphInput 建立多行的 text input（modal 用），maxLength 上限 4000
+func NewParagraphInput(customID, label, placeholder string, maxLength int) Component {
+	return Component{
+		Type:        ComponentTypeTextInput,
+		CustomID:    customID,
+		Style:       TextInputStyleParagraph,
+		Label:       label,
+		Placeholder: placeholder,
+		MinLength:   1,
+		MaxLength:   maxLength,
+		Required:    true,
+	}
+}
+
+// ModalResponse 回應一個 modal，每個 input 各佔一列；送出時會收到 custom_id 相同的 modal submit interaction
+func ModalResponse(customID, title string, inputs ...Component) *InteractionResponse {
+	rows := make([]Component, len(inputs))
+	for i, input := range inputs {
+		rows[i] = ActionRow(input)
+	}
+	return &InteractionResponse{
+		Type: ResponseTypeModal,
+		Data: &InteractionResponseData{CustomID: customID, Title: title, Components: rows},
+	}
+}
+
+// DeferredReply 先回應「思考中」，之後用 EditOriginalResponse 補上內容（處理超過 3 秒的操作）
+func DeferredReply(ephemeral bool) *InteractionResponse {
+	resp := &InteractionResponse{Type: ResponseTypeDeferredChannelMessage}
+	if ephemeral {
+		resp.Data = &InteractionResponseData{Flags: MessageFlagEphemeral}
+	}
+	return resp
+}
+
+// SubmittedValue 取出 modal submit 中 customID 的 text input 內容
+func (d *InteractionData) SubmittedValue(customID string) string {
+	for _, row := range d.Components {
+		for _, c := range row.Components {
+			if c.CustomID == customID {
+				return c.Value
+			}
+		}
+	}
+	return ""
+}
+
+// TargetMessage 回傳 message command 的目標訊息，不是 message command 時回傳 nil
+func (d *InteractionData) TargetMessage() *MessageResponse {
+	if d.Resolved == nil || d.TargetID == "" {
+		return nil
+	}
+	msg, ok := d.Resolved.Messages[d.TargetID]
+	if !ok {
+		return nil
+	}
+	return &msg
+}
+
+// EditOriginalResponse 修改 interaction 的原始回應（DeferredReply 之後補上內容），interaction token 15 分鐘內有效
+func (c *Client) EditOriginalResponse(ctx context.Context, applicationID, interactionToken string, message ThreadMessage) error {
+	url := c.endpoint("/webhooks/%s/%s/messages/@original", applicationID, interactionToken)
+	if err := c.request(ctx, "PATCH", url, message, nil); err != nil {
+		return fmt.Errorf("failed to edit interaction response: %w", err)
+	}
+	return nil
+}
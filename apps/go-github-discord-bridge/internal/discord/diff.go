@@ -0,0 +1,86 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	"dizzycode1112/github-discord-bridge/internal/i18n"
+)
+
+// diffHunk unified diff 裡的一個 hunk（"@@ ... @@" 開頭）
+type diffHunk struct {
+	file  string
+	lines []string
+}
+
+// parseDiffHunks 從 unified diff 依序取出每個檔案的 hunk；binary 檔案沒有 hunk
+func parseDiffHunks(diff string) []diffHunk {
+	var hunks []diffHunk
+	file := ""
+	var current *diffHunk
+	for _, line := range strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current = nil
+			// "diff --git a/path b/path"，改名時取新的路徑
+			if i := strings.LastIndex(line, " b/"); i >= 0 {
+				file = line[i+3:]
+			}
+		case strings.HasPrefix(line, "@@"):
+			hunks = append(hunks, diffHunk{file: file, lines: []string{line}})
+			current = &hunks[len(hunks)-1]
+		case current != nil && line != "" && strings.ContainsRune(" +-\\", rune(line[0])):
+			current.lines = append(current.lines, line)
+		case current != nil && line == "":
+			// 檔案結尾的空行，不是 hunk 內容
+		default:
+			current = nil // "index ..."、"--- a/..." 等 header
+		}
+	}
+	return hunks
+}
+
+// FormatPRDiff 把 PR 的 diff 前 maxHunks 個 hunk 轉成 ```diff code block
+// 放不進一個 embed 時改成附上完整的 .diff 檔案；diff 沒有任何 hunk（只有 binary / 改名）時回傳 false
+func FormatPRDiff(diff string, prNumber int, prURL string, maxHunks int) (ThreadMessage, bool) {
+	hunks := parseDiffHunks(diff)
+	if len(hunks) == 0 || maxHunks <= 0 {
+		return ThreadMessage{}, false
+	}
+
+	shown := hunks[:min(maxHunks, len(hunks))]
+	var b strings.Builder
+	lastFile := ""
+	for _, h := range shown {
+		if h.file != lastFile {
+			fmt.Fprintf(&b, "`%s`\n", h.file)
+			lastFile = h.file
+		}
+		b.WriteString("```diff\n")
+		for _, line := range h.lines {
+			// 避免程式碼裡的 ``` 提早結束 code block
+			b.WriteString(strings.ReplaceAll(line, "```", "`\u200b``"))
+			b.WriteByte('\n')
+		}
+		b.WriteString("```\n")
+	}
+	if rest := len(hunks) - len(shown); rest > 0 {
+		b.WriteString(i18n.Tf("… and %d more hunk(s) — [view all changes](%s)", rest, prURL+"/files"))
+	}
+
+	embed := Embed{
+		Title:       i18n.Tf("📄 Diff of PR #%d", prNumber),
+		URL:         prURL + "/files",
+		Description: strings.TrimSpace(b.String()),
+		Color:       ColorGray,
+	}
+
+	if len([]rune(embed.Description)) > MaxEmbedDescription {
+		embed.Description = i18n.T("The diff is too large to show inline, see the attached file.")
+		return ThreadMessage{
+			Embeds: []Embed{embed},
+			Files:  []File{{Name: fmt.Sprintf("pr-%d.diff", prNumber), ContentType: "text/x-diff", Data: []byte(diff)}},
+		}, true
+	}
+	return ThreadMessage{Embeds: []Embed{embed}}, true
+}
@@ -0,0 +1,122 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DryRunRequest dry-run 時沒有送出的 Discord API request
+type DryRunRequest struct {
+	Method string          // HTTP method
+	Route  string          // 低基數的 route（例如 "/channels/:id/threads"，見 metricRoute）
+	Path   string          // 完整 path（含 ID，不含 /api/v10）
+	Body   json.RawMessage // request 的 JSON（附件內容不含在內），沒有 body 時為 nil
+}
+
+// DryRunFunc 決定 request 要不要略過：回傳 true 時不送到 Discord，改回傳模擬的回應；ctx 為 request 的 context
+type DryRunFunc func(ctx context.Context, req DryRunRequest) bool
+
+// WithDryRun 設定 dry-run：fn 回傳 true 的 request 不會送出，呼叫端收到的回應以 request 內容加上假的 ID 模擬
+// （建立的 thread、message、forum tag 的 ID 以 "dry-run-" 開頭）
+func WithDryRun(fn DryRunFunc) Option {
+	return func(c *Client) {
+		c.dryRun = fn
+	}
+}
+
+// dryRunIDs dry-run 產生的假 ID 流水號（所有 client 共用）
+var dryRunIDs atomic.Int64
+
+// dryRunTags dry-run 模擬建立的 forum tag：ID → 名稱（不會寫進 tag 快取，見 tagCache.set）
+var dryRunTags sync.Map
+
+// IsDryRunID ID 是否為 dry-run 產生的假 ID
+func IsDryRunID(id string) bool {
+	return strings.HasPrefix(id, "dry-run-")
+}
+
+func nextDryRunID() string {
+	return fmt.Sprintf("dry-run-%d", dryRunIDs.Add(1))
+}
+
+// dryRunPath 去掉 /api/v10 這類前綴，和 Discord 文件上的 path 一致
+func dryRunPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for len(segments) > 0 && (segments[0] == "api" || (len(segments[0]) > 1 && segments[0][0] == 'v' && isDigits(segments[0][1:]))) {
+		segments = segments[1:]
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// dryRunResponse 依 route 模擬 Discord 的回應並解析到 out
+// 建立類的 request（thread、message）回傳 request 內容加上新的 ID，PATCH channel 回傳 request 內容（新的 forum tag 補上 ID），
+// GET channel 回傳沒有 tag 的 forum channel
+func dryRunResponse(req DryRunRequest, out any) error {
+	if out == nil {
+		return nil
+	}
+
+	resp := map[string]any{}
+	if len(req.Body) > 0 && req.Body[0] == '{' {
+		if err := json.Unmarshal(req.Body, &resp); err != nil {
+			return fmt.Errorf("failed to build dry-run response: %w", err)
+		}
+	}
+	segments := strings.Split(strings.Trim(req.Path, "/"), "/")
+	pathID := ""
+	if len(segments) > 1 {
+		pathID = segments[1]
+	}
+
+	switch {
+	case req.Route == "/users/@me":
+		resp = map[string]any{"id": "dry-run-bot", "username": "dry-run", "bot": true}
+	case req.Method == "GET" && req.Route == "/channels/:id":
+		resp = map[string]any{"id": pathID, "type": ChannelTypeGuildForum, "name": "dry-run", "available_tags": []any{}}
+	case req.Method == "PATCH" && req.Route == "/channels/:id":
+		resp["id"] = pathID
+		if tags, ok := resp["available_tags"].([]any); ok {
+			for _, tag := range tags {
+				if t, ok := tag.(map[string]any); ok && t["id"] == nil {
+					t["id"] = nextDryRunID()
+					dryRunTags.Store(t["id"], t["name"])
+				}
+			}
+		}
+	case req.Method == "POST" && req.Route == "/channels/:id/threads":
+		resp["id"] = nextDryRunID()
+		resp["parent_id"] = pathID
+		if message, ok := resp["message"].(map[string]any); ok {
+			message["id"] = nextDryRunID()
+			message["channel_id"] = resp["id"]
+		}
+	case req.Method == "POST" && req.Route == "/channels/:id/messages":
+		resp["id"] = nextDryRunID()
+		resp["channel_id"] = pathID
+	case len(req.Body) > 0 && req.Body[0] == '[':
+		// PUT guild commands：原樣回傳
+		return json.Unmarshal(req.Body, out)
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to build dry-run response: %w", err)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// TagName 依 ID 找 forum tag 的名稱：先查 available_tags 快取，再查 dry-run 模擬建立的 tag，不會呼叫 API；找不到回傳空字串
+func (c *Client) TagName(id string) string {
+	if name := c.tagCache.name(id); name != "" {
+		return name
+	}
+	if name, ok := dryRunTags.Load(id); ok {
+		s, _ := name.(string)
+		return s
+	}
+	return ""
+}
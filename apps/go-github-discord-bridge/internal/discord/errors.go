@@ -0,0 +1,91 @@
+package discord
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sentinel errors：搭配 errors.Is 判斷 Discord API 的失敗類型
+var (
+	// ErrNotFound channel / thread / message 不存在或已被刪除
+	ErrNotFound = errors.New("discord: resource not found")
+	// ErrMissingPermissions bot 缺少執行操作需要的權限
+	ErrMissingPermissions = errors.New("discord: missing permissions")
+	// ErrRateLimited 被 Discord rate limit（429）
+	ErrRateLimited = errors.New("discord: rate limited")
+	// ErrUnauthorized bot token 無效
+	ErrUnauthorized = errors.New("discord: unauthorized")
+)
+
+// Discord JSON error codes（只列 bridge 會判斷的）
+// https://discord.com/developers/docs/topics/opcodes-and-status-codes#json
+const (
+	ErrorCodeUnknownChannel     = 10003
+	ErrorCodeUnknownMessage     = 10008
+	ErrorCodeMissingAccess      = 50001
+	ErrorCodeMissingPermissions = 50013
+	ErrorCodeThreadArchived     = 50083
+)
+
+// DiscordAPIError Discord API 回傳非 2xx 時的錯誤
+type DiscordAPIError struct {
+	StatusCode int           // HTTP status
+	Code       int           // Discord JSON error code（沒有時為 0）
+	Message    string        // Discord 回傳的錯誤訊息
+	RetryAfter time.Duration // 429 時建議的等待時間
+	Body       string        // 原始 response body（除錯用）
+}
+
+func (e *DiscordAPIError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("discord API error (status %d, code %d): %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("discord API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// Is 讓 errors.Is(err, ErrNotFound) 這類判斷可以命中 *DiscordAPIError
+func (e *DiscordAPIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || e.Code == ErrorCodeUnknownChannel || e.Code == ErrorCodeUnknownMessage
+	case ErrMissingPermissions:
+		return e.StatusCode == http.StatusForbidden || e.Code == ErrorCodeMissingAccess || e.Code == ErrorCodeMissingPermissions
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	default:
+		return false
+	}
+}
+
+// newAPIError 從 response 組出 DiscordAPIError（body 不是 JSON 時只保留原始內容）
+func newAPIError(resp *http.Response, body []byte) *DiscordAPIError {
+	apiErr := &DiscordAPIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
+
+	var parsed struct {
+		Code       int     `json:"code"`
+		Message    string  `json:"message"`
+		RetryAfter float64 `json:"retry_after"` // 秒，可能有小數
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		apiErr.Code = parsed.Code
+		apiErr.Message = parsed.Message
+		apiErr.RetryAfter = time.Duration(parsed.RetryAfter * float64(time.Second))
+	}
+
+	if apiErr.RetryAfter == 0 && resp.StatusCode == http.StatusTooManyRequests {
+		if secs, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); err == nil {
+			apiErr.RetryAfter = time.Duration(secs * float64(time.Second))
+		}
+	}
+
+	return apiErr
+}
@@ -1,8 +1,12 @@
 package discord
 
 import (
+	"dizzycode1112/github-discord-bridge/internal/event"
 	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/i18n"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -13,41 +17,49 @@ const (
 	ColorRed    = 0xED4245 // PR closed without merge
 	ColorPurple = 0x5865F2 // PR merged
 	ColorGray   = 0x99AAB5 // General info
+
+	ColorOrange  = 0xE67E22 // Security: medium
+	ColorDarkRed = 0x992D22 // Security: critical
 )
 
 // FormatPROpened 格式化「PR 開啟」的訊息
 func FormatPROpened(pr *github.PullRequest) ThreadMessage {
-	description := pr.Body
-	if len(description) > 500 {
-		description = description[:497] + "..."
-	}
+	description := truncateMarkdown(ConvertGitHubMarkdownFor(pr.Body, pr.HTMLURL), 500)
 	if description == "" {
-		description = "*No description provided*"
+		description = i18n.T("*No description provided*")
+	}
+
+	title := i18n.Tf("Pull Request #%d Opened", pr.Number)
+	color := ColorGreen
+	if pr.Draft {
+		title = i18n.Tf("Draft Pull Request #%d Opened", pr.Number)
+		color = ColorGray
 	}
 
 	embed := Embed{
-		Title:       fmt.Sprintf("Pull Request #%d Opened", pr.Number),
+		Title:       title,
 		Description: description,
 		URL:         pr.HTMLURL,
-		Color:       ColorGreen,
+		Color:       color,
 		Fields: []EmbedField{
 			{
-				Name:   "Author",
+				Name:   i18n.T("Author"),
 				Value:  fmt.Sprintf("[@%s](%s)", pr.User.Login, pr.User.HTMLURL),
 				Inline: true,
 			},
 			{
-				Name:   "Branch",
+				Name:   i18n.T("Branch"),
 				Value:  fmt.Sprintf("`%s` → `%s`", pr.Head.Ref, pr.Base.Ref),
 				Inline: true,
 			},
 			{
-				Name:   "Changes",
+				Name:   i18n.T("Changes"),
 				Value:  fmt.Sprintf("+%d −%d", pr.Additions, pr.Deletions),
 				Inline: true,
 			},
 		},
 		Timestamp: pr.CreatedAt.Format(time.RFC3339),
+		Author:    authorFromUser(pr.User),
 		Footer: &EmbedFooter{
 			Text:    "GitHub",
 			IconURL: "https://github.githubassets.com/images/modules/logos_page/GitHub-Mark.png",
@@ -60,7 +72,7 @@ func FormatPROpened(pr *github.PullRequest) ThreadMessage {
 }
 
 // FormatPRReview 格式化「PR Review」的訊息
-// prAuthorLogin: PR 作者的 GitHub 帳號，用來查 userMap 取得 Discord ID 做 mention
+// prAuthorLogin: PR 作者的 GitHub 帳號，用來查 userMap（key 為小寫 login）取得 Discord ID 做 mention
 func FormatPRReview(review *github.Review, prNumber int, prURL string, prAuthorLogin string, userMap map[string]string) ThreadMessage {
 	var emoji string
 	var color int
@@ -80,15 +92,11 @@ func FormatPRReview(review *github.Review, prNumber int, prURL string, prAuthorL
 		color = ColorGray
 	}
 
-	title := fmt.Sprintf("%s Review by @%s", emoji, review.User.Login)
+	title := i18n.Tf("%s Review by @%s", emoji, review.User.Login)
 
 	description := "**" + formatReviewState(review.State) + "**"
 	if review.Body != "" {
-		body := review.Body
-		if len(body) > 800 {
-			body = body[:797] + "..."
-		}
-		description += "\n\n" + body
+		description += "\n\n" + truncateMarkdown(ConvertGitHubMarkdownFor(review.Body, review.HTMLURL), 800)
 	}
 
 	embed := Embed{
@@ -97,13 +105,15 @@ func FormatPRReview(review *github.Review, prNumber int, prURL string, prAuthorL
 		URL:         review.HTMLURL,
 		Color:       color,
 		Timestamp:   review.SubmittedAt.Format(time.RFC3339),
+		Author:      authorFromUser(review.User),
 	}
 
 	// approved / changes_requested 才 mention PR 作者（commented 不打擾）
 	// 格式包含 review state 和 PR 資訊，方便 AI agent 解析後去 GitHub 查看
+	// content 固定用原始的 review state、不翻譯（有人依這行做比對），只有 embed 依語系顯示
 	var content string
 	if review.State == "approved" || review.State == "changes_requested" {
-		if discordID, ok := userMap[prAuthorLogin]; ok {
+		if discordID, ok := userMap[strings.ToLower(prAuthorLogin)]; ok {
 			content = fmt.Sprintf("<@%s> %s PR #%d — %s", discordID, review.State, prNumber, prURL)
 		} else {
 			content = fmt.Sprintf("@%s %s PR #%d — %s", prAuthorLogin, review.State, prNumber, prURL)
@@ -120,16 +130,17 @@ func FormatPRReview(review *github.Review, prNumber int, prURL string, prAuthorL
 func FormatReviewRequested(reviewer *github.User, requestedBy string, prNumber int, prURL string, userMap map[string]string) ThreadMessage {
 	// Discord mention 只在 content 才有效，embed title/description 不支援
 	var content string
-	if discordID, ok := userMap[reviewer.Login]; ok {
+	if discordID, ok := userMap[strings.ToLower(reviewer.Login)]; ok {
 		content = fmt.Sprintf("<@%s>", discordID)
 	}
 
 	embed := Embed{
-		Title:       fmt.Sprintf("🔔 Review requested from @%s", reviewer.Login),
-		Description: fmt.Sprintf("@%s requested a review on PR #%d", requestedBy, prNumber),
+		Title:       i18n.Tf("🔔 Review requested from @%s", reviewer.Login),
+		Description: i18n.Tf("@%s requested a review on PR #%d", requestedBy, prNumber),
 		URL:         prURL,
 		Color:       ColorYellow,
 		Timestamp:   time.Now().Format(time.RFC3339),
+		Thumbnail:   thumbnailFromUser(*reviewer),
 	}
 
 	return ThreadMessage{
@@ -138,28 +149,65 @@ func FormatReviewRequested(reviewer *github.User, requestedBy string, prNumber i
 	}
 }
 
+// FormatStalePR 格式化「PR 太久沒有更新」的提醒（STALE_PR_SCHEDULE），mention 還沒 review 的 reviewer，沒有 reviewer 時 mention 作者
+func FormatStalePR(pr *github.PullRequest, userMap map[string]string) ThreadMessage {
+	var logins []string
+	for _, reviewer := range pr.RequestedReviewers {
+		logins = append(logins, reviewer.Login)
+	}
+	if len(logins) == 0 {
+		logins = []string{pr.User.Login}
+	}
+
+	var mentions []string
+	for _, login := range logins {
+		if discordID, ok := userMap[strings.ToLower(login)]; ok {
+			mentions = append(mentions, fmt.Sprintf("<@%s>", discordID))
+		}
+	}
+
+	lines := []string{fmt.Sprintf("**%s**", pr.Title)}
+	if updated := FormatTime(pr.UpdatedAt); updated != "" {
+		lines = append(lines, i18n.Tf("Last updated %s", updated))
+	}
+	lines = append(lines, i18n.Tf("Waiting on @%s", strings.Join(logins, ", @")))
+
+	embed := Embed{
+		Title:       i18n.Tf("⏰ PR #%d needs attention", pr.Number),
+		Description: strings.Join(lines, "\n"),
+		URL:         pr.HTMLURL,
+		Color:       ColorOrange,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	return ThreadMessage{
+		Content: strings.Join(mentions, " "),
+		Embeds:  []Embed{embed},
+	}
+}
+
 // FormatPRMerged 格式化「PR 合併」的訊息
 func FormatPRMerged(pr *github.PullRequest, mergedBy string) ThreadMessage {
 	embed := Embed{
-		Title:       fmt.Sprintf("🎉 PR #%d Merged", pr.Number),
-		Description: fmt.Sprintf("**%s** has been merged into `%s`", pr.Title, pr.Base.Ref),
+		Title:       i18n.Tf("🎉 PR #%d Merged", pr.Number),
+		Description: i18n.Tf("**%s** has been merged into `%s`", pr.Title, pr.Base.Ref),
 		URL:         pr.HTMLURL,
 		Color:       ColorPurple,
 		Fields: []EmbedField{
 			{
-				Name:   "Merged by",
+				Name:   i18n.T("Merged by"),
 				Value:  fmt.Sprintf("@%s", mergedBy),
 				Inline: true,
 			},
 			{
-				Name:   "Changes",
+				Name:   i18n.T("Changes"),
 				Value:  fmt.Sprintf("+%d −%d", pr.Additions, pr.Deletions),
 				Inline: true,
 			},
 		},
 		Timestamp: time.Now().Format(time.RFC3339),
 		Footer: &EmbedFooter{
-			Text: "Thread will be archived soon",
+			Text: i18n.T("Thread will be archived soon"),
 		},
 	}
 
@@ -171,20 +219,20 @@ func FormatPRMerged(pr *github.PullRequest, mergedBy string) ThreadMessage {
 // FormatPRClosed 格式化「PR 關閉（未合併）」的訊息
 func FormatPRClosed(pr *github.PullRequest, closedBy string) ThreadMessage {
 	embed := Embed{
-		Title:       fmt.Sprintf("❌ PR #%d Closed", pr.Number),
-		Description: fmt.Sprintf("**%s** was closed without merging", pr.Title),
+		Title:       i18n.Tf("❌ PR #%d Closed", pr.Number),
+		Description: i18n.Tf("**%s** was closed without merging", pr.Title),
 		URL:         pr.HTMLURL,
 		Color:       ColorRed,
 		Fields: []EmbedField{
 			{
-				Name:   "Closed by",
+				Name:   i18n.T("Closed by"),
 				Value:  fmt.Sprintf("@%s", closedBy),
 				Inline: true,
 			},
 		},
 		Timestamp: time.Now().Format(time.RFC3339),
 		Footer: &EmbedFooter{
-			Text: "Thread will be archived soon",
+			Text: i18n.T("Thread will be archived soon"),
 		},
 	}
 
@@ -193,16 +241,45 @@ func FormatPRClosed(pr *github.PullRequest, closedBy string) ThreadMessage {
 	}
 }
 
+// WithChangedFiles 在訊息的第一個 embed 加上「改了哪些檔案」欄位，最多列 max 個
+func WithChangedFiles(message ThreadMessage, files []github.PullRequestFile, max int) ThreadMessage {
+	if len(message.Embeds) == 0 || len(files) == 0 || max <= 0 {
+		return message
+	}
+
+	statusEmoji := map[string]string{"added": "🆕", "removed": "🗑️", "renamed": "🔀"}
+	var lines []string
+	for i, f := range files {
+		if i == max {
+			lines = append(lines, i18n.Tf("…and %d more", len(files)-max))
+			break
+		}
+		emoji, ok := statusEmoji[f.Status]
+		if !ok {
+			emoji = "📝"
+		}
+		lines = append(lines, fmt.Sprintf("%s `%s` +%d −%d", emoji, truncateRunes(f.Filename, 80), f.Additions, f.Deletions))
+	}
+
+	embeds := append([]Embed{}, message.Embeds...)
+	embeds[0].Fields = append(append([]EmbedField{}, embeds[0].Fields...), EmbedField{
+		Name:  i18n.Tf("Files (%d)", len(files)),
+		Value: truncateRunes(strings.Join(lines, "\n"), 1024),
+	})
+	message.Embeds = embeds
+	return message
+}
+
 // FormatPRUpdated 格式化「PR 更新」的訊息（force push, new commits）
 func FormatPRUpdated(pr *github.PullRequest) ThreadMessage {
 	embed := Embed{
-		Title:       "🔄 PR Updated",
-		Description: fmt.Sprintf("New commits pushed to `%s`", pr.Head.Ref),
+		Title:       i18n.T("🔄 PR Updated"),
+		Description: i18n.Tf("New commits pushed to `%s`", pr.Head.Ref),
 		URL:         pr.HTMLURL,
 		Color:       ColorYellow,
 		Fields: []EmbedField{
 			{
-				Name:   "Changes",
+				Name:   i18n.T("Changes"),
 				Value:  fmt.Sprintf("+%d −%d", pr.Additions, pr.Deletions),
 				Inline: true,
 			},
@@ -215,15 +292,356 @@ func FormatPRUpdated(pr *github.PullRequest) ThreadMessage {
 	}
 }
 
+// FormatPRDraftChanged 格式化「PR 轉為 ready for review / draft」的訊息
+func FormatPRDraftChanged(pr *github.PullRequest, changedBy string) ThreadMessage {
+	title := i18n.Tf("👀 PR #%d Ready for Review", pr.Number)
+	description := i18n.Tf("**%s** is ready for review", pr.Title)
+	color := ColorGreen
+	if pr.Draft {
+		title = i18n.Tf("📝 PR #%d Converted to Draft", pr.Number)
+		description = i18n.Tf("**%s** was converted back to a draft", pr.Title)
+		color = ColorGray
+	}
+
+	embed := Embed{
+		Title:       title,
+		Description: description,
+		URL:         pr.HTMLURL,
+		Color:       color,
+		Fields: []EmbedField{
+			{
+				Name:   i18n.T("Changed by"),
+				Value:  fmt.Sprintf("@%s", changedBy),
+				Inline: true,
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatIssueOpened 格式化「Issue 開啟」的訊息（issue thread 的開頭訊息）
+func FormatIssueOpened(issue *github.Issue) ThreadMessage {
+	description := truncateMarkdown(ConvertGitHubMarkdownFor(issue.Body, issue.HTMLURL), 500)
+	if description == "" {
+		description = i18n.T("*No description provided*")
+	}
+
+	embed := Embed{
+		Title:       i18n.Tf("Issue #%d Opened", issue.Number),
+		Description: description,
+		URL:         issue.HTMLURL,
+		Color:       ColorGreen,
+		Fields: []EmbedField{
+			{
+				Name:   i18n.T("Author"),
+				Value:  fmt.Sprintf("[@%s](%s)", issue.User.Login, issue.User.HTMLURL),
+				Inline: true,
+			},
+		},
+		Timestamp: issue.CreatedAt.Format(time.RFC3339),
+		Author:    authorFromUser(issue.User),
+		Footer: &EmbedFooter{
+			Text:    "GitHub",
+			IconURL: "https://github.githubassets.com/images/modules/logos_page/GitHub-Mark.png",
+		},
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatIssueClosed 格式化「Issue 關閉」的訊息
+func FormatIssueClosed(issue *github.Issue, closedBy string) ThreadMessage {
+	embed := Embed{
+		Title:       i18n.Tf("✅ Issue #%d Closed", issue.Number),
+		Description: i18n.Tf("**%s** has been closed", issue.Title),
+		URL:         issue.HTMLURL,
+		Color:       ColorPurple,
+		Fields: []EmbedField{
+			{
+				Name:   i18n.T("Closed by"),
+				Value:  fmt.Sprintf("@%s", closedBy),
+				Inline: true,
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+		Footer: &EmbedFooter{
+			Text: i18n.T("Thread will be archived soon"),
+		},
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatIssueReopened 格式化「Issue 重新開啟」的訊息
+func FormatIssueReopened(issue *github.Issue, reopenedBy string) ThreadMessage {
+	embed := Embed{
+		Title:       i18n.Tf("🔄 Issue #%d Reopened", issue.Number),
+		Description: i18n.Tf("**%s** has been reopened by @%s", issue.Title, reopenedBy),
+		URL:         issue.HTMLURL,
+		Color:       ColorYellow,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatIssueComment 格式化 issue / PR 留言（作者、permalink、留言內容截斷至 1000 字）
+func FormatIssueComment(comment *github.Comment, number int) ThreadMessage {
+	body := truncateMarkdown(ConvertGitHubMarkdownFor(comment.Body, comment.HTMLURL), 1000)
+	if body == "" {
+		body = i18n.T("*Empty comment*")
+	}
+
+	embed := Embed{
+		Title:       i18n.Tf("💬 Comment by @%s on #%d", comment.User.Login, number),
+		Description: body,
+		URL:         comment.HTMLURL,
+		Color:       ColorGray,
+		Timestamp:   comment.CreatedAt.Format(time.RFC3339),
+		Author:      authorFromUser(comment.User),
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatReviewComment 格式化 PR 程式碼上的 review 留言，附上被留言的程式碼片段（diff hunk 的最後幾行）
+func FormatReviewComment(comment *github.Comment, prNumber int) ThreadMessage {
+	description := truncateMarkdown(ConvertGitHubMarkdownFor(comment.Body, comment.HTMLURL), 1000)
+	if snippet := diffSnippet(comment.DiffHunk, 6); snippet != "" {
+		description += "\n```diff\n" + snippet + "\n```"
+	}
+
+	location := comment.Path
+	if comment.Line > 0 {
+		location = fmt.Sprintf("%s:%d", comment.Path, comment.Line)
+	}
+
+	embed := Embed{
+		Title:       i18n.Tf("💬 Review comment by @%s on PR #%d", comment.User.Login, prNumber),
+		Description: description,
+		URL:         comment.HTMLURL,
+		Color:       ColorGray,
+		Timestamp:   comment.CreatedAt.Format(time.RFC3339),
+		Author:      authorFromUser(comment.User),
+	}
+	if location != "" {
+		embed.Fields = []EmbedField{{Name: i18n.T("File"), Value: fmt.Sprintf("`%s`", location)}}
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// diffSnippet 取 diff hunk 的最後 maxLines 行（留言對應的是 hunk 的最後一行），去掉 "@@" 標頭
+// 每行限制長度，避免超長的程式碼行撐爆 embed
+func diffSnippet(hunk string, maxLines int) string {
+	if hunk == "" {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimRight(hunk, "\n"), "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "@@") {
+		lines = lines[1:]
+	}
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	for i, line := range lines {
+		// 避免程式碼裡的 ``` 提早結束 code block
+		lines[i] = truncateRunes(strings.ReplaceAll(line, "```", "`\u200b``"), 120)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatRelease 格式化 release 訊息：tag、release 名稱、release notes（轉換後截斷）和 asset 下載連結
+func FormatRelease(release *github.Release, repoFullName string) ThreadMessage {
+	name := release.Name
+	if name == "" {
+		name = release.TagName
+	}
+
+	title := fmt.Sprintf("🚀 %s %s", repoFullName, name)
+	if release.Prerelease {
+		title += i18n.T(" (pre-release)")
+	}
+
+	notes := ConvertGitHubMarkdownFor(release.Body, release.HTMLURL)
+	if notes == "" {
+		notes = i18n.T("*No release notes*")
+	}
+
+	embed := Embed{
+		Title:       truncateRunes(title, 256),
+		Description: truncateMarkdown(notes, 3000),
+		URL:         release.HTMLURL,
+		Color:       ColorPurple,
+		Fields: []EmbedField{
+			{
+				Name:   i18n.T("Tag"),
+				Value:  fmt.Sprintf("`%s`", release.TagName),
+				Inline: true,
+			},
+		},
+		Timestamp: release.PublishedAt.Format(time.RFC3339),
+		Author:    authorFromUser(release.Author),
+	}
+
+	if len(release.Assets) > 0 {
+		var links []string
+		for _, asset := range release.Assets {
+			links = append(links, fmt.Sprintf("[%s](%s) (%s)", asset.Name, asset.BrowserDownloadURL, formatSize(asset.Size)))
+		}
+		// embed field value 上限 1024 字元
+		embed.Fields = append(embed.Fields, EmbedField{
+			Name:  i18n.T("Assets"),
+			Value: truncateRunes(strings.Join(links, "\n"), 1024),
+		})
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// formatSize 把 byte 數轉成易讀的大小（KB / MB / GB）
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// FormatDiscussionCreated 格式化 discussion thread 的開頭訊息
+func FormatDiscussionCreated(d *github.Discussion) ThreadMessage {
+	description := truncateMarkdown(ConvertGitHubMarkdownFor(d.Body, d.HTMLURL), 1000)
+	if description == "" {
+		description = i18n.T("*No description provided*")
+	}
+
+	embed := Embed{
+		Title:       i18n.Tf("💭 Discussion #%d: %s", d.Number, truncateRunes(d.Title, 200)),
+		Description: description,
+		URL:         d.HTMLURL,
+		Color:       ColorPurple,
+		Timestamp:   d.CreatedAt.Format(time.RFC3339),
+		Author:      authorFromUser(d.User),
+	}
+	// Discord 拒絕 value 為空字串的 field
+	if d.Category.Name != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Category"), Value: d.Category.Name, Inline: true})
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatDiscussionAnswered 格式化「discussion 已有解答」的訊息，附上被標記為 answer 的留言
+func FormatDiscussionAnswered(d *github.Discussion, answer *github.Comment) ThreadMessage {
+	embed := Embed{
+		Title: i18n.Tf("✅ Discussion #%d answered", d.Number),
+		URL:   d.AnswerHTMLURL,
+		Color: ColorGreen,
+	}
+	if answer != nil {
+		embed.Description = i18n.Tf("Answer by @%s:\n\n%s", answer.User.Login, truncateMarkdown(ConvertGitHubMarkdownFor(answer.Body, answer.HTMLURL), 1000))
+		embed.URL = answer.HTMLURL
+		embed.Author = authorFromUser(answer.User)
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatMilestone 格式化 milestone 進度（open / closed 數量、進度條、due date）
+func FormatMilestone(m *github.Milestone, repoFullName string) ThreadMessage {
+	title := i18n.Tf("🎯 Milestone: %s", m.Title)
+	color := ColorYellow
+	if m.State == "closed" {
+		title = i18n.Tf("🏁 Milestone closed: %s", m.Title)
+		color = ColorPurple
+	}
+
+	progress := m.Progress()
+	filled := progress / 10
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", 10-filled)
+
+	description := fmt.Sprintf("`%s` %d%%", bar, progress)
+	if m.Description != "" {
+		description = truncateRunes(m.Description, 500) + "\n\n" + description
+	}
+
+	embed := Embed{
+		Title:       truncateRunes(title, 256),
+		Description: description,
+		URL:         m.HTMLURL,
+		Color:       color,
+		Fields: []EmbedField{
+			{Name: i18n.T("Open"), Value: fmt.Sprintf("%d", m.OpenIssues), Inline: true},
+			{Name: i18n.T("Closed"), Value: fmt.Sprintf("%d", m.ClosedIssues), Inline: true},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+		Footer:    &EmbedFooter{Text: repoFullName},
+	}
+	if m.DueOn != nil {
+		// Discord timestamp 格式會依照看的人的時區顯示
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Due"), Value: FormatDate(*m.DueOn), Inline: true})
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// authorFromUser 用 GitHub 使用者（頭像、帳號、profile 連結）組出 embed author
+func authorFromUser(user github.User) *EmbedAuthor {
+	if user.Login == "" {
+		return nil
+	}
+	return &EmbedAuthor{
+		Name:    user.Login,
+		URL:     user.HTMLURL,
+		IconURL: user.AvatarURL,
+	}
+}
+
+// thumbnailFromUser 用 GitHub 頭像當 embed 縮圖，沒有頭像時回傳 nil
+func thumbnailFromUser(user github.User) *EmbedImage {
+	if user.AvatarURL == "" {
+		return nil
+	}
+	return &EmbedImage{URL: user.AvatarURL}
+}
+
 // formatReviewState 轉換 review state 成易讀的文字
 func formatReviewState(state string) string {
 	switch state {
 	case "approved":
-		return "✅ Approved"
+		return i18n.T("✅ Approved")
 	case "changes_requested":
-		return "🔴 Changes Requested"
+		return i18n.T("🔴 Changes Requested")
 	case "commented":
-		return "💬 Commented"
+		return i18n.T("💬 Commented")
 	default:
 		return state
 	}
@@ -238,19 +656,19 @@ func FormatWorkflowRunResult(wr *github.WorkflowRun) ThreadMessage {
 	switch wr.Conclusion {
 	case "success":
 		emoji = "✅"
-		title = fmt.Sprintf("%s CI Passed", emoji)
+		title = i18n.Tf("%s CI Passed", emoji)
 		color = ColorGreen
 	case "failure":
 		emoji = "❌"
-		title = fmt.Sprintf("%s CI Failed", emoji)
+		title = i18n.Tf("%s CI Failed", emoji)
 		color = ColorRed
 	case "timed_out":
 		emoji = "⏰"
-		title = fmt.Sprintf("%s CI Timed Out", emoji)
+		title = i18n.Tf("%s CI Timed Out", emoji)
 		color = ColorRed
 	case "cancelled":
 		emoji = "🚫"
-		title = fmt.Sprintf("%s CI Cancelled", emoji)
+		title = i18n.Tf("%s CI Cancelled", emoji)
 		color = ColorGray
 	default:
 		emoji = "❓"
@@ -272,31 +690,806 @@ func FormatWorkflowRunResult(wr *github.WorkflowRun) ThreadMessage {
 		Color:       color,
 		Timestamp:   time.Now().Format(time.RFC3339),
 	}
+	if wr.HeadBranch != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Branch"), Value: fmt.Sprintf("`%s`", wr.HeadBranch), Inline: true})
+	}
+	if started := FormatTime(wr.RunStartedAt); started != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Started"), Value: started, Inline: true})
+	}
+	if d := wr.Duration(); d > 0 {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Duration"), Value: d.Round(time.Second).String(), Inline: true})
+	}
+	if wr.RunNumber > 0 {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Run"), Value: fmt.Sprintf("[#%d](%s)", wr.RunNumber, wr.HTMLURL), Inline: true})
+	}
 
 	return ThreadMessage{
 		Embeds: []Embed{embed},
 	}
 }
 
-// FormatThreadTitle 格式化 thread 標題（限制 100 字元）
-// repoFullName 格式為 "owner/repo"，只取 repo 名稱作為前綴
-func FormatThreadTitle(prNumber int, prTitle string, repoFullName string) string {
+// FormatIssueThreadTitle 格式化 issue thread 標題，格式和 PR 相同（"repo#123: title"）
+func FormatIssueThreadTitle(issueNumber int, issueTitle string, repoFullName string) string {
+	return FormatThreadTitle(issueNumber, issueTitle, repoFullName)
+}
+
+// FormatReleaseThreadTitle 格式化 release thread 標題："repo v1.2.0: release 名稱"
+func FormatReleaseThreadTitle(release *github.Release, repoFullName string) string {
 	repoName := repoFullName
-	if idx := len(repoFullName) - 1; idx >= 0 {
-		for i := idx; i >= 0; i-- {
-			if repoFullName[i] == '/' {
-				repoName = repoFullName[i+1:]
-				break
-			}
-		}
+	if idx := strings.LastIndex(repoFullName, "/"); idx >= 0 {
+		repoName = repoFullName[idx+1:]
+	}
+	return BuildThreadName(fmt.Sprintf("%s %s:", repoName, release.TagName), release.Name)
+}
+
+// FormatCheckRun 格式化單一 check 的結果（名稱、conclusion、details 連結和摘要）
+func FormatCheckRun(cr *github.CheckRun) ThreadMessage {
+	color := ColorRed
+	emoji := "❌"
+	switch cr.Conclusion {
+	case "success":
+		color, emoji = ColorGreen, "✅"
+	case "timed_out":
+		emoji = "⏰"
+	case "action_required":
+		color, emoji = ColorYellow, "⚠️"
+	case "cancelled", "skipped", "neutral":
+		color, emoji = ColorGray, "🚫"
+	}
+
+	url := cr.DetailsURL
+	if url == "" {
+		url = cr.HTMLURL
+	}
+
+	description := fmt.Sprintf("**%s** — `%s`", cr.Name, cr.Conclusion)
+	if cr.Output.Title != "" {
+		description += "\n" + cr.Output.Title
+	}
+	if cr.Output.Summary != "" {
+		description += "\n\n" + truncateMarkdown(ConvertGitHubMarkdown(cr.Output.Summary), 800)
+	}
+
+	embed := Embed{
+		Title:       truncateRunes(fmt.Sprintf("%s Check %s: %s", emoji, cr.Conclusion, cr.Name), 256),
+		Description: description,
+		URL:         url,
+		Color:       color,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	if completed := FormatTime(cr.CompletedAt); completed != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Completed"), Value: completed, Inline: true})
+	}
+	if cr.App.Name != "" {
+		embed.Footer = &EmbedFooter{Text: cr.App.Name}
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatCheckSuite 格式化 check suite 的整體結果
+func FormatCheckSuite(cs *github.CheckSuite) ThreadMessage {
+	color, emoji := ColorRed, "❌"
+	if cs.Conclusion == "success" {
+		color, emoji = ColorGreen, "✅"
 	}
 
-	title := fmt.Sprintf("[%s] PR #%d: %s", repoName, prNumber, prTitle)
+	commitShort := cs.HeadSHA
+	if len(commitShort) > 7 {
+		commitShort = commitShort[:7]
+	}
 
-	// Discord forum thread title 限制 100 字元
-	if len(title) > 100 {
-		return title[:97] + "..."
+	embed := Embed{
+		Title:       fmt.Sprintf("%s %s checks: %s", emoji, cs.App.Name, cs.Conclusion),
+		Description: i18n.Tf("Commit `%s` on `%s`", commitShort, cs.HeadBranch),
+		Color:       color,
+		Timestamp:   time.Now().Format(time.RFC3339),
 	}
 
-	return title
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatStatusRollup 把同一個 commit 的所有 status context 合成一則訊息（每個 context 一行）
+// 任一個失敗就是紅色，還有 pending 是黃色，全部成功才是綠色
+func FormatStatusRollup(repoFullName, sha, branch string, statuses []github.CommitStatus) ThreadMessage {
+	commitShort := sha
+	if len(commitShort) > 7 {
+		commitShort = commitShort[:7]
+	}
+
+	var lines []string
+	color, summary := ColorGreen, i18n.T("✅ All checks passed")
+	pending := false
+	for _, s := range statuses {
+		emoji := "⏳"
+		switch s.State {
+		case "success":
+			emoji = "✅"
+		case "failure", "error":
+			emoji = "❌"
+			color, summary = ColorRed, i18n.T("❌ Some checks failed")
+		default:
+			pending = true
+		}
+
+		line := fmt.Sprintf("%s **%s**", emoji, s.Context)
+		if s.Description != "" {
+			line += " — " + truncateRunes(s.Description, 100)
+		}
+		if s.TargetURL != "" {
+			line += fmt.Sprintf(" ([details](%s))", s.TargetURL)
+		}
+		lines = append(lines, line)
+	}
+	if pending && color != ColorRed {
+		color, summary = ColorYellow, i18n.T("⏳ Checks in progress")
+	}
+
+	title := fmt.Sprintf("%s · `%s`", summary, commitShort)
+	if branch != "" {
+		title += i18n.Tf(" on `%s`", branch)
+	}
+
+	embed := Embed{
+		Title:       title,
+		Description: truncateRunes(strings.Join(lines, "\n"), MaxEmbedDescription),
+		URL:         github.WebURL(repoFullName + "/commit/" + sha),
+		Color:       color,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatDeployment 格式化「開始部署」的訊息
+func FormatDeployment(d *github.Deployment, repoFullName string) ThreadMessage {
+	commitShort := d.SHA
+	if len(commitShort) > 7 {
+		commitShort = commitShort[:7]
+	}
+
+	description := fmt.Sprintf("`%s` (`%s`) → **%s**", d.Ref, commitShort, d.Environment)
+	if d.Description != "" {
+		description += "\n" + truncateRunes(d.Description, 500)
+	}
+
+	embed := Embed{
+		Title:       i18n.Tf("🚀 Deploying %s to %s…", repoFullName, d.Environment),
+		Description: description,
+		Color:       ColorYellow,
+		Timestamp:   d.CreatedAt.Format(time.RFC3339),
+		Author:      authorFromUser(d.Creator),
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatDeploymentStatus 格式化部署結果（成功 / 失敗）
+func FormatDeploymentStatus(status *github.DeploymentStatus, d *github.Deployment, repoFullName string) ThreadMessage {
+	environment := status.Environment
+	if environment == "" && d != nil {
+		environment = d.Environment
+	}
+
+	var title string
+	color := ColorGray
+	switch status.State {
+	case "success":
+		title = i18n.Tf("✅ %s deployed to %s", repoFullName, environment)
+		color = ColorGreen
+	case "failure", "error":
+		title = i18n.Tf("❌ Deployment of %s to %s failed", repoFullName, environment)
+		color = ColorRed
+	default:
+		title = i18n.Tf("ℹ️ Deployment of %s to %s: %s", repoFullName, environment, status.State)
+	}
+
+	url := status.LogURL
+	if url == "" {
+		url = status.TargetURL
+	}
+
+	embed := Embed{
+		Title:       title,
+		Description: truncateRunes(status.Description, 500),
+		URL:         url,
+		Color:       color,
+		Timestamp:   status.CreatedAt.Format(time.RFC3339),
+	}
+	if d != nil {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Ref"), Value: fmt.Sprintf("`%s`", d.Ref), Inline: true})
+	}
+	if status.EnvironmentURL != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Environment URL"), Value: status.EnvironmentURL, Inline: true})
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatRefChanged 格式化 branch / tag 建立或刪除的訊息
+func FormatRefChanged(refType, ref, repoFullName string, sender github.User, created bool) ThreadMessage {
+	emoji, verb, color := "🌱", i18n.T("created"), ColorGreen
+	if refType == "tag" {
+		emoji = "🏷️"
+	}
+	if !created {
+		emoji, verb, color = "🗑️", i18n.T("deleted"), ColorGray
+	}
+
+	embed := Embed{
+		Description: i18n.Tf("%s %s `%s` %s in **%s** by @%s", emoji, i18n.T(refType), ref, verb, repoFullName, sender.Login),
+		Color:       color,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	if created {
+		embed.URL = github.WebURL(repoFullName + "/tree/" + ref)
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// SeverityColor 依安全性 alert 的嚴重程度回傳顏色
+func SeverityColor(severity string) int {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return ColorDarkRed
+	case "high", "error":
+		return ColorRed
+	case "medium", "moderate", "warning":
+		return ColorOrange
+	case "low", "note":
+		return ColorYellow
+	default:
+		return ColorGray
+	}
+}
+
+// FormatDependabotAlert 格式化 dependabot alert：套件、受影響版本、修正版本和 CVE / GHSA 連結
+// roleMention 不為空時（critical）放在 content 讓 role 收到通知
+func FormatDependabotAlert(alert *github.Alert, action, repoFullName, roleMention string) ThreadMessage {
+	severity := alert.Severity()
+
+	pkg := i18n.T("unknown package")
+	if alert.Dependency != nil {
+		pkg = fmt.Sprintf("%s (%s)", alert.Dependency.Package.Name, alert.Dependency.Package.Ecosystem)
+	}
+
+	title := i18n.Tf("🛡️ Dependabot alert #%d: %s", alert.Number, pkg)
+	color := SeverityColor(severity)
+	if action == "fixed" || action == "dismissed" || action == "auto_dismissed" {
+		title = i18n.Tf("✅ Dependabot alert #%d %s: %s", alert.Number, i18n.T(strings.ReplaceAll(action, "_", " ")), pkg)
+		color = ColorGray
+	}
+
+	embed := Embed{
+		Title:     truncateRunes(title, 256),
+		URL:       alert.HTMLURL,
+		Color:     color,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Footer:    &EmbedFooter{Text: repoFullName},
+	}
+
+	if adv := alert.SecurityAdvisory; adv != nil {
+		embed.Description = truncateRunes(adv.Summary, 500)
+		var links []string
+		if adv.CVEID != "" {
+			links = append(links, fmt.Sprintf("[%s](https://nvd.nist.gov/vuln/detail/%s)", adv.CVEID, adv.CVEID))
+		}
+		if adv.GHSAID != "" {
+			links = append(links, fmt.Sprintf("[%s](%s)", adv.GHSAID, github.WebURL("advisories/"+adv.GHSAID)))
+		}
+		if len(links) > 0 {
+			embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Advisory"), Value: strings.Join(links, " · "), Inline: true})
+		}
+	}
+	if severity != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Severity"), Value: strings.ToUpper(severity), Inline: true})
+	}
+	if vuln := alert.SecurityVulnerability; vuln != nil {
+		if vuln.VulnerableVersionRange != "" {
+			embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Vulnerable"), Value: fmt.Sprintf("`%s`", vuln.VulnerableVersionRange), Inline: true})
+		}
+		if vuln.FirstPatchedVersion != nil && vuln.FirstPatchedVersion.Identifier != "" {
+			embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Patched"), Value: fmt.Sprintf("`%s`", vuln.FirstPatchedVersion.Identifier), Inline: true})
+		}
+	}
+	if alert.Dependency != nil && alert.Dependency.ManifestPath != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Manifest"), Value: fmt.Sprintf("`%s`", alert.Dependency.ManifestPath)})
+	}
+
+	return ThreadMessage{
+		Content: roleMention,
+		Embeds:  []Embed{embed},
+	}
+}
+
+// FormatCodeScanningAlert 格式化 code scanning alert：rule ID、位置、工具和 alert 連結（修正建議在 alert 頁面）
+func FormatCodeScanningAlert(alert *github.Alert, action, repoFullName, roleMention string) ThreadMessage {
+	ruleName := i18n.T("unknown rule")
+	if alert.Rule != nil {
+		ruleName = alert.Rule.Description
+		if ruleName == "" {
+			ruleName = alert.Rule.Name
+		}
+	}
+
+	title := i18n.Tf("🔍 Code scanning alert #%d: %s", alert.Number, ruleName)
+	color := SeverityColor(alert.Severity())
+	if action == "fixed" || action == "closed_by_user" {
+		title = i18n.Tf("✅ Code scanning alert #%d %s: %s", alert.Number, i18n.T(strings.ReplaceAll(action, "_", " ")), ruleName)
+		color = ColorGray
+	}
+
+	embed := Embed{
+		Title:     truncateRunes(title, 256),
+		URL:       alert.HTMLURL,
+		Color:     color,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Footer:    &EmbedFooter{Text: repoFullName},
+	}
+
+	if alert.Rule != nil && alert.Rule.ID != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Rule"), Value: fmt.Sprintf("`%s`", alert.Rule.ID), Inline: true})
+	}
+	if severity := alert.Severity(); severity != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Severity"), Value: strings.ToUpper(severity), Inline: true})
+	}
+	if alert.Tool != nil && alert.Tool.Name != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Tool"), Value: alert.Tool.Name, Inline: true})
+	}
+	if inst := alert.MostRecentInstance; inst != nil {
+		if inst.Location.Path != "" {
+			embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Location"), Value: fmt.Sprintf("`%s:%d`", inst.Location.Path, inst.Location.StartLine)})
+		}
+		embed.Description = truncateRunes(inst.Message.Text, 500)
+	}
+
+	return ThreadMessage{
+		Content: roleMention,
+		Embeds:  []Embed{embed},
+	}
+}
+
+// FormatSecretScanningAlert 格式化 secret scanning alert，secret 本身一律遮蔽
+func FormatSecretScanningAlert(alert *github.Alert, action, repoFullName, roleMention string) ThreadMessage {
+	secretType := alert.SecretTypeDisplayName
+	if secretType == "" {
+		secretType = alert.SecretType
+	}
+
+	title := i18n.Tf("🔑 Secret leaked: %s (alert #%d)", secretType, alert.Number)
+	color := ColorDarkRed
+	if action == "resolved" {
+		title = i18n.Tf("✅ Secret scanning alert #%d resolved: %s", alert.Number, secretType)
+		color = ColorGray
+	}
+
+	embed := Embed{
+		Title:       truncateRunes(title, 256),
+		Description: i18n.T("Revoke the secret with its provider first, then resolve the alert on GitHub."),
+		URL:         alert.HTMLURL,
+		Color:       color,
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Footer:      &EmbedFooter{Text: repoFullName},
+	}
+	if redacted := github.RedactSecret(alert.Secret); redacted != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Secret"), Value: fmt.Sprintf("`%s`", redacted), Inline: true})
+	}
+	if alert.Resolution != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Resolution"), Value: alert.Resolution, Inline: true})
+	}
+
+	return ThreadMessage{
+		Content: roleMention,
+		Embeds:  []Embed{embed},
+	}
+}
+
+// FormatWikiUpdate 格式化 wiki（gollum）變更：列出建立 / 修改的頁面和 diff 連結
+func FormatWikiUpdate(pages []github.WikiPage, repoFullName string, sender github.User) ThreadMessage {
+	var lines []string
+	for _, page := range pages {
+		line := fmt.Sprintf("📝 [%s](%s) %s", page.Title, page.HTMLURL, i18n.T(page.Action))
+		if page.Action == "edited" {
+			line += fmt.Sprintf(" ([diff](%s))", page.DiffURL())
+		}
+		if page.Summary != "" {
+			line += " — " + truncateRunes(page.Summary, 100)
+		}
+		lines = append(lines, line)
+	}
+
+	embed := Embed{
+		Title:       i18n.Tf("📚 Wiki updated in %s", repoFullName),
+		Description: truncateRunes(strings.Join(lines, "\n"), MaxEmbedDescription),
+		URL:         github.WebURL(repoFullName + "/wiki"),
+		Color:       ColorGray,
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Author:      authorFromUser(sender),
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatPackagePublished 格式化套件發布：名稱、版本、registry 連結
+func FormatPackagePublished(pkg *github.Package, repoFullName string, sender github.User) ThreadMessage {
+	version := ""
+	url := pkg.HTMLURL
+	if v := pkg.PackageVersion; v != nil {
+		version = v.DisplayVersion()
+		if v.HTMLURL != "" {
+			url = v.HTMLURL
+		}
+	}
+
+	embed := Embed{
+		Title:     truncateRunes(i18n.Tf("📦 Package published: %s %s", pkg.Name, version), 256),
+		URL:       url,
+		Color:     ColorPurple,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Author:    authorFromUser(sender),
+		Footer:    &EmbedFooter{Text: repoFullName},
+	}
+	if pkg.PackageType != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Type"), Value: pkg.PackageType, Inline: true})
+	}
+	if version != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Version"), Value: fmt.Sprintf("`%s`", version), Inline: true})
+	}
+	if pkg.PackageVersion != nil && pkg.PackageVersion.PackageURL != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Install"), Value: fmt.Sprintf("`%s`", pkg.PackageVersion.PackageURL)})
+	} else if pkg.Registry != nil && pkg.Registry.URL != "" {
+		embed.Fields = append(embed.Fields, EmbedField{Name: i18n.T("Registry"), Value: pkg.Registry.URL})
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatRepositoryEvent 格式化 repository 建立 / 改名 / 封存 / 轉移的訊息
+func FormatRepositoryEvent(action string, repo github.Repository, previousFullName string, sender github.User) ThreadMessage {
+	var description string
+	color := ColorGray
+	switch action {
+	case "created":
+		description = i18n.Tf("📁 Repository **%s** created", repo.FullName)
+		color = ColorGreen
+	case "renamed":
+		description = i18n.Tf("✏️ Repository renamed from **%s** to **%s**", previousFullName, repo.FullName)
+	case "transferred":
+		description = i18n.Tf("🚚 Repository transferred from **%s** to **%s**", previousFullName, repo.FullName)
+	case "archived":
+		description = i18n.Tf("🗄️ Repository **%s** archived (read-only)", repo.FullName)
+		color = ColorYellow
+	case "unarchived":
+		description = i18n.Tf("📂 Repository **%s** unarchived", repo.FullName)
+	default:
+		description = fmt.Sprintf("Repository **%s** %s", repo.FullName, action)
+	}
+
+	embed := Embed{
+		Description: description + i18n.Tf(" by @%s", sender.Login),
+		URL:         repo.HTMLURL,
+		Color:       color,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatLowPriorityEvent 低優先 sender（例如 dependabot[bot]）的事件只發一行摘要
+func FormatLowPriorityEvent(ev event.Event) ThreadMessage {
+	subject := ev.Subject()
+	if ev.Title != "" {
+		subject += " " + truncateRunes(ev.Title, 100)
+	}
+	if ev.URL != "" {
+		subject = fmt.Sprintf("[%s](%s)", subject, ev.URL)
+	}
+
+	embed := Embed{
+		Description: fmt.Sprintf("🤖 **@%s** · `%s` · %s", ev.Actor.Login, ev.Key(), subject),
+		Color:       ColorGray,
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatInstallation 格式化 GitHub App 安裝 / 移除 / 增減 repo 的訊息
+func FormatInstallation(ghEvent string, payload *github.WebhookPayload) ThreadMessage {
+	account := payload.Sender.Login
+	if payload.Installation != nil && payload.Installation.Account != nil {
+		account = payload.Installation.Account.Login
+	}
+
+	var description string
+	color := ColorGray
+	switch payload.Action {
+	case "created":
+		description = i18n.Tf("🔌 GitHub App installed on **%s**", account)
+		color = ColorGreen
+	case "deleted":
+		description = i18n.Tf("🔌 GitHub App uninstalled from **%s**", account)
+		color = ColorRed
+	case "suspend":
+		description = i18n.Tf("⏸️ GitHub App suspended on **%s**", account)
+		color = ColorYellow
+	case "unsuspend":
+		description = i18n.Tf("▶️ GitHub App unsuspended on **%s**", account)
+	case "added":
+		description = i18n.Tf("➕ Repositories added to the GitHub App on **%s**", account)
+	case "removed":
+		description = i18n.Tf("➖ Repositories removed from the GitHub App on **%s**", account)
+	default:
+		description = fmt.Sprintf("GitHub App %s %s on **%s**", ghEvent, payload.Action, account)
+	}
+	description += i18n.Tf(" by @%s", payload.Sender.Login)
+
+	embed := Embed{
+		Description: description,
+		Color:       color,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	for _, group := range []struct {
+		name  string
+		repos []github.Repository
+	}{
+		{i18n.T("Repositories"), payload.Repositories},
+		{i18n.T("Added"), payload.ReposAdded},
+		{i18n.T("Removed"), payload.ReposRemoved},
+	} {
+		if len(group.repos) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(group.repos))
+		for _, r := range group.repos {
+			names = append(names, r.FullName)
+		}
+		embed.Fields = append(embed.Fields, EmbedField{
+			Name:  group.name,
+			Value: truncateRunes(strings.Join(names, ", "), 1024),
+		})
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatPing 格式化 webhook 連線確認訊息（"✅ Webhook connected for owner/repo"）
+func FormatPing(payload *github.WebhookPayload) ThreadMessage {
+	target := payload.Repository.FullName
+	url := payload.Repository.HTMLURL
+	if target == "" && payload.Organization != nil {
+		target = payload.Organization.Login
+		url = github.WebURL(target)
+	}
+
+	embed := Embed{
+		Title:     i18n.Tf("✅ Webhook connected for %s", target),
+		URL:       url,
+		Color:     ColorGreen,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if payload.Zen != "" {
+		embed.Description = "> " + payload.Zen
+	}
+	if payload.Hook != nil && len(payload.Hook.Events) > 0 {
+		embed.Fields = append(embed.Fields, EmbedField{
+			Name:  i18n.T("Events"),
+			Value: truncateRunes(strings.Join(payload.Hook.Events, ", "), 1024),
+		})
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatThreadTitle 格式化 thread 標題："repo#123: title"（限制 100 字元，會移除控制字元）
+// repoFullName 格式為 "owner/repo"，只取 repo 名稱作為前綴
+func FormatThreadTitle(prNumber int, prTitle string, repoFullName string) string {
+	repoName := repoFullName
+	if idx := strings.LastIndex(repoFullName, "/"); idx >= 0 {
+		repoName = repoFullName[idx+1:]
+	}
+
+	// Discord forum thread title 限制 100 字元，"repo#123" 一定保留，只截斷 PR 標題
+	return BuildThreadName(fmt.Sprintf("%s#%d:", repoName, prNumber), prTitle)
+}
+
+// MaxEmbedDescription embed description 的長度上限（Discord 限制 4096 字元）
+const MaxEmbedDescription = 4096
+
+// FormatPush 格式化 push 事件：branch、compare 連結和 commit 清單（最多 maxCommits 筆）
+func FormatPush(push *github.WebhookPayload, maxCommits int) ThreadMessage {
+	branch := push.RefName()
+	count := len(push.Commits)
+
+	title := i18n.Tf("📦 %d new commit(s) pushed to `%s`", count, branch)
+	color := ColorYellow
+	if push.Forced {
+		title = i18n.Tf("⚠️ Force-pushed %d commit(s) to `%s`", count, branch)
+		color = ColorRed
+	}
+
+	if maxCommits <= 0 {
+		maxCommits = count
+	}
+
+	var lines []string
+	for i, commit := range push.Commits {
+		if i >= maxCommits {
+			lines = append(lines, i18n.Tf("… and %d more commit(s)", count-maxCommits))
+			break
+		}
+		lines = append(lines, formatCommitLine(commit))
+	}
+
+	embed := Embed{
+		Title:       truncateRunes(title, 256),
+		Description: truncateRunes(strings.Join(lines, "\n"), MaxEmbedDescription),
+		URL:         push.Compare,
+		Color:       color,
+		Fields: []EmbedField{
+			{
+				Name:   i18n.T("Branch"),
+				Value:  fmt.Sprintf("`%s`", branch),
+				Inline: true,
+			},
+			{
+				Name:   i18n.T("Pushed by"),
+				Value:  fmt.Sprintf("@%s", push.Sender.Login),
+				Inline: true,
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+		Author:    authorFromUser(push.Sender),
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// formatCommitLine 單一 commit 的清單項目："- [`abc1234`](url) 第一行 commit message — author"
+func formatCommitLine(commit github.Commit) string {
+	sha := commit.ID
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+
+	subject, _, _ := strings.Cut(commit.Message, "\n")
+	subject = truncateRunes(strings.TrimSpace(subject), 72)
+
+	author := commit.Author.Name
+	if commit.Author.Username != "" {
+		author = "@" + commit.Author.Username
+	}
+
+	return fmt.Sprintf("- [`%s`](%s) %s — %s", sha, commit.URL, subject, author)
+}
+
+// FormatCommitReference 在被提到的 issue / PR thread 發「被 commit 參照」的通知
+func FormatCommitReference(commit github.Commit, repoFullName string, closes bool) ThreadMessage {
+	verb := i18n.T("Referenced by commit")
+	if closes {
+		verb = i18n.T("Will be closed by commit")
+	}
+
+	embed := Embed{
+		Description: i18n.Tf("🔗 %s in **%s**\n%s", verb, repoFullName, formatCommitLine(commit)),
+		Color:       ColorGray,
+	}
+	if !commit.Timestamp.IsZero() {
+		embed.Timestamp = commit.Timestamp.Format(time.RFC3339)
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatPRReference 在被提到的 issue / PR thread 發「被 PR 參照」的通知
+func FormatPRReference(pr *github.PullRequest, repoFullName string, closes bool) ThreadMessage {
+	verb := i18n.T("Referenced by")
+	if closes {
+		verb = i18n.T("Will be closed by")
+	}
+
+	embed := Embed{
+		Description: fmt.Sprintf("🔗 %s [%s#%d %s](%s) — @%s", verb, repoFullName, pr.Number, truncateRunes(pr.Title, 100), pr.HTMLURL, pr.User.Login),
+		Color:       ColorGray,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
+}
+
+// FormatDigest 格式化 repo 的定期摘要：開頭列出各 event key 的次數，接著依時間列出最多 maxLines 個事件
+// counts 是完整的次數（events 可能因為上限只保留一部分）
+func FormatDigest(repoFullName string, events []event.Event, counts map[string]int, since time.Time, maxLines int) ThreadMessage {
+	return formatEventSummary(func(total int) string { return i18n.Tf("🗞️ %s digest: %d event(s)", repoFullName, total) },
+		events, counts, since, maxLines)
+}
+
+// FormatBurst 格式化 repo 超過速率限制時合併的事件（內容和 FormatDigest 相同，標題不同）
+func FormatBurst(repoFullName string, events []event.Event, counts map[string]int, since time.Time, maxLines int) ThreadMessage {
+	return formatEventSummary(func(total int) string { return i18n.Tf("🌊 %s burst: %d event(s) combined", repoFullName, total) },
+		events, counts, since, maxLines)
+}
+
+func formatEventSummary(title func(total int) string, events []event.Event, counts map[string]int, since time.Time, maxLines int) ThreadMessage {
+	keys := make([]string, 0, len(counts))
+	total := 0
+	for key, n := range counts {
+		keys = append(keys, key)
+		total += n
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return strings.Compare(a, b)
+	})
+
+	var summary []string
+	for _, key := range keys {
+		summary = append(summary, fmt.Sprintf("`%s` ×%d", key, counts[key]))
+	}
+
+	lines := []string{strings.Join(summary, " · "), ""}
+	if s := FormatTime(since); s != "" {
+		lines = []string{i18n.Tf("Since %s", s), strings.Join(summary, " · "), ""}
+	}
+	for i, ev := range events {
+		if i == maxLines {
+			lines = append(lines, i18n.Tf("…and %d more", total-maxLines))
+			break
+		}
+		subject := ev.Subject()
+		if ev.Title != "" {
+			subject += " " + truncateRunes(ev.Title, 80)
+		}
+		if ev.URL != "" {
+			subject = fmt.Sprintf("[%s](%s)", subject, ev.URL)
+		}
+		lines = append(lines, fmt.Sprintf("- `%s` @%s · %s", ev.Key(), ev.Actor.Login, subject))
+	}
+
+	embed := Embed{
+		Title:       title(total),
+		Description: truncateRunes(strings.Join(lines, "\n"), MaxEmbedDescription),
+		Color:       ColorGray,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	if len(events) > 0 {
+		embed.URL = repoURLFromPage(events[0].URL)
+	}
+
+	return ThreadMessage{
+		Embeds: []Embed{embed},
+	}
 }
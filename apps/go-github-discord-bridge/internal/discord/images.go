@@ -0,0 +1,102 @@
+package discord
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MaxGalleryImages Discord 把同一則訊息中 URL 相同的 embed 合併成圖庫，最多顯示 4 張
+const MaxGalleryImages = 4
+
+var (
+	codeFencePattern     = regexp.MustCompile("(?s)```.*?```")
+	markdownImagePattern = regexp.MustCompile(`!\[[^\]]*\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	bareURLPattern       = regexp.MustCompile(`(?m)^\s*(https://\S+)\s*$`)
+)
+
+// imageExtensions 視為圖片的副檔名
+var imageExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".webp"}
+
+// ExtractImageURLs 依出現順序取出 issue / PR 內文裡的圖片網址（最多 max 個，不含 code block 內的）
+// 包含 markdown 圖片、<img> 和單獨一行貼上的 GitHub 附件網址（拖曳上傳的截圖）
+func ExtractImageURLs(md string, max int) []string {
+	if max <= 0 || md == "" {
+		return nil
+	}
+	md = codeFencePattern.ReplaceAllString(md, "")
+
+	type match struct {
+		pos int
+		url string
+	}
+	var matches []match
+	for _, m := range markdownImagePattern.FindAllStringSubmatchIndex(md, -1) {
+		matches = append(matches, match{m[0], md[m[2]:m[3]]})
+	}
+	for _, m := range htmlImagePattern.FindAllStringSubmatchIndex(md, -1) {
+		matches = append(matches, match{m[0], md[m[2]:m[3]]})
+	}
+	for _, m := range bareURLPattern.FindAllStringSubmatchIndex(md, -1) {
+		if u := md[m[2]:m[3]]; isGitHubAttachment(u) {
+			matches = append(matches, match{m[0], u})
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int { return a.pos - b.pos })
+
+	var urls []string
+	for _, m := range matches {
+		if len(urls) == max {
+			break
+		}
+		if isImageURL(m.url) && !slices.Contains(urls, m.url) {
+			urls = append(urls, m.url)
+		}
+	}
+	return urls
+}
+
+// isGitHubAttachment 拖曳上傳到 issue / PR 的檔案網址
+func isGitHubAttachment(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch u.Host {
+	case "user-images.githubusercontent.com", "private-user-images.githubusercontent.com":
+		return true
+	case "github.com":
+		return strings.HasPrefix(u.Path, "/user-attachments/assets/")
+	}
+	return false
+}
+
+// isImageURL https 網址，且副檔名是圖片或是 GitHub 附件（user-attachments 沒有副檔名）
+func isImageURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return false
+	}
+	return slices.Contains(imageExtensions, strings.ToLower(path.Ext(u.Path))) || isGitHubAttachment(raw)
+}
+
+// WithImages 第一張圖設為第一個 embed 的大圖，其餘各用一個只有圖片的 embed（URL 和第一個 embed 相同，Discord 會合併成圖庫）
+// 第一個 embed 沒有 URL 時合併不了，只放第一張
+func WithImages(message ThreadMessage, urls []string) ThreadMessage {
+	if len(message.Embeds) == 0 || len(urls) == 0 {
+		return message
+	}
+
+	first := message.Embeds[0]
+	first.Image = &EmbedImage{URL: urls[0]}
+	embeds := []Embed{first}
+	if first.URL != "" {
+		for _, u := range urls[1:min(len(urls), MaxGalleryImages)] {
+			embeds = append(embeds, Embed{URL: first.URL, Image: &EmbedImage{URL: u}})
+		}
+	}
+	message.Embeds = append(embeds, message.Embeds[1:]...)
+	return message
+}
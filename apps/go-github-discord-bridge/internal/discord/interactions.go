@@ -0,0 +1,314 @@
+package discord
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/i18n"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// Interaction 類型
+const (
+	InteractionTypePing               = 1
+	InteractionTypeApplicationCommand = 2
+	InteractionTypeMessageComponent   = 3
+	InteractionTypeAutocomplete       = 4
+	InteractionTypeModalSubmit        = 5
+)
+
+// Interaction 回應類型
+const (
+	ResponseTypePong                     = 1
+	ResponseTypeChannelMessageWithSource = 4
+	ResponseTypeDeferredChannelMessage   = 5
+	ResponseTypeDeferredUpdateMessage    = 6
+	ResponseTypeUpdateMessage            = 7
+	ResponseTypeModal                    = 9
+)
+
+// MessageFlagEphemeral 只有觸發 interaction 的人看得到的訊息
+const MessageFlagEphemeral = 1 << 6
+
+// Interaction Discord 送到 interactions endpoint 的 payload
+type Interaction struct {
+	ID            string           `json:"id"`
+	ApplicationID string           `json:"application_id"`
+	Type          int              `json:"type"`
+	Data          *InteractionData `json:"data,omitempty"`
+	GuildID       string           `json:"guild_id,omitempty"`
+	ChannelID     string           `json:"channel_id,omitempty"`
+	Member        *GuildMember     `json:"member,omitempty"` // guild 內觸發時才有
+	User          *DiscordUser     `json:"user,omitempty"`   // DM 觸發時才有
+	Token         string           `json:"token"`
+	Message       *MessageResponse `json:"message,omitempty"` // component interaction 所屬的訊息
+}
+
+// InteractionData slash command / component / modal 的資料
+type InteractionData struct {
+	ID            string              `json:"id,omitempty"`
+	Name          string              `json:"name,omitempty"` // slash command 名稱
+	Type          int                 `json:"type,omitempty"`
+	Options       []InteractionOption `json:"options,omitempty"`
+	CustomID      string              `json:"custom_id,omitempty"` // component / modal 的 custom_id
+	ComponentType int                 `json:"component_type,omitempty"`
+	Values        []string            `json:"values,omitempty"` // select menu 選取的值
+
+	TargetID   string        `json:"target_id,omitempty"`  // message command 的目標訊息 ID
+	Resolved   *ResolvedData `json:"resolved,omitempty"`   // message command 的目標訊息內容
+	Components []Component   `json:"components,omitempty"` // modal submit 填寫的內容
+}
+
+// ResolvedData interaction 參照到的物件（只取需要的欄位）
+type ResolvedData struct {
+	Messages map[string]MessageResponse `json:"messages,omitempty"`
+}
+
+// InteractionOption slash command 的參數（sub command 會再巢狀一層 Options）
+type InteractionOption struct {
+	Name    string              `json:"name"`
+	Type    int                 `json:"type"`
+	Value   any                 `json:"value,omitempty"`
+	Options []InteractionOption `json:"options,omitempty"`
+}
+
+// GuildMember guild 成員資訊（只取需要的欄位）
+type GuildMember struct {
+	User  *DiscordUser `json:"user,omitempty"`
+	Nick  string       `json:"nick,omitempty"`
+	Roles []string     `json:"roles,omitempty"`
+}
+
+// DiscordUser Discord 使用者資訊
+type DiscordUser struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	GlobalName string `json:"global_name,omitempty"`
+	Bot        bool   `json:"bot,omitempty"`
+}
+
+// DisplayName 有設定 global name（顯示名稱）時用它，否則用 username
+func (u *DiscordUser) DisplayName() string {
+	if u.GlobalName != "" {
+		return u.GlobalName
+	}
+	return u.Username
+}
+
+// InteractionResponse 回給 Discord 的 interaction 回應
+type InteractionResponse struct {
+	Type int                      `json:"type"`
+	Data *InteractionResponseData `json:"data,omitempty"`
+}
+
+// InteractionResponseData interaction 回應的訊息內容
+type InteractionResponseData struct {
+	Content string  `json:"content,omitempty"`
+	Embeds  []Embed `json:"embeds,omitempty"`
+	Flags   int     `json:"flags,omitempty"`
+
+	// modal 才用到的欄位（ResponseTypeModal）
+	CustomID   string      `json:"custom_id,omitempty"`
+	Title      string      `json:"title,omitempty"`
+	Components []Component `json:"components,omitempty"`
+}
+
+// Invoker 回傳觸發 interaction 的使用者（guild 內從 member 取，DM 從 user 取）
+func (i *Interaction) Invoker() *DiscordUser {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// SubCommand 回傳 slash command 的 sub command 名稱和它的參數（例如 /issue status → "status"）
+// 沒有 sub command 時回傳空字串和第一層參數
+func (i *Interaction) SubCommand() (string, []InteractionOption) {
+	if i.Data == nil {
+		return "", nil
+	}
+	for _, opt := range i.Data.Options {
+		if opt.Type == OptionTypeSubCommand {
+			return opt.Name, opt.Options
+		}
+	}
+	return "", i.Data.Options
+}
+
+// OptionString 從參數列表取出字串參數（integer 參數會轉成十進位字串）
+func OptionString(options []InteractionOption, name string) string {
+	for _, opt := range options {
+		if opt.Name != name {
+			continue
+		}
+		switch v := opt.Value.(type) {
+		case string:
+			return v
+		case float64:
+			return fmt.Sprintf("%d", int64(v))
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// EphemeralReply 建立只有觸發者看得到的文字回應
+func EphemeralReply(content string) *InteractionResponse {
+	return &InteractionResponse{
+		Type: ResponseTypeChannelMessageWithSource,
+		Data: &InteractionResponseData{
+			Content: content,
+			Flags:   MessageFlagEphemeral,
+		},
+	}
+}
+
+// InteractionHandler 處理單一 interaction，回傳要回給 Discord 的回應
+type InteractionHandler func(interaction *Interaction) (*InteractionResponse, error)
+
+// InteractionRouter 驗證 Discord 簽名後，依 command 名稱或 custom_id 前綴分派 interaction
+// custom_id 慣例為 "prefix:args"，例如 "reply:owner/repo#123"
+type InteractionRouter struct {
+	publicKey ed25519.PublicKey
+
+	mu         sync.RWMutex
+	commands   map[string]InteractionHandler
+	components map[string]InteractionHandler
+}
+
+// NewInteractionRouter 建立 interaction router，publicKeyHex 為 Developer Portal 上的 Public Key
+func NewInteractionRouter(publicKeyHex string) (*InteractionRouter, error) {
+	key, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key length %d", len(key))
+	}
+
+	return &InteractionRouter{
+		publicKey:  ed25519.PublicKey(key),
+		commands:   make(map[string]InteractionHandler),
+		components: make(map[string]InteractionHandler),
+	}, nil
+}
+
+// HandleCommand 註冊 slash command（application command）的 handler
+func (r *InteractionRouter) HandleCommand(name string, handler InteractionHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands[name] = handler
+}
+
+// HandleComponent 註冊 component（button、select menu）和 modal submit 的 handler，依 custom_id 前綴比對
+func (r *InteractionRouter) HandleComponent(prefix string, handler InteractionHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.components[prefix] = handler
+}
+
+// interactionMaxSkew X-Signature-Timestamp 和現在最多差多久，超過的視為重放的舊 request
+const interactionMaxSkew = 5 * time.Minute
+
+// VerifyInteraction 驗證 Discord 的 Ed25519 簽名（簽的內容是 timestamp + body），timestamp（unix 秒）超過 interactionMaxSkew 的拒絕
+func (r *InteractionRouter) VerifyInteraction(body []byte, signatureHex, timestamp string) bool {
+	sig, err := hex.DecodeString(signatureHex)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := time.Since(time.Unix(unix, 0)); skew > interactionMaxSkew || skew < -interactionMaxSkew {
+		return false
+	}
+	msg := append([]byte(timestamp), body...)
+	return ed25519.Verify(r.publicKey, msg, sig)
+}
+
+// maxInteractionBodySize interaction body 的上限；body 在驗證簽名前就要讀進記憶體，不能讓未驗證的請求無限制地送
+const maxInteractionBodySize = 64 << 10
+
+// ServeHTTP 實作 http.Handler，掛在 interactions endpoint
+func (r *InteractionRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxInteractionBodySize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "payload too large"})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to read body"})
+		return
+	}
+
+	// Discord 會定期送錯誤簽名測試 endpoint，驗證失敗一定要回 401
+	if !r.VerifyInteraction(body, req.Header.Get("X-Signature-Ed25519"), req.Header.Get("X-Signature-Timestamp")) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid request signature"})
+		return
+	}
+
+	var interaction Interaction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+
+	if interaction.Type == InteractionTypePing {
+		writeJSON(w, http.StatusOK, InteractionResponse{Type: ResponseTypePong})
+		return
+	}
+
+	handler := r.lookup(&interaction)
+	if handler == nil {
+		writeJSON(w, http.StatusOK, EphemeralReply("Unknown interaction"))
+		return
+	}
+
+	resp, err := handler(&interaction)
+	if err != nil {
+		// 錯誤可能帶內部細節（storage、GitHub API 的錯誤），只寫進 log，不回給使用者
+		applogger.Log.Error("Failed to handle interaction", "interactionID", interaction.ID, "command", interaction.Data.Name, "customID", interaction.Data.CustomID, "error", err)
+		writeJSON(w, http.StatusOK, EphemeralReply(i18n.T("❌ Something went wrong, please try again later.")))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// lookup 依 interaction 類型找對應的 handler
+func (r *InteractionRouter) lookup(interaction *Interaction) InteractionHandler {
+	if interaction.Data == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	switch interaction.Type {
+	case InteractionTypeApplicationCommand:
+		return r.commands[interaction.Data.Name]
+	case InteractionTypeMessageComponent, InteractionTypeModalSubmit:
+		prefix, _, _ := strings.Cut(interaction.Data.CustomID, ":")
+		return r.components[prefix]
+	default:
+		return nil
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,252 @@
+package discord
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+	headingPattern     = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*$`)
+	taskListPattern    = regexp.MustCompile(`^(\s*)[-*] \[([ xX])\] `)
+	blankLinesPattern  = regexp.MustCompile(`\n{3,}`)
+
+	// [text](url "title") 和 ![alt](url)
+	linkPattern = regexp.MustCompile(`(!?)\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+
+	footnoteDefPattern = regexp.MustCompile(`^\[\^([^\]]+)\]:\s*`)
+	footnoteRefPattern = regexp.MustCompile(`\[\^([^\]]+)\]`)
+
+	htmlBreakPattern   = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlDetailsPattern = regexp.MustCompile(`(?i)</?details[^>]*>`)
+	htmlSummaryPattern = regexp.MustCompile(`(?is)<summary[^>]*>(.*?)</summary>`)
+	htmlImagePattern   = regexp.MustCompile(`(?i)<img\s[^>]*?src=["']([^"']+)["'][^>]*>`)
+
+	tableSeparatorPattern = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+)
+
+// ConvertGitHubMarkdown 把 GitHub markdown 轉成 embed 能正常顯示的格式（相對連結不轉換）
+func ConvertGitHubMarkdown(md string) string {
+	return ConvertGitHubMarkdownFor(md, "")
+}
+
+// ConvertGitHubMarkdownFor 把 GitHub markdown 轉成 embed 能正常顯示的格式
+// embed description 不支援標題、表格、圖片和 HTML：
+//   - 標題轉粗體、task list 轉 ✅ / ⬜、移除 HTML comment（PR / release template 常見）
+//   - 表格轉成對齊的 code block
+//   - 圖片（markdown 和 <img>）轉成連結，<details> / <summary> / <br> 轉成純文字
+//   - footnote 的 [^1] 轉成 [1]
+//   - pageURL（issue / PR / release 的 html_url）不為空時，相對連結依所在 repo 轉成絕對網址
+func ConvertGitHubMarkdownFor(md, pageURL string) string {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	md = htmlCommentPattern.ReplaceAllString(md, "")
+	md = htmlSummaryPattern.ReplaceAllString(md, "**$1**\n")
+	md = htmlDetailsPattern.ReplaceAllString(md, "")
+	md = htmlBreakPattern.ReplaceAllString(md, "\n")
+	md = htmlImagePattern.ReplaceAllString(md, "![image]($1)")
+
+	repoURL := repoURLFromPage(pageURL)
+
+	lines := strings.Split(md, "\n")
+	out := make([]string, 0, len(lines))
+	inCodeBlock := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			out = append(out, line)
+			continue
+		}
+		if inCodeBlock {
+			out = append(out, line)
+			continue
+		}
+
+		if n := tableLength(lines[i:]); n > 0 {
+			out = append(out, formatTable(lines[i:i+n])...)
+			i += n - 1
+			continue
+		}
+
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			line = "**" + m[1] + "**"
+		} else if m := taskListPattern.FindStringSubmatch(line); m != nil {
+			box := "⬜ "
+			if m[2] != " " {
+				box = "✅ "
+			}
+			line = m[1] + "- " + box + line[len(m[0]):]
+		}
+
+		line = footnoteDefPattern.ReplaceAllString(line, "[$1] ")
+		line = footnoteRefPattern.ReplaceAllString(line, "[$1]")
+		line = rewriteLinks(line, repoURL, pageURL)
+		out = append(out, line)
+	}
+
+	md = strings.Join(out, "\n")
+	md = blankLinesPattern.ReplaceAllString(md, "\n\n")
+	return strings.TrimSpace(md)
+}
+
+// truncateMarkdown 截斷 ConvertGitHubMarkdown 的結果：先截斷，切在 code block（包含轉換後的表格）中間時再補上結尾的 ```，
+// 不然 embed 後面的內容都會被當成 code；補上的 ``` 也算在 max 裡
+func truncateMarkdown(md string, max int) string {
+	const closing = "\n```"
+	truncated := truncateRunes(md, max)
+	if !openCodeFence(truncated) {
+		return truncated
+	}
+	truncated = truncateRunes(md, max-len(closing))
+	if !openCodeFence(truncated) {
+		return truncated
+	}
+	return truncated + closing
+}
+
+// openCodeFence md 結尾是否還在 code block 裡（``` 的行數是奇數）
+func openCodeFence(md string) bool {
+	open := false
+	for _, line := range strings.Split(md, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			open = !open
+		}
+	}
+	return open
+}
+
+// rewriteLinks 圖片轉成「🖼️ alt」連結，相對連結轉成絕對網址
+func rewriteLinks(line, repoURL, pageURL string) string {
+	return linkPattern.ReplaceAllStringFunc(line, func(match string) string {
+		m := linkPattern.FindStringSubmatch(match)
+		image, text, target := m[1] == "!", m[2], absoluteURL(m[3], repoURL, pageURL)
+		if image {
+			if text == "" {
+				text = "image"
+			}
+			text = "🖼️ " + text
+		}
+		return "[" + text + "](" + target + ")"
+	})
+}
+
+// absoluteURL 相對連結轉絕對網址：
+// "#anchor" 接在 pageURL 後面、"/owner/repo/..." 接在 host 後面、其他相對路徑視為 repo 預設 branch 上的檔案
+func absoluteURL(target, repoURL, pageURL string) string {
+	if repoURL == "" {
+		return target
+	}
+	if u, err := url.Parse(target); err != nil || u.Scheme != "" {
+		return target
+	}
+
+	switch {
+	case strings.HasPrefix(target, "#"):
+		if i := strings.IndexByte(pageURL, '#'); i >= 0 {
+			pageURL = pageURL[:i]
+		}
+		return pageURL + target
+	case strings.HasPrefix(target, "/"):
+		u, _ := url.Parse(repoURL)
+		return u.Scheme + "://" + u.Host + target
+	default:
+		return repoURL + "/blob/HEAD/" + strings.TrimPrefix(target, "./")
+	}
+}
+
+// repoURLFromPage 從 "https://github.com/owner/repo/issues/1" 這類網址取出 "https://github.com/owner/repo"
+func repoURLFromPage(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	parts := strings.SplitN(strings.Trim(u.Path, "/"), "/", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + "/" + parts[0] + "/" + parts[1]
+}
+
+// tableLength lines 開頭是表格（表頭 + 分隔列）時回傳表格的行數，否則回傳 0
+func tableLength(lines []string) int {
+	if len(lines) < 2 || !strings.Contains(lines[0], "|") || !tableSeparatorPattern.MatchString(strings.TrimSpace(lines[1])) {
+		return 0
+	}
+	n := 2
+	for n < len(lines) && strings.Contains(lines[n], "|") && strings.TrimSpace(lines[n]) != "" {
+		n++
+	}
+	return n
+}
+
+// formatTable 把 markdown 表格轉成欄位對齊的 code block（分隔列改成 ─）
+func formatTable(lines []string) []string {
+	var rows [][]string
+	var widths []int
+	for i, line := range lines {
+		if i == 1 {
+			continue // 分隔列
+		}
+		cells := splitTableRow(line)
+		for j, cell := range cells {
+			if j >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[j] = max(widths[j], utf8.RuneCountInString(cell))
+		}
+		rows = append(rows, cells)
+	}
+
+	out := []string{"```"}
+	for i, cells := range rows {
+		var b strings.Builder
+		for j := range widths {
+			cell := ""
+			if j < len(cells) {
+				cell = cells[j]
+			}
+			if j > 0 {
+				b.WriteString(" │ ")
+			}
+			b.WriteString(cell)
+			if j < len(widths)-1 {
+				b.WriteString(strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell)))
+			}
+		}
+		out = append(out, strings.TrimRight(b.String(), " "))
+
+		if i == 0 {
+			var sep []string
+			for _, w := range widths {
+				sep = append(sep, strings.Repeat("─", w))
+			}
+			out = append(out, strings.Join(sep, "─┼─"))
+		}
+	}
+	return append(out, "```")
+}
+
+// splitTableRow "| a | b |" → ["a", "b"]（\| 不當作分隔）
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
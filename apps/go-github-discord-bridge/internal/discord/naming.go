@@ -0,0 +1,122 @@
+package discord
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxThreadNameLength Discord thread 名稱上限（字元數）
+const MaxThreadNameLength = 100
+
+// truncateSuffix 標題被截斷時加在最後的字串
+const truncateSuffix = "..."
+
+// SanitizeThreadName 整理 thread 名稱：換行 / tab 換成空白、移除其他控制字元和零寬字元、壓縮連續空白
+// Discord 會默默拿掉部分字元，先整理好才能準確計算長度
+func SanitizeThreadName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+
+	lastSpace := true // 開頭的空白直接丟掉
+	for _, r := range name {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t' || unicode.IsSpace(r):
+			if !lastSpace {
+				b.WriteRune(' ')
+				lastSpace = true
+			}
+		case unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+			// 控制字元、零寬字元（U+200B 等）、bidi 控制字元
+			continue
+		default:
+			b.WriteRune(r)
+			lastSpace = false
+		}
+	}
+
+	return strings.TrimRight(b.String(), " ")
+}
+
+// BuildThreadName 組出 thread 名稱：prefix（例如 "[repo] PR #123: "）完整保留，只截斷 title
+// 以字元（rune）計算長度，避免把多位元組字元（中文、emoji）切壞；盡量在空白處截斷
+func BuildThreadName(prefix, title string) string {
+	prefix = SanitizeThreadName(prefix)
+	if prefix != "" {
+		prefix += " "
+	}
+	title = SanitizeThreadName(title)
+
+	budget := MaxThreadNameLength - len([]rune(prefix))
+	if budget <= len(truncateSuffix) {
+		// prefix 本身就快超過上限（極長的 repo 名稱），只能整體硬切
+		runes := []rune(prefix + title)
+		return string(runes[:min(len(runes), MaxThreadNameLength)])
+	}
+
+	titleRunes := []rune(title)
+	if len(titleRunes) <= budget {
+		return prefix + title
+	}
+
+	cut := titleRunes[:budget-len(truncateSuffix)]
+	// 最後 20 個字元內有空白就在空白處切，避免切在單字中間
+	if idx := lastSpaceIndex(cut); idx > 0 && len(cut)-idx <= 20 {
+		cut = cut[:idx]
+	}
+
+	return prefix + strings.TrimRight(string(cut), " ") + truncateSuffix
+}
+
+func lastSpaceIndex(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if runes[i] == ' ' {
+			return i
+		}
+	}
+	return -1
+}
+
+// truncateRunes 以字元為單位截斷字串，超過 max 時保留 max-3 個字元並加上 "..."
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	if max <= len(truncateSuffix) {
+		return string(runes[:max])
+	}
+	return string(runes[:max-len(truncateSuffix)]) + truncateSuffix
+}
+
+// PrefixThreadName 在已組好的 thread 名稱前面加上 emoji 等前綴，加上之後超過上限時截斷結尾
+func PrefixThreadName(prefix, name string) string {
+	prefix = SanitizeThreadName(prefix)
+	if prefix == "" {
+		return name
+	}
+	return truncateRunes(prefix+" "+name, MaxThreadNameLength)
+}
+
+// ReplaceTitleEmoji 把 embed 標題開頭的 emoji（"🎉 PR #1 Merged" 的 "🎉"）換成 emoji；標題沒有 emoji 開頭時直接加在前面
+func ReplaceTitleEmoji(title, emoji string) string {
+	if emoji == "" {
+		return title
+	}
+	if first, rest, ok := strings.Cut(title, " "); ok && isEmojiToken(first) {
+		title = rest
+	}
+	return emoji + " " + title
+}
+
+// isEmojiToken token 裡沒有字母、數字和標點（"🎉"、"⚠️"、"🔴" 這類 emoji 和 variation selector）
+func isEmojiToken(token string) bool {
+	if token == "" {
+		return false
+	}
+	for _, r := range token {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsPunct(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package discord
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Discord message nonce 最長 25 個字元
+const maxNonceLength = 25
+
+// DefaultNonceTTL 記住已送出 nonce 的時間，涵蓋 GitHub 自動 / 手動 redeliver 的常見間隔
+const DefaultNonceTTL = 30 * time.Minute
+
+// MessageNonce 把任意 key（通常含 GitHub delivery GUID）轉成 Discord 可接受的 nonce
+// GUID 本身 36 字元超過上限，所以取 sha256 前 25 個 hex 字元
+func MessageNonce(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:maxNonceLength]
+}
+
+// nonceCache 記住最近成功送出的 nonce → message ID
+// Discord 的 enforce_nonce 只保證幾分鐘內不重複，redeliver 可能更晚才到，所以自己再記一份
+type nonceCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]nonceEntry
+}
+
+type nonceEntry struct {
+	messageID string
+	seenAt    time.Time
+}
+
+func newNonceCache(ttl time.Duration) *nonceCache {
+	return &nonceCache{
+		ttl:     ttl,
+		entries: make(map[string]nonceEntry),
+	}
+}
+
+// lookup 回傳 nonce 對應的 message ID，以及是否在 TTL 內見過
+func (nc *nonceCache) lookup(nonce string) (string, bool) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	entry, ok := nc.entries[nonce]
+	if !ok || time.Since(entry.seenAt) > nc.ttl {
+		return "", false
+	}
+	return entry.messageID, true
+}
+
+// remember 記錄已送出的 nonce，順便清掉過期的項目
+func (nc *nonceCache) remember(nonce, messageID string) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range nc.entries {
+		if now.Sub(entry.seenAt) > nc.ttl {
+			delete(nc.entries, k)
+		}
+	}
+	nc.entries[nonce] = nonceEntry{messageID: messageID, seenAt: now}
+}
@@ -0,0 +1,204 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Discord permission bits（只列 bridge 需要的）
+const (
+	PermissionAdministrator         int64 = 1 << 3
+	PermissionManageChannels        int64 = 1 << 4
+	PermissionViewChannel           int64 = 1 << 10
+	PermissionSendMessages          int64 = 1 << 11
+	PermissionManageThreads         int64 = 1 << 34
+	PermissionCreatePublicThreads   int64 = 1 << 35
+	PermissionSendMessagesInThreads int64 = 1 << 38
+)
+
+// requiredPermissions bridge 在 forum channel 上需要的權限，以及缺少時的說明
+var requiredPermissions = []struct {
+	bit  int64
+	name string
+	why  string
+}{
+	{PermissionViewChannel, "VIEW_CHANNEL", "bot 看不到 forum channel"},
+	{PermissionSendMessages, "SEND_MESSAGES", "無法在 forum 發新貼文"},
+	{PermissionCreatePublicThreads, "CREATE_PUBLIC_THREADS", "無法為 PR 建立 thread"},
+	{PermissionSendMessagesInThreads, "SEND_MESSAGES_IN_THREADS", "無法在 thread 內發送事件通知"},
+	{PermissionManageChannels, "MANAGE_CHANNELS", "無法建立 repo forum tag"},
+}
+
+// PermissionOverwrite channel 的權限覆寫（type 0 = role，1 = member）
+type PermissionOverwrite struct {
+	ID    string `json:"id"`
+	Type  int    `json:"type"`
+	Allow string `json:"allow"`
+	Deny  string `json:"deny"`
+}
+
+// Role guild role（只取計算權限需要的欄位）
+type Role struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Permissions string `json:"permissions"`
+}
+
+// Guild guild 資訊（只取計算權限需要的欄位）
+type Guild struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	OwnerID string `json:"owner_id"`
+	Roles   []Role `json:"roles"`
+}
+
+// PreflightError 啟動檢查失敗，Problems 列出所有需要修正的項目
+type PreflightError struct {
+	Problems []string
+}
+
+func (e *PreflightError) Error() string {
+	return "discord preflight failed:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// GetCurrentUser 取得 bot 自己的使用者資訊（也用來驗證 token）
+func (c *Client) GetCurrentUser(ctx context.Context) (*DiscordUser, error) {
+	var user DiscordUser
+	if err := c.request(ctx, "GET", c.endpoint("/users/@me"), nil, &user); err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+	return &user, nil
+}
+
+// Preflight 啟動時檢查 token、forum channel 和 bot 權限
+// 一次回報所有問題（*PreflightError），避免上線後才在 runtime 收到 403
+func (c *Client) Preflight(ctx context.Context) error {
+	bot, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			return &PreflightError{Problems: []string{"DISCORD_BOT_TOKEN 無效（401），請到 Developer Portal 重新產生 token"}}
+		}
+		return err
+	}
+
+	var channel Channel
+	if err := c.request(ctx, "GET", c.endpoint("/channels/%s", c.forumChannelID), nil, &channel); err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return &PreflightError{Problems: []string{fmt.Sprintf("找不到 channel %s，請確認 DISCORD_FORUM_CHANNEL_ID", c.forumChannelID)}}
+		case errors.Is(err, ErrMissingPermissions):
+			return &PreflightError{Problems: []string{fmt.Sprintf("bot 沒有權限讀取 channel %s（需要 VIEW_CHANNEL）", c.forumChannelID)}}
+		default:
+			return fmt.Errorf("failed to get forum channel: %w", err)
+		}
+	}
+
+	var problems []string
+	if channel.Type != ChannelTypeGuildForum {
+		problems = append(problems, fmt.Sprintf("channel %s（#%s）不是 forum channel（type %d），請指定 forum channel", channel.ID, channel.Name, channel.Type))
+	}
+
+	missing, err := c.MissingPermissions(ctx, &channel, bot.ID)
+	if err != nil {
+		return err
+	}
+	for _, p := range missing {
+		problems = append(problems, fmt.Sprintf("bot 在 #%s 缺少 %s 權限：%s", channel.Name, p.Name, p.Why))
+	}
+
+	if len(problems) > 0 {
+		return &PreflightError{Problems: problems}
+	}
+	return nil
+}
+
+// MissingPermission bot 缺少的必要權限和缺少時的影響
+type MissingPermission struct {
+	Name string
+	Why  string
+}
+
+// MissingPermissions 列出 bot 在 channel 上缺少的必要權限，channel 要是 GetChannel 取得的（含 guild_id 和 permission_overwrites）
+func (c *Client) MissingPermissions(ctx context.Context, channel *Channel, botID string) ([]MissingPermission, error) {
+	perms, err := c.channelPermissions(ctx, channel, botID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute bot permissions: %w", err)
+	}
+	var missing []MissingPermission
+	for _, p := range requiredPermissions {
+		if perms&p.bit == 0 {
+			missing = append(missing, MissingPermission{Name: p.name, Why: p.why})
+		}
+	}
+	return missing, nil
+}
+
+// channelPermissions 依 Discord 的規則計算 bot 在 channel 上的有效權限
+// https://discord.com/developers/docs/topics/permissions#permission-overwrites
+func (c *Client) channelPermissions(ctx context.Context, channel *Channel, userID string) (int64, error) {
+	var guild Guild
+	if err := c.request(ctx, "GET", c.endpoint("/guilds/%s", channel.GuildID), nil, &guild); err != nil {
+		return 0, err
+	}
+
+	var member GuildMember
+	if err := c.request(ctx, "GET", c.endpoint("/guilds/%s/members/%s", channel.GuildID, userID), nil, &member); err != nil {
+		return 0, err
+	}
+
+	if guild.OwnerID == userID {
+		return ^int64(0), nil
+	}
+
+	rolePerms := make(map[string]int64, len(guild.Roles))
+	for _, role := range guild.Roles {
+		rolePerms[role.ID] = parsePermissions(role.Permissions)
+	}
+
+	// @everyone role 的 ID 等於 guild ID
+	perms := rolePerms[guild.ID]
+	for _, roleID := range member.Roles {
+		perms |= rolePerms[roleID]
+	}
+	if perms&PermissionAdministrator != 0 {
+		return ^int64(0), nil
+	}
+
+	memberRoles := make(map[string]bool, len(member.Roles))
+	for _, roleID := range member.Roles {
+		memberRoles[roleID] = true
+	}
+
+	// 套用順序：@everyone → 所有 role（合併）→ member
+	var roleAllow, roleDeny int64
+	var memberOverwrite *PermissionOverwrite
+	for i, ow := range channel.PermissionOverwrites {
+		switch {
+		case ow.ID == guild.ID:
+			perms &^= parsePermissions(ow.Deny)
+			perms |= parsePermissions(ow.Allow)
+		case ow.Type == 0 && memberRoles[ow.ID]:
+			roleAllow |= parsePermissions(ow.Allow)
+			roleDeny |= parsePermissions(ow.Deny)
+		case ow.Type == 1 && ow.ID == userID:
+			memberOverwrite = &channel.PermissionOverwrites[i]
+		}
+	}
+	perms &^= roleDeny
+	perms |= roleAllow
+	if memberOverwrite != nil {
+		perms &^= parsePermissions(memberOverwrite.Deny)
+		perms |= parsePermissions(memberOverwrite.Allow)
+	}
+
+	return perms, nil
+}
+
+// parsePermissions Discord 的 permission 是十進位字串（超過 53 bit，JSON number 會失真）
+func parsePermissions(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
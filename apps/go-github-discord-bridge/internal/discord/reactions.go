@@ -0,0 +1,52 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// DefaultReaction forum channel 的 default_reaction_emoji（每個新 post 下方預設顯示的 reaction 按鈕）
+// EmojiID（custom emoji）和 EmojiName（unicode emoji）二選一
+type DefaultReaction struct {
+	EmojiID   string `json:"emoji_id,omitempty"`
+	EmojiName string `json:"emoji_name,omitempty"`
+}
+
+// newDefaultReaction 依 emoji 字串組出 DefaultReaction，規則和 forum tag 的 emoji 相同
+func newDefaultReaction(emoji string) *DefaultReaction {
+	if emoji == "" {
+		return nil
+	}
+	if IsSnowflake(emoji) {
+		return &DefaultReaction{EmojiID: emoji}
+	}
+	return &DefaultReaction{EmojiName: emoji}
+}
+
+// SetDefaultReactionEmoji 設定 forum channel 的 default_reaction_emoji，emoji 為空字串時清除
+// emoji 可以是 unicode emoji（例如 "👍"）或 custom emoji ID（純數字），需要 MANAGE_CHANNELS 權限
+func (c *Client) SetDefaultReactionEmoji(ctx context.Context, emoji string) error {
+	type PatchBody struct {
+		DefaultReactionEmoji *DefaultReaction `json:"default_reaction_emoji"`
+	}
+
+	reqBody := PatchBody{DefaultReactionEmoji: newDefaultReaction(emoji)}
+	if err := c.request(ctx, "PATCH", c.endpoint("/channels/%s", c.forumChannelID), reqBody, nil, withAuditLogReason("Set forum default reaction emoji")); err != nil {
+		return fmt.Errorf("failed to set default reaction emoji: %w", err)
+	}
+	return nil
+}
+
+// AddReaction 由 bot 對訊息加上 reaction
+// emoji 為 unicode emoji（例如 "🐛"）或 custom emoji 的 "name:id" 格式
+// forum post 的開頭訊息 ID 和 thread ID 相同，所以 messageID 可以直接傳 thread ID
+func (c *Client) AddReaction(ctx context.Context, channelID, messageID, emoji string) error {
+	if emoji == "" {
+		return nil
+	}
+	if err := c.request(ctx, "PUT", c.endpoint("/channels/%s/messages/%s/reactions/%s/@me", channelID, messageID, url.PathEscape(emoji)), nil, nil); err != nil {
+		return fmt.Errorf("failed to add reaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package discord
+
+import "regexp"
+
+// snowflakePattern Discord 的 ID（snowflake）是 17～20 位數字
+var snowflakePattern = regexp.MustCompile(`^[0-9]{17,20}$`)
+
+// IsSnowflake id 是不是 Discord ID（常見錯誤是貼成 channel 名稱或網址）
+func IsSnowflake(id string) bool {
+	return snowflakePattern.MatchString(id)
+}
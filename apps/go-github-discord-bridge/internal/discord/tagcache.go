@@ -0,0 +1,88 @@
+package discord
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultTagCacheTTL available_tags 快取的預設存活時間
+// tag 幾乎只會由 bridge 自己新增，PATCH 後會直接更新快取，所以 TTL 可以設長一點
+const DefaultTagCacheTTL = 10 * time.Minute
+
+// tagCache forum channel available_tags 的記憶體快取
+// 過期後同時進來的查詢透過 singleflight 合併成一次 GET
+type tagCache struct {
+	ttl time.Duration
+
+	mu        sync.RWMutex
+	tags      []ForumTag
+	fetchedAt time.Time
+
+	group singleflight.Group
+}
+
+func newTagCache(ttl time.Duration) *tagCache {
+	return &tagCache{ttl: ttl}
+}
+
+// get 回傳快取的 tags，過期或尚未載入時呼叫 fetch 重新取得
+func (tc *tagCache) get(fetch func() ([]ForumTag, error)) ([]ForumTag, error) {
+	tc.mu.RLock()
+	if tc.tags != nil && time.Since(tc.fetchedAt) < tc.ttl {
+		tags := tc.tags
+		tc.mu.RUnlock()
+		return tags, nil
+	}
+	tc.mu.RUnlock()
+
+	v, err, _ := tc.group.Do("available_tags", func() (any, error) {
+		tags, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		tc.set(tags)
+		return tags, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]ForumTag), nil
+}
+
+// set 以最新的 tags 覆寫快取（例如 PATCH 後 Discord 回傳的完整列表）
+// 含有 dry-run 模擬的 tag 時改為清掉快取，之後真的送出的 request 才不會用到假的 tag ID
+func (tc *tagCache) set(tags []ForumTag) {
+	if slices.ContainsFunc(tags, func(t ForumTag) bool { return IsDryRunID(t.ID) }) {
+		tc.invalidate()
+		return
+	}
+	if tags == nil {
+		tags = []ForumTag{}
+	}
+	tc.mu.Lock()
+	tc.tags = tags
+	tc.fetchedAt = time.Now()
+	tc.mu.Unlock()
+}
+
+// invalidate 清掉快取，下次 get 會重新 fetch
+func (tc *tagCache) invalidate() {
+	tc.mu.Lock()
+	tc.tags = nil
+	tc.mu.Unlock()
+}
+
+// name 依 ID 找快取裡的 tag 名稱（不會 fetch），找不到回傳空字串
+func (tc *tagCache) name(id string) string {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	for _, tag := range tc.tags {
+		if tag.ID == id {
+			return tag.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 訊息文字裡時間的顯示方式（DISCORD_TIME_STYLE）
+// absolute 以外都用 Discord 的 timestamp markup（<t:unix:R>），由 Discord 依每個讀者的時區顯示
+const (
+	TimeStyleRelative = "relative" // 「3 小時前」
+	TimeStyleShort    = "short"    // 「2026年10月14日 14:05」
+	TimeStyleLong     = "long"     // 含星期
+	TimeStyleDate     = "date"     // 只有日期
+	TimeStyleAbsolute = "absolute" // 固定時區的純文字（DISCORD_TIMEZONE + DISCORD_TIME_LAYOUT）
+)
+
+// timeStyleMarkup Discord timestamp markup 的樣式代碼
+var timeStyleMarkup = map[string]string{
+	TimeStyleRelative: "R",
+	TimeStyleShort:    "f",
+	TimeStyleLong:     "F",
+	TimeStyleDate:     "D",
+}
+
+// DefaultTimeLayout absolute 模式預設的時間格式
+const DefaultTimeLayout = "2006-01-02 15:04 MST"
+
+type timeSettings struct {
+	style    string
+	location *time.Location
+	layout   string
+}
+
+// currentTime 目前的設定（啟動時設定一次）；nil = relative
+var currentTime atomic.Pointer[timeSettings]
+
+// SetTimeFormat 設定訊息文字裡時間的顯示方式；timezone 是 IANA 名稱（"Asia/Taipei"），只有 absolute 會用到
+func SetTimeFormat(style, timezone, layout string) error {
+	settings, err := parseTimeFormat(style, timezone, layout)
+	if err != nil {
+		return err
+	}
+	currentTime.Store(settings)
+	return nil
+}
+
+// CheckTimeFormat 只檢查設定是否有效、不套用（reload 時先確認所有設定都有效才套用）
+func CheckTimeFormat(style, timezone, layout string) error {
+	_, err := parseTimeFormat(style, timezone, layout)
+	return err
+}
+
+func parseTimeFormat(style, timezone, layout string) (*timeSettings, error) {
+	style = strings.ToLower(strings.TrimSpace(style))
+	if style == "" {
+		style = TimeStyleRelative
+	}
+	if _, ok := timeStyleMarkup[style]; !ok && style != TimeStyleAbsolute {
+		return nil, fmt.Errorf("unknown time style %q", style)
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q: %w", timezone, err)
+	}
+	if layout == "" {
+		layout = DefaultTimeLayout
+	}
+	return &timeSettings{style: style, location: location, layout: layout}, nil
+}
+
+func timeFormat() timeSettings {
+	if s := currentTime.Load(); s != nil {
+		return *s
+	}
+	return timeSettings{style: TimeStyleRelative, location: time.UTC, layout: DefaultTimeLayout}
+}
+
+// FormatTime 依 DISCORD_TIME_STYLE 顯示時間點；zero time 回傳空字串
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	s := timeFormat()
+	if s.style == TimeStyleAbsolute {
+		return t.In(s.location).Format(s.layout)
+	}
+	return fmt.Sprintf("<t:%d:%s>", t.Unix(), timeStyleMarkup[s.style])
+}
+
+// FormatDate 只顯示日期（milestone 截止日等）：absolute 時依 DISCORD_TIMEZONE 顯示 YYYY-MM-DD，其他樣式用 <t:unix:D>
+func FormatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	s := timeFormat()
+	if s.style == TimeStyleAbsolute {
+		return t.In(s.location).Format(time.DateOnly)
+	}
+	return fmt.Sprintf("<t:%d:D>", t.Unix())
+}
+
+// LocalizeTimestamps absolute 模式時把 embed 的 timestamp（Discord 一律用讀者的時區顯示）改成 footer 裡固定時區的時間
+// 其他模式原樣回傳
+func LocalizeTimestamps(message ThreadMessage) ThreadMessage {
+	s := timeFormat()
+	if s.style != TimeStyleAbsolute {
+		return message
+	}
+
+	embeds := make([]Embed, len(message.Embeds))
+	for i, embed := range message.Embeds {
+		if t, err := time.Parse(time.RFC3339, embed.Timestamp); err == nil {
+			text := t.In(s.location).Format(s.layout)
+			footer := EmbedFooter{Text: text}
+			if embed.Footer != nil {
+				footer = *embed.Footer
+				if footer.Text != "" {
+					footer.Text += " · " + text
+				} else {
+					footer.Text = text
+				}
+			}
+			embed.Footer, embed.Timestamp = &footer, ""
+		}
+		embeds[i] = embed
+	}
+	message.Embeds = embeds
+	return message
+}
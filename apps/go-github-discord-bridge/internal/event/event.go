@@ -0,0 +1,62 @@
+// Package event 定義和來源無關的事件模型
+// 各 provider（目前只有 GitHub）把自己的 webhook payload 轉成 Event，過濾、路由和格式化只看 Event
+package event
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event 正規化後的事件
+type Event struct {
+	Provider   string // "github"
+	Type       string // 來源的事件類型，例如 "pull_request"、"issues"、"push"
+	Action     string // opened、closed 等；沒有 action 的事件（例如 push）為空字串
+	Repo       string // "owner/repo"，org 層級的事件為空字串
+	Actor      Actor  // 觸發事件的人
+	DeliveryID string
+
+	// 事件的主體（PR、issue、release 等），沒有主體的事件只有 URL
+	Number int // PR / issue / discussion 編號，沒有時為 0
+	Title  string
+	Body   string
+	URL    string
+	Labels []string
+
+	CreatedAt time.Time // 主體建立的時間
+	UpdatedAt time.Time // 事件發生的時間（payload 沒帶時為收到的時間）
+}
+
+// Actor 觸發事件的使用者
+type Actor struct {
+	Login     string
+	URL       string
+	AvatarURL string
+}
+
+// Key 回傳 "type.action"（沒有 action 時只有 type），和 announcement / reaction 設定的 key 格式相同
+func (e Event) Key() string {
+	if e.Action == "" {
+		return e.Type
+	}
+	return e.Type + "." + e.Action
+}
+
+// Subject 回傳主體的識別（"owner/repo#123"），沒有編號時回傳 repo
+func (e Event) Subject() string {
+	if e.Number == 0 {
+		return e.Repo
+	}
+	return e.Repo + "#" + strconv.Itoa(e.Number)
+}
+
+// HasLabel 是否帶有指定 label（不分大小寫）
+func (e Event) HasLabel(name string) bool {
+	for _, label := range e.Labels {
+		if strings.EqualFold(label, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,242 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/tracing"
+)
+
+// ErrNotFound API 回 404（repo / PR 不存在或 token 沒有權限）
+var ErrNotFound = errors.New("github: not found")
+
+// APIClient 呼叫 GitHub REST API 補齊 webhook payload 沒帶的資料（PR 改了哪些檔案、commit 完整訊息等）
+// 認證優先用 GitHub App 的 installation token（依 context 裡的 installation ID），沒有時用 personal access token
+type APIClient struct {
+	apiURL     string
+	token      string
+	app        *AppAuth
+	httpClient *http.Client
+}
+
+// NewAPIClient 建立 API client，token 和 app 都沒有時回傳 nil（不做 enrichment）
+func NewAPIClient(apiURL, token string, app *AppAuth) *APIClient {
+	if token == "" && app == nil {
+		return nil
+	}
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	return &APIClient{
+		apiURL:     strings.TrimRight(apiURL, "/"),
+		token:      token,
+		app:        app,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// PullRequestFile PR 變更的檔案
+type PullRequestFile struct {
+	Filename  string `json:"filename"`
+	Status    string `json:"status"` // added, removed, modified, renamed
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Patch     string `json:"patch,omitempty"` // 檔案太大或 binary 時沒有
+}
+
+// RepoCommit GET /repos/{owner}/{repo}/commits/{sha} 的回應（只取用到的欄位）
+type RepoCommit struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Commit  struct {
+		Message string       `json:"message"`
+		Author  CommitAuthor `json:"author"`
+	} `json:"commit"`
+}
+
+// ListPullRequestFiles 取得 PR 變更的檔案（最多 100 個，夠顯示摘要用）
+func (c *APIClient) ListPullRequestFiles(ctx context.Context, repoFullName string, number int) ([]PullRequestFile, error) {
+	var files []PullRequestFile
+	err := c.get(ctx, fmt.Sprintf("/repos/%s/pulls/%d/files?per_page=100", repoFullName, number), &files)
+	return files, err
+}
+
+// GetCommit 取得單一 commit（完整 commit message）
+func (c *APIClient) GetCommit(ctx context.Context, repoFullName, sha string) (*RepoCommit, error) {
+	var commit RepoCommit
+	if err := c.get(ctx, fmt.Sprintf("/repos/%s/commits/%s", repoFullName, url.PathEscape(sha)), &commit); err != nil {
+		return nil, err
+	}
+	return &commit, nil
+}
+
+// ListIssueLabels 取得 issue / PR 目前的 label
+func (c *APIClient) ListIssueLabels(ctx context.Context, repoFullName string, number int) ([]Label, error) {
+	var labels []Label
+	err := c.get(ctx, fmt.Sprintf("/repos/%s/issues/%d/labels?per_page=100", repoFullName, number), &labels)
+	return labels, err
+}
+
+// APIPageSize 列表 API 每頁筆數（GitHub 上限 100）
+const APIPageSize = 100
+
+// ListOpenIssues 取得 repo 目前開啟的 issue 第 page 頁（從 1 開始，舊的在前）
+// GitHub 的 issues API 也會回傳 PR（PullRequest 不為 nil），回傳筆數少於 APIPageSize 表示最後一頁
+func (c *APIClient) ListOpenIssues(ctx context.Context, repoFullName string, page int) ([]Issue, error) {
+	var issues []Issue
+	err := c.get(ctx, fmt.Sprintf("/repos/%s/issues?state=open&sort=created&direction=asc&per_page=%d&page=%d", repoFullName, APIPageSize, page), &issues)
+	return issues, err
+}
+
+// GetIssue 取得單一 issue（PR 也可以用，state 相同）
+func (c *APIClient) GetIssue(ctx context.Context, repoFullName string, number int) (*Issue, error) {
+	var issue Issue
+	if err := c.get(ctx, fmt.Sprintf("/repos/%s/issues/%d", repoFullName, number), &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// GetPullRequest 取得單一 PR（issues API 回傳的 PR 沒有 head / base 等欄位）
+func (c *APIClient) GetPullRequest(ctx context.Context, repoFullName string, number int) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.get(ctx, fmt.Sprintf("/repos/%s/pulls/%d", repoFullName, number), &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// CreateIssueComment 在 issue / PR 留言（PR 的一般留言也走 issues API），需要 issues / pull requests 的寫入權限
+func (c *APIClient) CreateIssueComment(ctx context.Context, repoFullName string, number int, body string) (*Comment, error) {
+	var comment Comment
+	payload := map[string]string{"body": body}
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repoFullName, number), payload, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// Gist GET /users/{login}/gists 的一筆（只取用到的欄位）
+type Gist struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	HTMLURL     string `json:"html_url"`
+}
+
+// ListUserGists 取得使用者最近的 public gist（最多 30 個，/github link 驗證帳號所有權用）
+func (c *APIClient) ListUserGists(ctx context.Context, login string) ([]Gist, error) {
+	var gists []Gist
+	err := c.get(ctx, fmt.Sprintf("/users/%s/gists?per_page=30", url.PathEscape(login)), &gists)
+	return gists, err
+}
+
+// ErrDiffTooLarge GetPullRequestDiff 的 diff 超過 maxBytes
+var ErrDiffTooLarge = errors.New("github: diff too large")
+
+// GetPullRequestDiff 取得 PR 的 unified diff（Accept: application/vnd.github.diff），超過 maxBytes 時回傳 ErrDiffTooLarge
+func (c *APIClient) GetPullRequestDiff(ctx context.Context, repoFullName string, number int, maxBytes int64) (string, error) {
+	path := fmt.Sprintf("/repos/%s/pulls/%d", repoFullName, number)
+	resp, err := c.send(ctx, http.MethodGet, path, "application/vnd.github.diff", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("github api GET %s: %w", path, err)
+	}
+	if int64(len(data)) > maxBytes {
+		return "", ErrDiffTooLarge
+	}
+	return string(data), nil
+}
+
+// get 送 GET 並把 JSON 回應解到 out
+func (c *APIClient) get(ctx context.Context, path string, out any) error {
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+// do 送 request；body 不為 nil 時以 JSON 送出，out 不為 nil 時解析 JSON 回應
+func (c *APIClient) do(ctx context.Context, method, path string, body any, out any) error {
+	resp, err := c.send(ctx, method, path, "application/vnd.github+json", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send 送 request 並檢查 status code，成功時由呼叫端關閉 resp.Body
+func (c *APIClient) send(ctx context.Context, method, path, accept string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	token, err := c.tokenFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	ctx, span := tracing.Start(ctx, "github api "+method, tracing.KindClient)
+	defer span.End()
+	span.SetAttr("http.request.method", method)
+	span.SetAttr("url.path", req.URL.Path)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		span.SetError(err)
+		return nil, fmt.Errorf("github api %s %s: %w", method, path, err)
+	}
+	span.SetAttr("http.response.status_code", resp.StatusCode)
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("github api %s %s: %w", method, path, ErrNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		err := fmt.Errorf("github api %s %s: status %d", method, path, resp.StatusCode)
+		span.SetError(err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// tokenFor 選擇這次 request 用的 token：有 installation ID 且設定了 GitHub App 時用 installation token
+func (c *APIClient) tokenFor(ctx context.Context) (string, error) {
+	if installationID := InstallationIDFromContext(ctx); c.app != nil && installationID != 0 {
+		return c.app.InstallationToken(ctx, installationID)
+	}
+	return c.token, nil
+}
@@ -0,0 +1,205 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAPIURL github.com 的 REST API
+const DefaultAPIURL = "https://api.github.com"
+
+// installationTokenSlack token 剩不到這段時間就提前換新，避免 request 途中過期
+const installationTokenSlack = 5 * time.Minute
+
+// AppAuth 以 GitHub App 身分呼叫 API：用 private key 簽 JWT，再換成各 installation 的 access token
+// installation token 效期 1 小時，這裡依 installation 快取到快過期為止
+type AppAuth struct {
+	appID      string
+	key        *rsa.PrivateKey
+	apiURL     string
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	tokens map[int64]installationToken
+}
+
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// NewAppAuth 建立 GitHub App 認證，privateKeyPEM 為 App 設定頁下載的 PEM（PKCS#1 或 PKCS#8）
+// apiURL 為空字串時使用 github.com
+func NewAppAuth(appID string, privateKeyPEM []byte, apiURL string) (*AppAuth, error) {
+	key, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	return &AppAuth{
+		appID:      appID,
+		key:        key,
+		apiURL:     strings.TrimRight(apiURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		tokens:     make(map[int64]installationToken),
+	}, nil
+}
+
+// JWT 產生呼叫 /app 系列 API 用的 JWT（RS256，效期 9 分鐘；iat 往前 60 秒容忍時鐘誤差）
+func (a *AppAuth) JWT() (string, error) {
+	now := time.Now()
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	claims := map[string]any{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.appID,
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// InvalidateTokens 清掉快取的 installation token，下次呼叫會重新取得（例如 App 權限變更後）
+func (a *AppAuth) InvalidateTokens() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	clear(a.tokens)
+}
+
+// InstallationToken 取得 installation 的 access token（有快取）
+func (a *AppAuth) InstallationToken(ctx context.Context, installationID int64) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if cached, ok := a.tokens[installationID]; ok && time.Until(cached.expiresAt) > installationTokenSlack {
+		return cached.token, nil
+	}
+
+	jwt, err := a.JWT()
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", a.apiURL, installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("failed to request installation token for %d: status %d", installationID, resp.StatusCode)
+	}
+
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode installation token: %w", err)
+	}
+
+	a.tokens[installationID] = installationToken{token: body.Token, expiresAt: body.ExpiresAt}
+	return body.Token, nil
+}
+
+// RepoInstallationID 查詢 App 安裝在 repo 上的 installation ID（不是從 webhook 觸發時用，例如 backfill）
+func (a *AppAuth) RepoInstallationID(ctx context.Context, repoFullName string) (int64, error) {
+	jwt, err := a.JWT()
+	if err != nil {
+		return 0, err
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/installation", a.apiURL, repoFullName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up installation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to look up installation for %s: status %d", repoFullName, resp.StatusCode)
+	}
+
+	var body struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to decode installation: %w", err)
+	}
+	return body.ID, nil
+}
+
+// Forget 丟掉 installation 的快取 token（App 被移除或停用時）
+func (a *AppAuth) Forget(installationID int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.tokens, installationID)
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("github app private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse github app private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("github app private key is not an RSA key")
+	}
+	return key, nil
+}
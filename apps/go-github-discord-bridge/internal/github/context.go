@@ -0,0 +1,57 @@
+package github
+
+import "context"
+
+type contextKey string
+
+const deliveryIDKey contextKey = "github-delivery-id"
+
+// WithDeliveryID 把 X-GitHub-Delivery（每次 delivery 的 GUID，redeliver 時不變）放進 context
+func WithDeliveryID(ctx context.Context, deliveryID string) context.Context {
+	return context.WithValue(ctx, deliveryIDKey, deliveryID)
+}
+
+// DeliveryIDFromContext 取出 delivery ID，沒有時回傳空字串
+func DeliveryIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(deliveryIDKey).(string)
+	return id
+}
+
+const installationIDKey contextKey = "github-installation-id"
+
+// WithInstallationID 把 payload 的 installation ID（GitHub App 模式才有）放進 context，API client 依此換 installation token
+func WithInstallationID(ctx context.Context, installationID int64) context.Context {
+	return context.WithValue(ctx, installationIDKey, installationID)
+}
+
+// InstallationIDFromContext 取出 installation ID，沒有時回傳 0
+func InstallationIDFromContext(ctx context.Context) int64 {
+	id, _ := ctx.Value(installationIDKey).(int64)
+	return id
+}
+
+const deliveryInfoKey contextKey = "github-delivery-info"
+
+// DeliveryInfo WebhookHandler 處理完一次 delivery 後填入的摘要，給 request log 等 middleware 使用
+type DeliveryInfo struct {
+	DeliveryID string
+	Event      string
+	Repo       string
+	Action     string
+	Result     string // processed、queued、ignored、skipped、failed、rejected、invalid、unauthorized 等
+	TraceID    string
+}
+
+// WithDeliveryInfo 在 request context 放一個空的 DeliveryInfo，WebhookHandler 會把結果寫進去
+func WithDeliveryInfo(ctx context.Context) (context.Context, *DeliveryInfo) {
+	info := &DeliveryInfo{}
+	return context.WithValue(ctx, deliveryInfoKey, info), info
+}
+
+// deliveryInfoFromContext 沒有 middleware 放 DeliveryInfo 時回傳一個丟棄用的，呼叫端不用判斷 nil
+func deliveryInfoFromContext(ctx context.Context) *DeliveryInfo {
+	if info, ok := ctx.Value(deliveryInfoKey).(*DeliveryInfo); ok {
+		return info
+	}
+	return &DeliveryInfo{}
+}
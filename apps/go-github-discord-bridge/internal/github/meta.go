@@ -0,0 +1,55 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// FetchHookRanges 從 GET {apiURL}/meta 取得 GitHub 送 webhook 的來源 IP 範圍（"hooks"），不需要認證
+// GitHub Enterprise Server 的 /meta 沒有 hooks 時回傳 error
+func FetchHookRanges(ctx context.Context, apiURL string) ([]netip.Prefix, error) {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(apiURL, "/")+"/meta", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch GitHub meta: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch GitHub meta: %s", resp.Status)
+	}
+
+	var meta struct {
+		Hooks []string `json:"hooks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return nil, fmt.Errorf("failed to decode GitHub meta: %w", err)
+	}
+	if len(meta.Hooks) == 0 {
+		return nil, fmt.Errorf("GitHub meta has no hook ranges")
+	}
+
+	ranges := make([]netip.Prefix, 0, len(meta.Hooks))
+	for _, cidr := range meta.Hooks {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hook range %q in GitHub meta: %w", cidr, err)
+		}
+		ranges = append(ranges, prefix.Masked())
+	}
+	return ranges, nil
+}
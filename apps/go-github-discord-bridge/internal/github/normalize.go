@@ -0,0 +1,85 @@
+package github
+
+import (
+	"context"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/event"
+)
+
+// Normalize 把 webhook payload 轉成和來源無關的 event.Event
+// 主體依序取 PR → issue → discussion → release → 其他事件專用欄位
+func (w *WebhookPayload) Normalize(ctx context.Context, ghEvent string) event.Event {
+	ev := event.Event{
+		Provider:   "github",
+		Type:       ghEvent,
+		Action:     w.Action,
+		Repo:       w.Repository.FullName,
+		Actor:      actorFromUser(w.Sender),
+		DeliveryID: DeliveryIDFromContext(ctx),
+		URL:        w.Repository.HTMLURL,
+		UpdatedAt:  time.Now(),
+	}
+
+	switch {
+	case w.PullRequest != nil:
+		pr := w.PullRequest
+		ev.Number, ev.Title, ev.Body, ev.URL = pr.Number, pr.Title, pr.Body, pr.HTMLURL
+		ev.Labels = labelNames(pr.Labels)
+		ev.CreatedAt = pr.CreatedAt
+		if !pr.UpdatedAt.IsZero() {
+			ev.UpdatedAt = pr.UpdatedAt
+		}
+	case w.Issue != nil:
+		issue := w.Issue
+		ev.Number, ev.Title, ev.Body, ev.URL = issue.Number, issue.Title, issue.Body, issue.HTMLURL
+		ev.Labels = labelNames(issue.Labels)
+		ev.CreatedAt = issue.CreatedAt
+	case w.Discussion != nil:
+		d := w.Discussion
+		ev.Number, ev.Title, ev.Body, ev.URL = d.Number, d.Title, d.Body, d.HTMLURL
+		ev.CreatedAt = d.CreatedAt
+	case w.Release != nil:
+		r := w.Release
+		ev.Title, ev.Body, ev.URL = r.Name, r.Body, r.HTMLURL
+		if ev.Title == "" {
+			ev.Title = r.TagName
+		}
+		ev.CreatedAt = r.PublishedAt
+	case w.WorkflowRun != nil:
+		wr := w.WorkflowRun
+		ev.Title, ev.URL = wr.Name, wr.HTMLURL
+		ev.CreatedAt = wr.RunStartedAt
+	case w.Alert != nil:
+		ev.URL = w.Alert.HTMLURL
+	case w.Compare != "":
+		ev.Title, ev.URL = w.RefName(), w.Compare
+		if w.HeadCommit != nil {
+			ev.Body = w.HeadCommit.Message
+		}
+	}
+
+	// issue_comment / review comment 的主體是 issue / PR，內容和連結換成留言本身
+	if c := w.Comment; c != nil {
+		ev.Body, ev.URL = c.Body, c.HTMLURL
+		ev.UpdatedAt = c.CreatedAt
+	}
+
+	return ev
+}
+
+func actorFromUser(u User) event.Actor {
+	return event.Actor{
+		Login:     u.Login,
+		URL:       u.HTMLURL,
+		AvatarURL: u.AvatarURL,
+	}
+}
+
+func labelNames(labels []Label) []string {
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
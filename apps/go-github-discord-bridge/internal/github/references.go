@@ -0,0 +1,51 @@
+package github
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// issueReferencePattern 比對 "#123"、"owner/repo#123" 和前面帶 closing keyword 的 "fixes #123"
+// 前面必須是開頭或非單字字元，避免誤判 URL fragment（例如 "page#12"）
+var issueReferencePattern = regexp.MustCompile(`(?i)(?:^|[^\w/#])(?:(close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+)?(?:([\w.-]+/[\w.-]+))?#(\d+)\b`)
+
+// IssueReference commit message 或 PR 內文中提到的 issue / PR
+type IssueReference struct {
+	Repo   string // "owner/repo"
+	Number int
+	Closes bool // 帶 closing keyword（fixes、closes、resolves）
+}
+
+// Key 回傳和 GetPRIdentifier 相同格式的 key（"owner/repo#123"）
+func (r IssueReference) Key() string {
+	return r.Repo + "#" + strconv.Itoa(r.Number)
+}
+
+// ParseIssueReferences 從文字中找出 issue / PR 參照，沒寫 repo 的參照視為 defaultRepo
+// 同一個 issue 只回傳一次（有任一次帶 closing keyword 就算 Closes）
+func ParseIssueReferences(text, defaultRepo string) []IssueReference {
+	var refs []IssueReference
+	index := make(map[string]int)
+
+	for _, m := range issueReferencePattern.FindAllStringSubmatch(text, -1) {
+		number, err := strconv.Atoi(m[3])
+		if err != nil || number == 0 {
+			continue
+		}
+		repo := m[2]
+		if repo == "" {
+			repo = defaultRepo
+		}
+
+		ref := IssueReference{Repo: repo, Number: number, Closes: m[1] != ""}
+		key := strings.ToLower(ref.Key())
+		if i, ok := index[key]; ok {
+			refs[i].Closes = refs[i].Closes || ref.Closes
+			continue
+		}
+		index[key] = len(refs)
+		refs = append(refs, ref)
+	}
+	return refs
+}
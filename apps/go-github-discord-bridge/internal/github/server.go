@@ -0,0 +1,508 @@
+package github
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/metrics"
+	"dizzycode1112/github-discord-bridge/internal/tracing"
+)
+
+// MaxPayloadSize 預設的 webhook payload 上限（GitHub 本身最大送 25 MB），可用 WithMaxBodySize 調整
+const MaxPayloadSize = 25 << 20
+
+// EventHandler 處理單一 GitHub event，event 為 X-GitHub-Event header（例如 "pull_request"）
+type EventHandler func(ctx context.Context, event string, payload *WebhookPayload) error
+
+// Delivery 一次已驗證簽名的 webhook delivery
+type Delivery struct {
+	ID      string          // X-GitHub-Delivery
+	Event   string          // X-GitHub-Event
+	Body    []byte          // 原始 payload，persistent queue 存這個
+	Payload *WebhookPayload // 解析後的 payload，nil 時 Process 會從 Body 解析
+}
+
+// Dispatcher 把 delivery 交給別人處理（例如放進 worker pool，之後呼叫 Process），回傳 nil 代表已接受、webhook 回 202 "queued"
+// 回傳錯誤時交給 ErrorHandler 寫出回應（例如 queue 滿了回 503）
+type Dispatcher func(ctx context.Context, delivery Delivery) error
+
+// Recorder 收到簽名正確、payload 可以解析的 delivery 時呼叫（在 repo filter 和分派之前），header 為原始的 request header
+// 用來保存原始 payload 供之後重新處理；不應該阻塞太久，錯誤由 Recorder 自己處理
+type Recorder func(ctx context.Context, header http.Header, delivery Delivery)
+
+// ErrorHandler EventHandler 回傳錯誤時寫出回應，可依錯誤類型決定 status code（例如 503 + Retry-After）
+type ErrorHandler func(w http.ResponseWriter, err error)
+
+// WebhookHandler 接收 GitHub webhook 的 http.Handler
+// 驗證 X-Hub-Signature-256 後依 X-GitHub-Event 分派到註冊的 EventHandler
+type WebhookHandler struct {
+	secrets     *webhookSecrets // SetSecrets 時整組換掉（h.mu 保護）
+	repoFilter  func(repoFullName string) bool
+	allowSHA1   bool       // 舊版 GHES 只送 X-Hub-Signature（HMAC-SHA1）
+	dispatch    Dispatcher // nil = 同步呼叫 handler
+	record      Recorder   // nil = 不保存原始 payload
+	maxBodySize int64
+
+	mu       sync.RWMutex
+	handlers map[string]EventHandler
+	fallback EventHandler
+	onError  ErrorHandler
+}
+
+// WebhookOption WebhookHandler 的設定選項
+type WebhookOption func(*WebhookHandler)
+
+// WithRepoSecrets 設定各 repo 專用的 webhook secret，key 為 "owner/repo"，或 "owner" 代表整個 org / user
+// 比對順序：完整 repo 名稱 → owner → 預設 secret
+func WithRepoSecrets(secrets map[string]string) WebhookOption {
+	return func(h *WebhookHandler) {
+		h.secrets.addRepoSecrets(secrets)
+	}
+}
+
+// WithSecondarySecret 設定第二把預設 secret，簽名符合任一把都接受
+// 輪替 secret 時先把新 secret 設為 secondary，GitHub 端更新完成後再換成 primary 並移除舊的
+func WithSecondarySecret(secret string) WebhookOption {
+	return func(h *WebhookHandler) {
+		h.secrets.secondary = secret
+	}
+}
+
+// WithRepoFilter 設定要處理哪些 repo，回傳 false 的 repo 直接回 202 "skipped" 不分派
+// org 層級 webhook 會收到所有 repo 的事件，用來只處理設定的 repo；沒有 repository 的事件不過濾
+func WithRepoFilter(allow func(repoFullName string) bool) WebhookOption {
+	return func(h *WebhookHandler) {
+		h.repoFilter = allow
+	}
+}
+
+// WithLegacySignature 沒有 X-Hub-Signature-256 時改驗 X-Hub-Signature（HMAC-SHA1）
+// 只給不支援 SHA-256 簽名的舊版 GitHub Enterprise Server 使用，github.com 不需要
+func WithLegacySignature() WebhookOption {
+	return func(h *WebhookHandler) {
+		h.allowSHA1 = true
+	}
+}
+
+// WithDispatcher 非同步處理事件：交給 dispatch 後立即回 202，不等 handler 跑完（避免 Discord 很慢時超過 GitHub 的 10 秒 timeout）
+// ping 一律同步處理
+func WithDispatcher(dispatch Dispatcher) WebhookOption {
+	return func(h *WebhookHandler) {
+		h.dispatch = dispatch
+	}
+}
+
+// WithRecorder 每個 delivery 在處理之前先交給 record 保存（含 ping 和之後被過濾的事件）
+func WithRecorder(record Recorder) WebhookOption {
+	return func(h *WebhookHandler) {
+		h.record = record
+	}
+}
+
+// WithMaxBodySize body 的上限（byte），超過時回 413 且不會讀進記憶體；<= 0 時用 MaxPayloadSize
+func WithMaxBodySize(n int64) WebhookOption {
+	return func(h *WebhookHandler) {
+		if n > 0 {
+			h.maxBodySize = n
+		}
+	}
+}
+
+// NewWebhookHandler 建立 webhook handler，secret 為預設 secret
+// 沒有設定任何 secret 時不驗證簽名（只建議在本機開發使用）
+func NewWebhookHandler(secret string, opts ...WebhookOption) *WebhookHandler {
+	h := &WebhookHandler{
+		secrets:     newWebhookSecrets(secret, "", nil),
+		handlers:    make(map[string]EventHandler),
+		onError:     defaultErrorHandler,
+		maxBodySize: MaxPayloadSize,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// SetSecrets 換成新的 primary / secondary / 各 repo 的 secret（secrets backend 輪替時），之後的 request 使用新的 secret
+func (h *WebhookHandler) SetSecrets(secret, secondary string, repoSecrets map[string]string) {
+	secrets := newWebhookSecrets(secret, secondary, repoSecrets)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.secrets = secrets
+}
+
+// On 註冊特定 event 的 handler
+func (h *WebhookHandler) On(event string, handler EventHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers[event] = handler
+}
+
+// OnDefault 註冊沒有對應 handler 的 event 要交給誰處理，沒設定時回 200 "ignored"
+func (h *WebhookHandler) OnDefault(handler EventHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fallback = handler
+}
+
+// OnError 設定 EventHandler 失敗時的回應方式，預設回 500
+func (h *WebhookHandler) OnError(handler ErrorHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onError = handler
+}
+
+// ServeHTTP 實作 http.Handler，掛在 GitHub webhook endpoint
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	// GitHub webhook 的 content type 要設為 application/json；form（application/x-www-form-urlencoded）格式不支援
+	if !isJSONContentType(r.Header.Get("Content-Type")) {
+		writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "content type must be application/json"})
+		return
+	}
+	// 有 Content-Length 時先檢查，不用讀完 body 才拒絕
+	if r.ContentLength > h.maxBodySize {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "payload too large"})
+		return
+	}
+
+	// receive span 涵蓋整個 webhook 的處理；上游（proxy、gateway）有帶 traceparent 時接在它的 trace 底下
+	event := r.Header.Get("X-GitHub-Event")
+	ctx, span := tracing.Start(tracing.Extract(r.Context(), r.Header), "github webhook "+event, tracing.KindServer)
+	defer span.End()
+	span.SetAttr("github.event", event)
+	span.SetAttr("github.delivery", r.Header.Get("X-GitHub-Delivery"))
+
+	info := deliveryInfoFromContext(r.Context())
+	info.DeliveryID, info.Event, info.TraceID = r.Header.Get("X-GitHub-Delivery"), event, tracing.TraceID(ctx)
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "payload too large"})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed to read body"})
+		return
+	}
+
+	// 驗證 webhook signature
+	if reason, message := h.checkSignature(ctx, r.Header, body); reason != "" {
+		metrics.SignatureFailures.Inc(reason)
+		info.Result = "unauthorized"
+		span.SetError(errors.New(message))
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": message})
+		return
+	}
+
+	if event == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing X-GitHub-Event header"})
+		return
+	}
+
+	// 各種結果（processed、ignored 等）的次數和處理時間
+	start, result := time.Now(), "processed"
+	metrics.WebhooksInFlight.Add(1)
+	defer func() {
+		span.SetAttr("webhook.result", result)
+		info.Result = result
+		metrics.WebhooksInFlight.Add(-1)
+		metrics.WebhooksReceived.Inc(event, result)
+		metrics.WebhookDuration.Observe(time.Since(start).Seconds(), event)
+	}()
+
+	var payload WebhookPayload
+	_, parseSpan := tracing.Start(ctx, "parse payload", tracing.KindInternal)
+	err = json.Unmarshal(body, &payload)
+	parseSpan.SetAttr("payload.size", len(body))
+	parseSpan.SetError(err)
+	parseSpan.End()
+	if err != nil {
+		result = "invalid"
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid payload"})
+		return
+	}
+	info.Repo, info.Action = payload.Repository.FullName, payload.Action
+	if h.record != nil {
+		h.record(ctx, r.Header, Delivery{ID: r.Header.Get("X-GitHub-Delivery"), Event: event, Body: body, Payload: &payload})
+	}
+
+	// 處理 ping event（GitHub 建立 webhook 時發送）：一律回 pong，有註冊 "ping" handler 時才交給它（不走 fallback）
+	// 處理途中 GitHub 斷線（超過 10 秒 timeout）也把事件處理完，避免 thread 建到一半
+	ctx = WithDeliveryID(context.WithoutCancel(ctx), r.Header.Get("X-GitHub-Delivery"))
+	if event == "ping" {
+		if handler, onError := h.pingHandler(); handler != nil {
+			if err := handler(ctx, event, &payload); err != nil {
+				result = "failed"
+				span.SetError(err)
+				onError(w, err)
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "pong"})
+		return
+	}
+
+	if repo := payload.Repository.FullName; repo != "" && h.repoFilter != nil && !h.repoFilter(repo) {
+		result = "skipped"
+		writeJSON(w, http.StatusAccepted, map[string]string{"status": "skipped"})
+		return
+	}
+
+	handler, onError := h.lookup(event)
+	if handler == nil {
+		result = "ignored"
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ignored"})
+		return
+	}
+
+	if payload.Installation != nil {
+		ctx = WithInstallationID(ctx, payload.Installation.ID)
+	}
+	if h.dispatch != nil {
+		delivery := Delivery{ID: r.Header.Get("X-GitHub-Delivery"), Event: event, Body: body, Payload: &payload}
+		if err := h.dispatch(ctx, delivery); err != nil {
+			result = "rejected"
+			span.SetError(err)
+			onError(w, err)
+			return
+		}
+		result = "queued"
+		writeJSON(w, http.StatusAccepted, map[string]string{"status": "queued"})
+		return
+	}
+	if err := handler(ctx, event, &payload); err != nil {
+		result = "failed"
+		span.SetError(err)
+		onError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "processed"})
+}
+
+// Process 直接處理一個已驗證簽名的 delivery（不經過 Dispatcher）：套用 repo filter 後交給註冊的 handler
+// 被 repo filter 略過或沒有對應的 handler 時回傳 nil；給 Dispatcher 的 worker 和重啟後重新處理 persistent queue 使用
+func (h *WebhookHandler) Process(ctx context.Context, d Delivery) error {
+	payload := d.Payload
+	if payload == nil {
+		payload = &WebhookPayload{}
+		if err := json.Unmarshal(d.Body, payload); err != nil {
+			return fmt.Errorf("invalid payload: %w", err)
+		}
+	}
+	if repo := payload.Repository.FullName; repo != "" && h.repoFilter != nil && !h.repoFilter(repo) {
+		return nil
+	}
+	handler, _ := h.lookup(d.Event)
+	if handler == nil {
+		return nil
+	}
+
+	ctx = WithDeliveryID(ctx, d.ID)
+	if payload.Installation != nil {
+		ctx = WithInstallationID(ctx, payload.Installation.ID)
+	}
+	return handler(ctx, d.Event, payload)
+}
+
+// checkSignature 驗證簽名，失敗時回傳 metrics 用的 reason 和回應的錯誤訊息；通過或沒有設定 secret 時 reason 為空字串
+func (h *WebhookHandler) checkSignature(ctx context.Context, header http.Header, body []byte) (reason, message string) {
+	h.mu.RLock()
+	keys := h.secrets
+	h.mu.RUnlock()
+	if !keys.enabled() {
+		return "", ""
+	}
+	_, span := tracing.Start(ctx, "verify signature", tracing.KindInternal)
+	defer span.End()
+
+	verify := VerifySignature
+	signature := header.Get("X-Hub-Signature-256")
+	if signature == "" && h.allowSHA1 {
+		verify, signature = VerifySignatureSHA1, header.Get("X-Hub-Signature")
+		span.SetAttr("signature.algorithm", "sha1")
+	}
+	if signature == "" {
+		return "missing", "missing signature"
+	}
+	secrets := keys.forPayload(body)
+	if len(secrets) == 0 {
+		return "no_secret", "no secret configured for repository"
+	}
+	if !verifyAny(body, signature, secrets, verify) {
+		span.SetAttr("signature.valid", false)
+		return "invalid", "invalid signature"
+	}
+	span.SetAttr("signature.valid", true)
+	return "", ""
+}
+
+// webhookSecrets 驗證簽名用的 secret
+type webhookSecrets struct {
+	primary   string
+	secondary string            // secret rotation 期間同時接受的舊 / 新 secret
+	repos     map[string]string // "owner/repo" 或 "owner" → secret
+}
+
+func newWebhookSecrets(primary, secondary string, repoSecrets map[string]string) *webhookSecrets {
+	s := &webhookSecrets{primary: primary, secondary: secondary, repos: make(map[string]string)}
+	s.addRepoSecrets(repoSecrets)
+	return s
+}
+
+func (s *webhookSecrets) addRepoSecrets(secrets map[string]string) {
+	for key, secret := range secrets {
+		if secret != "" {
+			s.repos[strings.ToLower(key)] = secret
+		}
+	}
+}
+
+// enabled 有設定任何 secret 就一律驗證簽名
+func (s *webhookSecrets) enabled() bool {
+	return s.primary != "" || s.secondary != "" || len(s.repos) > 0
+}
+
+// forPayload 依 payload 的 repository 選出要用的 secret（簽名驗證前，只解析 repository 欄位）
+// 沒有對應的 repo / owner secret 時回傳預設的 primary / secondary secret；有對應時另外接受 secondary（輪替 repo 的 secret 時用）
+func (s *webhookSecrets) forPayload(body []byte) []string {
+	if len(s.repos) == 0 {
+		return s.defaults()
+	}
+
+	var probe struct {
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil || probe.Repository.FullName == "" {
+		// org 層級的事件（例如 organization、installation）沒有 repository
+		return s.defaults()
+	}
+
+	fullName := strings.ToLower(probe.Repository.FullName)
+	if secret, ok := s.repos[fullName]; ok {
+		return s.withSecondary(secret)
+	}
+	if owner, _, found := strings.Cut(fullName, "/"); found {
+		if secret, ok := s.repos[owner]; ok {
+			return s.withSecondary(secret)
+		}
+	}
+	return s.defaults()
+}
+
+// withSecondary repo / owner 的 secret，加上有設定的 secondary
+func (s *webhookSecrets) withSecondary(secret string) []string {
+	if s.secondary == "" || s.secondary == secret {
+		return []string{secret}
+	}
+	return []string{secret, s.secondary}
+}
+
+// defaults 回傳有設定的預設 secret（primary 在前）
+func (s *webhookSecrets) defaults() []string {
+	var secrets []string
+	for _, secret := range []string{s.primary, s.secondary} {
+		if secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+// verifyAny 簽名符合任一把 secret 就通過
+func verifyAny(payload []byte, signature string, secrets []string, verify func(payload []byte, signature, secret string) bool) bool {
+	for _, secret := range secrets {
+		if verify(payload, signature, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup 找 event 對應的 handler，沒有時用 fallback
+func (h *WebhookHandler) lookup(event string) (EventHandler, ErrorHandler) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if handler, ok := h.handlers[event]; ok {
+		return handler, h.onError
+	}
+	return h.fallback, h.onError
+}
+
+// pingHandler 明確註冊的 ping handler（沒有時回傳 nil）
+func (h *WebhookHandler) pingHandler() (EventHandler, ErrorHandler) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.handlers["ping"], h.onError
+}
+
+// isJSONContentType application/json 或 application/*+json（可以帶 charset 等參數）
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || (strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+func defaultErrorHandler(w http.ResponseWriter, err error) {
+	writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to process event"})
+}
+
+// VerifySignature 驗證 X-Hub-Signature-256（"sha256=" + hex(HMAC-SHA256(secret, body))）
+// secret 為空字串時一律通過
+func VerifySignature(payload []byte, signature, secret string) bool {
+	if secret == "" {
+		return true
+	}
+
+	return hmac.Equal([]byte(signature), []byte(Sign(payload, secret)))
+}
+
+// Sign 產生 X-Hub-Signature-256 的值（`main send` 送範例 payload 時用）
+func Sign(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignatureSHA1 驗證舊版的 X-Hub-Signature（"sha1=" + hex(HMAC-SHA1(secret, body))）
+// secret 為空字串時一律通過
+func VerifySignatureSHA1(payload []byte, signature, secret string) bool {
+	if secret == "" {
+		return true
+	}
+
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(payload)
+	expectedSignature := "sha1=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(signature), []byte(expectedSignature))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,114 @@
+package github
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func signSHA1(payload []byte, secret string) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(payload)
+	return "sha1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestCheckSignature(t *testing.T) {
+	repoBody := []byte(`{"action":"opened","repository":{"full_name":"Acme/Widgets"}}`)
+	otherRepoBody := []byte(`{"action":"opened","repository":{"full_name":"acme/gadgets"}}`)
+	unknownRepoBody := []byte(`{"action":"opened","repository":{"full_name":"someone/else"}}`)
+	orgBody := []byte(`{"action":"created","installation":{"id":1}}`)
+
+	repoSecrets := map[string]string{"acme/widgets": "repo-secret", "acme": "owner-secret"}
+
+	tests := []struct {
+		name       string
+		primary    string
+		secondary  string
+		repos      map[string]string
+		sha1       bool
+		body       []byte
+		header     string // X-Hub-Signature-256
+		headerSHA1 string // X-Hub-Signature
+		wantReason string
+	}{
+		{name: "no secret configured", body: repoBody, header: "sha256=bogus"},
+		{name: "primary", primary: "primary", body: repoBody, header: Sign(repoBody, "primary")},
+		{name: "wrong secret", primary: "primary", body: repoBody, header: Sign(repoBody, "other"), wantReason: "invalid"},
+		{name: "tampered body", primary: "primary", body: repoBody, header: Sign(otherRepoBody, "primary"), wantReason: "invalid"},
+		{name: "missing signature", primary: "primary", body: repoBody, wantReason: "missing"},
+		{name: "secondary", primary: "new", secondary: "old", body: repoBody, header: Sign(repoBody, "old")},
+		{name: "primary with secondary set", primary: "new", secondary: "old", body: repoBody, header: Sign(repoBody, "new")},
+		{name: "secondary only", secondary: "old", body: repoBody, header: Sign(repoBody, "old")},
+		{name: "repo secret", primary: "primary", repos: repoSecrets, body: repoBody, header: Sign(repoBody, "repo-secret")},
+		{name: "repo secret replaces primary", primary: "primary", repos: repoSecrets, body: repoBody, header: Sign(repoBody, "primary"), wantReason: "invalid"},
+		{name: "repo secret replaces owner secret", primary: "primary", repos: repoSecrets, body: repoBody, header: Sign(repoBody, "owner-secret"), wantReason: "invalid"},
+		{name: "secondary alongside repo secret", primary: "primary", secondary: "old", repos: repoSecrets, body: repoBody, header: Sign(repoBody, "old")},
+		{name: "owner secret", primary: "primary", repos: repoSecrets, body: otherRepoBody, header: Sign(otherRepoBody, "owner-secret")},
+		{name: "unmatched repo uses primary", primary: "primary", repos: repoSecrets, body: unknownRepoBody, header: Sign(unknownRepoBody, "primary")},
+		{name: "unmatched repo without primary", repos: repoSecrets, body: unknownRepoBody, header: Sign(unknownRepoBody, "repo-secret"), wantReason: "no_secret"},
+		{name: "event without repository uses primary", primary: "primary", repos: repoSecrets, body: orgBody, header: Sign(orgBody, "primary")},
+		{name: "sha1 not allowed", primary: "primary", body: repoBody, headerSHA1: signSHA1(repoBody, "primary"), wantReason: "missing"},
+		{name: "legacy sha1", primary: "primary", sha1: true, body: repoBody, headerSHA1: signSHA1(repoBody, "primary")},
+		{name: "legacy sha1 with secondary", primary: "new", secondary: "old", sha1: true, body: repoBody, headerSHA1: signSHA1(repoBody, "old")},
+		{name: "legacy sha1 wrong secret", primary: "primary", sha1: true, body: repoBody, headerSHA1: signSHA1(repoBody, "other"), wantReason: "invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []WebhookOption{WithSecondarySecret(tt.secondary), WithRepoSecrets(tt.repos)}
+			if tt.sha1 {
+				opts = append(opts, WithLegacySignature())
+			}
+			h := NewWebhookHandler(tt.primary, opts...)
+
+			header := http.Header{}
+			if tt.header != "" {
+				header.Set("X-Hub-Signature-256", tt.header)
+			}
+			if tt.headerSHA1 != "" {
+				header.Set("X-Hub-Signature", tt.headerSHA1)
+			}
+			if reason, _ := h.checkSignature(context.Background(), header, tt.body); reason != tt.wantReason {
+				t.Errorf("checkSignature reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestSetSecrets(t *testing.T) {
+	body := []byte(`{"repository":{"full_name":"acme/widgets"}}`)
+	h := NewWebhookHandler("old")
+	h.SetSecrets("new", "", map[string]string{"acme/widgets": "repo-secret"})
+
+	for secret, want := range map[string]string{"old": "invalid", "new": "invalid", "repo-secret": ""} {
+		header := http.Header{"X-Hub-Signature-256": {Sign(body, secret)}}
+		if reason, _ := h.checkSignature(context.Background(), header, body); reason != want {
+			t.Errorf("signed with %q: reason = %q, want %q", secret, reason, want)
+		}
+	}
+}
+
+func TestVerifyAny(t *testing.T) {
+	body := []byte(`{}`)
+	tests := []struct {
+		name    string
+		secrets []string
+		secret  string
+		want    bool
+	}{
+		{name: "first", secrets: []string{"a", "b"}, secret: "a", want: true},
+		{name: "second", secrets: []string{"a", "b"}, secret: "b", want: true},
+		{name: "none match", secrets: []string{"a", "b"}, secret: "c"},
+		{name: "no secrets", secrets: nil, secret: "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyAny(body, Sign(body, tt.secret), tt.secrets, VerifySignature); got != tt.want {
+				t.Errorf("verifyAny = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
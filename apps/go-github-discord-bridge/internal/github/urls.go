@@ -0,0 +1,23 @@
+package github
+
+import "strings"
+
+// DefaultBaseURL github.com 的 web 網址
+const DefaultBaseURL = "https://github.com"
+
+// baseURL 產生連結用的 web 網址，GitHub Enterprise Server 時由 SetBaseURL 改成自己的 host
+var baseURL = DefaultBaseURL
+
+// SetBaseURL 設定 web 網址（例如 "https://github.example.com"），空字串時回到 github.com
+// 只在啟動時呼叫一次
+func SetBaseURL(url string) {
+	if url == "" {
+		url = DefaultBaseURL
+	}
+	baseURL = strings.TrimRight(url, "/")
+}
+
+// WebURL 組出 web 介面的連結，path 例如 "owner/repo/commit/abc123"
+func WebURL(path string) string {
+	return baseURL + "/" + strings.TrimLeft(path, "/")
+}
@@ -2,18 +2,102 @@ package github
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
 // WebhookPayload 是 GitHub webhook 的主要結構
 type WebhookPayload struct {
-	Action      string       `json:"action"` // opened, synchronize, closed, etc.
-	PullRequest *PullRequest `json:"pull_request,omitempty"`
-	Review            *Review      `json:"review,omitempty"`
-	RequestedReviewer *User        `json:"requested_reviewer,omitempty"`
-	WorkflowRun       *WorkflowRun `json:"workflow_run,omitempty"`
-	Repository        Repository   `json:"repository"`
-	Sender            User         `json:"sender"`
+	Action            string            `json:"action"` // opened, synchronize, closed, etc.
+	PullRequest       *PullRequest      `json:"pull_request,omitempty"`
+	Review            *Review           `json:"review,omitempty"`
+	RequestedReviewer *User             `json:"requested_reviewer,omitempty"`
+	Assignee          *User             `json:"assignee,omitempty"` // assigned / unassigned 時的對象
+	Label             *Label            `json:"label,omitempty"`    // labeled / unlabeled 時的 label
+	Milestone         *Milestone        `json:"milestone,omitempty"`
+	Alert             *Alert            `json:"alert,omitempty"`            // dependabot_alert / code_scanning_alert / secret_scanning_alert
+	Pages             []WikiPage        `json:"pages,omitempty"`            // gollum（wiki）event
+	Package           *Package          `json:"package,omitempty"`          // package event
+	RegistryPackage   *Package          `json:"registry_package,omitempty"` // registry_package event（欄位和 package 相同）
+	Changes           *Changes          `json:"changes,omitempty"`          // edited / renamed / transferred 時的舊值
+	Issue             *Issue            `json:"issue,omitempty"`            // issues / issue_comment event
+	Comment           *Comment          `json:"comment,omitempty"`          // issue_comment / pull_request_review_comment event
+	Release           *Release          `json:"release,omitempty"`
+	Discussion        *Discussion       `json:"discussion,omitempty"`
+	Answer            *Comment          `json:"answer,omitempty"` // discussion answered 時被標記的留言
+	WorkflowRun       *WorkflowRun      `json:"workflow_run,omitempty"`
+	CheckRun          *CheckRun         `json:"check_run,omitempty"`
+	CheckSuite        *CheckSuite       `json:"check_suite,omitempty"`
+	Deployment        *Deployment       `json:"deployment,omitempty"`
+	DeploymentStatus  *DeploymentStatus `json:"deployment_status,omitempty"`
+	Repository        Repository        `json:"repository"`
+	Sender            User              `json:"sender"`
+	Organization      *User             `json:"organization,omitempty"` // org 層級的 webhook 才有
+	Installation      *Installation     `json:"installation,omitempty"` // 以 GitHub App 接收時每個事件都有
+	Repositories      []Repository      `json:"repositories,omitempty"` // installation created：這次安裝的 repo
+	ReposAdded        []Repository      `json:"repositories_added,omitempty"`
+	ReposRemoved      []Repository      `json:"repositories_removed,omitempty"`
+
+	// ping event 專用欄位（建立 webhook 時 GitHub 送的第一個事件）
+	Zen    string `json:"zen,omitempty"`
+	HookID int64  `json:"hook_id,omitempty"`
+	Hook   *Hook  `json:"hook,omitempty"`
+
+	// push / create / delete event 專用欄位
+	Ref        string   `json:"ref,omitempty"`      // push：refs/heads/main、refs/tags/v1.0.0；create / delete：main、v1.0.0
+	RefType    string   `json:"ref_type,omitempty"` // create / delete：branch、tag
+	Before     string   `json:"before,omitempty"`
+	After      string   `json:"after,omitempty"`
+	Compare    string   `json:"compare,omitempty"` // before...after 的 compare URL
+	Created    bool     `json:"created,omitempty"`
+	Deleted    bool     `json:"deleted,omitempty"`
+	Forced     bool     `json:"forced,omitempty"`
+	Commits    []Commit `json:"commits,omitempty"` // GitHub 最多只帶 20 個 commit
+	HeadCommit *Commit  `json:"head_commit,omitempty"`
+
+	// status event 專用欄位（commit status API，比 check run 早的舊機制，CircleCI / Codecov 等外部服務還在用）
+	SHA         string         `json:"sha,omitempty"`
+	State       string         `json:"state,omitempty"`   // pending, success, failure, error
+	Context     string         `json:"context,omitempty"` // 例如 ci/circleci: build、codecov/patch
+	Description string         `json:"description,omitempty"`
+	TargetURL   string         `json:"target_url,omitempty"`
+	Branches    []StatusBranch `json:"branches,omitempty"` // head 是這個 commit 的 branch
+}
+
+// Installation GitHub App 的 installation（App 裝在哪個 user / org）
+// 一般事件只有 ID，installation / installation_repositories event 才有 Account
+type Installation struct {
+	ID      int64 `json:"id"`
+	Account *User `json:"account,omitempty"`
+}
+
+// Hook ping event 帶的 webhook 設定
+type Hook struct {
+	Type   string   `json:"type"` // Repository, Organization, App
+	Events []string `json:"events"`
+}
+
+// StatusBranch status event 的 branches 欄位
+type StatusBranch struct {
+	Name string `json:"name"`
+}
+
+// CommitStatus 單一 status context 的最新狀態
+type CommitStatus struct {
+	Context     string `json:"context"`
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
+	TargetURL   string `json:"target_url,omitempty"`
+}
+
+// CommitStatus 取出 status event 的 context 狀態
+func (w *WebhookPayload) CommitStatus() CommitStatus {
+	return CommitStatus{
+		Context:     w.Context,
+		State:       w.State,
+		Description: w.Description,
+		TargetURL:   w.TargetURL,
+	}
 }
 
 type PullRequest struct {
@@ -27,10 +111,99 @@ type PullRequest struct {
 	Base      Branch    `json:"base"`
 	Head      Branch    `json:"head"`
 	Merged    bool      `json:"merged"`
+	Draft     bool      `json:"draft"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Additions int       `json:"additions"`
 	Deletions int       `json:"deletions"`
+
+	Assignees          []User  `json:"assignees"`
+	RequestedReviewers []User  `json:"requested_reviewers"`
+	Labels             []Label `json:"labels"`
+}
+
+// Issue GitHub issue（issue_comment 在 PR 上留言時也會用這個結構，PullRequest 不為 nil）
+type Issue struct {
+	Number      int               `json:"number"`
+	Title       string            `json:"title"`
+	Body        string            `json:"body"`
+	State       string            `json:"state"` // open, closed
+	HTMLURL     string            `json:"html_url"`
+	User        User              `json:"user"`
+	PullRequest *IssuePullRequest `json:"pull_request,omitempty"`
+	Labels      []Label           `json:"labels"`
+	Milestone   *Milestone        `json:"milestone,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// Milestone GitHub milestone，OpenIssues / ClosedIssues 包含 PR
+type Milestone struct {
+	Number       int        `json:"number"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	State        string     `json:"state"` // open, closed
+	HTMLURL      string     `json:"html_url"`
+	OpenIssues   int        `json:"open_issues"`
+	ClosedIssues int        `json:"closed_issues"`
+	DueOn        *time.Time `json:"due_on,omitempty"`
+}
+
+// Progress 已完成的比例（0–100）
+func (m *Milestone) Progress() int {
+	total := m.OpenIssues + m.ClosedIssues
+	if total == 0 {
+		return 0
+	}
+	return m.ClosedIssues * 100 / total
+}
+
+// Label issue / PR 的 label
+type Label struct {
+	Name  string `json:"name"`
+	Color string `json:"color"` // hex，不含 #
+}
+
+// IssuePullRequest issue 對應的 PR 連結，只有 issue 其實是 PR 時才有
+type IssuePullRequest struct {
+	HTMLURL string `json:"html_url"`
+}
+
+// IsPullRequest issue 是否其實是 PR
+func (i *Issue) IsPullRequest() bool {
+	return i.PullRequest != nil
+}
+
+// Comment issue / PR 上的留言
+// Path、DiffHunk、Line 只有 pull_request_review_comment（留在程式碼上的留言）才有
+type Comment struct {
+	ID        int       `json:"id"`
+	Body      string    `json:"body"`
+	HTMLURL   string    `json:"html_url"` // 留言的 permalink
+	User      User      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Path     string `json:"path,omitempty"`
+	DiffHunk string `json:"diff_hunk,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// Discussion GitHub Discussion（編號和 issue / PR 共用）
+type Discussion struct {
+	Number        int                `json:"number"`
+	Title         string             `json:"title"`
+	Body          string             `json:"body"`
+	HTMLURL       string             `json:"html_url"`
+	User          User               `json:"user"`
+	Category      DiscussionCategory `json:"category"`
+	AnswerHTMLURL string             `json:"answer_html_url,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+// DiscussionCategory discussion 的分類（Q&A、Ideas 等）
+type DiscussionCategory struct {
+	Name         string `json:"name"`
+	Slug         string `json:"slug"`
+	IsAnswerable bool   `json:"is_answerable"`
 }
 
 type Review struct {
@@ -42,24 +215,361 @@ type Review struct {
 	SubmittedAt time.Time `json:"submitted_at"`
 }
 
+// Release GitHub release
+type Release struct {
+	ID          int            `json:"id"`
+	TagName     string         `json:"tag_name"`
+	Name        string         `json:"name"`
+	Body        string         `json:"body"` // release notes（GitHub markdown）
+	HTMLURL     string         `json:"html_url"`
+	Draft       bool           `json:"draft"`
+	Prerelease  bool           `json:"prerelease"`
+	Author      User           `json:"author"`
+	PublishedAt time.Time      `json:"published_at"`
+	Assets      []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset release 附帶的檔案
+type ReleaseAsset struct {
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
 type WorkflowRun struct {
-	ID           int              `json:"id"`
-	Name         string           `json:"name"`
-	HeadSHA      string           `json:"head_sha"`
-	Status       string           `json:"status"`     // completed
-	Conclusion   string           `json:"conclusion"` // success, failure, timed_out, cancelled
-	HTMLURL      string           `json:"html_url"`
-	PullRequests []WorkflowRunPR  `json:"pull_requests"`
+	ID           int             `json:"id"`
+	Name         string          `json:"name"`
+	HeadSHA      string          `json:"head_sha"`
+	Status       string          `json:"status"`     // completed
+	Conclusion   string          `json:"conclusion"` // success, failure, timed_out, cancelled
+	HTMLURL      string          `json:"html_url"`
+	PullRequests []WorkflowRunPR `json:"pull_requests"`
+	HeadBranch   string          `json:"head_branch"`
+	Event        string          `json:"event"` // 觸發的事件：push、pull_request 等
+	RunNumber    int             `json:"run_number"`
+	RunStartedAt time.Time       `json:"run_started_at"`
+	UpdatedAt    time.Time       `json:"updated_at"` // completed 時即為結束時間
+}
+
+// Duration workflow run 的執行時間（completed 後才準確）
+func (wr *WorkflowRun) Duration() time.Duration {
+	if wr.RunStartedAt.IsZero() || wr.UpdatedAt.Before(wr.RunStartedAt) {
+		return 0
+	}
+	return wr.UpdatedAt.Sub(wr.RunStartedAt)
+}
+
+// CheckRun 單一 check（例如 lint、測試 matrix 的其中一項）
+type CheckRun struct {
+	ID           int             `json:"id"`
+	Name         string          `json:"name"`
+	HeadSHA      string          `json:"head_sha"`
+	Status       string          `json:"status"`     // queued, in_progress, completed
+	Conclusion   string          `json:"conclusion"` // success, failure, neutral, cancelled, skipped, timed_out, action_required
+	HTMLURL      string          `json:"html_url"`
+	DetailsURL   string          `json:"details_url"` // check 提供者的詳細頁面（CI log）
+	StartedAt    time.Time       `json:"started_at"`
+	CompletedAt  time.Time       `json:"completed_at"`
+	Output       CheckRunOutput  `json:"output"`
+	App          CheckApp        `json:"app"`
+	PullRequests []WorkflowRunPR `json:"pull_requests"`
+}
+
+// CheckRunOutput check run 的結果摘要
+type CheckRunOutput struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+}
+
+// CheckSuite 同一個 app 對同一個 commit 的所有 check
+type CheckSuite struct {
+	ID           int             `json:"id"`
+	HeadBranch   string          `json:"head_branch"`
+	HeadSHA      string          `json:"head_sha"`
+	Status       string          `json:"status"`
+	Conclusion   string          `json:"conclusion"`
+	App          CheckApp        `json:"app"`
+	PullRequests []WorkflowRunPR `json:"pull_requests"`
+}
+
+// CheckApp 建立 check 的 GitHub App
+type CheckApp struct {
+	Slug string `json:"slug"` // github-actions
+	Name string `json:"name"`
+}
+
+// IsFailedConclusion check 的 conclusion 是否代表失敗
+func IsFailedConclusion(conclusion string) bool {
+	switch conclusion {
+	case "failure", "timed_out", "action_required":
+		return true
+	default:
+		return false
+	}
+}
+
+// Deployment GitHub deployment
+type Deployment struct {
+	ID          int       `json:"id"`
+	SHA         string    `json:"sha"`
+	Ref         string    `json:"ref"`
+	Task        string    `json:"task"`
+	Environment string    `json:"environment"` // production、staging 等
+	Description string    `json:"description"`
+	Creator     User      `json:"creator"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DeploymentStatus deployment 的狀態更新
+type DeploymentStatus struct {
+	ID             int       `json:"id"`
+	State          string    `json:"state"` // pending, queued, in_progress, success, failure, error, inactive
+	Description    string    `json:"description"`
+	Environment    string    `json:"environment"`
+	EnvironmentURL string    `json:"environment_url"` // 部署後的網址
+	LogURL         string    `json:"log_url"`
+	TargetURL      string    `json:"target_url"`
+	Creator        User      `json:"creator"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Alert 安全性 alert（dependabot、code scanning、secret scanning 共用，各自只會有部分欄位）
+type Alert struct {
+	Number  int    `json:"number"`
+	State   string `json:"state"` // open, fixed, dismissed, auto_dismissed, resolved
+	HTMLURL string `json:"html_url"`
+
+	// dependabot_alert
+	Dependency            *AlertDependency            `json:"dependency,omitempty"`
+	SecurityAdvisory      *SecurityAdvisory           `json:"security_advisory,omitempty"`
+	SecurityVulnerability *AlertSecurityVulnerability `json:"security_vulnerability,omitempty"`
+
+	// code_scanning_alert
+	Rule               *CodeScanningRule     `json:"rule,omitempty"`
+	Tool               *CodeScanningTool     `json:"tool,omitempty"`
+	MostRecentInstance *CodeScanningInstance `json:"most_recent_instance,omitempty"`
+
+	// secret_scanning_alert；Secret 是外洩的原始值，絕對不能原樣發出去（用 RedactSecret）
+	SecretType            string `json:"secret_type,omitempty"`
+	SecretTypeDisplayName string `json:"secret_type_display_name,omitempty"`
+	Secret                string `json:"secret,omitempty"`
+	Resolution            string `json:"resolution,omitempty"`
+}
+
+// CodeScanningRule code scanning 觸發的規則
+type CodeScanningRule struct {
+	ID                    string `json:"id"`
+	Name                  string `json:"name"`
+	Severity              string `json:"severity"`                // note, warning, error
+	SecuritySeverityLevel string `json:"security_severity_level"` // low, medium, high, critical
+	Description           string `json:"description"`
+}
+
+// CodeScanningTool 掃描工具（CodeQL 等）
+type CodeScanningTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// CodeScanningInstance alert 最近一次出現的位置
+type CodeScanningInstance struct {
+	Ref      string `json:"ref"`
+	Location struct {
+		Path      string `json:"path"`
+		StartLine int    `json:"start_line"`
+		EndLine   int    `json:"end_line"`
+	} `json:"location"`
+	Message struct {
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+// RedactSecret 遮蔽外洩的 secret，只保留可辨識類型的前 4 個字元（例如 "ghp_••••••••"）
+func RedactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	runes := []rune(secret)
+	if len(runes) < 16 {
+		return "••••••••"
+	}
+	return string(runes[:4]) + "••••••••"
+}
+
+// AlertDependency dependabot alert 針對的套件
+type AlertDependency struct {
+	Package      AlertPackage `json:"package"`
+	ManifestPath string       `json:"manifest_path"`
+}
+
+// AlertPackage 套件名稱和生態系（npm、go、pip 等）
+type AlertPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+// SecurityAdvisory GitHub advisory（GHSA）
+type SecurityAdvisory struct {
+	GHSAID   string `json:"ghsa_id"`
+	CVEID    string `json:"cve_id"`
+	Summary  string `json:"summary"`
+	Severity string `json:"severity"` // low, medium, high, critical
+}
+
+// AlertSecurityVulnerability 受影響的版本範圍和修正版本
+type AlertSecurityVulnerability struct {
+	Severity               string `json:"severity"`
+	VulnerableVersionRange string `json:"vulnerable_version_range"`
+	FirstPatchedVersion    *struct {
+		Identifier string `json:"identifier"`
+	} `json:"first_patched_version,omitempty"`
+}
+
+// Severity alert 的嚴重程度（dependabot 取 advisory、code scanning 取 rule 的 severity）
+func (a *Alert) Severity() string {
+	if a.SecurityVulnerability != nil && a.SecurityVulnerability.Severity != "" {
+		return a.SecurityVulnerability.Severity
+	}
+	if a.SecurityAdvisory != nil {
+		return a.SecurityAdvisory.Severity
+	}
+	if a.Rule != nil {
+		if a.Rule.SecuritySeverityLevel != "" {
+			return a.Rule.SecuritySeverityLevel
+		}
+		return a.Rule.Severity
+	}
+	if a.SecretType != "" {
+		// 外洩的 secret 一律視為 critical
+		return "critical"
+	}
+	return ""
+}
+
+// WikiPage gollum event 裡被建立或修改的 wiki 頁面
+type WikiPage struct {
+	PageName string `json:"page_name"`
+	Title    string `json:"title"`
+	Summary  string `json:"summary"`
+	Action   string `json:"action"` // created, edited
+	SHA      string `json:"sha"`
+	HTMLURL  string `json:"html_url"`
+}
+
+// DiffURL 這次修改的 diff 頁面（wiki 的 _compare）
+func (p *WikiPage) DiffURL() string {
+	if p.SHA == "" {
+		return p.HTMLURL
+	}
+	return p.HTMLURL + "/_compare/" + p.SHA
+}
+
+// Package GitHub Packages 的套件（container、npm、maven 等）
+type Package struct {
+	ID             int             `json:"id"`
+	Name           string          `json:"name"`
+	Namespace      string          `json:"namespace"`
+	PackageType    string          `json:"package_type"` // npm, container, maven, rubygems, nuget
+	HTMLURL        string          `json:"html_url"`
+	PackageVersion *PackageVersion `json:"package_version,omitempty"`
+	Registry       *struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"registry,omitempty"`
+}
+
+// PackageVersion 發布的版本
+type PackageVersion struct {
+	Version           string `json:"version"`
+	HTMLURL           string `json:"html_url"`
+	PackageURL        string `json:"package_url"` // 例如 ghcr.io/owner/image:tag
+	ContainerMetadata *struct {
+		Tag struct {
+			Name string `json:"name"`
+		} `json:"tag"`
+	} `json:"container_metadata,omitempty"`
+}
+
+// DisplayVersion container 以 tag 顯示（version 是 digest），其他類型用 version
+func (v *PackageVersion) DisplayVersion() string {
+	if v.ContainerMetadata != nil && v.ContainerMetadata.Tag.Name != "" {
+		return v.ContainerMetadata.Tag.Name
+	}
+	return v.Version
+}
+
+// Changes 變更前的值（只列 bridge 用到的欄位）
+type Changes struct {
+	Repository *struct {
+		Name struct {
+			From string `json:"from"`
+		} `json:"name"`
+	} `json:"repository,omitempty"` // repository renamed
+	Owner *struct {
+		From struct {
+			User         *User `json:"user,omitempty"`
+			Organization *User `json:"organization,omitempty"`
+		} `json:"from"`
+	} `json:"owner,omitempty"` // repository transferred
+	Title *struct {
+		From string `json:"from"`
+	} `json:"title,omitempty"` // issue / PR title edited
+}
+
+// PreviousFullName repository renamed / transferred 前的 "owner/repo"，沒有變更資訊時回傳空字串
+func (w *WebhookPayload) PreviousFullName() string {
+	if w.Changes == nil {
+		return ""
+	}
+
+	owner, name, _ := strings.Cut(w.Repository.FullName, "/")
+	if c := w.Changes.Repository; c != nil && c.Name.From != "" {
+		name = c.Name.From
+	}
+	if c := w.Changes.Owner; c != nil {
+		switch {
+		case c.From.Organization != nil:
+			owner = c.From.Organization.Login
+		case c.From.User != nil:
+			owner = c.From.User.Login
+		}
+	}
+
+	previous := owner + "/" + name
+	if previous == w.Repository.FullName {
+		return ""
+	}
+	return previous
 }
 
 type WorkflowRunPR struct {
 	Number int `json:"number"`
 }
 
-type Repository struct {
+// Commit push event 裡的 commit 資訊
+type Commit struct {
+	ID        string       `json:"id"`
+	Message   string       `json:"message"`
+	URL       string       `json:"url"`
+	Timestamp time.Time    `json:"timestamp"`
+	Author    CommitAuthor `json:"author"`
+	Distinct  bool         `json:"distinct"` // false 表示這個 commit 之前已經被 push 過（例如 merge 進其他 branch）
+}
+
+// CommitAuthor commit 的作者（git 設定的名稱，Username 只有對應到 GitHub 帳號時才有）
+type CommitAuthor struct {
 	Name     string `json:"name"`
-	FullName string `json:"full_name"` // owner/repo
-	HTMLURL  string `json:"html_url"`
+	Email    string `json:"email"`
+	Username string `json:"username,omitempty"`
+}
+
+type Repository struct {
+	Name            string `json:"name"`
+	FullName        string `json:"full_name"` // owner/repo
+	HTMLURL         string `json:"html_url"`
+	StargazersCount int    `json:"stargazers_count"`
+	ForksCount      int    `json:"forks_count"`
+	WatchersCount   int    `json:"watchers_count"`
 }
 
 type User struct {
@@ -73,11 +583,33 @@ type Branch struct {
 	SHA string `json:"sha"`
 }
 
-// GetPRIdentifier 回傳唯一識別這個 PR 的 key
-// 格式: "owner/repo#123"
+// GetPRIdentifier 回傳唯一識別這個 PR（或 issue、discussion）的 key
+// 格式: "owner/repo#123"；issue 和 PR 共用編號，所以同一個格式不會撞 key
 func (w *WebhookPayload) GetPRIdentifier() string {
 	if w.PullRequest != nil {
 		return fmt.Sprintf("%s#%d", w.Repository.FullName, w.PullRequest.Number)
 	}
+	if w.Issue != nil {
+		return fmt.Sprintf("%s#%d", w.Repository.FullName, w.Issue.Number)
+	}
+	if w.Discussion != nil {
+		return fmt.Sprintf("%s#%d", w.Repository.FullName, w.Discussion.Number)
+	}
 	return ""
 }
+
+// RefName 回傳 push 的 branch / tag 名稱（去掉 refs/heads/、refs/tags/ 前綴）
+func (w *WebhookPayload) RefName() string {
+	if name, ok := strings.CutPrefix(w.Ref, "refs/heads/"); ok {
+		return name
+	}
+	if name, ok := strings.CutPrefix(w.Ref, "refs/tags/"); ok {
+		return name
+	}
+	return w.Ref
+}
+
+// IsTagPush 是否為 tag 的 push
+func (w *WebhookPayload) IsTagPush() bool {
+	return strings.HasPrefix(w.Ref, "refs/tags/")
+}
@@ -0,0 +1,77 @@
+// Package i18n 產生的 Discord 訊息文字的在地化
+// 英文原文就是 catalog 的 key（和 gettext 相同），沒有翻譯的字串直接顯示英文；
+// 翻譯可用 %[n]s 調整參數順序，例如 "%s deployed to %s" → "%[2]s 已部署 %[1]s"
+package i18n
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultLocale 原文的語系，不需要 catalog
+const DefaultLocale = "en"
+
+// catalogs 語系 → 英文原文 → 翻譯
+var catalogs = map[string]map[string]string{
+	"zh-TW": zhTW,
+}
+
+// current 目前的 catalog；nil = 英文（啟動時設定一次，之後只讀）
+var current atomic.Pointer[map[string]string]
+
+// Locales 支援的語系
+func Locales() []string {
+	locales := []string{DefaultLocale}
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales[1:])
+	return locales
+}
+
+// SetLocale 設定產生訊息用的語系（不分大小寫，"zh_TW" 和 "zh-tw" 都可以），不支援的語系回傳 error
+func SetLocale(locale string) error {
+	catalog, err := lookup(locale)
+	if err != nil {
+		return err
+	}
+	current.Store(catalog)
+	return nil
+}
+
+// CheckLocale 只檢查語系是否支援、不切換（reload 時先確認所有設定都有效才套用）
+func CheckLocale(locale string) error {
+	_, err := lookup(locale)
+	return err
+}
+
+// lookup 找出語系的 catalog；英文回傳 nil
+func lookup(locale string) (*map[string]string, error) {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if locale == "" || strings.EqualFold(locale, DefaultLocale) {
+		return nil, nil
+	}
+	for name, catalog := range catalogs {
+		if strings.EqualFold(name, locale) {
+			return &catalog, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported locale %q (supported: %s)", locale, strings.Join(Locales(), ", "))
+}
+
+// T 翻譯 msg，目前語系沒有這個字串時回傳原文
+func T(msg string) string {
+	if catalog := current.Load(); catalog != nil {
+		if translated, ok := (*catalog)[msg]; ok {
+			return translated
+		}
+	}
+	return msg
+}
+
+// Tf 翻譯 format 後再套用 fmt.Sprintf
+func Tf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
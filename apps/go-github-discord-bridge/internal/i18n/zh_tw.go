@@ -0,0 +1,223 @@
+package i18n
+
+// zhTW 繁體中文
+var zhTW = map[string]string{
+	// 共用
+	"*No description provided*":    "*沒有說明*",
+	"*Empty comment*":              "*空白留言*",
+	"Thread will be archived soon": "Thread 即將封存",
+	"Author":                       "作者",
+	"Branch":                       "分支",
+	"Changes":                      "變更",
+	"Closed by":                    "關閉者",
+	"Changed by":                   "變更者",
+	"Merged by":                    "合併者",
+	"Pushed by":                    "推送者",
+	" by @%s":                      "（@%s）",
+	" on `%s`":                     "（`%s`）",
+	"…and %d more":                 "…還有 %d 個",
+	"Files (%d)":                   "檔案（%d）",
+	"File":                         "檔案",
+	"Ref":                          "Ref",
+	"Type":                         "類型",
+	"Version":                      "版本",
+	"Severity":                     "嚴重程度",
+	"Events":                       "事件",
+	"created":                      "建立",
+	"deleted":                      "刪除",
+	"edited":                       "編輯",
+	"❌ Something went wrong, please try again later.": "❌ 處理時發生錯誤，請稍後再試。",
+
+	// PR
+	"Pull Request #%d Opened":              "Pull Request #%d 已開啟",
+	"Draft Pull Request #%d Opened":        "Draft Pull Request #%d 已開啟",
+	"%s Review by @%s":                     "%s @%s 的 review",
+	"✅ Approved":                           "✅ 已核准",
+	"🔴 Changes Requested":                  "🔴 要求修改",
+	"💬 Commented":                          "💬 留言",
+	"🔔 Review requested from @%s":          "🔔 請 @%s review",
+	"@%s requested a review on PR #%d":     "@%s 請求 review PR #%d",
+	"⏰ PR #%d needs attention":             "⏰ PR #%d 需要處理",
+	"Last updated %s":                      "最後更新於 %s",
+	"Waiting on @%s":                       "等待 @%s",
+	"🎉 PR #%d Merged":                      "🎉 PR #%d 已合併",
+	"**%s** has been merged into `%s`":     "**%s** 已合併到 `%s`",
+	"❌ PR #%d Closed":                      "❌ PR #%d 已關閉",
+	"**%s** was closed without merging":    "**%s** 未合併就關閉了",
+	"🔄 PR Updated":                         "🔄 PR 已更新",
+	"New commits pushed to `%s`":           "`%s` 有新的 commit",
+	"👀 PR #%d Ready for Review":            "👀 PR #%d 可以 review 了",
+	"**%s** is ready for review":           "**%s** 可以 review 了",
+	"📝 PR #%d Converted to Draft":          "📝 PR #%d 轉回 draft",
+	"**%s** was converted back to a draft": "**%s** 已轉回 draft",
+	"💬 Review comment by @%s on PR #%d":    "💬 @%s 在 PR #%d 的 review 留言",
+	"Referenced by commit":                 "被 commit 提及",
+	"Will be closed by commit":             "將由 commit 關閉",
+	"🔗 %s in **%s**\n%s":                   "🔗 %s（**%s**）\n%s",
+	"Referenced by":                        "被提及於",
+	"Will be closed by":                    "將由以下 PR 關閉",
+
+	// Issue / discussion / comment
+	"Issue #%d Opened":                "Issue #%d 已開啟",
+	"✅ Issue #%d Closed":              "✅ Issue #%d 已關閉",
+	"**%s** has been closed":          "**%s** 已關閉",
+	"🔄 Issue #%d Reopened":            "🔄 Issue #%d 已重新開啟",
+	"**%s** has been reopened by @%s": "**%s** 已由 @%s 重新開啟",
+	"💬 Comment by @%s on #%d":         "💬 @%s 在 #%d 的留言",
+	"💭 Discussion #%d: %s":            "💭 討論 #%d：%s",
+	"Category":                        "分類",
+	"✅ Discussion #%d answered":       "✅ 討論 #%d 已有解答",
+	"Answer by @%s:\n\n%s":            "@%s 的解答：\n\n%s",
+	"🎯 Milestone: %s":                 "🎯 Milestone：%s",
+	"🏁 Milestone closed: %s":          "🏁 Milestone 已關閉：%s",
+	"Open":                            "未完成",
+	"Closed":                          "已完成",
+	"Due":                             "截止日",
+
+	// Release / package / wiki / push / ref
+	" (pre-release)":                    "（pre-release）",
+	"*No release notes*":                "*沒有 release notes*",
+	"Tag":                               "Tag",
+	"Assets":                            "檔案",
+	"📦 Package published: %s %s":        "📦 已發布套件：%s %s",
+	"Install":                           "安裝",
+	"Registry":                          "Registry",
+	"📚 Wiki updated in %s":              "📚 %s 的 wiki 已更新",
+	"📦 %d new commit(s) pushed to `%s`": "📦 `%[2]s` 有 %[1]d 個新的 commit",
+	"⚠️ Force-pushed %d commit(s) to `%s`": "⚠️ 強制推送 %d 個 commit 到 `%s`",
+	"… and %d more commit(s)":              "… 還有 %d 個 commit",
+	"%s %s `%s` %s in **%s** by @%s":       "%s 已在 **%[5]s** %[4]s %[2]s `%[3]s`（@%[6]s）",
+	"branch":                               "branch",
+	"tag":                                  "tag",
+
+	// CI / deployment
+	"%s CI Passed":                    "%s CI 通過",
+	"%s CI Failed":                    "%s CI 失敗",
+	"%s CI Timed Out":                 "%s CI 逾時",
+	"%s CI Cancelled":                 "%s CI 已取消",
+	"Started":                         "開始時間",
+	"Completed":                       "完成時間",
+	"Duration":                        "耗時",
+	"Run":                             "執行",
+	"Commit `%s` on `%s`":             "Commit `%s`（`%s`）",
+	"✅ All checks passed":             "✅ 所有檢查都通過",
+	"❌ Some checks failed":            "❌ 部分檢查失敗",
+	"⏳ Checks in progress":            "⏳ 檢查進行中",
+	"🚀 Deploying %s to %s…":           "🚀 正在部署 %s 到 %s…",
+	"✅ %s deployed to %s":             "✅ %s 已部署到 %s",
+	"❌ Deployment of %s to %s failed": "❌ %s 部署到 %s 失敗",
+	"ℹ️ Deployment of %s to %s: %s":   "ℹ️ %s 部署到 %s：%s",
+	"Environment URL":                 "環境網址",
+
+	// 安全性
+	"unknown package":                  "未知套件",
+	"🛡️ Dependabot alert #%d: %s":      "🛡️ Dependabot 警告 #%d：%s",
+	"✅ Dependabot alert #%d %s: %s":    "✅ Dependabot 警告 #%d %s：%s",
+	"fixed":                            "已修正",
+	"dismissed":                        "已略過",
+	"auto dismissed":                   "已自動略過",
+	"closed by user":                   "已手動關閉",
+	"Advisory":                         "安全公告",
+	"Vulnerable":                       "受影響版本",
+	"Patched":                          "修正版本",
+	"Manifest":                         "Manifest",
+	"unknown rule":                     "未知規則",
+	"🔍 Code scanning alert #%d: %s":    "🔍 Code scanning 警告 #%d：%s",
+	"✅ Code scanning alert #%d %s: %s": "✅ Code scanning 警告 #%d %s：%s",
+	"Rule":                             "規則",
+	"Tool":                             "工具",
+	"Location":                         "位置",
+	"🔑 Secret leaked: %s (alert #%d)":  "🔑 Secret 外洩：%s（警告 #%d）",
+	"✅ Secret scanning alert #%d resolved: %s":                                     "✅ Secret scanning 警告 #%d 已解決：%s",
+	"Revoke the secret with its provider first, then resolve the alert on GitHub.": "請先到提供者撤銷這個 secret，再到 GitHub 解決這個警告。",
+	"Secret":     "Secret",
+	"Resolution": "處理方式",
+
+	// Repository / GitHub App / ping
+	"📁 Repository **%s** created":                          "📁 已建立 repository **%s**",
+	"✏️ Repository renamed from **%s** to **%s**":          "✏️ Repository **%s** 已改名為 **%s**",
+	"🚚 Repository transferred from **%s** to **%s**":       "🚚 Repository **%s** 已轉移到 **%s**",
+	"🗄️ Repository **%s** archived (read-only)":            "🗄️ Repository **%s** 已封存（唯讀）",
+	"📂 Repository **%s** unarchived":                       "📂 Repository **%s** 已解除封存",
+	"🔌 GitHub App installed on **%s**":                     "🔌 GitHub App 已安裝到 **%s**",
+	"🔌 GitHub App uninstalled from **%s**":                 "🔌 GitHub App 已從 **%s** 移除",
+	"⏸️ GitHub App suspended on **%s**":                    "⏸️ GitHub App 已在 **%s** 停用",
+	"▶️ GitHub App unsuspended on **%s**":                  "▶️ GitHub App 已在 **%s** 恢復",
+	"➕ Repositories added to the GitHub App on **%s**":     "➕ **%s** 的 GitHub App 新增了 repository",
+	"➖ Repositories removed from the GitHub App on **%s**": "➖ **%s** 的 GitHub App 移除了 repository",
+	"Repositories":               "Repositories",
+	"Added":                      "新增",
+	"Removed":                    "移除",
+	"✅ Webhook connected for %s": "✅ %s 的 webhook 已連線",
+
+	"🔄 PR Reopened":            "🔄 PR 已重新開啟",
+	"**%s** has been reopened": "**%s** 已重新開啟",
+	"📋 %s activity":            "📋 %s 動態",
+	"Pushes and other repository-wide events are posted in this thread.": "Push 和其他 repository 層級的事件會發在這個 thread。",
+	"🏢 Enterprise %s":                      "🏢 Enterprise %s",
+	"%s by @%s":                            "%s（@%s）",
+	"🔓 Anonymous Git read access enabled":  "🔓 已開啟匿名 Git 讀取",
+	"🔒 Anonymous Git read access disabled": "🔒 已關閉匿名 Git 讀取",
+
+	// star / fork / watch
+	"%s [@%s](%s) %s **%s**":            "%s [@%s](%s) %s **%s**",
+	" (%d stars)":                       "（%d 顆星）",
+	"%s %d new %s (%s)":                 "%s 新增 %d 個 %s（%s）",
+	"📈 %s community activity (last %s)": "📈 %s 社群動態（最近 %s）",
+	"starred":                           "收藏了",
+	"forked":                            "fork 了",
+	"started watching":                  "開始關注",
+	"star":                              "star",
+	"stars":                             "star",
+	"fork":                              "fork",
+	"forks":                             "fork",
+	"watcher":                           "關注者",
+	"watchers":                          "關注者",
+
+	// /github link
+	"Self-service linking is disabled. Ask an admin to add you to GITHUB_DISCORD_USER_MAP.": "沒有開放自行綁定，請管理員把你加到 GITHUB_DISCORD_USER_MAP。",
+	"Could not identify the Discord user.":                                                  "無法識別 Discord 使用者。",
+	"`%s` is not a valid GitHub username.":                                                  "`%s` 不是有效的 GitHub 帳號。",
+	"`%s` is already mapped to another Discord user by an admin.":                           "`%s` 已由管理員對應到其他 Discord 使用者。",
+	"`%s` is already linked to another Discord user.":                                       "`%s` 已綁定其他 Discord 使用者。",
+	"Linked GitHub `%s` to you. Review requests and reviews will mention you.":              "已綁定 GitHub `%s`，review request 和 review 會 mention 你。",
+	"`%s` is not linked to you.":                                                            "`%s` 沒有綁定你。",
+	"Linking requires GitHub API credentials (GITHUB_TOKEN or a GitHub App).":               "綁定需要 GitHub API 認證（GITHUB_TOKEN 或 GitHub App）。",
+	"To prove you own `%s`, create a public gist on that account with the description `%s`, then run `/github verify` within an hour. You can delete the gist afterwards.": "請用 `%s` 建立一個 description 為 `%s` 的 public gist 證明帳號是你的，並在一小時內執行 `/github verify`。完成後可以刪掉 gist。",
+	"No pending link. Run `/github link` first.":           "沒有等待驗證的綁定，請先執行 `/github link`。",
+	"No public gist of `%s` has the description `%s` yet.": "`%s` 還沒有 description 為 `%s` 的 public gist。",
+	"Unlinked GitHub `%s`.":                                "已解除綁定 GitHub `%s`。",
+	"Unknown subcommand.":                                  "未知的子命令。",
+
+	// Reply to GitHub
+	"Reply to GitHub":                                                         "回覆到 GitHub",
+	"Replying to GitHub is disabled.":                                         "沒有開放回覆到 GitHub。",
+	"You don't have a role that is allowed to reply to GitHub.":               "你沒有可以回覆到 GitHub 的 role。",
+	"Link your GitHub account with `/github link` before replying to GitHub.": "回覆到 GitHub 前請先用 `/github link` 綁定 GitHub 帳號。",
+	"This thread is not linked to a GitHub issue or pull request.":            "這個 thread 沒有對應的 GitHub issue 或 pull request。",
+	"Comment": "留言",
+	"Posted to GitHub with your Discord name": "會以你的 Discord 名稱貼到 GitHub",
+	"Reply to #%d":                         "回覆 #%d",
+	"The reply is empty.":                  "回覆內容是空的。",
+	"Could not read the selected message.": "無法讀取選取的訊息。",
+	"Only messages written by people can be posted to GitHub.": "只有使用者寫的訊息可以貼到 GitHub。",
+	"The message has no text to post.":                         "這則訊息沒有可以貼的文字。",
+	"❌ Failed to post to GitHub, please try again later.":      "❌ 無法貼到 GitHub，請稍後再試。",
+	"Posted to GitHub: %s":                                     "已貼到 GitHub：%s",
+	"Posted from Discord by **%s** (%s)":                       "由 **%s**（%s）從 Discord 發表",
+	", shared by **%s** (%s)":                                  "，**%s**（%s）轉貼",
+	"view message":                                             "查看訊息",
+	"💬 Replied on GitHub":                                      "💬 已回覆到 GitHub",
+
+	// PR diff
+	"… and %d more hunk(s) — [view all changes](%s)":               "… 還有 %d 個 hunk — [查看所有變更](%s)",
+	"📄 Diff of PR #%d":                                             "📄 PR #%d 的 diff",
+	"The diff is too large to show inline, see the attached file.": "diff 太大無法直接顯示，請看附件。",
+
+	// digest
+	"🗞️ %s digest: %d event(s)": "🗞️ %s 摘要：%d 個事件",
+	"Since %s":                  "自 %s 起",
+
+	// burst（超過 REPO_RATE_LIMIT 時合併的事件）
+	"🌊 %s burst: %d event(s) combined": "🌊 %s 短時間內大量事件：合併 %d 個",
+}
@@ -0,0 +1,47 @@
+package metrics
+
+// 這個服務輸出的指標，名稱統一用 bridge_ 開頭
+var (
+	WebhooksReceived = NewCounter("bridge_webhooks_received_total",
+		"GitHub webhooks received, by event type and result (processed, queued, ignored, skipped, failed, rejected, invalid).", "event", "result")
+	SignatureFailures = NewCounter("bridge_webhook_signature_failures_total",
+		"GitHub webhooks rejected because of a missing or invalid signature.", "reason")
+	WebhooksBlocked = NewCounter("bridge_webhooks_blocked_total",
+		"GitHub webhooks rejected by GITHUB_IP_ALLOWLIST, by reason (not_allowed, ranges_unavailable, invalid_ip).", "reason")
+	WebhookDuration = NewHistogram("bridge_webhook_duration_seconds",
+		"Time spent handling a GitHub webhook, by event type.", nil, "event")
+	WebhooksInFlight = NewGauge("bridge_webhooks_in_flight",
+		"GitHub webhooks currently being processed.")
+
+	DiscordRequests = NewCounter("bridge_discord_requests_total",
+		"Discord API requests, by method, route and status (2xx, 4xx, 429, 5xx, error, circuit_open).", "method", "route", "status")
+	DiscordLatency = NewHistogram("bridge_discord_request_duration_seconds",
+		"Discord API request latency, by method and route.", nil, "method", "route")
+
+	QueueDepth = NewGaugeFunc("bridge_queue_depth",
+		"Webhook events waiting in or being processed by the worker pool.", nil)
+	QueueJobs = NewCounter("bridge_queue_jobs_total",
+		"Queued webhook events finished by the worker pool, by event type and result (processed, failed).", "event", "result")
+	QueueRetries = NewCounter("bridge_queue_retries_total",
+		"Retries of failed webhook events by the worker pool, by event type.", "event")
+	EventsCoalesced = NewCounter("bridge_events_coalesced_total",
+		"Webhook events combined into a burst summary because their repository exceeded REPO_RATE_LIMIT, by event type.", "event")
+	DeadLetters = NewCounter("bridge_dead_letters_total",
+		"Webhook events moved to the dead-letter store after exhausting retries, by event type.", "event")
+
+	ThreadsCreated = NewCounter("bridge_threads_created_total",
+		"Discord forum threads created.")
+
+	HALeader = NewGaugeFunc("bridge_ha_leader",
+		"1 if this replica currently holds the HA_MODE leader lease (always 0 when HA_MODE is off).", nil)
+
+	JobRuns = NewCounter("bridge_scheduled_job_runs_total",
+		"Scheduled job runs, by job and result (success, failed, skipped).", "job", "result")
+	JobDuration = NewHistogram("bridge_scheduled_job_duration_seconds",
+		"Time spent running a scheduled job, by job.", []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 900}, "job")
+	JobLastSuccess = NewGaugeVec("bridge_scheduled_job_last_success_timestamp_seconds",
+		"Unix time of the last successful run of a scheduled job, by job.", "job")
+
+	TraceSpansDropped = NewCounter("bridge_trace_spans_dropped_total",
+		"Trace spans dropped because the export queue was full or the collector request failed.")
+)
@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var journalBucket = []byte("jobs")
+
+// Record journal 裡的一個 job（Run 無法序列化，重啟後由 Data 重建）
+type Record struct {
+	ID   uint64 `json:"-"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// Journal 把 queue 裡的 job 存到磁碟，process 當掉或重啟後可以繼續處理
+type Journal interface {
+	// Append 寫入一個 job，回傳遞增的 ID（決定重啟後的處理順序）
+	Append(rec Record) (uint64, error)
+	// Remove job 處理完（成功或放棄）後刪除
+	Remove(id uint64) error
+	// Pending 尚未處理完的 job，依 Append 的順序
+	Pending() ([]Record, error)
+}
+
+// BoltJournal 以 bbolt 檔案實作 Journal，每次 Append / Remove 都會 fsync
+type BoltJournal struct {
+	db *bolt.DB
+}
+
+// OpenBoltJournal 開啟（不存在時建立）journal 檔案；檔案被其他 process 鎖住時 5 秒後放棄
+func OpenBoltJournal(path string) (*BoltJournal, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create queue directory: %w", err)
+		}
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open queue journal: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(journalBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize queue journal: %w", err)
+	}
+	return &BoltJournal{db: db}, nil
+}
+
+func journalKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// Append 寫入 job，ID 為 bucket 的 sequence（big-endian key，Pending 依 key 順序就是 Append 的順序）
+func (j *BoltJournal) Append(rec Record) (uint64, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return 0, err
+	}
+	var id uint64
+	err = j.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(journalBucket)
+		if id, err = b.NextSequence(); err != nil {
+			return err
+		}
+		return b.Put(journalKey(id), data)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to append to queue journal: %w", err)
+	}
+	return id, nil
+}
+
+// Remove 刪除 job
+func (j *BoltJournal) Remove(id uint64) error {
+	err := j.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(journalBucket).Delete(journalKey(id))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove from queue journal: %w", err)
+	}
+	return nil
+}
+
+// Pending 尚未處理完的 job，依 ID 排序
+func (j *BoltJournal) Pending() ([]Record, error) {
+	var records []Record
+	err := j.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(journalBucket).ForEach(func(k, v []byte) error {
+			var rec Record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("corrupt queue journal entry %x: %w", k, err)
+			}
+			rec.ID = binary.BigEndian.Uint64(k)
+			records = append(records, rec)
+			return nil
+		})
+	})
+	return records, err
+}
+
+// Close 關閉檔案
+func (j *BoltJournal) Close() error {
+	return j.db.Close()
+}
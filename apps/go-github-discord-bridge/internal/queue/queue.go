@@ -0,0 +1,262 @@
+// Package queue 依 key 分片的 worker pool：同一個 key 的 job 由同一個 worker 依序處理，不同 key 平行處理
+// 失敗的 job 依 Options.Retryable 決定是否在同一個 worker 上重試（重試期間同一個 key 的後續 job 會等待，維持順序）
+// 設定 Options.Journal 時 job 會先寫到磁碟，重啟後用 Restore 接著處理
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+var (
+	// ErrFull queue 已滿（webhook 應回 503 讓 GitHub 稍後重送）
+	ErrFull = errors.New("queue: full")
+	// ErrClosed queue 已經關閉（shutdown 中）
+	ErrClosed = errors.New("queue: closed")
+	// ErrAborted Close 的期限到了，job 沒有處理完就停止重試（傳給 Job.Done）
+	ErrAborted = errors.New("queue: aborted")
+)
+
+// Job 一個要處理的工作
+type Job struct {
+	Key  string       // 排序用的 key（例如 "owner/repo#123"），同一個 key 依 Enqueue 的順序處理
+	Name string       // 用於 log / metrics（例如 event 名稱）
+	Data []byte       // 寫進 Journal 的內容，重啟後用來重建 Run；nil = 不寫入
+	Run  func() error // 實際的處理
+	// Done job 結束時呼叫：成功為 nil、放棄重試為最後一次的錯誤、Close 的期限到了而中止為 ErrAborted；nil = 不呼叫
+	// 例如從 message broker 取得的 job 處理完才 ack
+	Done func(err error)
+
+	id uint64 // Journal 的 ID，0 = 沒有寫入
+}
+
+// Options Pool 的設定
+type Options struct {
+	Workers     int                  // worker 數，<= 0 時視為 1
+	Size        int                  // 所有 worker 合計最多等待幾個 job，<= 0 時為 Workers * 100
+	MaxAttempts int                  // 每個 job 最多執行幾次（含第一次），<= 0 時視為 1
+	Backoff     time.Duration        // 第一次重試前等待的時間，之後每次加倍
+	Retryable   func(err error) bool // nil = 所有錯誤都重試
+	// Wait 回傳 > 0 時等待這段時間後重試，且不計入 MaxAttempts（例如 Discord 暫時無法使用、被 rate limit），
+	// 讓服務中斷期間的 job 等到恢復後依序送出，而不是重試幾次就放棄
+	Wait    func(err error) time.Duration
+	Journal Journal // nil = 只存在記憶體
+	OnRetry func(job Job, attempt int, err error)
+	OnDone  func(job Job, attempts int, err error) // job 結束（成功或放棄重試）時呼叫
+}
+
+// Pool 依 key 分片的 worker pool
+type Pool struct {
+	opts   Options
+	shards []chan Job
+	depth  atomic.Int64
+
+	mu        sync.RWMutex
+	closed    bool
+	wg        sync.WaitGroup
+	abort     chan struct{} // Close 的期限到了：停止重試等待
+	abortOnce sync.Once
+}
+
+// New 建立 pool 並啟動 worker
+func New(opts Options) *Pool {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.Size <= 0 {
+		opts.Size = opts.Workers * 100
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+
+	perShard := max(opts.Size/opts.Workers, 1)
+	p := &Pool{opts: opts, shards: make([]chan Job, opts.Workers), abort: make(chan struct{})}
+	for i := range p.shards {
+		p.shards[i] = make(chan Job, perShard)
+		p.wg.Add(1)
+		go p.work(p.shards[i])
+	}
+	return p
+}
+
+// Enqueue 把 job 放進 key 對應的 worker，不會阻塞；已滿時回傳 ErrFull，關閉後回傳 ErrClosed
+// 有 Journal 且 job.Data 不為 nil 時先寫入磁碟，寫入成功才放進 queue
+func (p *Pool) Enqueue(job Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+
+	shard := p.shards[p.shard(job.Key)]
+	if len(shard) == cap(shard) {
+		return ErrFull
+	}
+	if p.opts.Journal != nil && job.Data != nil {
+		id, err := p.opts.Journal.Append(Record{Key: job.Key, Name: job.Name, Data: job.Data})
+		if err != nil {
+			return err
+		}
+		job.id = id
+	}
+
+	select {
+	case shard <- job:
+		p.depth.Add(1)
+		return nil
+	default:
+		p.forget(job)
+		return ErrFull
+	}
+}
+
+// Restore 把 Journal 裡尚未處理完的 job 依原本的順序放回 queue（在開始接受新的 job 之前呼叫）
+// build 從 Record 重建 Run；build 失敗的 record 直接刪除並回傳在 error 裡，不影響其他 record
+func (p *Pool) Restore(build func(rec Record) (func() error, error)) (int, error) {
+	if p.opts.Journal == nil {
+		return 0, nil
+	}
+	records, err := p.opts.Journal.Pending()
+	if err != nil {
+		return 0, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return 0, ErrClosed
+	}
+
+	var errs []error
+	restored := 0
+	for _, rec := range records {
+		run, err := build(rec)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("job %d (%s): %w", rec.ID, rec.Name, err))
+			p.forget(Job{id: rec.ID})
+			continue
+		}
+		// 可能超過 queue 的容量，worker 已經在跑，這裡阻塞等待即可
+		p.depth.Add(1)
+		p.shards[p.shard(rec.Key)] <- Job{Key: rec.Key, Name: rec.Name, Data: rec.Data, Run: run, id: rec.ID}
+		restored++
+	}
+	return restored, errors.Join(errs...)
+}
+
+// forget 把 job 從 Journal 刪除；刪除失敗時重啟後這個 job 會再處理一次
+func (p *Pool) forget(job Job) {
+	if p.opts.Journal == nil || job.id == 0 {
+		return
+	}
+	if err := p.opts.Journal.Remove(job.id); err != nil {
+		applogger.Log.Warn("Failed to remove job from queue journal", "job", job.Name, "key", job.Key, "id", job.id, "error", err)
+	}
+}
+
+// Len 等待中和處理中的 job 數
+func (p *Pool) Len() int {
+	return int(p.depth.Load())
+}
+
+// Cap 最多可以等待的 job 數
+func (p *Pool) Cap() int {
+	return cap(p.shards[0]) * len(p.shards)
+}
+
+// Close 停止接受新的 job，等已經在 queue 裡的 job 處理完；ctx 先到期時停止重試並回傳 ctx.Err()
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, shard := range p.shards {
+			close(shard)
+		}
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.abortOnce.Do(func() { close(p.abort) })
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) shard(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(p.shards)))
+}
+
+func (p *Pool) work(jobs <-chan Job) {
+	defer p.wg.Done()
+	for job := range jobs {
+		if p.run(job) {
+			p.forget(job)
+		} else if job.Done != nil {
+			job.Done(ErrAborted)
+		}
+		p.depth.Add(-1)
+	}
+}
+
+// run 執行 job，失敗且可重試時等待 backoff 後再執行，最多 MaxAttempts 次；job 結束（成功或放棄）時回傳 true
+// Close 的期限到了時不再重試並回傳 false，有 Journal 時 job 留在磁碟上，下次啟動會再處理
+func (p *Pool) run(job Job) bool {
+	backoff := p.opts.Backoff
+	attempt := 1
+	for {
+		err := job.Run()
+		if err == nil {
+			p.done(job, attempt, nil)
+			return true
+		}
+
+		wait := time.Duration(0)
+		if p.opts.Wait != nil {
+			wait = p.opts.Wait(err)
+		}
+		if wait <= 0 {
+			if attempt >= p.opts.MaxAttempts || (p.opts.Retryable != nil && !p.opts.Retryable(err)) {
+				p.done(job, attempt, err)
+				return true
+			}
+			if p.opts.OnRetry != nil {
+				p.opts.OnRetry(job, attempt, err)
+			}
+			wait = backoff
+			backoff *= 2
+			attempt++
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-p.abort:
+			return false
+		}
+	}
+}
+
+func (p *Pool) done(job Job, attempts int, err error) {
+	if p.opts.OnDone != nil {
+		p.opts.OnDone(job, attempts, err)
+	}
+	if job.Done != nil {
+		job.Done(err)
+	}
+}
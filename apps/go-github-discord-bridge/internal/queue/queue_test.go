@@ -0,0 +1,317 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memJournal 記憶體裡的 Journal
+type memJournal struct {
+	mu      sync.Mutex
+	next    uint64
+	records map[uint64]Record
+}
+
+func newMemJournal() *memJournal {
+	return &memJournal{records: make(map[uint64]Record)}
+}
+
+func (j *memJournal) Append(rec Record) (uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.next++
+	rec.ID = j.next
+	j.records[rec.ID] = rec
+	return rec.ID, nil
+}
+
+func (j *memJournal) Remove(id uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.records, id)
+	return nil
+}
+
+func (j *memJournal) Pending() ([]Record, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var records []Record
+	for _, rec := range j.records {
+		records = append(records, rec)
+	}
+	slices.SortFunc(records, func(a, b Record) int { return int(a.ID) - int(b.ID) })
+	return records, nil
+}
+
+func (j *memJournal) len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.records)
+}
+
+func closePool(t *testing.T, p *Pool) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestPoolOrdering(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	p := New(Options{Workers: 4, Size: 1000, MaxAttempts: 3, Backoff: time.Millisecond})
+
+	var mu sync.Mutex
+	got := make(map[string][]int)
+	keys := []string{"owner/a#1", "owner/b#2", "owner/c#3"}
+	for i := range 50 {
+		for _, key := range keys {
+			failed := false
+			err := p.Enqueue(Job{Key: key, Name: "test", Run: func() error {
+				// 每個 key 的第一個 job 先失敗一次，重試期間後面的 job 要等它
+				if i == 0 && !failed {
+					failed = true
+					return errFlaky
+				}
+				mu.Lock()
+				got[key] = append(got[key], i)
+				mu.Unlock()
+				return nil
+			}})
+			if err != nil {
+				t.Fatalf("Enqueue(%s, %d): %v", key, i, err)
+			}
+		}
+	}
+	closePool(t, p)
+
+	for _, key := range keys {
+		if len(got[key]) != 50 || !slices.IsSorted(got[key]) {
+			t.Errorf("%s ran in order %v, want 0..49", key, got[key])
+		}
+	}
+	if p.Len() != 0 {
+		t.Errorf("Len() = %d after Close, want 0", p.Len())
+	}
+}
+
+func TestPoolRetry(t *testing.T) {
+	errTemporary := errors.New("temporary")
+	errPermanent := errors.New("permanent")
+
+	tests := []struct {
+		name         string
+		results      []error // 第 n 次執行的結果，用完之後都成功
+		maxAttempts  int
+		retryable    func(err error) bool
+		wantAttempts int
+		wantRetries  int
+		wantErr      error
+	}{
+		{name: "success", results: nil, maxAttempts: 3, wantAttempts: 1},
+		{name: "succeeds on retry", results: []error{errTemporary, errTemporary}, maxAttempts: 3, wantAttempts: 3, wantRetries: 2},
+		{name: "gives up after max attempts", results: []error{errTemporary, errTemporary, errTemporary}, maxAttempts: 3, wantAttempts: 3, wantRetries: 2, wantErr: errTemporary},
+		{name: "no retry by default", results: []error{errTemporary}, maxAttempts: 0, wantAttempts: 1, wantErr: errTemporary},
+		{
+			name:         "non-retryable error",
+			results:      []error{errTemporary, errPermanent},
+			maxAttempts:  5,
+			retryable:    func(err error) bool { return !errors.Is(err, errPermanent) },
+			wantAttempts: 2,
+			wantRetries:  1,
+			wantErr:      errPermanent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts, retries int
+			var doneErr error
+			p := New(Options{
+				MaxAttempts: tt.maxAttempts,
+				Backoff:     time.Millisecond,
+				Retryable:   tt.retryable,
+				OnRetry:     func(Job, int, error) { retries++ },
+				OnDone:      func(_ Job, n int, _ error) { attempts = n },
+			})
+
+			runs := 0
+			err := p.Enqueue(Job{
+				Key: "owner/repo#1",
+				Run: func() error {
+					runs++
+					if runs <= len(tt.results) {
+						return tt.results[runs-1]
+					}
+					return nil
+				},
+				Done: func(err error) { doneErr = err },
+			})
+			if err != nil {
+				t.Fatalf("Enqueue: %v", err)
+			}
+			closePool(t, p)
+
+			if attempts != tt.wantAttempts || runs != tt.wantAttempts {
+				t.Errorf("attempts = %d, runs = %d, want %d", attempts, runs, tt.wantAttempts)
+			}
+			if retries != tt.wantRetries {
+				t.Errorf("retries = %d, want %d", retries, tt.wantRetries)
+			}
+			if !errors.Is(doneErr, tt.wantErr) {
+				t.Errorf("Done(%v), want %v", doneErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPoolWait(t *testing.T) {
+	errOutage := errors.New("outage")
+	var attempts int
+	p := New(Options{
+		MaxAttempts: 1,
+		Wait: func(err error) time.Duration {
+			if errors.Is(err, errOutage) {
+				return time.Millisecond
+			}
+			return 0
+		},
+		OnRetry: func(Job, int, error) { t.Error("OnRetry called for an outage") },
+		OnDone:  func(_ Job, n int, _ error) { attempts = n },
+	})
+
+	runs := 0
+	var doneErr error
+	err := p.Enqueue(Job{
+		Key: "owner/repo#1",
+		Run: func() error {
+			// 服務中斷期間的等待不計入 MaxAttempts
+			if runs++; runs <= 5 {
+				return errOutage
+			}
+			return nil
+		},
+		Done: func(err error) { doneErr = err },
+	})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	closePool(t, p)
+
+	if runs != 6 || attempts != 1 || doneErr != nil {
+		t.Errorf("runs = %d, attempts = %d, Done(%v), want 6 runs, 1 attempt, Done(nil)", runs, attempts, doneErr)
+	}
+}
+
+func TestPoolCloseAborts(t *testing.T) {
+	journal := newMemJournal()
+	p := New(Options{
+		MaxAttempts: 1,
+		Journal:     journal,
+		Wait:        func(error) time.Duration { return time.Hour },
+	})
+
+	done := make(chan error, 1)
+	err := p.Enqueue(Job{
+		Key:  "owner/repo#1",
+		Data: []byte("payload"),
+		Run:  func() error { return errors.New("discord is down") },
+		Done: func(err error) { done <- err },
+	})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrAborted) {
+			t.Errorf("Done(%v), want %v", err, ErrAborted)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not aborted")
+	}
+	// 中止的 job 留在 journal，重啟後再處理
+	if journal.len() != 1 {
+		t.Errorf("journal has %d records, want 1", journal.len())
+	}
+	if err := p.Enqueue(Job{Key: "owner/repo#2", Run: func() error { return nil }}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Enqueue after Close = %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestPoolFull(t *testing.T) {
+	p := New(Options{Workers: 1, Size: 1})
+	started, release := make(chan struct{}), make(chan struct{})
+	block := func() error {
+		close(started)
+		<-release
+		return nil
+	}
+	noop := func() error { return nil }
+
+	if err := p.Enqueue(Job{Key: "a", Run: block}); err != nil {
+		t.Fatalf("Enqueue 1: %v", err)
+	}
+	<-started
+	if err := p.Enqueue(Job{Key: "b", Run: noop}); err != nil {
+		t.Fatalf("Enqueue 2: %v", err)
+	}
+	if err := p.Enqueue(Job{Key: "c", Run: noop}); !errors.Is(err, ErrFull) {
+		t.Errorf("Enqueue 3 = %v, want %v", err, ErrFull)
+	}
+	if p.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", p.Len())
+	}
+	close(release)
+	closePool(t, p)
+}
+
+func TestPoolRestore(t *testing.T) {
+	journal := newMemJournal()
+	for i := range 5 {
+		if _, err := journal.Append(Record{Key: "owner/repo#1", Name: "test", Data: []byte(fmt.Sprint(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := journal.Append(Record{Key: "owner/repo#2", Name: "broken", Data: []byte("x")}); err != nil {
+		t.Fatal(err)
+	}
+
+	p := New(Options{Workers: 2, Journal: journal})
+	var mu sync.Mutex
+	var got []string
+	restored, err := p.Restore(func(rec Record) (func() error, error) {
+		if rec.Name == "broken" {
+			return nil, errors.New("unknown job")
+		}
+		return func() error {
+			mu.Lock()
+			got = append(got, string(rec.Data))
+			mu.Unlock()
+			return nil
+		}, nil
+	})
+	if restored != 5 || err == nil {
+		t.Errorf("Restore = %d, %v, want 5 and an error for the broken record", restored, err)
+	}
+	closePool(t, p)
+
+	if want := []string{"0", "1", "2", "3", "4"}; !slices.Equal(got, want) {
+		t.Errorf("restored jobs ran as %v, want %v", got, want)
+	}
+	if journal.len() != 0 {
+		t.Errorf("journal has %d records after processing, want 0", journal.len())
+	}
+}
@@ -0,0 +1,150 @@
+// Package scheduler 依排程（cron 表示式或固定間隔）執行週期性的背景工作：digest、reconcile、GC、stale PR 提醒、tag GC
+// 每次執行前加上隨機的 jitter，避免多個 instance 或多個 job 在同一秒打 GitHub / Discord API；
+// 每個 job 的執行次數、耗時和最後成功的時間輸出到 /metrics（bridge_scheduled_job_*）
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/metrics"
+	"dizzycode1112/github-discord-bridge/pkg/applogger"
+)
+
+// Schedule 計算下一次執行的時間；*cron.Schedule 和 Every 都符合
+type Schedule interface {
+	// Next 回傳 t 之後下一次執行的時間，zero time = 不會再執行
+	Next(t time.Time) time.Time
+	String() string
+}
+
+// Every 固定間隔的排程（RECONCILE_INTERVAL、GC_INTERVAL 這類設定）
+type Every time.Duration
+
+func (e Every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+func (e Every) String() string {
+	return "every " + time.Duration(e).String()
+}
+
+// Job 一個週期性的工作
+type Job struct {
+	Name     string // metrics 和 log 用，同一個 Scheduler 內不能重複
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+	// Skip 回傳 true 時這一輪不執行（例如 HA_MODE 時不是 leader），nil = 一律執行
+	Skip func() bool
+}
+
+// Scheduler 每個 job 一個 goroutine，同一個 job 不會重疊執行（上一輪還沒結束時略過錯過的時間點）
+type Scheduler struct {
+	location *time.Location
+	jitter   time.Duration
+
+	mu   sync.Mutex
+	jobs []Job
+}
+
+// New location 為 cron 表示式使用的時區（nil = UTC），jitter 為每次執行前最多延遲多久（0 = 不延遲）
+func New(location *time.Location, jitter time.Duration) *Scheduler {
+	if location == nil {
+		location = time.UTC
+	}
+	return &Scheduler{location: location, jitter: jitter}
+}
+
+// Add 加入 job，要在 Run 之前呼叫
+func (s *Scheduler) Add(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.Name == job.Name {
+			return fmt.Errorf("duplicate scheduled job %q", job.Name)
+		}
+	}
+	s.jobs = append(s.jobs, job)
+	return nil
+}
+
+// Len 已加入的 job 數
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.jobs)
+}
+
+// Run 執行所有 job 直到 ctx 結束，等執行中的 job 回傳後才回傳
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	jobs := append([]Job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	log := applogger.Log
+	log.Info("Scheduled job registered", "job", job.Name, "schedule", job.Schedule.String())
+
+	for {
+		next := job.Schedule.Next(time.Now().In(s.location))
+		if next.IsZero() {
+			log.Warn("Scheduled job never fires", "job", job.Name, "schedule", job.Schedule.String())
+			return
+		}
+		if s.jitter > 0 {
+			next = next.Add(rand.N(s.jitter))
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.run(ctx, job)
+		}
+	}
+}
+
+// run 執行一次並記錄 metrics；panic 不會讓整個 process 結束，記為 failed
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	log := applogger.Log
+	if job.Skip != nil && job.Skip() {
+		metrics.JobRuns.Inc(job.Name, "skipped")
+		return
+	}
+
+	start := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return job.Run(ctx)
+	}()
+	elapsed := time.Since(start)
+	metrics.JobDuration.Observe(elapsed.Seconds(), job.Name)
+
+	if err != nil {
+		metrics.JobRuns.Inc(job.Name, "failed")
+		log.Error("Scheduled job failed", "job", job.Name, "durationMs", elapsed.Milliseconds(), "error", err)
+		return
+	}
+	metrics.JobRuns.Inc(job.Name, "success")
+	metrics.JobLastSuccess.Set(float64(time.Now().Unix()), job.Name)
+}
@@ -0,0 +1,369 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	awsContainerCredentialsHost = "http://169.254.170.2"
+	awsIMDSHost                 = "http://169.254.169.254"
+)
+
+// AWS 以 Secrets Manager 的 GetSecretValue 讀取 secret（SecretString 必須是 JSON object）
+// credential 依序使用 AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY（可搭配 AWS_SESSION_TOKEN）、
+// EKS IRSA 的 AWS_WEB_IDENTITY_TOKEN_FILE / AWS_ROLE_ARN（STS AssumeRoleWithWebIdentity）、
+// ECS / EKS Pod Identity 的 container credentials endpoint、EC2 instance metadata（IMDSv2）
+type AWS struct {
+	SecretID string // secret 名稱或 ARN
+	Region   string
+	Endpoint string // 空值 = https://secretsmanager.<Region>.amazonaws.com（VPC endpoint、LocalStack 時設定）
+
+	mu    sync.Mutex
+	creds *awsCredentials // 暫時性的 credential，到期前 5 分鐘重新取得
+}
+
+type awsCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+func (a *AWS) String() string {
+	return "aws-secretsmanager " + a.SecretID
+}
+
+// Fetch 讀取 secret 最新的版本（AWSCURRENT）
+func (a *AWS) Fetch(ctx context.Context) (map[string]string, error) {
+	if a.Region == "" {
+		return nil, errors.New("AWS_REGION is required")
+	}
+	creds, err := a.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + a.Region + ".amazonaws.com"
+	}
+	payload, err := json.Marshal(map[string]string{"SecretId": a.SecretID})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signV4(req, payload, creds, a.Region, "secretsmanager", time.Now())
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AWS secret: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		if body.Type != "" {
+			return nil, fmt.Errorf("failed to read AWS secret %s: %s: %s %s", a.SecretID, resp.Status, body.Type, body.Message)
+		}
+		return nil, fmt.Errorf("failed to read AWS secret %s: %s", a.SecretID, resp.Status)
+	}
+
+	var body struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode AWS secret: %w", err)
+	}
+	if body.SecretString == "" {
+		return nil, fmt.Errorf("AWS secret %s has no SecretString (binary secrets are not supported)", a.SecretID)
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body.SecretString), &data); err != nil {
+		return nil, fmt.Errorf("AWS secret %s must be a JSON object of environment variables: %w", a.SecretID, err)
+	}
+	return decodeValues(data)
+}
+
+// credentials 環境變數的 credential 每次都重讀；從 endpoint 取得的暫時性 credential 快取到快到期為止
+func (a *AWS) credentials(ctx context.Context) (awsCredentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return awsCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.creds != nil && time.Until(a.creds.Expiration) > 5*time.Minute {
+		return *a.creds, nil
+	}
+
+	var creds *awsCredentials
+	var err error
+	switch {
+	case os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" && os.Getenv("AWS_ROLE_ARN") != "":
+		creds, err = fetchWebIdentityCredentials(ctx, a.Region)
+	case containerCredentialsURL() != "":
+		creds, err = fetchContainerCredentials(ctx)
+	default:
+		creds, err = fetchInstanceCredentials(ctx)
+	}
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("no AWS credentials (set AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY or run with an IAM role): %w", err)
+	}
+	a.creds = creds
+	return *creds, nil
+}
+
+// stsEndpoint AWS_ENDPOINT_URL_STS（VPC endpoint、LocalStack），空值 = region 的 STS endpoint
+func stsEndpoint(region string) string {
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL_STS"); endpoint != "" {
+		return strings.TrimRight(endpoint, "/") + "/"
+	}
+	return "https://sts." + region + ".amazonaws.com/"
+}
+
+// fetchWebIdentityCredentials 用 EKS 掛進 pod 的 service account token 呼叫 STS AssumeRoleWithWebIdentity（IRSA）
+// 這個 API 不需要簽名；token 檔案會被 kubelet 輪替，每次都重讀
+func fetchWebIdentityCredentials(ctx context.Context, region string) (*awsCredentials, error) {
+	token, err := os.ReadFile(os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = "github-discord-bridge"
+	}
+
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {os.Getenv("AWS_ROLE_ARN")},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, stsEndpoint(region), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assume role with web identity: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to assume role with web identity: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Code    string `xml:"Error>Code"`
+			Message string `xml:"Error>Message"`
+		}
+		if xml.Unmarshal(data, &body) == nil && body.Code != "" {
+			return nil, fmt.Errorf("failed to assume role %s with web identity: %s: %s %s", form.Get("RoleArn"), resp.Status, body.Code, body.Message)
+		}
+		return nil, fmt.Errorf("failed to assume role %s with web identity: %s", form.Get("RoleArn"), resp.Status)
+	}
+
+	var body struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("failed to decode AssumeRoleWithWebIdentity response: %w", err)
+	}
+	if body.Credentials.AccessKeyID == "" || body.Credentials.SecretAccessKey == "" {
+		return nil, errors.New("AssumeRoleWithWebIdentity returned no access key")
+	}
+	creds := awsCredentials(body.Credentials)
+	return &creds, nil
+}
+
+// containerCredentialsURL ECS / EKS Pod Identity 設定的 credential endpoint，空值 = 不是在 container 裡
+func containerCredentialsURL() string {
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" {
+		return awsContainerCredentialsHost + uri
+	}
+	return os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+}
+
+func fetchContainerCredentials(ctx context.Context) (*awsCredentials, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, containerCredentialsURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if path := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read container authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return decodeCredentials(req)
+}
+
+// fetchInstanceCredentials 從 EC2 instance metadata（IMDSv2）取得 instance role 的 credential
+func fetchInstanceCredentials(ctx context.Context) (*awsCredentials, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, awsIMDSHost+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	token, err := readMetadata(req)
+	if err != nil {
+		return nil, err
+	}
+
+	const rolePath = awsIMDSHost + "/latest/meta-data/iam/security-credentials/"
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, rolePath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	role, err := readMetadata(req)
+	if err != nil {
+		return nil, err
+	}
+	role, _, _ = strings.Cut(strings.TrimSpace(role), "\n")
+	if role == "" {
+		return nil, errors.New("instance has no IAM role")
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, rolePath+role, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	return decodeCredentials(req)
+}
+
+func readMetadata(req *http.Request) (string, error) {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("instance metadata unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("instance metadata %s: %s", req.URL.Path, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return string(data), err
+}
+
+func decodeCredentials(req *http.Request) (*awsCredentials, error) {
+	data, err := readMetadata(req)
+	if err != nil {
+		return nil, err
+	}
+	var creds awsCredentials
+	if err := json.Unmarshal([]byte(data), &creds); err != nil {
+		return nil, fmt.Errorf("failed to decode AWS credentials: %w", err)
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, errors.New("credential endpoint returned no access key")
+	}
+	return &creds, nil
+}
+
+// signV4 以 AWS Signature Version 4 簽署 request（簽 Host、Content-Type 和所有 X-Amz-* header）
+func signV4(req *http.Request, payload []byte, creds awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// canonicalQuery 依 key 排序、RFC 3986 編碼的 query string（url.Values.Encode 把空白編成 "+"，SigV4 要求 "%20"）
+func canonicalQuery(u *url.URL) string {
+	return strings.ReplaceAll(u.Query().Encode(), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,246 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSignV4 AWS 公開的 Signature Version 4 test suite（aws-sig-v4-test-suite）和 IAM 文件的範例
+func TestSignV4(t *testing.T) {
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		method        string
+		url           string
+		contentType   string
+		body          string
+		service       string
+		signedHeaders string
+		signature     string
+	}{
+		{
+			name:          "get-vanilla",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/",
+			service:       "service",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:          "get-vanilla-query-order-key-case",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			service:       "service",
+			signedHeaders: "host;x-amz-date",
+			signature:     "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:          "post-vanilla",
+			method:        http.MethodPost,
+			url:           "https://example.amazonaws.com/",
+			service:       "service",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name:          "post-x-www-form-urlencoded",
+			method:        http.MethodPost,
+			url:           "https://example.amazonaws.com/",
+			contentType:   "application/x-www-form-urlencoded",
+			body:          "Param1=value1",
+			service:       "service",
+			signedHeaders: "content-type;host;x-amz-date",
+			signature:     "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+		{
+			name:          "iam ListUsers",
+			method:        http.MethodGet,
+			url:           "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08",
+			contentType:   "application/x-www-form-urlencoded; charset=utf-8",
+			service:       "iam",
+			signedHeaders: "content-type;host;x-amz-date",
+			signature:     "5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			signV4(req, []byte(tt.body), creds, "us-east-1", tt.service, now)
+
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/" + tt.service + "/aws4_request" +
+				", SignedHeaders=" + tt.signedHeaders + ", Signature=" + tt.signature
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization =\n  %s\nwant\n  %s", got, want)
+			}
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date = %q", got)
+			}
+		})
+	}
+}
+
+func TestSignV4SessionToken(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "https://secretsmanager.us-east-1.amazonaws.com/", nil)
+	signV4(req, nil, awsCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}, "us-east-1", "secretsmanager", time.Now())
+
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "token" {
+		t.Errorf("X-Amz-Security-Token = %q, want %q", got, "token")
+	}
+	if auth := req.Header.Get("Authorization"); !strings.Contains(auth, "SignedHeaders=host;x-amz-date;x-amz-security-token,") {
+		t.Errorf("session token is not signed: %s", auth)
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "", want: ""},
+		{query: "b=2&a=1", want: "a=1&b=2"},
+		{query: "q=hello%20world", want: "q=hello%20world"},
+		{query: "q=a+b", want: "q=a%20b"},
+		{query: "q=a%2Bb", want: "q=a%2Bb"},
+		{query: "q=-_.~", want: "q=-_.~"},
+		{query: "q=%E4%B8%AD", want: "q=%E4%B8%AD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := canonicalQuery(&url.URL{RawQuery: tt.query}); got != tt.want {
+				t.Errorf("canonicalQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebIdentityCredentials(t *testing.T) {
+	const okResponse = `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>ASIAEXAMPLE</AccessKeyId>
+      <SecretAccessKey>secret-key</SecretAccessKey>
+      <SessionToken>session-token</SessionToken>
+      <Expiration>2030-01-02T03:04:05Z</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`
+	const errorResponse = `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>InvalidIdentityToken</Code>
+    <Message>Token expired</Message>
+  </Error>
+</ErrorResponse>`
+
+	tests := []struct {
+		name        string
+		sessionName string
+		status      int
+		response    string
+		wantErr     string
+	}{
+		{name: "ok", status: http.StatusOK, response: okResponse},
+		{name: "session name", sessionName: "custom", status: http.StatusOK, response: okResponse},
+		{name: "sts error", status: http.StatusBadRequest, response: errorResponse, wantErr: "InvalidIdentityToken Token expired"},
+		{name: "no credentials", status: http.StatusOK, response: `<AssumeRoleWithWebIdentityResponse/>`, wantErr: "no access key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var form url.Values
+			sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "" {
+					t.Error("AssumeRoleWithWebIdentity request must not be signed")
+				}
+				r.ParseForm()
+				form = r.PostForm
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.response))
+			}))
+			defer sts.Close()
+
+			tokenFile := filepath.Join(t.TempDir(), "token")
+			if err := os.WriteFile(tokenFile, []byte("eyJhbGciOi.service-account-token\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("AWS_ACCESS_KEY_ID", "")
+			t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+			t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+			t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/bridge")
+			t.Setenv("AWS_ROLE_SESSION_NAME", tt.sessionName)
+			t.Setenv("AWS_ENDPOINT_URL_STS", sts.URL)
+
+			a := &AWS{SecretID: "bridge", Region: "ap-northeast-1"}
+			creds, err := a.credentials(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("credentials error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("credentials: %v", err)
+			}
+
+			want := awsCredentials{
+				AccessKeyID:     "ASIAEXAMPLE",
+				SecretAccessKey: "secret-key",
+				SessionToken:    "session-token",
+				Expiration:      time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+			}
+			if creds != want {
+				t.Errorf("credentials = %+v, want %+v", creds, want)
+			}
+			wantSession := tt.sessionName
+			if wantSession == "" {
+				wantSession = "github-discord-bridge"
+			}
+			for key, value := range map[string]string{
+				"Action":           "AssumeRoleWithWebIdentity",
+				"Version":          "2011-06-15",
+				"RoleArn":          "arn:aws:iam::123456789012:role/bridge",
+				"RoleSessionName":  wantSession,
+				"WebIdentityToken": "eyJhbGciOi.service-account-token",
+			} {
+				if got := form.Get(key); got != value {
+					t.Errorf("%s = %q, want %q", key, got, value)
+				}
+			}
+
+			// 沒到期前用快取，不再呼叫 STS
+			sts.Close()
+			if cached, err := a.credentials(context.Background()); err != nil || cached != want {
+				t.Errorf("cached credentials = %+v, %v", cached, err)
+			}
+		})
+	}
+}
+
+func TestStaticCredentialsTakePrecedence(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "/nonexistent")
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/bridge")
+
+	creds, err := (&AWS{Region: "us-east-1"}).credentials(context.Background())
+	if err != nil {
+		t.Fatalf("credentials: %v", err)
+	}
+	if creds.AccessKeyID != "AKID" || creds.SecretAccessKey != "secret" {
+		t.Errorf("credentials = %+v, want the static keys", creds)
+	}
+}
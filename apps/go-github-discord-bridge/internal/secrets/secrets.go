@@ -0,0 +1,43 @@
+// Package secrets 從 HashiCorp Vault（KV v1 / v2）或 AWS Secrets Manager 讀取 secret，取代直接放在環境變數裡的 token
+// secret 的內容是「環境變數名稱 → 值」，例如 {"DISCORD_BOT_TOKEN": "...", "GITHUB_WEBHOOK_SECRET": "..."}
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Source 讀取 secret 的 backend
+type Source interface {
+	// Fetch 讀取最新的 secret（每次呼叫都重新向 backend 要，不快取）
+	Fetch(ctx context.Context) (map[string]string, error)
+	// String log 用的描述（不含 credential）
+	String() string
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// decodeValues 把 JSON object 轉成環境變數；值為數字 / boolean 時轉成字串，巢狀的 object / array 視為錯誤
+func decodeValues(data map[string]json.RawMessage) (map[string]string, error) {
+	values := make(map[string]string, len(data))
+	for key, raw := range data {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			values[key] = s
+			continue
+		}
+		text := strings.TrimSpace(string(raw))
+		if text == "null" {
+			continue
+		}
+		if strings.HasPrefix(text, "{") || strings.HasPrefix(text, "[") {
+			return nil, fmt.Errorf("secret key %s must be a string", key)
+		}
+		values[key] = text
+	}
+	return values, nil
+}
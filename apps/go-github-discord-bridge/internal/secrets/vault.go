@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Vault 以 HTTP API 讀取 Vault KV secret（token 認證）
+type Vault struct {
+	Addr      string // 例如 https://vault.example.com:8200
+	Token     string
+	TokenFile string // 每次 Fetch 都重讀（Vault Agent 會定期更新這個檔案），有設定時優先於 Token
+	Namespace string // Vault Enterprise namespace，空值 = 不送
+	Path      string // /v1/ 之後的 API 路徑，KV v2 要包含 data/（例如 secret/data/bridge）
+}
+
+func (v *Vault) String() string {
+	return "vault " + v.Path
+}
+
+// Fetch 讀取 secret；KV v2 的回應是 data.data（另有 data.metadata），KV v1 直接是 data
+func (v *Vault) Fetch(ctx context.Context) (map[string]string, error) {
+	token, err := v.token()
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimRight(v.Addr, "/") + "/v1/" + strings.TrimLeft(v.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Vault secret: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Errors []string `json:"errors"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		if len(body.Errors) > 0 {
+			return nil, fmt.Errorf("failed to read Vault secret %s: %s: %s", v.Path, resp.Status, strings.Join(body.Errors, "; "))
+		}
+		return nil, fmt.Errorf("failed to read Vault secret %s: %s", v.Path, resp.Status)
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode Vault secret: %w", err)
+	}
+	data := body.Data
+	if inner, ok := data["data"]; ok {
+		if _, v2 := data["metadata"]; v2 {
+			data = nil
+			if err := json.Unmarshal(inner, &data); err != nil {
+				return nil, fmt.Errorf("failed to decode Vault KV v2 secret: %w", err)
+			}
+		}
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("Vault secret %s is empty (deleted, or a KV v2 path without data/?)", v.Path)
+	}
+	return decodeValues(data)
+}
+
+func (v *Vault) token() (string, error) {
+	if v.TokenFile != "" {
+		data, err := os.ReadFile(v.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read Vault token file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if v.Token == "" {
+		return "", errors.New("VAULT_TOKEN or VAULT_TOKEN_FILE is required")
+	}
+	return v.Token, nil
+}
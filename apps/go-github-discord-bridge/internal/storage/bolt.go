@@ -0,0 +1,476 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltMappingsBucket   = []byte("mappings")
+	boltRecordsBucket    = []byte("records")
+	boltDeliveriesBucket = []byte("deliveries")
+	boltDeadLetterBucket = []byte("dead_letters")
+)
+
+// boltPurgeInterval 多久清一次過期的 mapping / record / delivery
+const boltPurgeInterval = time.Hour
+
+// boltMapping mappings bucket 裡每個 key 存的 JSON
+type boltMapping struct {
+	ThreadID  string `json:"thread_id"`
+	Closed    bool   `json:"closed,omitempty"`
+	ExpiresAt int64  `json:"expires_at,omitempty"` // unix 秒，0 = 不過期
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+func (m boltMapping) expired(now time.Time) bool {
+	return m.ExpiresAt != 0 && m.ExpiresAt <= now.Unix()
+}
+
+// boltRecord records bucket 裡每個 key 存的 JSON
+type boltRecord struct {
+	Value     string `json:"value"`
+	ExpiresAt int64  `json:"expires_at,omitempty"` // unix 秒，0 = 不過期
+}
+
+func (r boltRecord) expired(now time.Time) bool {
+	return r.ExpiresAt != 0 && r.ExpiresAt <= now.Unix()
+}
+
+// BoltStore 以嵌入式 bbolt 檔案保存 mapping，單一 binary 即可部署，不需要外部資料庫
+// bbolt 同時只能被一個 process 開啟，多 instance 部署請用 Redis
+type BoltStore struct {
+	db   *bolt.DB
+	done chan struct{}
+}
+
+// NewBoltStore 開啟（不存在時建立）bbolt 檔案；檔案被其他 process 鎖住時 5 秒後放棄
+func NewBoltStore(path string) (*BoltStore, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create bolt directory: %w", err)
+		}
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltMappingsBucket, boltRecordsBucket, boltDeliveriesBucket, boltDeadLetterBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create bolt buckets: %w", err)
+	}
+
+	s := &BoltStore{db: db, done: make(chan struct{})}
+	go s.purgeLoop()
+	return s, nil
+}
+
+// Set 儲存 mapping（無 TTL），已存在時覆寫並重新標為 open
+func (s *BoltStore) Set(prID, threadID string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return putMapping(tx, prID, boltMapping{ThreadID: threadID, UpdatedAt: time.Now().Unix()})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set mapping: %w", err)
+	}
+	return nil
+}
+
+// Get 取得 Thread ID（過期的 mapping 視為不存在）
+func (s *BoltStore) Get(prID string) (string, bool, error) {
+	var threadID string
+	var exists bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		m, ok, err := getMapping(tx, prID)
+		if err != nil || !ok || m.expired(time.Now()) {
+			return err
+		}
+		threadID, exists = m.ThreadID, true
+		return nil
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get mapping: %w", err)
+	}
+	return threadID, exists, nil
+}
+
+// Delete 刪除對應關係
+func (s *BoltStore) Delete(prID string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltMappingsBucket).Delete([]byte(prID))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete mapping: %w", err)
+	}
+	return nil
+}
+
+// MarkAsClosed 標記為 closed 並設定 7 天後過期；mapping 不存在時不做事
+func (s *BoltStore) MarkAsClosed(prID string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		m, ok, err := getMapping(tx, prID)
+		if err != nil || !ok || m.expired(now) {
+			return err
+		}
+		m.Closed = true
+		m.ExpiresAt = now.Add(ClosedPRTTL).Unix()
+		m.UpdatedAt = now.Unix()
+		return putMapping(tx, prID, m)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark as closed: %w", err)
+	}
+	return nil
+}
+
+// RenamePrefix 把 oldPrefix 開頭的 key 改成 newPrefix 開頭（保留狀態和過期時間）
+func (s *BoltStore) RenamePrefix(oldPrefix, newPrefix string) (int, error) {
+	if oldPrefix == newPrefix {
+		return 0, nil
+	}
+
+	renamed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltMappingsBucket)
+
+		// 先收集再改，不在 cursor 走訪途中寫入
+		type entry struct{ key, value []byte }
+		var entries []entry
+		c := b.Cursor()
+		prefix := []byte(oldPrefix)
+		for k, v := c.Seek(prefix); k != nil && strings.HasPrefix(string(k), oldPrefix); k, v = c.Next() {
+			entries = append(entries, entry{append([]byte{}, k...), append([]byte{}, v...)})
+		}
+
+		for _, e := range entries {
+			newKey := newPrefix + strings.TrimPrefix(string(e.key), oldPrefix)
+			if err := b.Put([]byte(newKey), e.value); err != nil {
+				return err
+			}
+			if err := b.Delete(e.key); err != nil {
+				return err
+			}
+			renamed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to rename keys: %w", err)
+	}
+	return renamed, nil
+}
+
+// ListStale 列出 updated 早於 before 的 mapping（已過期的不列）
+func (s *BoltStore) ListStale(before time.Time) ([]Mapping, error) {
+	var stale []Mapping
+	now := time.Now()
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltMappingsBucket).ForEach(func(k, v []byte) error {
+			var m boltMapping
+			if err := json.Unmarshal(v, &m); err != nil {
+				return nil // 壞掉的資料交給 GC 以外的流程處理，不中斷列舉
+			}
+			if m.expired(now) || m.UpdatedAt >= before.Unix() {
+				return nil
+			}
+			stale = append(stale, Mapping{
+				Key:       string(k),
+				ThreadID:  m.ThreadID,
+				Closed:    m.Closed,
+				UpdatedAt: time.Unix(m.UpdatedAt, 0),
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale mappings: %w", err)
+	}
+	return stale, nil
+}
+
+// SetRecord 寫入 record，ttl 0 = 不過期
+func (s *BoltStore) SetRecord(key, value string, ttl time.Duration) error {
+	r := boltRecord{Value: value}
+	if ttl > 0 {
+		r.ExpiresAt = time.Now().Add(ttl).Unix()
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltRecordsBucket).Put([]byte(key), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set record: %w", err)
+	}
+	return nil
+}
+
+// GetRecord 取得 record（過期的視為不存在）
+func (s *BoltStore) GetRecord(key string) (string, bool, error) {
+	var r boltRecord
+	var exists bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltRecordsBucket).Get([]byte(key))
+		if v == nil {
+			return nil
+		}
+		if err := json.Unmarshal(v, &r); err != nil {
+			return err
+		}
+		exists = !r.expired(time.Now())
+		return nil
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get record: %w", err)
+	}
+	if !exists {
+		return "", false, nil
+	}
+	return r.Value, true, nil
+}
+
+// DeleteRecord 刪除 record
+func (s *BoltStore) DeleteRecord(key string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltRecordsBucket).Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete record: %w", err)
+	}
+	return nil
+}
+
+// MarkDelivered 記錄 delivery ID，ttl 後過期
+func (s *BoltStore) MarkDelivered(deliveryID string, ttl time.Duration) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		expiresAt := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+		return tx.Bucket(boltDeliveriesBucket).Put([]byte(deliveryID), []byte(expiresAt))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark delivery: %w", err)
+	}
+	return nil
+}
+
+// IsDelivered delivery ID 是否已記錄且尚未過期
+func (s *BoltStore) IsDelivered(deliveryID string) (bool, error) {
+	var delivered bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltDeliveriesBucket).Get([]byte(deliveryID))
+		if v == nil {
+			return nil
+		}
+		expiresAt, err := strconv.ParseInt(string(v), 10, 64)
+		delivered = err == nil && expiresAt > time.Now().Unix()
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check delivery: %w", err)
+	}
+	return delivered, nil
+}
+
+// ClaimDelivery 在同一個 write transaction 內檢查並寫入
+func (s *BoltStore) ClaimDelivery(deliveryID string, ttl time.Duration) (bool, error) {
+	var claimed bool
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltDeliveriesBucket)
+		now := time.Now()
+		if v := b.Get([]byte(deliveryID)); v != nil {
+			if expiresAt, err := strconv.ParseInt(string(v), 10, 64); err == nil && expiresAt > now.Unix() {
+				return nil
+			}
+		}
+		claimed = true
+		return b.Put([]byte(deliveryID), []byte(strconv.FormatInt(now.Add(ttl).Unix(), 10)))
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim delivery: %w", err)
+	}
+	return claimed, nil
+}
+
+// ReleaseDelivery 刪掉佔用中的 delivery ID
+func (s *BoltStore) ReleaseDelivery(deliveryID string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltDeliveriesBucket).Delete([]byte(deliveryID))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release delivery: %w", err)
+	}
+	return nil
+}
+
+// AddDeadLetter 以 delivery ID 為 key 存 JSON
+func (s *BoltStore) AddDeadLetter(dl DeadLetter) error {
+	data, err := json.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltDeadLetterBucket).Put([]byte(dl.DeliveryID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add dead letter: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters 列出所有 dead letter
+func (s *BoltStore) ListDeadLetters() ([]DeadLetter, error) {
+	var letters []DeadLetter
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltDeadLetterBucket).ForEach(func(k, v []byte) error {
+			var dl DeadLetter
+			if err := json.Unmarshal(v, &dl); err != nil {
+				return err
+			}
+			letters = append(letters, dl)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	sortDeadLetters(letters)
+	return letters, nil
+}
+
+// GetDeadLetter 取得一個 dead letter
+func (s *BoltStore) GetDeadLetter(deliveryID string) (DeadLetter, bool, error) {
+	var dl DeadLetter
+	var exists bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltDeadLetterBucket).Get([]byte(deliveryID))
+		if v == nil {
+			return nil
+		}
+		exists = true
+		return json.Unmarshal(v, &dl)
+	})
+	if err != nil {
+		return dl, false, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	return dl, exists, nil
+}
+
+// DeleteDeadLetter 刪除 dead letter
+func (s *BoltStore) DeleteDeadLetter(deliveryID string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltDeadLetterBucket).Delete([]byte(deliveryID))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	return nil
+}
+
+// Ping 開一個唯讀 transaction（檔案已關閉時會失敗）
+func (s *BoltStore) Ping(ctx context.Context) error {
+	return s.db.View(func(*bolt.Tx) error { return nil })
+}
+
+// Close 停止背景清理並關閉檔案
+func (s *BoltStore) Close() error {
+	close(s.done)
+	return s.db.Close()
+}
+
+// purgeLoop 定期刪除過期的資料
+func (s *BoltStore) purgeLoop() {
+	ticker := time.NewTicker(boltPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.purge(time.Now())
+		}
+	}
+}
+
+func (s *BoltStore) purge(now time.Time) {
+	s.db.Update(func(tx *bolt.Tx) error {
+		mappings := tx.Bucket(boltMappingsBucket)
+		var expired [][]byte
+		mappings.ForEach(func(k, v []byte) error {
+			var m boltMapping
+			if json.Unmarshal(v, &m) == nil && m.expired(now) {
+				expired = append(expired, append([]byte{}, k...))
+			}
+			return nil
+		})
+		for _, k := range expired {
+			mappings.Delete(k)
+		}
+
+		records := tx.Bucket(boltRecordsBucket)
+		expired = expired[:0]
+		records.ForEach(func(k, v []byte) error {
+			var r boltRecord
+			if json.Unmarshal(v, &r) == nil && r.expired(now) {
+				expired = append(expired, append([]byte{}, k...))
+			}
+			return nil
+		})
+		for _, k := range expired {
+			records.Delete(k)
+		}
+
+		deliveries := tx.Bucket(boltDeliveriesBucket)
+		expired = expired[:0]
+		deliveries.ForEach(func(k, v []byte) error {
+			if expiresAt, err := strconv.ParseInt(string(v), 10, 64); err != nil || expiresAt <= now.Unix() {
+				expired = append(expired, append([]byte{}, k...))
+			}
+			return nil
+		})
+		for _, k := range expired {
+			deliveries.Delete(k)
+		}
+		return nil
+	})
+}
+
+func getMapping(tx *bolt.Tx, key string) (boltMapping, bool, error) {
+	var m boltMapping
+	v := tx.Bucket(boltMappingsBucket).Get([]byte(key))
+	if v == nil {
+		return m, false, nil
+	}
+	if err := json.Unmarshal(v, &m); err != nil {
+		return m, false, err
+	}
+	return m, true, nil
+}
+
+func putMapping(tx *bolt.Tx, key string, m boltMapping) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(boltMappingsBucket).Put([]byte(key), data)
+}
@@ -0,0 +1,43 @@
+-- thread_mappings.key 和 Redis 的 key 相同（"owner/repo#123"、"owner/repo#activity" 等）
+CREATE TABLE thread_mappings (
+	key        TEXT PRIMARY KEY,
+	repo       TEXT NOT NULL,
+	number     INTEGER,
+	thread_id  TEXT NOT NULL,
+	state      TEXT NOT NULL DEFAULT 'open',
+	expires_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX thread_mappings_repo_number ON thread_mappings (repo, number);
+CREATE INDEX thread_mappings_updated_at ON thread_mappings (updated_at);
+
+CREATE TABLE deliveries (
+	delivery_id TEXT PRIMARY KEY,
+	expires_at  TIMESTAMPTZ NOT NULL
+);
+
+-- 每個建立過的 thread（mapping 過期或被覆寫後仍保留）
+CREATE TABLE threads (
+	key        TEXT NOT NULL,
+	thread_id  TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (key, thread_id)
+);
+CREATE INDEX threads_thread_id ON threads (thread_id);
+
+-- 每個處理過的 webhook event
+CREATE TABLE events (
+	id           BIGSERIAL PRIMARY KEY,
+	delivery_id  TEXT NOT NULL DEFAULT '',
+	event        TEXT NOT NULL,
+	action       TEXT NOT NULL DEFAULT '',
+	repo         TEXT NOT NULL DEFAULT '',
+	number       INTEGER,
+	sender       TEXT NOT NULL DEFAULT '',
+	status       TEXT NOT NULL,
+	error        TEXT NOT NULL DEFAULT '',
+	processed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX events_repo_number ON events (repo, number);
+CREATE INDEX events_delivery_id ON events (delivery_id);
+CREATE INDEX events_processed_at ON events (processed_at);
@@ -0,0 +1,11 @@
+-- 重試後仍處理失敗的 webhook delivery（原始 payload），重新處理成功或捨棄後刪除
+CREATE TABLE dead_letters (
+	delivery_id TEXT PRIMARY KEY,
+	event       TEXT NOT NULL,
+	repo        TEXT NOT NULL DEFAULT '',
+	body        BYTEA NOT NULL,
+	error       TEXT NOT NULL DEFAULT '',
+	attempts    INTEGER NOT NULL DEFAULT 0,
+	failed_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX dead_letters_failed_at ON dead_letters (failed_at);
@@ -0,0 +1,7 @@
+-- HA_MODE 的 leader election：每個 lease 同時只有一個 holder（replica ID），過期後其他 replica 可以接手
+-- expires_at 用資料庫的 now() 計算，不受各 replica 時鐘誤差影響
+CREATE TABLE leases (
+	name       TEXT PRIMARY KEY,
+	holder     TEXT NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,6 @@
+-- 不是 thread mapping 的資料（/github link 綁定等），和 thread_mappings 分開，不會出現在 mapping 的匯出 / 統計
+CREATE TABLE records (
+	key        TEXT PRIMARY KEY,
+	value      TEXT NOT NULL,
+	expires_at TIMESTAMPTZ
+);
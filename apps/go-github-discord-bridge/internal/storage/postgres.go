@@ -0,0 +1,495 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/event"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // 註冊 database/sql 的 "pgx" driver
+)
+
+// postgresPurgeInterval 多久清一次過期的 mapping / record / delivery
+const postgresPurgeInterval = time.Hour
+
+// postgresMigrationLock 多個 instance 同時啟動時，用 advisory lock 確保 migration 只跑一次
+const postgresMigrationLock = 0x67683264 // "gh2d"
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+// PostgresStore 以 Postgres 保存 mapping，並額外保留每個處理過的 event 和建立過的 thread，方便事後查詢
+// 多個 instance 可共用同一個資料庫
+type PostgresStore struct {
+	db     *sql.DB
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewPostgresStore 連線並套用尚未執行的 migration（接受 postgres:// URL 或 key=value DSN）
+func NewPostgresStore(databaseURL string) (*PostgresStore, error) {
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// 測試連線
+	if err := db.PingContext(ctx); err != nil {
+		cancel()
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	if err := migratePostgres(ctx, db); err != nil {
+		cancel()
+		db.Close()
+		return nil, err
+	}
+
+	s := &PostgresStore{db: db, ctx: ctx, cancel: cancel}
+	go s.purgeLoop()
+	return s, nil
+}
+
+// migratePostgres 依檔名順序（0001_xxx.sql、0002_xxx.sql…）執行 schema_migrations 還沒記錄的 migration
+// 每個檔案在自己的 transaction 裡執行，失敗時整個檔案 rollback
+func migratePostgres(ctx context.Context, db *sql.DB) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to migrate postgres schema: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, postgresMigrationLock); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, postgresMigrationLock)
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	names, err := fs.Glob(postgresMigrations, "migrations/postgres/*.sql")
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		base := strings.TrimPrefix(name, "migrations/postgres/")
+		version, err := strconv.Atoi(strings.SplitN(base, "_", 2)[0])
+		if err != nil {
+			return fmt.Errorf("invalid migration file name %s", base)
+		}
+
+		var applied bool
+		if err := conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(&applied); err != nil {
+			return fmt.Errorf("failed to check migration %s: %w", base, err)
+		}
+		if applied {
+			continue
+		}
+
+		script, err := postgresMigrations.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", base, err)
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %s: %w", base, err)
+		}
+		if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %s: %w", base, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, version, base); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %s: %w", base, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %s: %w", base, err)
+		}
+	}
+	return nil
+}
+
+// Set 儲存 mapping（無 TTL），已存在時覆寫並重新標為 open；同時記到 threads 歷史
+func (s *PostgresStore) Set(prID, threadID string) error {
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	repo, number := splitMappingKey(prID)
+	if _, err := tx.ExecContext(s.ctx, `
+		INSERT INTO thread_mappings (key, repo, number, thread_id, state, expires_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'open', NULL, now())
+		ON CONFLICT (key) DO UPDATE SET
+			thread_id = excluded.thread_id, state = 'open', expires_at = NULL, updated_at = excluded.updated_at`,
+		prID, repo, number, threadID); err != nil {
+		return fmt.Errorf("failed to set mapping: %w", err)
+	}
+	if _, err := tx.ExecContext(s.ctx, `
+		INSERT INTO threads (key, thread_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		prID, threadID); err != nil {
+		return fmt.Errorf("failed to record thread: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to set mapping: %w", err)
+	}
+	return nil
+}
+
+// Get 取得 Thread ID（過期的 mapping 視為不存在）
+func (s *PostgresStore) Get(prID string) (string, bool, error) {
+	var threadID string
+	err := s.db.QueryRowContext(s.ctx,
+		`SELECT thread_id FROM thread_mappings WHERE key = $1 AND (expires_at IS NULL OR expires_at > now())`,
+		prID).Scan(&threadID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get mapping: %w", err)
+	}
+	return threadID, true, nil
+}
+
+// Delete 刪除對應關係（threads 歷史保留）
+func (s *PostgresStore) Delete(prID string) error {
+	if _, err := s.db.ExecContext(s.ctx, `DELETE FROM thread_mappings WHERE key = $1`, prID); err != nil {
+		return fmt.Errorf("failed to delete mapping: %w", err)
+	}
+	return nil
+}
+
+// MarkAsClosed 標記為 closed 並設定 7 天後過期；mapping 不存在時不做事
+func (s *PostgresStore) MarkAsClosed(prID string) error {
+	_, err := s.db.ExecContext(s.ctx, `
+		UPDATE thread_mappings SET state = 'closed', expires_at = now() + $1 * interval '1 second', updated_at = now()
+		WHERE key = $2 AND (expires_at IS NULL OR expires_at > now())`,
+		int64(ClosedPRTTL.Seconds()), prID)
+	if err != nil {
+		return fmt.Errorf("failed to mark as closed: %w", err)
+	}
+	return nil
+}
+
+// RenamePrefix 把 oldPrefix 開頭的 key 改成 newPrefix 開頭（保留 state 和 expires_at）
+// 新 key 已存在時覆寫，和 Redis RENAME 的行為一致；threads 歷史也一起搬
+func (s *PostgresStore) RenamePrefix(oldPrefix, newPrefix string) (int, error) {
+	if oldPrefix == newPrefix {
+		return 0, nil
+	}
+
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(s.ctx, `SELECT key FROM thread_mappings WHERE left(key, length($1)) = $1 FOR UPDATE`, oldPrefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan keys: %w", err)
+	}
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan keys: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to scan keys: %w", err)
+	}
+
+	for _, key := range keys {
+		newKey := newPrefix + strings.TrimPrefix(key, oldPrefix)
+		repo, number := splitMappingKey(newKey)
+		if _, err := tx.ExecContext(s.ctx, `DELETE FROM thread_mappings WHERE key = $1`, newKey); err != nil {
+			return 0, fmt.Errorf("failed to rename %s: %w", key, err)
+		}
+		if _, err := tx.ExecContext(s.ctx, `UPDATE thread_mappings SET key = $1, repo = $2, number = $3 WHERE key = $4`, newKey, repo, number, key); err != nil {
+			return 0, fmt.Errorf("failed to rename %s: %w", key, err)
+		}
+		if _, err := tx.ExecContext(s.ctx, `
+			INSERT INTO threads (key, thread_id, created_at) SELECT $1, thread_id, created_at FROM threads WHERE key = $2
+			ON CONFLICT DO NOTHING`, newKey, key); err != nil {
+			return 0, fmt.Errorf("failed to rename %s: %w", key, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit rename: %w", err)
+	}
+	return len(keys), nil
+}
+
+// ListStale 列出 updated_at 早於 before 的 mapping（已過期的不列）
+func (s *PostgresStore) ListStale(before time.Time) ([]Mapping, error) {
+	rows, err := s.db.QueryContext(s.ctx, `
+		SELECT key, thread_id, state, updated_at FROM thread_mappings
+		WHERE updated_at < $1 AND (expires_at IS NULL OR expires_at > now())
+		ORDER BY updated_at`,
+		before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale mappings: %w", err)
+	}
+	defer rows.Close()
+
+	var stale []Mapping
+	for rows.Next() {
+		var m Mapping
+		var state string
+		if err := rows.Scan(&m.Key, &m.ThreadID, &state, &m.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to list stale mappings: %w", err)
+		}
+		m.Closed = state == "closed"
+		stale = append(stale, m)
+	}
+	return stale, rows.Err()
+}
+
+// SetRecord 寫入 record，ttl 0 = expires_at 為 NULL
+func (s *PostgresStore) SetRecord(key, value string, ttl time.Duration) error {
+	var expiresAt sql.NullTime
+	if ttl > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().Add(ttl), Valid: true}
+	}
+	_, err := s.db.ExecContext(s.ctx, `
+		INSERT INTO records (key, value, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at`,
+		key, value, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to set record: %w", err)
+	}
+	return nil
+}
+
+// GetRecord 取得 record（過期的視為不存在）
+func (s *PostgresStore) GetRecord(key string) (string, bool, error) {
+	var value string
+	err := s.db.QueryRowContext(s.ctx,
+		`SELECT value FROM records WHERE key = $1 AND (expires_at IS NULL OR expires_at > now())`,
+		key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get record: %w", err)
+	}
+	return value, true, nil
+}
+
+// DeleteRecord 刪除 record
+func (s *PostgresStore) DeleteRecord(key string) error {
+	if _, err := s.db.ExecContext(s.ctx, `DELETE FROM records WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("failed to delete record: %w", err)
+	}
+	return nil
+}
+
+// MarkDelivered 記錄 delivery ID，ttl 後過期
+func (s *PostgresStore) MarkDelivered(deliveryID string, ttl time.Duration) error {
+	_, err := s.db.ExecContext(s.ctx, `
+		INSERT INTO deliveries (delivery_id, expires_at) VALUES ($1, $2)
+		ON CONFLICT (delivery_id) DO UPDATE SET expires_at = excluded.expires_at`,
+		deliveryID, time.Now().Add(ttl))
+	if err != nil {
+		return fmt.Errorf("failed to mark delivery: %w", err)
+	}
+	return nil
+}
+
+// IsDelivered delivery ID 是否已記錄且尚未過期
+func (s *PostgresStore) IsDelivered(deliveryID string) (bool, error) {
+	var delivered bool
+	err := s.db.QueryRowContext(s.ctx,
+		`SELECT EXISTS (SELECT 1 FROM deliveries WHERE delivery_id = $1 AND expires_at > now())`,
+		deliveryID).Scan(&delivered)
+	if err != nil {
+		return false, fmt.Errorf("failed to check delivery: %w", err)
+	}
+	return delivered, nil
+}
+
+// ClaimDelivery 只在 delivery ID 不存在或已過期時寫入，多個 instance 共用同一個資料庫時也是原子的
+func (s *PostgresStore) ClaimDelivery(deliveryID string, ttl time.Duration) (bool, error) {
+	res, err := s.db.ExecContext(s.ctx, `
+		INSERT INTO deliveries (delivery_id, expires_at) VALUES ($1, $2)
+		ON CONFLICT (delivery_id) DO UPDATE SET expires_at = excluded.expires_at
+		WHERE deliveries.expires_at <= now()`,
+		deliveryID, time.Now().Add(ttl))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim delivery: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim delivery: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ReleaseDelivery 刪掉佔用中的 delivery ID
+func (s *PostgresStore) ReleaseDelivery(deliveryID string) error {
+	if _, err := s.db.ExecContext(s.ctx, `DELETE FROM deliveries WHERE delivery_id = $1`, deliveryID); err != nil {
+		return fmt.Errorf("failed to release delivery: %w", err)
+	}
+	return nil
+}
+
+// AcquireLease 只在 lease 不存在、已過期或 holder 相同時寫入，多個 instance 同時呼叫也只有一個成功
+func (s *PostgresStore) AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	res, err := s.db.ExecContext(s.ctx, `
+		INSERT INTO leases (name, holder, expires_at) VALUES ($1, $2, now() + $3 * interval '1 millisecond')
+		ON CONFLICT (name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+		WHERE leases.holder = excluded.holder OR leases.expires_at <= now()`,
+		name, holder, ttl.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ReleaseLease 刪掉 holder 持有的 lease
+func (s *PostgresStore) ReleaseLease(name, holder string) error {
+	if _, err := s.db.ExecContext(s.ctx, `DELETE FROM leases WHERE name = $1 AND holder = $2`, name, holder); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// RecordEvent 寫一筆處理紀錄到 events
+func (s *PostgresStore) RecordEvent(ev event.Event, status string, handleErr error) error {
+	var number sql.NullInt64
+	if ev.Number != 0 {
+		number = sql.NullInt64{Int64: int64(ev.Number), Valid: true}
+	}
+	errText := ""
+	if handleErr != nil {
+		errText = handleErr.Error()
+	}
+
+	_, err := s.db.ExecContext(s.ctx, `
+		INSERT INTO events (delivery_id, event, action, repo, number, sender, status, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		ev.DeliveryID, ev.Type, ev.Action, ev.Repo, number, ev.Actor.Login, status, errText)
+	if err != nil {
+		return fmt.Errorf("failed to record event: %w", err)
+	}
+	return nil
+}
+
+// AddDeadLetter 寫入 dead letter，已存在時覆寫
+func (s *PostgresStore) AddDeadLetter(dl DeadLetter) error {
+	_, err := s.db.ExecContext(s.ctx, `
+		INSERT INTO dead_letters (delivery_id, event, repo, body, error, attempts, failed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (delivery_id) DO UPDATE SET
+			event = excluded.event, repo = excluded.repo, body = excluded.body,
+			error = excluded.error, attempts = excluded.attempts, failed_at = excluded.failed_at`,
+		dl.DeliveryID, dl.Event, dl.Repo, []byte(dl.Body), dl.Error, dl.Attempts, dl.FailedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add dead letter: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters 依 failed_at 列出所有 dead letter
+func (s *PostgresStore) ListDeadLetters() ([]DeadLetter, error) {
+	rows, err := s.db.QueryContext(s.ctx, `
+		SELECT delivery_id, event, repo, body, error, attempts, failed_at FROM dead_letters ORDER BY failed_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var letters []DeadLetter
+	for rows.Next() {
+		var dl DeadLetter
+		var body []byte
+		if err := rows.Scan(&dl.DeliveryID, &dl.Event, &dl.Repo, &body, &dl.Error, &dl.Attempts, &dl.FailedAt); err != nil {
+			return nil, fmt.Errorf("failed to list dead letters: %w", err)
+		}
+		dl.Body = body
+		letters = append(letters, dl)
+	}
+	return letters, rows.Err()
+}
+
+// GetDeadLetter 取得一個 dead letter
+func (s *PostgresStore) GetDeadLetter(deliveryID string) (DeadLetter, bool, error) {
+	var dl DeadLetter
+	var body []byte
+	err := s.db.QueryRowContext(s.ctx, `
+		SELECT delivery_id, event, repo, body, error, attempts, failed_at FROM dead_letters WHERE delivery_id = $1`,
+		deliveryID).Scan(&dl.DeliveryID, &dl.Event, &dl.Repo, &body, &dl.Error, &dl.Attempts, &dl.FailedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dl, false, nil
+	}
+	if err != nil {
+		return dl, false, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	dl.Body = body
+	return dl, true, nil
+}
+
+// DeleteDeadLetter 刪除 dead letter
+func (s *PostgresStore) DeleteDeadLetter(deliveryID string) error {
+	if _, err := s.db.ExecContext(s.ctx, `DELETE FROM dead_letters WHERE delivery_id = $1`, deliveryID); err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	return nil
+}
+
+// Ping 確認資料庫連線
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close 停止背景清理並關閉連線
+func (s *PostgresStore) Close() error {
+	s.cancel()
+	return s.db.Close()
+}
+
+// purgeLoop 定期刪除過期的 mapping / record / delivery（events 和 threads 是歷史紀錄，不清）
+func (s *PostgresStore) purgeLoop() {
+	ticker := time.NewTicker(postgresPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.db.ExecContext(s.ctx, `DELETE FROM thread_mappings WHERE expires_at IS NOT NULL AND expires_at <= now()`)
+			s.db.ExecContext(s.ctx, `DELETE FROM records WHERE expires_at IS NOT NULL AND expires_at <= now()`)
+			s.db.ExecContext(s.ctx, `DELETE FROM deliveries WHERE expires_at <= now()`)
+		}
+	}
+}
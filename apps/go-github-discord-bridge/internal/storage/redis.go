@@ -2,7 +2,9 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -11,6 +13,43 @@ import (
 const (
 	// ClosedPRTTL PR 關閉後保留 7 天
 	ClosedPRTTL = 7 * 24 * time.Hour
+
+	// deliveryKeyPrefix delivery ID 的 key 前綴，和 "owner/repo#123" 這類 mapping 分開
+	deliveryKeyPrefix = "delivery:"
+
+	// deadLettersKey 存所有 dead letter 的 hash（field = delivery ID，value = JSON）
+	deadLettersKey = "dead-letters"
+
+	// recordKeyPrefix SetRecord 的 key 前綴，和 mapping 分開
+	recordKeyPrefix = "record:"
+
+	// leaseKeyPrefix lease 的 key 前綴（value = holder）
+	leaseKeyPrefix = "lease:"
+
+	// updatedKeyPrefix 每個 mapping 最後一次 Set / MarkAsClosed 的時間（unix 秒），TTL 和 mapping 相同
+	// 不能用 OBJECT IDLETIME：每次 Get 都會重置，常被讀取的 mapping 永遠不會變舊
+	updatedKeyPrefix = "updated:"
+
+	// mappingKeyPattern mapping 的 key 都是 "owner/repo..." 開頭，SCAN 只比對含有 "/" 的 key
+	mappingKeyPattern = "*/*"
+)
+
+var (
+	// acquireLeaseScript holder 已持有時延長 TTL，否則只在 key 不存在時寫入
+	acquireLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0`)
+	// releaseLeaseScript 只刪掉 holder 自己持有的 lease
+	releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
 )
 
 type RedisStore struct {
@@ -42,12 +81,22 @@ func NewRedisStore(redisURL string) (*RedisStore, error) {
 // Set 儲存 PR → Thread 對應，不設定 TTL（永久保存）
 func (r *RedisStore) Set(prID, threadID string) error {
 	// TTL = 0 表示永不過期
-	if err := r.client.Set(r.ctx, prID, threadID, 0).Err(); err != nil {
+	if err := r.setMapping(prID, threadID, 0); err != nil {
 		return fmt.Errorf("failed to set mapping: %w", err)
 	}
 	return nil
 }
 
+// setMapping 在同一個 transaction 寫入 mapping 和它的更新時間
+func (r *RedisStore) setMapping(key, threadID string, ttl time.Duration) error {
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(r.ctx, key, threadID, ttl)
+		pipe.Set(r.ctx, updatedKeyPrefix+key, time.Now().Unix(), ttl)
+		return nil
+	})
+	return err
+}
+
 // Get 取得 Thread ID
 func (r *RedisStore) Get(prID string) (string, bool, error) {
 	val, err := r.client.Get(r.ctx, prID).Result()
@@ -67,7 +116,7 @@ func (r *RedisStore) Get(prID string) (string, bool, error) {
 
 // Delete 刪除對應關係
 func (r *RedisStore) Delete(prID string) error {
-	if err := r.client.Del(r.ctx, prID).Err(); err != nil {
+	if err := r.client.Del(r.ctx, prID, updatedKeyPrefix+prID).Err(); err != nil {
 		return fmt.Errorf("failed to delete mapping: %w", err)
 	}
 	return nil
@@ -87,14 +136,285 @@ func (r *RedisStore) MarkAsClosed(prID string) error {
 	}
 
 	// 重新設定，帶 7 天 TTL
-	if err := r.client.Set(r.ctx, prID, threadID, ClosedPRTTL).Err(); err != nil {
+	if err := r.setMapping(prID, threadID, ClosedPRTTL); err != nil {
 		return fmt.Errorf("failed to mark as closed: %w", err)
 	}
 
 	return nil
 }
 
+// RenamePrefix 用 SCAN 找出 oldPrefix 開頭的 key 逐一 RENAME（RENAME 會保留 TTL）
+func (r *RedisStore) RenamePrefix(oldPrefix, newPrefix string) (int, error) {
+	if oldPrefix == newPrefix {
+		return 0, nil
+	}
+
+	var keys []string
+	iter := r.client.Scan(r.ctx, 0, escapeGlob(oldPrefix)+"*", 100).Iterator()
+	for iter.Next(r.ctx) {
+		if key := iter.Val(); !isInternalKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return 0, fmt.Errorf("failed to scan keys: %w", err)
+	}
+
+	renamed := 0
+	for _, key := range keys {
+		newKey := newPrefix + strings.TrimPrefix(key, oldPrefix)
+		if err := r.client.Rename(r.ctx, key, newKey).Err(); err != nil {
+			// key 可能剛好過期了，略過繼續
+			if err.Error() == "ERR no such key" {
+				continue
+			}
+			return renamed, fmt.Errorf("failed to rename %s: %w", key, err)
+		}
+		// 舊資料可能沒有更新時間，沒有就算了
+		r.client.Rename(r.ctx, updatedKeyPrefix+key, updatedKeyPrefix+newKey)
+		renamed++
+	}
+	return renamed, nil
+}
+
+// ListStale 用 SCAN 列出 mapping（只比對 mappingKeyPattern），最後更新時間取自 updatedKeyPrefix 的 key
+// 有 TTL 的 key 視為 closed；delivery ID、lease 和 record 不是 thread mapping，不列
+func (r *RedisStore) ListStale(before time.Time) ([]Mapping, error) {
+	var stale []Mapping
+	iter := r.client.Scan(r.ctx, 0, mappingKeyPattern, 100).Iterator()
+	for iter.Next(r.ctx) {
+		key := iter.Val()
+		if isInternalKey(key) {
+			continue
+		}
+
+		pipe := r.client.Pipeline()
+		threadCmd := pipe.Get(r.ctx, key)
+		ttlCmd := pipe.TTL(r.ctx, key)
+		updatedCmd := pipe.Get(r.ctx, updatedKeyPrefix+key)
+		pipe.Exec(r.ctx)
+
+		threadID, err := threadCmd.Result()
+		if err != nil {
+			continue
+		}
+		ttl := ttlCmd.Val()
+		updatedAt, ok := unixSeconds(updatedCmd)
+		if !ok {
+			// 還沒有更新時間的舊資料：closed 由剩餘 TTL 推回關閉時間，open 的退回用 OBJECT IDLETIME 近似
+			if ttl > 0 {
+				updatedAt = time.Now().Add(ttl - ClosedPRTTL)
+			} else if idle, err := r.client.ObjectIdleTime(r.ctx, key).Result(); err == nil {
+				updatedAt = time.Now().Add(-idle)
+			} else {
+				continue
+			}
+		}
+		if !updatedAt.Before(before) {
+			continue
+		}
+
+		stale = append(stale, Mapping{
+			Key:       key,
+			ThreadID:  threadID,
+			Closed:    ttl > 0,
+			UpdatedAt: updatedAt,
+		})
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan keys: %w", err)
+	}
+	return stale, nil
+}
+
+// unixSeconds 解析 updatedKeyPrefix key 的值，key 不存在或格式不對時回傳 false
+func unixSeconds(cmd *redis.StringCmd) (time.Time, bool) {
+	sec, err := cmd.Int64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
+
+// isInternalKey 和 mapping 存在同一個 keyspace、但不是 thread mapping 的 key
+func isInternalKey(key string) bool {
+	return key == deadLettersKey ||
+		strings.HasPrefix(key, deliveryKeyPrefix) ||
+		strings.HasPrefix(key, recordKeyPrefix) ||
+		strings.HasPrefix(key, leaseKeyPrefix) ||
+		strings.HasPrefix(key, updatedKeyPrefix)
+}
+
+// escapeGlob 跳脫 Redis MATCH pattern 的特殊字元
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// AddDeadLetter HSET 到 dead-letters hash
+func (r *RedisStore) AddDeadLetter(dl DeadLetter) error {
+	data, err := json.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	if err := r.client.HSet(r.ctx, deadLettersKey, dl.DeliveryID, data).Err(); err != nil {
+		return fmt.Errorf("failed to add dead letter: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters HGETALL 後依失敗時間排序
+func (r *RedisStore) ListDeadLetters() ([]DeadLetter, error) {
+	values, err := r.client.HGetAll(r.ctx, deadLettersKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	letters := make([]DeadLetter, 0, len(values))
+	for id, v := range values {
+		var dl DeadLetter
+		if err := json.Unmarshal([]byte(v), &dl); err != nil {
+			return nil, fmt.Errorf("corrupt dead letter %s: %w", id, err)
+		}
+		letters = append(letters, dl)
+	}
+	sortDeadLetters(letters)
+	return letters, nil
+}
+
+// GetDeadLetter 取得一個 dead letter
+func (r *RedisStore) GetDeadLetter(deliveryID string) (DeadLetter, bool, error) {
+	var dl DeadLetter
+	v, err := r.client.HGet(r.ctx, deadLettersKey, deliveryID).Result()
+	if err == redis.Nil {
+		return dl, false, nil
+	}
+	if err != nil {
+		return dl, false, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	if err := json.Unmarshal([]byte(v), &dl); err != nil {
+		return dl, false, fmt.Errorf("corrupt dead letter %s: %w", deliveryID, err)
+	}
+	return dl, true, nil
+}
+
+// DeleteDeadLetter HDEL
+func (r *RedisStore) DeleteDeadLetter(deliveryID string) error {
+	if err := r.client.HDel(r.ctx, deadLettersKey, deliveryID).Err(); err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	return nil
+}
+
+// Ping 送一次 PING
+func (r *RedisStore) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 // Close 關閉 Redis 連線
 func (r *RedisStore) Close() error {
 	return r.client.Close()
 }
+
+// SetRecord SET recordKeyPrefix + key，ttl 0 = 不過期
+func (r *RedisStore) SetRecord(key, value string, ttl time.Duration) error {
+	if err := r.client.Set(r.ctx, recordKeyPrefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set record: %w", err)
+	}
+	return nil
+}
+
+// GetRecord 取得 record；舊版的 record 直接以 key 本身存在 mapping 的 keyspace，讀到時搬過來（RENAME 保留 TTL）
+func (r *RedisStore) GetRecord(key string) (string, bool, error) {
+	val, err := r.client.Get(r.ctx, recordKeyPrefix+key).Result()
+	if err == redis.Nil {
+		return r.migrateRecord(key)
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get record: %w", err)
+	}
+	return val, true, nil
+}
+
+// migrateRecord 把舊版存在 key 本身的 record 改名成 recordKeyPrefix + key，連同 mapping 的更新時間一起刪掉
+func (r *RedisStore) migrateRecord(key string) (string, bool, error) {
+	val, err := r.client.Get(r.ctx, key).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get record: %w", err)
+	}
+	// 搬移失敗不影響這次讀取，下次讀到時再搬
+	r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Rename(r.ctx, key, recordKeyPrefix+key)
+		pipe.Del(r.ctx, updatedKeyPrefix+key)
+		return nil
+	})
+	return val, true, nil
+}
+
+// DeleteRecord 刪除 record（連同還沒搬過來的舊版 key，避免之後又被 GetRecord 搬回來）
+func (r *RedisStore) DeleteRecord(key string) error {
+	if err := r.client.Del(r.ctx, recordKeyPrefix+key, key, updatedKeyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to delete record: %w", err)
+	}
+	return nil
+}
+
+// MarkDelivered 記錄 delivery ID（值不重要，只看 key 在不在）
+func (r *RedisStore) MarkDelivered(deliveryID string, ttl time.Duration) error {
+	if err := r.client.Set(r.ctx, deliveryKeyPrefix+deliveryID, "1", ttl).Err(); err != nil {
+		return fmt.Errorf("failed to mark delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimDelivery 用 SET NX 佔用 delivery ID，所有連到同一個 Redis 的 instance 共用
+func (r *RedisStore) ClaimDelivery(deliveryID string, ttl time.Duration) (bool, error) {
+	ok, err := r.client.SetNX(r.ctx, deliveryKeyPrefix+deliveryID, "processing", ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim delivery: %w", err)
+	}
+	return ok, nil
+}
+
+// ReleaseDelivery 刪掉佔用中的 delivery ID
+func (r *RedisStore) ReleaseDelivery(deliveryID string) error {
+	if err := r.client.Del(r.ctx, deliveryKeyPrefix+deliveryID).Err(); err != nil {
+		return fmt.Errorf("failed to release delivery: %w", err)
+	}
+	return nil
+}
+
+// IsDelivered delivery ID 是否已記錄
+func (r *RedisStore) IsDelivered(deliveryID string) (bool, error) {
+	n, err := r.client.Exists(r.ctx, deliveryKeyPrefix+deliveryID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check delivery: %w", err)
+	}
+	return n > 0, nil
+}
+
+// AcquireLease 以 Lua script 原子地檢查 holder 並寫入 / 續約
+func (r *RedisStore) AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	n, err := acquireLeaseScript.Run(r.ctx, r.client, []string{leaseKeyPrefix + name}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	return n == 1, nil
+}
+
+// ReleaseLease 刪掉 holder 持有的 lease
+func (r *RedisStore) ReleaseLease(name, holder string) error {
+	if err := releaseLeaseScript.Run(r.ctx, r.client, []string{leaseKeyPrefix + name}, holder).Err(); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,413 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // 純 Go 的 SQLite driver，CGO_ENABLED=0 也能編譯
+)
+
+// sqlitePurgeInterval 多久清一次過期的 mapping / record / delivery
+const sqlitePurgeInterval = time.Hour
+
+// sqliteSchema 啟動時建立的資料表
+// thread_mappings.key 和 Redis 的 key 相同（"owner/repo#123"、"owner/repo#activity" 等），repo / number 從 key 拆出來方便查詢
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS thread_mappings (
+		key        TEXT PRIMARY KEY,
+		repo       TEXT NOT NULL,
+		number     INTEGER,
+		thread_id  TEXT NOT NULL,
+		state      TEXT NOT NULL DEFAULT 'open',
+		expires_at INTEGER,
+		updated_at INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS thread_mappings_repo_number ON thread_mappings (repo, number)`,
+	`CREATE TABLE IF NOT EXISTS records (
+		key        TEXT PRIMARY KEY,
+		value      TEXT NOT NULL,
+		expires_at INTEGER
+	)`,
+	`CREATE TABLE IF NOT EXISTS deliveries (
+		delivery_id TEXT PRIMARY KEY,
+		expires_at  INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS dead_letters (
+		delivery_id TEXT PRIMARY KEY,
+		event       TEXT NOT NULL,
+		repo        TEXT NOT NULL DEFAULT '',
+		body        BLOB NOT NULL,
+		error       TEXT NOT NULL DEFAULT '',
+		attempts    INTEGER NOT NULL DEFAULT 0,
+		failed_at   INTEGER NOT NULL
+	)`,
+}
+
+// SQLiteStore 以單一 SQLite 檔案保存 mapping，重啟後不會遺失，適合不想另外架 Redis 的單機部署
+// TTL 以 expires_at（unix 秒）實作：查詢時過濾過期的資料，背景定期刪除
+type SQLiteStore struct {
+	db     *sql.DB
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewSQLiteStore 開啟（不存在時建立）SQLite 資料庫並建立資料表
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create sqlite directory: %w", err)
+		}
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+	// SQLite 同時只允許一個 writer，單一連線避免 SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for _, stmt := range sqliteSchema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			cancel()
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate sqlite schema: %w", err)
+		}
+	}
+
+	s := &SQLiteStore{db: db, ctx: ctx, cancel: cancel}
+	go s.purgeLoop()
+	return s, nil
+}
+
+// Set 儲存 mapping（無 TTL），已存在時覆寫並重新標為 open
+func (s *SQLiteStore) Set(prID, threadID string) error {
+	repo, number := splitMappingKey(prID)
+	_, err := s.db.ExecContext(s.ctx, `
+		INSERT INTO thread_mappings (key, repo, number, thread_id, state, expires_at, updated_at)
+		VALUES (?, ?, ?, ?, 'open', NULL, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			thread_id = excluded.thread_id, state = 'open', expires_at = NULL, updated_at = excluded.updated_at`,
+		prID, repo, number, threadID, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to set mapping: %w", err)
+	}
+	return nil
+}
+
+// Get 取得 Thread ID（過期的 mapping 視為不存在）
+func (s *SQLiteStore) Get(prID string) (string, bool, error) {
+	var threadID string
+	err := s.db.QueryRowContext(s.ctx,
+		`SELECT thread_id FROM thread_mappings WHERE key = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		prID, time.Now().Unix()).Scan(&threadID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get mapping: %w", err)
+	}
+	return threadID, true, nil
+}
+
+// Delete 刪除對應關係
+func (s *SQLiteStore) Delete(prID string) error {
+	if _, err := s.db.ExecContext(s.ctx, `DELETE FROM thread_mappings WHERE key = ?`, prID); err != nil {
+		return fmt.Errorf("failed to delete mapping: %w", err)
+	}
+	return nil
+}
+
+// MarkAsClosed 標記為 closed 並設定 7 天後過期；mapping 不存在時不做事
+func (s *SQLiteStore) MarkAsClosed(prID string) error {
+	now := time.Now()
+	_, err := s.db.ExecContext(s.ctx, `
+		UPDATE thread_mappings SET state = 'closed', expires_at = ?, updated_at = ?
+		WHERE key = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		now.Add(ClosedPRTTL).Unix(), now.Unix(), prID, now.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to mark as closed: %w", err)
+	}
+	return nil
+}
+
+// RenamePrefix 把 oldPrefix 開頭的 key 改成 newPrefix 開頭（保留 state 和 expires_at）
+// 新 key 已存在時覆寫，和 Redis RENAME 的行為一致
+func (s *SQLiteStore) RenamePrefix(oldPrefix, newPrefix string) (int, error) {
+	if oldPrefix == newPrefix {
+		return 0, nil
+	}
+
+	tx, err := s.db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(s.ctx, `SELECT key FROM thread_mappings WHERE substr(key, 1, ?) = ?`, len(oldPrefix), oldPrefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan keys: %w", err)
+	}
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan keys: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to scan keys: %w", err)
+	}
+
+	for _, key := range keys {
+		newKey := newPrefix + strings.TrimPrefix(key, oldPrefix)
+		repo, number := splitMappingKey(newKey)
+		if _, err := tx.ExecContext(s.ctx, `DELETE FROM thread_mappings WHERE key = ?`, newKey); err != nil {
+			return 0, fmt.Errorf("failed to rename %s: %w", key, err)
+		}
+		if _, err := tx.ExecContext(s.ctx, `UPDATE thread_mappings SET key = ?, repo = ?, number = ? WHERE key = ?`, newKey, repo, number, key); err != nil {
+			return 0, fmt.Errorf("failed to rename %s: %w", key, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit rename: %w", err)
+	}
+	return len(keys), nil
+}
+
+// ListStale 列出 updated_at 早於 before 的 mapping（已過期的不列）
+func (s *SQLiteStore) ListStale(before time.Time) ([]Mapping, error) {
+	rows, err := s.db.QueryContext(s.ctx, `
+		SELECT key, thread_id, state, updated_at FROM thread_mappings
+		WHERE updated_at < ? AND (expires_at IS NULL OR expires_at > ?)
+		ORDER BY updated_at`,
+		before.Unix(), time.Now().Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale mappings: %w", err)
+	}
+	defer rows.Close()
+
+	var stale []Mapping
+	for rows.Next() {
+		var m Mapping
+		var state string
+		var updatedAt int64
+		if err := rows.Scan(&m.Key, &m.ThreadID, &state, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to list stale mappings: %w", err)
+		}
+		m.Closed = state == "closed"
+		m.UpdatedAt = time.Unix(updatedAt, 0)
+		stale = append(stale, m)
+	}
+	return stale, rows.Err()
+}
+
+// SetRecord 寫入 record，ttl 0 = expires_at 為 NULL
+func (s *SQLiteStore) SetRecord(key, value string, ttl time.Duration) error {
+	var expiresAt sql.NullInt64
+	if ttl > 0 {
+		expiresAt = sql.NullInt64{Int64: time.Now().Add(ttl).Unix(), Valid: true}
+	}
+	_, err := s.db.ExecContext(s.ctx,
+		`INSERT OR REPLACE INTO records (key, value, expires_at) VALUES (?, ?, ?)`,
+		key, value, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to set record: %w", err)
+	}
+	return nil
+}
+
+// GetRecord 取得 record（過期的視為不存在）
+func (s *SQLiteStore) GetRecord(key string) (string, bool, error) {
+	var value string
+	err := s.db.QueryRowContext(s.ctx,
+		`SELECT value FROM records WHERE key = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		key, time.Now().Unix()).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get record: %w", err)
+	}
+	return value, true, nil
+}
+
+// DeleteRecord 刪除 record
+func (s *SQLiteStore) DeleteRecord(key string) error {
+	if _, err := s.db.ExecContext(s.ctx, `DELETE FROM records WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("failed to delete record: %w", err)
+	}
+	return nil
+}
+
+// MarkDelivered 記錄 delivery ID，ttl 後過期
+func (s *SQLiteStore) MarkDelivered(deliveryID string, ttl time.Duration) error {
+	_, err := s.db.ExecContext(s.ctx,
+		`INSERT OR REPLACE INTO deliveries (delivery_id, expires_at) VALUES (?, ?)`,
+		deliveryID, time.Now().Add(ttl).Unix())
+	if err != nil {
+		return fmt.Errorf("failed to mark delivery: %w", err)
+	}
+	return nil
+}
+
+// IsDelivered delivery ID 是否已記錄且尚未過期
+func (s *SQLiteStore) IsDelivered(deliveryID string) (bool, error) {
+	var one int
+	err := s.db.QueryRowContext(s.ctx,
+		`SELECT 1 FROM deliveries WHERE delivery_id = ? AND expires_at > ?`,
+		deliveryID, time.Now().Unix()).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check delivery: %w", err)
+	}
+	return true, nil
+}
+
+// ClaimDelivery 只在 delivery ID 不存在或已過期時寫入
+func (s *SQLiteStore) ClaimDelivery(deliveryID string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	res, err := s.db.ExecContext(s.ctx, `
+		INSERT INTO deliveries (delivery_id, expires_at) VALUES (?, ?)
+		ON CONFLICT(delivery_id) DO UPDATE SET expires_at = excluded.expires_at
+		WHERE deliveries.expires_at <= ?`,
+		deliveryID, now.Add(ttl).Unix(), now.Unix())
+	if err != nil {
+		return false, fmt.Errorf("failed to claim delivery: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim delivery: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ReleaseDelivery 刪掉佔用中的 delivery ID
+func (s *SQLiteStore) ReleaseDelivery(deliveryID string) error {
+	if _, err := s.db.ExecContext(s.ctx, `DELETE FROM deliveries WHERE delivery_id = ?`, deliveryID); err != nil {
+		return fmt.Errorf("failed to release delivery: %w", err)
+	}
+	return nil
+}
+
+// AddDeadLetter 寫入 dead letter，已存在時覆寫
+func (s *SQLiteStore) AddDeadLetter(dl DeadLetter) error {
+	_, err := s.db.ExecContext(s.ctx, `
+		INSERT OR REPLACE INTO dead_letters (delivery_id, event, repo, body, error, attempts, failed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		dl.DeliveryID, dl.Event, dl.Repo, []byte(dl.Body), dl.Error, dl.Attempts, dl.FailedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to add dead letter: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters 依 failed_at 列出所有 dead letter
+func (s *SQLiteStore) ListDeadLetters() ([]DeadLetter, error) {
+	rows, err := s.db.QueryContext(s.ctx, `
+		SELECT delivery_id, event, repo, body, error, attempts, failed_at FROM dead_letters ORDER BY failed_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var letters []DeadLetter
+	for rows.Next() {
+		dl, err := scanSQLiteDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list dead letters: %w", err)
+		}
+		letters = append(letters, dl)
+	}
+	return letters, rows.Err()
+}
+
+// GetDeadLetter 取得一個 dead letter
+func (s *SQLiteStore) GetDeadLetter(deliveryID string) (DeadLetter, bool, error) {
+	row := s.db.QueryRowContext(s.ctx, `
+		SELECT delivery_id, event, repo, body, error, attempts, failed_at FROM dead_letters WHERE delivery_id = ?`,
+		deliveryID)
+	dl, err := scanSQLiteDeadLetter(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dl, false, nil
+	}
+	if err != nil {
+		return dl, false, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	return dl, true, nil
+}
+
+// DeleteDeadLetter 刪除 dead letter
+func (s *SQLiteStore) DeleteDeadLetter(deliveryID string) error {
+	if _, err := s.db.ExecContext(s.ctx, `DELETE FROM dead_letters WHERE delivery_id = ?`, deliveryID); err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	return nil
+}
+
+func scanSQLiteDeadLetter(row interface{ Scan(dest ...any) error }) (DeadLetter, error) {
+	var dl DeadLetter
+	var body []byte
+	var failedAt int64
+	if err := row.Scan(&dl.DeliveryID, &dl.Event, &dl.Repo, &body, &dl.Error, &dl.Attempts, &failedAt); err != nil {
+		return dl, err
+	}
+	dl.Body = body
+	dl.FailedAt = time.Unix(failedAt, 0)
+	return dl, nil
+}
+
+// Ping 確認資料庫檔案還能存取
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close 停止背景清理並關閉資料庫
+func (s *SQLiteStore) Close() error {
+	s.cancel()
+	return s.db.Close()
+}
+
+// purgeLoop 定期刪除過期的資料，避免資料庫無限成長
+func (s *SQLiteStore) purgeLoop() {
+	ticker := time.NewTicker(sqlitePurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now().Unix()
+			s.db.ExecContext(s.ctx, `DELETE FROM thread_mappings WHERE expires_at IS NOT NULL AND expires_at <= ?`, now)
+			s.db.ExecContext(s.ctx, `DELETE FROM records WHERE expires_at IS NOT NULL AND expires_at <= ?`, now)
+			s.db.ExecContext(s.ctx, `DELETE FROM deliveries WHERE expires_at <= ?`, now)
+		}
+	}
+}
+
+// splitMappingKey 從 "owner/repo#123" 拆出 repo 和編號；"owner/repo#activity"、"owner/repo@v1.0.0" 這類 key 沒有編號
+func splitMappingKey(key string) (string, sql.NullInt64) {
+	repo := key
+	if i := strings.IndexAny(key, "#@"); i >= 0 {
+		repo = key[:i]
+		if n, err := strconv.ParseInt(key[i+1:], 10, 64); err == nil && key[i] == '#' {
+			return repo, sql.NullInt64{Int64: n, Valid: true}
+		}
+	}
+	return repo, sql.NullInt64{}
+}
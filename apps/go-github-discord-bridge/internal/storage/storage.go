@@ -1,5 +1,38 @@
 package storage
 
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/event"
+)
+
+// Mapping 一筆 key → Thread ID 的對應
+type Mapping struct {
+	Key       string    `json:"key"` // "owner/repo#123"、"owner/repo#activity" 等
+	ThreadID  string    `json:"thread_id"`
+	Closed    bool      `json:"closed,omitempty"` // 已呼叫過 MarkAsClosed（會在 TTL 後過期）
+	UpdatedAt time.Time `json:"updated_at"`       // 最後一次 Set / MarkAsClosed 的時間
+}
+
+// DeadLetter 重試後仍處理失敗的 webhook delivery，保留原始 payload 供檢查和重新處理
+type DeadLetter struct {
+	DeliveryID string          `json:"delivery_id"`
+	Event      string          `json:"event"`
+	Repo       string          `json:"repo,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	Error      string          `json:"error"`    // 最後一次失敗的錯誤
+	Attempts   int             `json:"attempts"` // 累計執行次數（含重新處理）
+	FailedAt   time.Time       `json:"failed_at"`
+}
+
+// sortDeadLetters 依失敗時間排序（舊的在前）
+func sortDeadLetters(letters []DeadLetter) {
+	slices.SortFunc(letters, func(a, b DeadLetter) int { return a.FailedAt.Compare(b.FailedAt) })
+}
+
 // Store 定義 PR → Discord Thread ID 的儲存介面
 type Store interface {
 	// Set 儲存 PR 和 Thread 的對應關係（無 TTL）
@@ -13,4 +46,73 @@ type Store interface {
 
 	// MarkAsClosed 標記 PR 已關閉，設定 7 天 TTL
 	MarkAsClosed(prID string) error
+
+	// RenamePrefix 把所有以 oldPrefix 開頭的 key 改成 newPrefix 開頭（保留 TTL），回傳改了幾筆
+	// 用於 repo 改名 / 轉移時搬移 "owner/repo#123" 這類 mapping
+	RenamePrefix(oldPrefix, newPrefix string) (int, error)
+
+	// ListStale 列出最後更新早於 before 的 mapping（已過期的不列），給 GC / reconciliation 使用
+	// before 給未來的時間等於列出全部
+	ListStale(before time.Time) ([]Mapping, error)
+
+	// SetRecord 儲存不是 thread mapping 的資料（/github link 綁定等），ttl 0 = 不過期，已存在時覆寫
+	// record 和 mapping 分開存放，不會出現在 ListStale、RenamePrefix 和 mapping 的匯出 / 統計
+	SetRecord(key, value string, ttl time.Duration) error
+
+	// GetRecord 取得 record（過期的視為不存在）
+	GetRecord(key string) (value string, exists bool, err error)
+
+	// DeleteRecord 刪除 record
+	DeleteRecord(key string) error
+
+	// MarkDelivered 記錄已處理完成的 GitHub delivery ID，ttl 過後自動忘記
+	MarkDelivered(deliveryID string, ttl time.Duration) error
+
+	// IsDelivered delivery ID 是否已處理過（GitHub 自動 / 手動 redeliver 時 ID 不變）
+	IsDelivered(deliveryID string) (bool, error)
+
+	// ClaimDelivery 原子地佔用 delivery ID（ttl 後自動釋放），已被佔用或已處理過時回傳 false
+	// 多個 instance 同時收到同一個 delivery 時只有一個會處理
+	ClaimDelivery(deliveryID string, ttl time.Duration) (claimed bool, err error)
+
+	// ReleaseDelivery 處理失敗時釋放 ClaimDelivery 的佔用，讓 redeliver 可以重試
+	ReleaseDelivery(deliveryID string) error
+
+	// AddDeadLetter 保存處理失敗的 delivery（不會過期），同一個 delivery ID 已存在時覆寫
+	AddDeadLetter(dl DeadLetter) error
+
+	// ListDeadLetters 列出所有 dead letter，依 FailedAt 排序
+	ListDeadLetters() ([]DeadLetter, error)
+
+	// GetDeadLetter 取得一個 dead letter
+	GetDeadLetter(deliveryID string) (dl DeadLetter, exists bool, err error)
+
+	// DeleteDeadLetter 重新處理成功或捨棄後刪除
+	DeleteDeadLetter(deliveryID string) error
+
+	// Ping 確認 backend 可以連線（readiness probe 使用）
+	Ping(ctx context.Context) error
+
+	// Close 釋放連線
+	Close() error
+}
+
+// EventRecorder 會保留處理紀錄的 backend 額外實作（目前只有 Postgres）
+type EventRecorder interface {
+	// RecordEvent 記錄一個 event 的處理結果，status 為 EventHandled / EventFailed
+	RecordEvent(ev event.Event, status string, handleErr error) error
 }
+
+// Leaser 多個 replica 共用的 backend 額外實作（Redis、Postgres），HA_MODE 的 leader election 用
+type Leaser interface {
+	// AcquireLease 沒有人持有、已過期或 holder 本身持有時，取得（續約）lease 到 ttl 後並回傳 true
+	AcquireLease(name, holder string, ttl time.Duration) (bool, error)
+	// ReleaseLease holder 持有時釋放，讓其他 replica 不用等 ttl 就能接手
+	ReleaseLease(name, holder string) error
+}
+
+// RecordEvent 的 status
+const (
+	EventHandled = "handled"
+	EventFailed  = "failed"
+)
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// embeddedStores 不需要外部服務的 backend
+func embeddedStores(t *testing.T) map[string]Store {
+	t.Helper()
+	dir := t.TempDir()
+	sqlite, err := NewSQLiteStore(filepath.Join(dir, "bridge.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	bolt, err := NewBoltStore(filepath.Join(dir, "bridge.bolt"))
+	if err != nil {
+		t.Fatalf("NewBoltStore: %v", err)
+	}
+	stores := map[string]Store{"sqlite": sqlite, "bolt": bolt}
+	t.Cleanup(func() {
+		for _, s := range stores {
+			s.Close()
+		}
+	})
+	return stores
+}
+
+func TestRecords(t *testing.T) {
+	for name, s := range embeddedStores(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if err := s.Set("acme/widgets#1", "thread-1"); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			if err := s.SetRecord("discord-user:octocat", "1234", 0); err != nil {
+				t.Fatalf("SetRecord: %v", err)
+			}
+			if err := s.SetRecord("discord-link-pending:1234", "pending", time.Second); err != nil {
+				t.Fatalf("SetRecord with ttl: %v", err)
+			}
+
+			for key, want := range map[string]string{"discord-user:octocat": "1234", "discord-link-pending:1234": "pending"} {
+				if got, exists, err := s.GetRecord(key); err != nil || !exists || got != want {
+					t.Errorf("GetRecord(%s) = %q, %v, %v, want %q", key, got, exists, err, want)
+				}
+			}
+			if _, exists, _ := s.Get("discord-user:octocat"); exists {
+				t.Error("Get found a record in the mappings")
+			}
+			if _, exists, _ := s.GetRecord("acme/widgets#1"); exists {
+				t.Error("GetRecord found a mapping")
+			}
+
+			// record 不算 mapping
+			mappings, err := s.ListStale(time.Now().Add(time.Hour))
+			if err != nil {
+				t.Fatalf("ListStale: %v", err)
+			}
+			if len(mappings) != 1 || mappings[0].Key != "acme/widgets#1" {
+				t.Errorf("ListStale = %+v, want only the mapping", mappings)
+			}
+
+			if err := s.SetRecord("discord-user:octocat", "5678", 0); err != nil {
+				t.Fatalf("SetRecord overwrite: %v", err)
+			}
+			if got, _, _ := s.GetRecord("discord-user:octocat"); got != "5678" {
+				t.Errorf("GetRecord after overwrite = %q, want %q", got, "5678")
+			}
+			if err := s.DeleteRecord("discord-user:octocat"); err != nil {
+				t.Fatalf("DeleteRecord: %v", err)
+			}
+			if _, exists, _ := s.GetRecord("discord-user:octocat"); exists {
+				t.Error("GetRecord found a deleted record")
+			}
+
+			// TTL 以秒計，等到下一秒之後
+			time.Sleep(2 * time.Second)
+			if _, exists, err := s.GetRecord("discord-link-pending:1234"); err != nil || exists {
+				t.Errorf("GetRecord of an expired record = %v, %v, want not found", exists, err)
+			}
+		})
+	}
+}
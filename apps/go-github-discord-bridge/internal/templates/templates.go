@@ -0,0 +1,355 @@
+// Package templates 以 Go text/template 讓使用者覆寫 embed 的 title / description / fields
+// template 分層套用，後面的層只覆寫自己有給的欄位：
+//
+//	default → event type（pull_request）→ event key（pull_request.opened）
+//	→ repo（owner/repo）→ repo + event type（owner/repo:pull_request）→ repo + event key（owner/repo:pull_request.opened）
+//
+// 沒有任何一層的事件照常用內建格式
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"dizzycode1112/github-discord-bridge/internal/discord"
+	"dizzycode1112/github-discord-bridge/internal/event"
+	"dizzycode1112/github-discord-bridge/internal/github"
+	"dizzycode1112/github-discord-bridge/internal/i18n"
+)
+
+// Discord embed 各欄位的長度上限，template 輸出超過時截斷
+const (
+	maxTitle       = 256
+	maxFieldName   = 256
+	maxFieldValue  = 1024
+	maxFooter      = 2048
+	templateSuffix = ".json"
+
+	// DefaultKey 套用到所有事件的最底層 template
+	DefaultKey = "default"
+)
+
+// Spec 使用者提供的 template 定義（JSON），每個欄位都是 Go template 字串
+// 空字串 / 沒給的欄位沿用內建格式；Fields 給了（包含 []）就整組取代
+type Spec struct {
+	Title       string      `json:"title,omitempty"`
+	Description string      `json:"description,omitempty"`
+	URL         string      `json:"url,omitempty"`
+	Color       string      `json:"color,omitempty"` // 見 discord.ParseColor：hex、十進位或顏色名稱
+	Footer      string      `json:"footer,omitempty"`
+	Fields      []FieldSpec `json:"fields,omitempty"`
+}
+
+// FieldSpec embed field 的 template；name 或 value 輸出為空時略過這個 field（可用 {{if}} 做條件欄位）
+type FieldSpec struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// Data template 的 context：直接用 {{.Title}}、{{.Repo}}、{{.Actor.Login}} 取 normalized event，
+// {{.Payload}} 取原始 webhook payload，{{.Default}} 取內建格式的 embed（只想改一部分時可以沿用）
+type Data struct {
+	event.Event
+	Payload *github.WebhookPayload
+	Default discord.Embed
+}
+
+// Template 一個 event key 編譯後的 template
+type Template struct {
+	key         string
+	title       *template.Template
+	description *template.Template
+	url         *template.Template
+	color       *template.Template
+	footer      *template.Template
+	fields      []fieldTemplate
+	hasFields   bool
+}
+
+type fieldTemplate struct {
+	name   *template.Template
+	value  *template.Template
+	inline bool
+}
+
+// Engine template key（見 package 說明）→ Template
+type Engine struct {
+	templates map[string]*Template
+}
+
+// splitRepoKey 把 "owner/repo:pull_request.opened" 拆成 repo 和 event key；沒有 repo 的 key 回傳空 repo
+func splitRepoKey(key string) (repo, eventKey string) {
+	if !strings.Contains(key, "/") {
+		return "", key
+	}
+	repo, eventKey, _ = strings.Cut(key, ":")
+	return repo, eventKey
+}
+
+// funcs template 內可用的函式
+var funcs = template.FuncMap{
+	"truncate": truncate,
+	"join":     strings.Join,
+	"lower":    strings.ToLower,
+	"upper":    strings.ToUpper,
+	"trim":     strings.TrimSpace,
+	"t":        i18n.T, // 依 DISCORD_LOCALE 翻譯內建字串，例如 {{t "Closed by"}}
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+}
+
+// Load 從 dir 讀取 template 檔，再套用 inline（DISCORD_TEMPLATES）；同一個 key 兩邊都有時 inline 優先
+// 目錄結構：
+//
+//	dir/default.json、dir/pull_request.json、dir/pull_request.opened.json
+//	dir/owner/repo/default.json（repo 層）、dir/owner/repo/release.published.json（repo + event）
+//
+// dir 為空字串時只用 inline；repo 的部分不分大小寫
+func Load(dir string, inline map[string]Spec) (*Engine, error) {
+	specs := make(map[string]Spec)
+
+	if dir != "" {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, templateSuffix) {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			key, ok := fileKey(filepath.ToSlash(rel))
+			if !ok {
+				return fmt.Errorf("template %s must be at <dir>/<event>.json or <dir>/<owner>/<repo>/<event>.json", path)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var spec Spec
+			if err := json.Unmarshal(data, &spec); err != nil {
+				return fmt.Errorf("invalid template %s: %w", path, err)
+			}
+			specs[key] = spec
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for key, spec := range inline {
+		specs[normalizeKey(key)] = spec
+	}
+
+	e := &Engine{templates: make(map[string]*Template, len(specs))}
+	for key, spec := range specs {
+		t, err := compile(key, spec)
+		if err != nil {
+			return nil, err
+		}
+		e.templates[key] = t
+	}
+	return e, nil
+}
+
+// fileKey 目錄裡的相對路徑 → template key
+func fileKey(rel string) (string, bool) {
+	name := strings.TrimSuffix(rel, templateSuffix)
+	parts := strings.Split(name, "/")
+	switch len(parts) {
+	case 1:
+		return name, true
+	case 3:
+		repo := strings.ToLower(parts[0] + "/" + parts[1])
+		if parts[2] == DefaultKey {
+			return repo, true
+		}
+		return repo + ":" + parts[2], true
+	default:
+		return "", false
+	}
+}
+
+// normalizeKey repo 部分轉小寫，"owner/repo:default" 視同 "owner/repo"
+func normalizeKey(key string) string {
+	repo, eventKey := splitRepoKey(key)
+	if repo == "" {
+		return key
+	}
+	repo = strings.ToLower(repo)
+	if eventKey == "" || eventKey == DefaultKey {
+		return repo
+	}
+	return repo + ":" + eventKey
+}
+
+// Len 載入了幾個 template
+func (e *Engine) Len() int {
+	return len(e.templates)
+}
+
+// Lookup 依分層順序合併 repo 和 event key（例如 "pull_request.opened"）適用的 template
+// repo 為空字串時（org 層級的事件）只看不分 repo 的層；沒有任何一層時回傳 false
+func (e *Engine) Lookup(repo, key string) (*Template, bool) {
+	eventType, _, _ := strings.Cut(key, ".")
+	layers := []string{DefaultKey, eventType, key}
+	if repo != "" {
+		repo = strings.ToLower(repo)
+		layers = append(layers, repo, repo+":"+eventType, repo+":"+key)
+	}
+
+	var merged *Template
+	for i, layer := range layers {
+		if i > 0 && layer == layers[i-1] {
+			continue // 沒有 action 的事件（push）type 和 key 相同
+		}
+		t, ok := e.templates[layer]
+		if !ok {
+			continue
+		}
+		if merged == nil {
+			merged = &Template{key: key}
+		}
+		merged.overlay(t)
+	}
+	return merged, merged != nil
+}
+
+// overlay 用 upper 有給的欄位覆寫 t
+func (t *Template) overlay(upper *Template) {
+	for _, part := range []struct{ dst, src **template.Template }{
+		{&t.title, &upper.title},
+		{&t.description, &upper.description},
+		{&t.url, &upper.url},
+		{&t.color, &upper.color},
+		{&t.footer, &upper.footer},
+	} {
+		if *part.src != nil {
+			*part.dst = *part.src
+		}
+	}
+	if upper.hasFields {
+		t.fields, t.hasFields = upper.fields, true
+	}
+}
+
+func compile(key string, spec Spec) (*Template, error) {
+	t := &Template{key: key, hasFields: spec.Fields != nil}
+
+	var err error
+	parse := func(part, text string) *template.Template {
+		if err != nil || text == "" {
+			return nil
+		}
+		var tmpl *template.Template
+		tmpl, err = template.New(key + "." + part).Funcs(funcs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			err = fmt.Errorf("invalid template %s: %w", key, err)
+		}
+		return tmpl
+	}
+
+	t.title = parse("title", spec.Title)
+	t.description = parse("description", spec.Description)
+	t.url = parse("url", spec.URL)
+	t.color = parse("color", spec.Color)
+	t.footer = parse("footer", spec.Footer)
+	for i, f := range spec.Fields {
+		t.fields = append(t.fields, fieldTemplate{
+			name:   parse(fmt.Sprintf("fields[%d].name", i), f.Name),
+			value:  parse(fmt.Sprintf("fields[%d].value", i), f.Value),
+			inline: f.Inline,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Apply 以 template 覆寫 message 第一個 embed 的欄位（data.Default 由這裡填入）
+// 任何一個欄位執行失敗就回傳 error，呼叫端應改用原本的 message
+func (t *Template) Apply(message discord.ThreadMessage, data Data) (discord.ThreadMessage, error) {
+	if len(message.Embeds) == 0 {
+		return message, nil
+	}
+
+	embed := message.Embeds[0]
+	data.Default = embed
+
+	var err error
+	exec := func(tmpl *template.Template) string {
+		if err != nil || tmpl == nil {
+			return ""
+		}
+		var buf bytes.Buffer
+		if execErr := tmpl.Execute(&buf, data); execErr != nil {
+			err = fmt.Errorf("template %s: %w", t.key, execErr)
+			return ""
+		}
+		return strings.TrimSpace(buf.String())
+	}
+
+	if v := exec(t.title); v != "" {
+		embed.Title = truncate(maxTitle, v)
+	}
+	if v := exec(t.description); v != "" {
+		embed.Description = truncate(discord.MaxEmbedDescription, v)
+	}
+	if v := exec(t.url); v != "" {
+		embed.URL = v
+	}
+	if v := exec(t.color); v != "" {
+		color, parseErr := discord.ParseColor(v)
+		if parseErr != nil && err == nil {
+			err = fmt.Errorf("template %s: %w", t.key, parseErr)
+		}
+		embed.Color = color
+	}
+	if v := exec(t.footer); v != "" {
+		embed.Footer = &discord.EmbedFooter{Text: truncate(maxFooter, v)}
+	}
+	if t.hasFields {
+		var fields []discord.EmbedField
+		for _, f := range t.fields {
+			name, value := exec(f.name), exec(f.value)
+			if name == "" || value == "" {
+				continue
+			}
+			fields = append(fields, discord.EmbedField{
+				Name:   truncate(maxFieldName, name),
+				Value:  truncate(maxFieldValue, value),
+				Inline: f.inline,
+			})
+		}
+		embed.Fields = fields
+	}
+	if err != nil {
+		return message, err
+	}
+
+	embeds := append([]discord.Embed{embed}, message.Embeds[1:]...)
+	message.Embeds = embeds
+	return message, nil
+}
+
+// truncate 截斷到 max 個字元（rune），超過時結尾加 "…"；template 內用法 {{truncate 100 .Body}}
+func truncate(max int, s string) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max-1]) + "…"
+}
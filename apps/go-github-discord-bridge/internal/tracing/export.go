@@ -0,0 +1,248 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dizzycode1112/github-discord-bridge/internal/metrics"
+)
+
+const (
+	exportBatchSize = 256             // 累積幾個 span 就送出
+	exportInterval  = 5 * time.Second // 最久多久送一次
+	exportQueueSize = 2048            // 等待匯出的 span 上限，滿了就丟掉新的 span
+)
+
+// Options Setup 的設定
+type Options struct {
+	Endpoint    string            // OTLP/HTTP collector，例如 http://localhost:4318（會送到 <Endpoint>/v1/traces）
+	ServiceName string            // resource 的 service.name
+	SampleRatio float64           // 沒有 parent 的 trace 有多少比例要記錄，0 ~ 1
+	Headers     map[string]string // 額外的 HTTP header（例如 collector 的認證）
+}
+
+// Tracer 收集 End 的 span，批次以 OTLP/HTTP JSON 送到 collector
+type Tracer struct {
+	url         string
+	serviceName string
+	ratio       float64
+	headers     map[string]string
+	httpClient  *http.Client
+
+	spans chan *Span
+	done  chan struct{}
+	once  sync.Once
+}
+
+var current atomic.Pointer[Tracer]
+
+func global() *Tracer {
+	return current.Load()
+}
+
+// Setup 啟用 tracing 並啟動背景 exporter，回傳 shutdown：送出剩下的 span 後停止
+// Endpoint 為空時不啟用，回傳的 shutdown 不做事
+func Setup(opts Options) (shutdown func(ctx context.Context) error) {
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }
+	}
+
+	t := &Tracer{
+		url:         strings.TrimRight(opts.Endpoint, "/") + "/v1/traces",
+		serviceName: opts.ServiceName,
+		ratio:       opts.SampleRatio,
+		headers:     opts.Headers,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		spans:       make(chan *Span, exportQueueSize),
+		done:        make(chan struct{}),
+	}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		t.run()
+	}()
+	current.Store(t)
+
+	return func(ctx context.Context) error {
+		current.CompareAndSwap(t, nil)
+		t.once.Do(func() { close(t.done) })
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (t *Tracer) enqueue(s *Span) {
+	select {
+	case t.spans <- s:
+	default:
+		metrics.TraceSpansDropped.Inc()
+	}
+}
+
+// run 累積到 exportBatchSize 或每 exportInterval 送一次，done 關閉時送出剩下的 span
+func (t *Tracer) run() {
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.export(batch); err != nil {
+			metrics.TraceSpansDropped.Add(float64(len(batch)))
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case s := <-t.spans:
+			batch = append(batch, s)
+			if len(batch) >= exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.done:
+			for {
+				select {
+				case s := <-t.spans:
+					batch = append(batch, s)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// OTLP JSON encoding（https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding），trace / span ID 用 hex
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpAttr `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              Kind       `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []otlpAttr `json:"attributes,omitempty"`
+	Status            struct {
+		Code    int    `json:"code,omitempty"` // 0 = unset、2 = error
+		Message string `json:"message,omitempty"`
+	} `json:"status"`
+}
+
+type otlpAttr struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func attrValue(v any) map[string]any {
+	switch v := v.(type) {
+	case string:
+		return map[string]any{"stringValue": v}
+	case bool:
+		return map[string]any{"boolValue": v}
+	case int:
+		return map[string]any{"intValue": strconv.Itoa(v)}
+	case int64:
+		return map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return map[string]any{"doubleValue": v}
+	default:
+		return map[string]any{"stringValue": fmt.Sprint(v)}
+	}
+}
+
+func (s *Span) otlp() otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := otlpSpan{
+		TraceID:           hex.EncodeToString(s.sc.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.sc.SpanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+	}
+	if s.parent != [8]byte{} {
+		out.ParentSpanID = hex.EncodeToString(s.parent[:])
+	}
+	for key, value := range s.attrs {
+		out.Attributes = append(out.Attributes, otlpAttr{Key: key, Value: attrValue(value)})
+	}
+	if s.failed {
+		out.Status.Code, out.Status.Message = 2, s.errMsg
+	}
+	return out
+}
+
+func (t *Tracer) export(batch []*Span) error {
+	var rs otlpResourceSpans
+	rs.Resource.Attributes = []otlpAttr{{Key: "service.name", Value: attrValue(t.serviceName)}}
+	scope := otlpScopeSpans{}
+	scope.Scope.Name = "github-discord-bridge"
+	for _, s := range batch {
+		scope.Spans = append(scope.Spans, s.otlp())
+	}
+	rs.ScopeSpans = []otlpScopeSpans{scope}
+
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{rs}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
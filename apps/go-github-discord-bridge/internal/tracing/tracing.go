@@ -0,0 +1,198 @@
+// Package tracing 最小的 OpenTelemetry 相容 tracing：W3C traceparent 傳遞 + OTLP/HTTP（JSON）匯出
+// 只實作這個服務需要的部分（span、attribute、error status、ratio sampling），不依賴 OTel SDK
+// 沒有呼叫 Setup 時 Start 回傳 nil span，所有 Span 方法都可以安全地對 nil 呼叫
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind span 的種類（對應 OTLP SpanKind）
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// SpanContext 跨 process 傳遞的 trace 識別（traceparent header 的內容）
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// Valid trace ID 和 span ID 都不是全 0
+func (sc SpanContext) Valid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Span 一段被追蹤的工作，End 之後才會匯出
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	parent [8]byte
+	name   string
+	kind   Kind
+	start  time.Time
+
+	mu     sync.Mutex
+	end    time.Time
+	attrs  map[string]any
+	errMsg string
+	failed bool
+}
+
+type spanContextKey struct{}
+
+// Start 開始一個 span，parent 為 ctx 裡的 span（或 Extract 取得的遠端 span）
+// 沒有 parent 時依 sampling ratio 決定是否記錄；有 parent 時沿用 parent 的決定
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	t := global()
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{tracer: t, name: name, kind: kind, start: time.Now(), attrs: make(map[string]any)}
+	if parent, ok := ctx.Value(spanContextKey{}).(SpanContext); ok && parent.Valid() {
+		span.sc.TraceID, span.parent, span.sc.Sampled = parent.TraceID, parent.SpanID, parent.Sampled
+	} else {
+		rand.Read(span.sc.TraceID[:])
+		span.sc.Sampled = t.sample(span.sc.TraceID)
+	}
+	rand.Read(span.sc.SpanID[:])
+	return context.WithValue(ctx, spanContextKey{}, span.sc), span
+}
+
+// SetAttr 設定 attribute，value 支援 string、bool、int / int64 和 float64（其他型別用 fmt 轉成字串）
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs[key] = value
+}
+
+// SetError err 不為 nil 時把 span 標成失敗
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed, s.errMsg = true, err.Error()
+}
+
+// End 結束 span 並交給 exporter（沒有被 sample 的 span 直接丟掉），重複呼叫只有第一次有效
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if s.sc.Sampled {
+		s.tracer.enqueue(s)
+	}
+}
+
+// SpanContextFrom ctx 裡目前的 span context
+func SpanContextFrom(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok && sc.Valid()
+}
+
+// TraceID ctx 裡目前 trace 的 ID（hex），沒有時回傳空字串，用來寫進 log 方便對照
+func TraceID(ctx context.Context) string {
+	if sc, ok := SpanContextFrom(ctx); ok {
+		return hex.EncodeToString(sc.TraceID[:])
+	}
+	return ""
+}
+
+// Inject 把 ctx 裡的 span context 寫進 traceparent header（W3C Trace Context）
+// 只用在自己的下游服務；Discord、GitHub 這類第三方 API 不送，避免把內部的 trace ID 帶出去
+func Inject(ctx context.Context, header http.Header) {
+	if value := Traceparent(ctx); value != "" {
+		header.Set("traceparent", value)
+	}
+}
+
+// Traceparent ctx 裡的 span context 編成 traceparent 的值，沒有時回傳空字串
+// 用在沒有 header 可以帶的地方（message broker 的訊息、queue journal）
+func Traceparent(ctx context.Context) string {
+	sc, ok := SpanContextFrom(ctx)
+	if !ok {
+		return ""
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+// Extract 讀取 request 的 traceparent header，有效時放進 ctx 當作之後 span 的 parent
+// 格式錯誤時忽略（開新的 trace），不影響 request 處理
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return WithTraceparent(ctx, header.Get("traceparent"))
+}
+
+// WithTraceparent Traceparent 的反向：value 有效時放進 ctx 當作之後 span 的 parent，空字串或格式錯誤時回傳原本的 ctx
+func WithTraceparent(ctx context.Context, value string) context.Context {
+	sc, ok := parseTraceparent(value)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// parseTraceparent 解析 "00-<32 hex trace id>-<16 hex span id>-<2 hex flags>"
+func parseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, false
+	}
+	sc.Sampled = flags[0]&0x01 == 1
+	return sc, sc.Valid()
+}
+
+// sample 依 trace ID 決定是否記錄（同一個 trace ID 的結果固定）
+func (t *Tracer) sample(traceID [16]byte) bool {
+	switch {
+	case t.ratio >= 1:
+		return true
+	case t.ratio <= 0:
+		return false
+	}
+	return float64(binary.BigEndian.Uint64(traceID[8:])>>11)/(1<<53) < t.ratio
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestTraceparent(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string // 空字串 = 無效，ctx 沒有 span context
+	}{
+		{name: "sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "not sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", want: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{name: "other flags", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03", want: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "future version with extra fields", value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", want: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "empty", value: ""},
+		{name: "version 00 with extra fields", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "invalid version", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "zero trace id", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "zero span id", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{name: "short trace id", value: "00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01"},
+		{name: "not hex", value: "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithTraceparent(context.Background(), tt.value)
+			if got := Traceparent(ctx); got != tt.want {
+				t.Errorf("Traceparent(WithTraceparent(%q)) = %q, want %q", tt.value, got, tt.want)
+			}
+
+			// header 版本的結果相同
+			header := http.Header{}
+			Inject(Extract(context.Background(), http.Header{"Traceparent": {tt.value}}), header)
+			if got := header.Get("traceparent"); got != tt.want {
+				t.Errorf("Inject(Extract(%q)) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}